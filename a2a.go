@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// a2aProtocolVersion is the A2A protocol release the agent cards conform to.
+const a2aProtocolVersion = "0.3.0"
+
+// a2aAgentCard is the subset of the A2A AgentCard the plugin fills in.
+type a2aAgentCard struct {
+	ProtocolVersion    string          `json:"protocolVersion"`
+	Name               string          `json:"name"`
+	Description        string          `json:"description"`
+	URL                string          `json:"url"`
+	Version            string          `json:"version"`
+	Capabilities       a2aCapabilities `json:"capabilities"`
+	DefaultInputModes  []string        `json:"defaultInputModes"`
+	DefaultOutputModes []string        `json:"defaultOutputModes"`
+	Skills             []a2aSkill      `json:"skills"`
+}
+
+type a2aCapabilities struct {
+	Streaming bool `json:"streaming"`
+}
+
+type a2aSkill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	OutputModes []string `json:"outputModes,omitempty"`
+}
+
+// generateA2ACards emits an A2A agent card per service into a companion
+// _<service>.agent.json file, advertising each tool as a skill. The card
+// URL is taken from the a2a_url option.
+func generateA2ACards(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	for _, svc := range services {
+		card := a2aAgentCard{
+			ProtocolVersion:    a2aProtocolVersion,
+			Name:               string(svc.service.Desc.FullName()),
+			Description:        serviceDescription(svc.service),
+			URL:                *a2aURL,
+			Version:            "0.0.0",
+			DefaultInputModes:  []string{"application/json"},
+			DefaultOutputModes: []string{"application/json"},
+			Skills:             make([]a2aSkill, 0, len(svc.methods)),
+		}
+		if card.Description == "" {
+			card.Description = "Tools of " + card.Name + "."
+		}
+		for _, m := range svc.methods {
+			skill := a2aSkill{
+				ID:          m.toolName,
+				Name:        m.toolName,
+				Description: m.description,
+				Tags:        m.toolDoc.GetTags(),
+			}
+			if skill.Tags == nil {
+				skill.Tags = []string{}
+			}
+			if resultFormat(m.toolDoc) == pb.ResultFormat_RESULT_FORMAT_TEMPLATE {
+				skill.OutputModes = []string{"text/plain"}
+			}
+			card.Skills = append(card.Skills, skill)
+		}
+		raw, err := json.MarshalIndent(card, "", "  ")
+		if err != nil {
+			// The card only holds strings, slices and booleans, so this cannot fail.
+			panic(err)
+		}
+
+		filename := file.GeneratedFilenamePrefix + "_" + strings.ToLower(svc.service.GoName) + ".agent.json"
+		g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+		g.P(string(raw))
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// anthropicKeywords are the JSON Schema keywords Anthropic honours in tool
+// input schemas. Numeric and length constraints and annotations such as
+// example are dropped.
+var anthropicKeywords = map[string]bool{
+	"type": true, "description": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true,
+	"items": true, "anyOf": true, "pattern": true, "format": true,
+	"$ref": true, "$defs": true, "definitions": true,
+}
+
+// anthropicFormats are the string formats Anthropic accepts.
+var anthropicFormats = map[string]bool{
+	"date-time": true, "time": true, "date": true, "duration": true,
+	"email": true, "hostname": true, "uri": true, "ipv4": true, "ipv6": true, "uuid": true,
+}
+
+// anthropicTool is an entry of the tools parameter of the Anthropic
+// Messages API.
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+// generateAnthropicFiles emits the tools of each service as an Anthropic
+// tools array into a companion _<service>.anthropic.json file, for clients
+// calling Anthropic directly with the same contract.
+func generateAnthropicFiles(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	for _, svc := range services {
+		tools := make([]anthropicTool, 0, len(svc.methods))
+		for _, m := range svc.methods {
+			tools = append(tools, anthropicTool{
+				Name:        m.toolName,
+				Description: m.description,
+				InputSchema: filterSchema(m.inputSchema, anthropicKeywords, anthropicFormats),
+			})
+		}
+		raw := marshalSchemaJSON(tools)
+
+		filename := file.GeneratedFilenamePrefix + "_" + strings.ToLower(svc.service.GoName) + ".anthropic.json"
+		g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+		g.P(string(raw))
+	}
+}
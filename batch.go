@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// checkBatch reports why m, declared with (genkit.tool.v1.tool_doc).batch,
+// cannot get a companion batch tool. Each request of a batch must map to a
+// single call returning a single result.
+func checkBatch(m *protogen.Method, doc *pb.ToolDoc) error {
+	if !doc.GetBatch() {
+		return nil
+	}
+	name := m.Desc.FullName()
+	switch {
+	case m.Desc.IsStreamingClient() || m.Desc.IsStreamingServer():
+		return fmt.Errorf("%s: batch cannot be set on streaming methods", name)
+	case doc.GetInterruptible():
+		return fmt.Errorf("%s: batch cannot be set on interruptible methods", name)
+	case confirmsCalls(doc):
+		return fmt.Errorf("%s: batch cannot be set on destructive methods, whose calls are confirmed one by one", name)
+	case doc.GetResource():
+		return fmt.Errorf("%s: batch cannot be set on resource methods", name)
+	case len(collectMediaFields(m.Desc.Output())) > 0:
+		return fmt.Errorf("%s: batch cannot be set on methods returning media fields", name)
+	}
+	return nil
+}
+
+// checkBatchNames rejects batch tools whose name or Go identifiers are
+// those of another tool, e.g. of a GetBatch method next to a batched Get.
+func checkBatchNames(services []serviceMeta) error {
+	names := make(map[string]*protogen.Method)
+	goNames := make(map[string]*protogen.Method)
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			names[m.toolName] = m.method
+			goNames[m.goName] = m.method
+		}
+	}
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			if !m.toolDoc.GetBatch() {
+				continue
+			}
+			if prev, ok := names[batchToolName(m)]; ok {
+				return fmt.Errorf("%s: batch tool name %q is taken by %s; give one of them a distinct (genkit.tool.v1.tool_doc).name", m.method.Desc.FullName(), batchToolName(m), prev.Desc.FullName())
+			}
+			if prev, ok := goNames[batchGoName(m)]; ok {
+				return fmt.Errorf("methods %s and %s generate the same Go identifiers; rename one of them", m.method.Desc.FullName(), prev.Desc.FullName())
+			}
+		}
+	}
+	return nil
+}
+
+// batchMethods returns the methods of methods declared with batch.
+func batchMethods(methods []methodMeta) []methodMeta {
+	var out []methodMeta
+	for _, m := range methods {
+		if m.toolDoc.GetBatch() {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// writeBatchTool emits the <tool>_batch companion of meta's tool, which
+// calls the implementation once per request listed in its input, with
+// bounded concurrency, and returns a result or error per request. Its
+// schemas are derived from the tool's and written to schemas.
+func writeBatchTool(g, schemas *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta) {
+	outType := "*" + g.QualifiedGoIdent(genkittoolsPackage.Ident("BatchOutput")) + "[" + toolOutputType(g, meta.method) + "]"
+	infoVar := toolInfoVarName(meta) + "Batch"
+	schemaVar := schemaVarName(meta) + "Batch"
+	outputSchemaVar := outputSchemaVarName(meta) + "Batch"
+	description := fmt.Sprintf("Call %s for each of several requests at once, returning a result or an error per request, in order. %s", meta.toolName, meta.description)
+
+	writeToolSchemas(schemas, infoVar, schemaVar,
+		schemas.QualifiedGoIdent(genkittoolsPackage.Ident("BatchSchema"))+"("+schemaVarName(meta)+")", outputSchemaVar,
+		schemas.QualifiedGoIdent(genkittoolsPackage.Ident("BatchOutputSchema"))+"("+outputSchemaVarName(meta)+")")
+	g.P("var ", infoVar, " = &", genkittoolsPackage.Ident("ToolInfo"), "{")
+	g.P("Name: string(", batchToolConstName(meta), "),")
+	g.P("Description: ", strconv.Quote(description), ",")
+	if tags := meta.toolDoc.GetTags(); len(tags) > 0 {
+		g.P("Tags: ", renderSchemaLiteral(tags), ",")
+	}
+	writeToolMetadata(g, meta.toolDoc)
+	g.P("Service: ", strconv.Quote(string(svc.Desc.FullName())), ",")
+	g.P("GeneratedWith: ", svc.GoName, "GeneratedWith,")
+	if !runtimeCodegen() {
+		g.P("InputSchema: ", schemaVar, ",")
+		g.P("OutputSchema: ", outputSchemaVar, ",")
+	}
+	g.P("}")
+	g.P()
+
+	if *lazyTools {
+		newName := "New" + batchGoName(meta) + "Tool"
+		g.P("// ", newName, " binds impl to an unregistered ", batchToolName(meta), " tool.")
+		g.P("func ", newName, "(impl ", implParamType(svc, meta), ", opts ...", genkittoolsPackage.Ident("Option"), ") ", genkitAIPackage.Ident("Tool"), " {")
+		g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	} else {
+		funcName := "define" + batchGoName(meta) + "Tool"
+		g.P("// ", funcName, " defines the ", batchToolName(meta), " tool, calling ", meta.toolName)
+		g.P("// for every request listed in its input.")
+		g.P("func ", funcName, "(g *genkit.Genkit, impl ", implParamType(svc, meta), ", o *", genkittoolsPackage.Ident("Options"), ") (", genkitAIPackage.Ident("Tool"), ", error) {")
+	}
+	def := toolDefinition{inType: "any", outType: outType, schemaVar: schemaVar}
+	writeDefinitionStart(g, def, infoVar)
+	g.P("func(ctx *", genkitAIPackage.Ident("ToolContext"), ", input any) (", outType, ", error) {")
+	if implToolContext() {
+		// The callback of RunBatch shadows ctx.
+		g.P("tc := ctx")
+	}
+	g.P("return ", genkittoolsPackage.Ident("RunBatch"), "(ctx, o, string(", batchToolConstName(meta), "), input, func(ctx context.Context, input any) (", toolOutputType(g, meta.method), ", error) {")
+	writeInvoke(g, svc, meta, "return ", "tc")
+	g.P("})")
+	g.P("},")
+	writeDefinitionEnd(g, def)
+	g.P("}")
+	g.P()
+}
+
+func batchToolName(meta methodMeta) string {
+	return meta.toolName + toolNameSuffix("batch")
+}
+
+func batchGoName(meta methodMeta) string {
+	return meta.goName + "Batch"
+}
+
+func batchToolConstName(meta methodMeta) string {
+	return batchGoName(meta) + "Tool"
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// checkBreakingChanges compares the schemas of the tools in files with the
+// snapshot schema_out wrote into dir, relative to the working directory,
+// and fails listing the changes that break callers written against the
+// snapshot: removed fields, type changes and, in inputs, newly required
+// fields and removed enum values. Tools missing from the snapshot are new
+// and are not checked.
+func checkBreakingChanges(files []fileMeta, dir string) error {
+	var changes []string
+	for _, f := range files {
+		for _, svc := range f.services {
+			for _, m := range svc.methods {
+				for _, kind := range []string{"input", "output"} {
+					schema := m.inputSchema
+					if kind == "output" {
+						schema = m.outputSchema
+					}
+					name := filepath.Join(dir, m.toolName+"."+kind+".schema.json")
+					raw, err := os.ReadFile(name)
+					if errors.Is(err, fs.ErrNotExist) {
+						continue
+					}
+					if err != nil {
+						return fmt.Errorf("breaking_against: %w", err)
+					}
+					var old map[string]any
+					if err := json.Unmarshal(raw, &old); err != nil {
+						return fmt.Errorf("breaking_against: %s: %w", name, err)
+					}
+					changes = append(changes, schemaChanges(m.toolName+" "+kind, "", old, jsonSchema(schema), kind == "input")...)
+				}
+			}
+		}
+	}
+	if len(changes) > 0 {
+		return fmt.Errorf("breaking_against=%s: tool schemas have breaking changes:\n\t%s", dir, strings.Join(changes, "\n\t"))
+	}
+	return nil
+}
+
+// jsonSchema returns schema as encoding/json decodes it, so that it
+// compares with the snapshot.
+func jsonSchema(schema map[string]any) map[string]any {
+	raw := marshalSchemaJSON(schema)
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// schemaChanges lists the breaking changes from old to new at path of the
+// schema named where.
+func schemaChanges(where, path string, old, new map[string]any, input bool) []string {
+	at := where
+	if path != "" {
+		at += " " + path
+	}
+	field := func(name any) string {
+		return fmt.Sprintf("%s %s/%v", where, path, name)
+	}
+	if !reflect.DeepEqual(old["type"], new["type"]) {
+		return []string{fmt.Sprintf("%s: type changed from %v to %v", at, typeName(old["type"]), typeName(new["type"]))}
+	}
+
+	var out []string
+	if input {
+		newEnum, _ := new["enum"].([]any)
+		oldEnum, _ := old["enum"].([]any)
+		for _, v := range oldEnum {
+			if newEnum != nil && !slices.Contains(newEnum, v) {
+				out = append(out, fmt.Sprintf("%s: enum value %v removed", at, v))
+			}
+		}
+
+		oldRequired := make(map[any]bool)
+		if required, ok := old["required"].([]any); ok {
+			for _, name := range required {
+				oldRequired[name] = true
+			}
+		}
+		if required, ok := new["required"].([]any); ok {
+			for _, name := range required {
+				if !oldRequired[name] {
+					out = append(out, field(name)+": now required")
+				}
+			}
+		}
+	}
+
+	oldProps, _ := old["properties"].(map[string]any)
+	newProps, _ := new["properties"].(map[string]any)
+	names := make([]string, 0, len(oldProps))
+	for name := range oldProps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		newProp, ok := newProps[name].(map[string]any)
+		if !ok {
+			out = append(out, field(name)+": removed")
+			continue
+		}
+		if oldProp, ok := oldProps[name].(map[string]any); ok {
+			out = append(out, schemaChanges(where, path+"/"+name, oldProp, newProp, input)...)
+		}
+	}
+
+	for _, key := range []string{"items", "additionalProperties"} {
+		oldSub, ok1 := old[key].(map[string]any)
+		newSub, ok2 := new[key].(map[string]any)
+		if ok1 && ok2 {
+			out = append(out, schemaChanges(where, path+"/*", oldSub, newSub, input)...)
+		}
+	}
+	return out
+}
+
+func typeName(t any) string {
+	if t == nil {
+		return "any"
+	}
+	return fmt.Sprint(t)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// writeCallConfig emits <Service>CallConfig, a struct with a field per
+// context_key of the requests of svc, and the functions attaching it to and
+// reading it from a context. Attached to the context passed to
+// genkit.Generate, it fills the annotated request fields of every tool call.
+func writeCallConfig(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	keys := callConfigKeys(methods)
+	if len(keys) == 0 {
+		return
+	}
+	configName := svc.GoName + "CallConfig"
+	fields := make([]string, len(keys))
+	taken := make(map[string]bool)
+	for i, key := range keys {
+		fields[i] = callConfigFieldName(key)
+		for n := 2; taken[fields[i]]; n++ {
+			fields[i] = callConfigFieldName(key) + strconv.Itoa(n)
+		}
+		taken[fields[i]] = true
+	}
+
+	g.P("// ", configName, " is the per-call configuration of the tools of ", svc.GoName, ".")
+	g.P("// Attach it with With", configName, " to the context passed to genkit.Generate:")
+	g.P("// the request fields annotated with its context keys are filled from it,")
+	g.P("// never from the model input. Empty fields are not attached.")
+	g.P("type ", configName, " struct {")
+	for i, key := range keys {
+		g.P("// ", fields[i], " is attached under the context_key ", strconv.Quote(key), ".")
+		g.P(fields[i], " string")
+	}
+	g.P("}")
+	g.P()
+
+	g.P("// With", configName, " returns a copy of ctx carrying cfg as caller metadata,")
+	g.P("// keeping any metadata attached earlier that cfg leaves empty.")
+	g.P("func With", configName, "(ctx context.Context, cfg ", configName, ") context.Context {")
+	g.P("md := make(map[string]string, ", len(keys), ")")
+	for i, key := range keys {
+		g.P("if cfg.", fields[i], ` != "" {`)
+		g.P("md[", strconv.Quote(key), "] = cfg.", fields[i])
+		g.P("}")
+	}
+	g.P("return ", genkittoolsPackage.Ident("ContextWithMetadata"), "(ctx, md)")
+	g.P("}")
+	g.P()
+
+	g.P("// ", configName, "FromContext returns the configuration attached to ctx as")
+	g.P("// caller metadata, for implementations to read. It reads the metadata of")
+	g.P("// genkittools.ContextWithMetadata, not that of a custom MetadataExtractor.")
+	g.P("func ", configName, "FromContext(ctx context.Context) ", configName, " {")
+	g.P("var cfg ", configName)
+	for i, key := range keys {
+		g.P("cfg.", fields[i], ", _ = ", genkittoolsPackage.Ident("MetadataFromContext"), "(ctx, ", strconv.Quote(key), ")")
+	}
+	g.P("return cfg")
+	g.P("}")
+	g.P()
+}
+
+// callConfigKeys returns the context keys of the requests of methods, sorted.
+func callConfigKeys(methods []methodMeta) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range methods {
+		for _, key := range m.contextFields {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// callConfigFieldName returns the Go field name of a context key, camel
+// casing its letter and digit runs: user_id gives UserId and x-tenant
+// gives XTenant.
+func callConfigFieldName(key string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		b.WriteString(capitalize(part))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		name = "Key" + name
+	}
+	return name
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const osPackage = protogen.GoImportPath("os")
+
+// generateCLIFile emits Run<Service>CLI functions exposing the tools of each
+// service on the command line into a companion _genkit.tools_cli.go file.
+func generateCLIFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_cli.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		writeServiceCLI(g, svc.service, svc.methods)
+	}
+}
+
+func writeServiceCLI(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	name := strings.ToLower(svc.GoName) + "-tools"
+
+	g.P("// Run", svc.GoName, "CLI runs the tools of ", svc.GoName, " on impl from the command")
+	g.P("// line, reading input from stdin and writing output to stdout, so the")
+	g.P("// coercion and the implementation can be exercised without a model:")
+	g.P("//")
+	g.P("//\t", name, " list")
+	g.P("//\t", name, " schema <tool>")
+	g.P("//\t", name, " call <tool> -input '{...}'")
+	g.P("//")
+	g.P("// Call it from main with os.Args[1:]. The options apply as they do to the")
+	g.P("// Genkit tools of the service.")
+	g.P("func Run", svc.GoName, "CLI(ctx ", contextPackage.Ident("Context"), ", impl ", implName, ", args []string, opts ...", genkittoolsPackage.Ident("Option"), ") error {")
+	g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	g.P("var tools []", genkittoolsPackage.Ident("CLITool"))
+	for _, m := range methods {
+		g.P("if o.Includes(string(", toolConstName(m), ")) {")
+		g.P("tools = append(tools, ", genkittoolsPackage.Ident("CLITool"), "{")
+		g.P("Info: ", toolInfoVarName(m), ",")
+		g.P("Call: func(ctx context.Context, input any) (any, error) {")
+		writeInvoke(g, svc, m, "return ", "")
+		g.P("},")
+		g.P("})")
+		g.P("}")
+	}
+	g.P("return ", genkittoolsPackage.Ident("RunCLI"), "(ctx, ", `"`, name, `"`, ", tools, args, ", osPackage.Ident("Stdin"), ", ", osPackage.Ident("Stdout"), ")")
+	g.P("}")
+	g.P()
+}
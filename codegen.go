@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// With codegen=runtime, tool schemas and request decoding are left to
+// genkittools, which derives them from the message descriptors at run time,
+// instead of being inlined as schema literals and per-message decoders. The
+// helpers below render either form.
+
+func runtimeCodegen() bool {
+	return *codegen == "runtime"
+}
+
+// inputSchemaExpr renders the input schema of meta's tool.
+func inputSchemaExpr(g *protogen.GeneratedFile, meta methodMeta) string {
+	if !runtimeCodegen() {
+		return renderSchema(g, meta.inputSchema)
+	}
+	expr := inputMessageSchemaExpr(g, meta.method.Input)
+	if meta.method.Desc.IsStreamingClient() {
+		expr = g.QualifiedGoIdent(genkittoolsPackage.Ident("BatchSchema")) + "(" + expr + ")"
+	}
+	return describeSchemaExpr(g, expr, meta.toolDoc.GetInput())
+}
+
+// outputSchemaExpr renders the output schema of meta's tool.
+func outputSchemaExpr(g *protogen.GeneratedFile, meta methodMeta) string {
+	if !runtimeCodegen() || resultFormat(meta.toolDoc) != pb.ResultFormat_RESULT_FORMAT_STRUCTURED {
+		return renderSchema(g, meta.outputSchema)
+	}
+	expr := omitMediaExpr(g, meta, messageSchemaExpr(g, meta.method.Output))
+	if meta.method.Desc.IsStreamingServer() && *streamingMode == "aggregate" {
+		expr = listSchemaExpr(g, expr)
+	}
+	return describeSchemaExpr(g, expr, meta.toolDoc.GetOutput())
+}
+
+// renderSchema renders an inlined schema: a map literal, with
+// schema_format=json a genkittools.ParseSchema call on its indented JSON,
+// which reads and diffs like the schema_out files, or with
+// schema_format=struct a typed genkittools.Schema literal.
+func renderSchema(g *protogen.GeneratedFile, schema map[string]any) string {
+	switch *schemaFormat {
+	case "json":
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(schema); err != nil {
+			// Schemas only hold plain maps, slices and scalars.
+			panic(err)
+		}
+		// A raw string cannot hold a backtick, so those are spliced in.
+		raw := "`" + strings.ReplaceAll(strings.TrimSuffix(b.String(), "\n"), "`", "` + \"`\" + `") + "`"
+		return g.QualifiedGoIdent(genkittoolsPackage.Ident("ParseSchema")) + "(" + raw + ")"
+	case "struct":
+		return g.QualifiedGoIdent(genkittoolsPackage.Ident("Schema")) + "{" + renderSchemaFields(g, schema) + "}.Map()"
+	}
+	return renderSchemaLiteral(schema)
+}
+
+// renderSchemaFields renders the fields of the genkittools.Schema literal
+// holding schema, mirroring genkittools.SchemaFromMap.
+func renderSchemaFields(g *protogen.GeneratedFile, schema map[string]any) string {
+	schemaType := g.QualifiedGoIdent(genkittoolsPackage.Ident("Schema"))
+	nested := func(v any) string {
+		return "&" + schemaType + "{" + renderSchemaFields(g, v.(map[string]any)) + "}"
+	}
+	byName := func(v any) string {
+		schemas := v.(map[string]any)
+		names := make([]string, 0, len(schemas))
+		for name := range schemas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var parts []string
+		for _, name := range names {
+			parts = append(parts, strconv.Quote(name)+": {"+renderSchemaFields(g, schemas[name].(map[string]any))+"}")
+		}
+		return "map[string]*" + schemaType + "{" + strings.Join(parts, ", ") + "}"
+	}
+
+	fields := make(map[string]string, len(schema))
+	nullable := false
+	for k, v := range schema {
+		switch k {
+		case "type":
+			if types, ok := v.([]string); ok {
+				// schema_draft writes [type, "null"].
+				v, nullable = types[0], true
+			}
+			fields["Type"] = strconv.Quote(v.(string))
+		case "description", "format", "pattern":
+			fields[strings.ToUpper(k[:1])+k[1:]] = strconv.Quote(v.(string))
+		case "enum":
+			if values, ok := v.([]any); ok {
+				var enum []string
+				for _, value := range values[:len(values)-1] {
+					enum = append(enum, value.(string))
+				}
+				v, nullable = enum, true
+			}
+			fields["Enum"] = renderSchemaLiteral(v)
+		case "example":
+			fields["Example"] = renderSchemaLiteral(v)
+		case "examples":
+			fields["Examples"] = renderSchemaLiteral(v)
+		case "properties":
+			fields["Properties"] = byName(v)
+		case "required":
+			fields["Required"] = renderSchemaLiteral(v)
+		case "additionalProperties":
+			fields["AdditionalProperties"] = nested(v)
+		case "items":
+			fields["Items"] = nested(v)
+		case "$ref":
+			fields["Ref"] = strconv.Quote(v.(string))
+		case "$defs":
+			fields["Defs"] = byName(v)
+		case "definitions":
+			fields["Definitions"] = byName(v)
+		default:
+			panic(fmt.Sprintf("schema_format=struct: genkittools.Schema has no %q keyword", k))
+		}
+	}
+	if nullable {
+		fields["Nullable"] = "true"
+	}
+
+	var parts []string
+	for _, name := range []string{
+		"Type", "Nullable", "Description", "Format", "Pattern", "Enum", "Example", "Examples",
+		"Properties", "Required", "AdditionalProperties", "Items", "Ref", "Defs", "Definitions",
+	} {
+		if expr, ok := fields[name]; ok {
+			parts = append(parts, name+": "+expr)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// messageSchemaExpr renders the genkittools.MessageSchema call building the
+// schema of msg, or the MessageSchemaForDraft call with schema_draft set.
+func messageSchemaExpr(g *protogen.GeneratedFile, msg *protogen.Message) string {
+	desc := "(*" + g.QualifiedGoIdent(msg.GoIdent) + ")(nil).ProtoReflect().Descriptor()"
+	switch *schemaDraft {
+	case "2020-12":
+		return g.QualifiedGoIdent(genkittoolsPackage.Ident("MessageSchemaForDraft")) + "(" + desc + ", " + g.QualifiedGoIdent(genkittoolsPackage.Ident("Draft2020_12")) + ")"
+	case "draft-07":
+		return g.QualifiedGoIdent(genkittoolsPackage.Ident("MessageSchemaForDraft")) + "(" + desc + ", " + g.QualifiedGoIdent(genkittoolsPackage.Ident("Draft07")) + ")"
+	}
+	return g.QualifiedGoIdent(genkittoolsPackage.Ident("MessageSchema")) + "(" + desc + ")"
+}
+
+// inputMessageSchemaExpr renders the schema of msg as a request: with its
+// output-only fields omitted by genkittools.OmitOutputOnly.
+func inputMessageSchemaExpr(g *protogen.GeneratedFile, msg *protogen.Message) string {
+	expr := messageSchemaExpr(g, msg)
+	if !hasOutputOnlyFields(msg.Desc, nil) {
+		return expr
+	}
+	desc := "(*" + g.QualifiedGoIdent(msg.GoIdent) + ")(nil).ProtoReflect().Descriptor()"
+	return g.QualifiedGoIdent(genkittoolsPackage.Ident("OmitOutputOnly")) + "(" + expr + ", " + desc + ")"
+}
+
+func listSchemaExpr(g *protogen.GeneratedFile, items string) string {
+	return g.QualifiedGoIdent(genkittoolsPackage.Ident("ListSchema")) + "(" + items + ")"
+}
+
+func describeSchemaExpr(g *protogen.GeneratedFile, expr, description string) string {
+	if description == "" {
+		return expr
+	}
+	return g.QualifiedGoIdent(genkittoolsPackage.Ident("DescribeSchema")) + "(" + expr + ", " + strconv.Quote(description) + ")"
+}
+
+// writeToolSchemas declares the schema variables inputVar and outputVar of
+// the tool described by infoVar, built by inputExpr and outputExpr. An
+// empty expression refers to a variable declared elsewhere. With
+// codegen=runtime, the variables and the schemas of infoVar are set by an
+// init function: descriptors are only usable once the .pb.go files, which
+// sort first, have been initialized.
+func writeToolSchemas(g *protogen.GeneratedFile, infoVar, inputVar, inputExpr, outputVar, outputExpr string) {
+	if !runtimeCodegen() {
+		if inputExpr != "" {
+			g.P("var ", inputVar, " = ", inputExpr)
+			g.P()
+		}
+		g.P("var ", outputVar, " = ", outputExpr)
+		g.P()
+		return
+	}
+	if inputExpr != "" {
+		g.P("var ", inputVar, " map[string]any")
+		g.P()
+	}
+	g.P("var ", outputVar, " map[string]any")
+	g.P()
+	g.P("func init() {")
+	if inputExpr != "" {
+		g.P(inputVar, " = ", inputExpr)
+	}
+	g.P(outputVar, " = ", outputExpr)
+	g.P(infoVar, ".InputSchema = ", inputVar)
+	g.P(infoVar, ".OutputSchema = ", outputVar)
+	g.P("}")
+	g.P()
+}
+
+// writeRuntimeCoerce emits the body of the coercion function of meta's tool
+// with codegen=runtime.
+func writeRuntimeCoerce(g *protogen.GeneratedFile, meta methodMeta) {
+	reqName := g.QualifiedGoIdent(meta.method.Input.GoIdent)
+	call := g.QualifiedGoIdent(genkittoolsPackage.Ident("Coerce")) + "(input, " + strconv.Quote(meta.toolName) + ", &" + reqName + "{})"
+	if !*useProtovalidate {
+		g.P("return ", call)
+		return
+	}
+	g.P("req, err := ", call)
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	writeProtovalidateCheck(g, meta)
+	g.P("return req, nil")
+}
+
+func hasInterruptible(services []serviceMeta) bool {
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			if m.toolDoc.GetInterruptible() || confirmsCalls(m.toolDoc) {
+				return true
+			}
+		}
+	}
+	return false
+}
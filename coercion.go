@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const jsonPackage = protogen.GoImportPath("encoding/json")
+
+// generateCoercionTestFile emits a test per service that round-trips a
+// populated request of each tool through its coercion function, into a
+// companion _genkit.tools_coercion_test.go file.
+func generateCoercionTestFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_coercion_test.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		name := svc.service.GoName
+		g.P("// Test", name, "ToolCoercion builds a request of each tool of ", name, " with every")
+		g.P("// field set, encodes it the way its input schema describes and checks that")
+		g.P("// the generated coercion decodes it back unchanged.")
+		g.P("func Test", name, "ToolCoercion(t *", testingPackage.Ident("T"), ") {")
+		for _, m := range svc.methods {
+			reqName := g.QualifiedGoIdent(m.method.Input.GoIdent)
+			raw := sampleRequest(m)
+
+			g.P("t.Run(string(", toolConstName(m), "), func(t *", testingPackage.Ident("T"), ") {")
+			g.P("want := &", reqName, "{}")
+			g.P("if err := ", protojsonPackage.Ident("Unmarshal"), "([]byte(", strconv.Quote(raw), "), want); err != nil {")
+			g.P("t.Fatal(err)")
+			g.P("}")
+			g.P("if err := ", genkittoolsPackage.Ident("CheckRoundTrip"), "(want, ", coerceFuncName(m), "); err != nil {")
+			g.P("t.Error(err)")
+			g.P("}")
+			g.P("})")
+		}
+		g.P("}")
+		g.P()
+	}
+}
+
+// sampleRequest returns the protojson form of a request of m with every
+// field the model sends populated.
+func sampleRequest(m methodMeta) string {
+	sample := fakeMessageValue(m.method.Input.Desc, nil)
+	stripUnsentFields(m.method.Input.Desc, sample)
+	raw, err := json.Marshal(sample)
+	if err != nil {
+		// Only plain maps, slices and scalars are produced, so this cannot fail.
+		panic(err)
+	}
+	return string(raw)
+}
+
+// stripUnsentFields removes the fields the model never sends from value,
+// the protojson form of msg built by fakeMessageValue: those filled from
+// caller metadata and output-only ones, which coercion ignores.
+func stripUnsentFields(msg protoreflect.MessageDescriptor, value map[string]any) {
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		if getFieldDoc(field).GetContextKey() != "" || isOutputOnly(field) {
+			delete(value, field.JSONName())
+			continue
+		}
+		if field.Message() == nil || field.IsList() || field.IsMap() {
+			continue
+		}
+		if nested, ok := value[field.JSONName()].(map[string]any); ok && wellKnownSchema(field.Message().FullName()) == nil {
+			stripUnsentFields(field.Message(), nested)
+		}
+	}
+}
+
+// generateFuzzTestFile emits a fuzz target per tool feeding arbitrary JSON to
+// its coercion function, into a companion _genkit.tools_fuzz_test.go file.
+func generateFuzzTestFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_fuzz_test.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			raw := sampleRequest(m)
+
+			g.P("// FuzzCoerce", m.goName, "Input feeds arbitrary JSON to the input coercion of")
+			g.P("// ", m.toolName, ", which must reject malformed model output with an error")
+			g.P("// instead of panicking or building an invalid request.")
+			g.P("func FuzzCoerce", m.goName, "Input(f *", testingPackage.Ident("F"), ") {")
+			for _, seed := range []string{raw, "{}", "null", "[]", `""`} {
+				g.P("f.Add([]byte(", strconv.Quote(seed), "))")
+			}
+			g.P("f.Fuzz(func(t *", testingPackage.Ident("T"), ", raw []byte) {")
+			g.P("var input any")
+			g.P("if err := ", jsonPackage.Ident("Unmarshal"), "(raw, &input); err != nil {")
+			g.P("t.Skip()")
+			g.P("}")
+			g.P("if err := ", genkittoolsPackage.Ident("CheckCoerce"), "(input, ", coerceFuncName(m), "); err != nil {")
+			g.P("t.Error(err)")
+			g.P("}")
+			g.P("})")
+			g.P("}")
+			g.P()
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+)
+
+// resolveCollisions makes the Go identifiers and tool names generated for
+// services unique within their file. Colliding identifiers and derived tool
+// names are disambiguated deterministically; collisions only a change to the
+// proto can fix are reported as errors.
+func resolveCollisions(services []serviceMeta) error {
+	if err := checkServiceNames(services); err != nil {
+		return err
+	}
+	if err := resolveGoNames(services); err != nil {
+		return err
+	}
+	resolveToolNames(services)
+	if err := checkBatchNames(services); err != nil {
+		return err
+	}
+	return checkServiceToolNames(services)
+}
+
+// checkServiceNames rejects services whose Go names, and hence
+// Register<Service>Tools and friends, are the same.
+func checkServiceNames(services []serviceMeta) error {
+	caseFold := *generateMarkdown || *generateOpenAPI || *generateDotprompt || *anthropicTools || *generateA2A
+	seen := make(map[string]*protogen.Service)
+	for _, svc := range services {
+		key := svc.service.GoName
+		if caseFold {
+			// Markdown, OpenAPI, prompt, Anthropic and agent card files are
+			// named after the lower-cased service.
+			key = strings.ToLower(key)
+		}
+		if prev, ok := seen[key]; ok {
+			return fmt.Errorf("services %s and %s generate the same Go identifiers and file names; rename one of them", prev.Desc.FullName(), svc.service.Desc.FullName())
+		}
+		seen[key] = svc.service
+	}
+	return nil
+}
+
+// methodGoName returns the prefix of the Go identifiers generated for m:
+// the go_name of doc, or <Service><Method>.
+func methodGoName(svc *protogen.Service, m *protogen.Method, doc *pb.ToolDoc) string {
+	if name := doc.GetGoName(); name != "" {
+		return name
+	}
+	return svc.GoName + m.GoName
+}
+
+// checkGoName reports a go_name of doc that is not an exported Go
+// identifier.
+func checkGoName(m *protogen.Method, doc *pb.ToolDoc) error {
+	name := doc.GetGoName()
+	if name != "" && (!token.IsIdentifier(name) || !token.IsExported(name)) {
+		return fmt.Errorf("%s: go_name %q is not an exported Go identifier", m.Desc.FullName(), name)
+	}
+	return nil
+}
+
+// resolveGoNames switches methods whose <Service><Method> identifiers
+// collide, such as Foo.BarGet and FooBar.Get, to <Service>_<Method>.
+// Names set with go_name are kept; a collision with one is an error.
+func resolveGoNames(services []serviceMeta) error {
+	count := make(map[string]int)
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			count[m.goName]++
+		}
+	}
+	for _, svc := range services {
+		for i := range svc.methods {
+			m := &svc.methods[i]
+			if count[m.goName] > 1 && m.toolDoc.GetGoName() == "" {
+				m.goName = svc.service.GoName + "_" + m.method.GoName
+			}
+		}
+	}
+
+	seen := make(map[string]*protogen.Method)
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			if prev, ok := seen[m.goName]; ok {
+				return fmt.Errorf("methods %s and %s generate the same Go identifiers; rename one of them", prev.Desc.FullName(), m.method.Desc.FullName())
+			}
+			seen[m.goName] = m.method
+		}
+	}
+	return nil
+}
+
+// resolveToolNames numbers derived tool names that are taken, in
+// declaration order: orders_get, orders_get_2, ... Names set with
+// (genkit.tool.v1.tool_doc).name are kept; checkDuplicateToolNames reports
+// those set twice.
+func resolveToolNames(services []serviceMeta) {
+	claimed := make(map[string]*protogen.Method)
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			if m.toolDoc.GetName() != "" && claimed[m.toolName] == nil {
+				claimed[m.toolName] = m.method
+			}
+		}
+	}
+
+	for _, svc := range services {
+		for i := range svc.methods {
+			m := &svc.methods[i]
+			if m.toolDoc.GetName() != "" {
+				continue
+			}
+			name := m.toolName
+			for n := 2; claimed[name] != nil; n++ {
+				name = m.toolName + toolNameSuffix(strconv.Itoa(n))
+			}
+			if name != m.toolName {
+				fmt.Fprintf(os.Stderr, "protoc-gen-go-genkit-tools: tool name %q of %s is taken by %s; using %q\n", m.toolName, m.method.Desc.FullName(), claimed[m.toolName].Desc.FullName(), name)
+				m.toolName = name
+			}
+			claimed[name] = m.method
+		}
+	}
+}
+
+// checkDuplicateToolNames fails when two methods or service-level tools
+// anywhere in the request map to the same tool name, which Genkit would
+// silently overwrite on registration.
+func checkDuplicateToolNames(files []fileMeta) error {
+	seen := make(map[string]*protogen.Method)
+	for _, f := range files {
+		for _, svc := range f.services {
+			for _, m := range svc.methods {
+				prev, ok := seen[m.toolName]
+				if !ok {
+					seen[m.toolName] = m.method
+					continue
+				}
+				return fmt.Errorf("tool name %q is used by both %s (%s) and %s (%s); give one of them a distinct (genkit.tool.v1.tool_doc).name",
+					m.toolName, prev.Desc.FullName(), sourcePosition(prev.Desc), m.method.Desc.FullName(), sourcePosition(m.method.Desc))
+			}
+		}
+	}
+	extra := make(map[string]*protogen.Service)
+	for _, f := range files {
+		for _, svc := range f.services {
+			for _, t := range serviceTools(svc) {
+				if m, ok := seen[t.name]; ok {
+					return fmt.Errorf("%s tool name %q of %s is used by %s (%s); give it a distinct (genkit.tool.v1.tool_doc).name", t.kind, t.name, svc.service.Desc.FullName(), m.Desc.FullName(), sourcePosition(m.Desc))
+				}
+				if prev, ok := extra[t.name]; ok {
+					return fmt.Errorf("services %s and %s have the same %s tool name %q; rename one of them or set %s=false", prev.Desc.FullName(), svc.service.Desc.FullName(), t.kind, t.name, t.option)
+				}
+				extra[t.name] = svc.service
+			}
+		}
+	}
+	return nil
+}
+
+// serviceTool is a tool generated for a service as a whole rather than for
+// one of its methods, such as the health tool of health=true.
+type serviceTool struct {
+	kind   string
+	option string
+	name   string
+	goName string
+}
+
+// serviceTools returns the service-level tools generated for svc.
+func serviceTools(svc serviceMeta) []serviceTool {
+	if len(svc.methods) == 0 {
+		return nil
+	}
+	var tools []serviceTool
+	if *healthTools {
+		tools = append(tools, serviceTool{"health", "health", healthToolName(svc.service), healthGoName(svc.service)})
+	}
+	if *listTools {
+		tools = append(tools, serviceTool{"list_tools", "list_tools", listToolsToolName(svc.service), listToolsGoName(svc.service)})
+	}
+	return tools
+}
+
+// checkServiceToolNames rejects service-level tools whose Go identifiers
+// are those of a method's tool, e.g. of a method called Health. Their tool
+// names are checked across the request by checkDuplicateToolNames.
+func checkServiceToolNames(services []serviceMeta) error {
+	for _, svc := range services {
+		for _, t := range serviceTools(svc) {
+			for _, other := range services {
+				for _, m := range other.methods {
+					if m.goName == t.goName || batchGoName(m) == t.goName {
+						return fmt.Errorf("%s: the %s tool of %s generates the same Go identifiers; rename the method or set %s=false", m.method.Desc.FullName(), t.kind, svc.service.Desc.FullName(), t.option)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sourcePosition returns the file:line:column at which desc is declared, or
+// just the file when the request carries no source info.
+func sourcePosition(desc protoreflect.Descriptor) string {
+	file := desc.ParentFile()
+	loc := file.SourceLocations().ByDescriptor(desc)
+	if loc.Path == nil {
+		return file.Path()
+	}
+	return fmt.Sprintf("%s:%d:%d", file.Path(), loc.StartLine+1, loc.StartColumn+1)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const connectPackage = protogen.GoImportPath("connectrpc.com/connect")
+
+// connectPackageSuffix matches the default package_suffix of
+// protoc-gen-connect-go, whose clients live in a <package>connect sub-package.
+const connectPackageSuffix = "connect"
+
+// generateConnectFile emits New<Service>ToolImpl adapters wrapping
+// protoc-gen-connect-go clients. They are written into the Connect
+// sub-package, next to the clients, because that package imports the base
+// package.
+func generateConnectFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	pkgName := string(file.GoPackageName) + connectPackageSuffix
+	prefix := filepath.ToSlash(file.GeneratedFilenamePrefix)
+	filename := path.Join(path.Dir(prefix), pkgName, path.Base(prefix)) + "_genkit.tools_connect.go"
+	g := plugin.NewGeneratedFile(filename, protogen.GoImportPath(path.Join(string(file.GoImportPath), pkgName)))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", pkgName)
+	g.P()
+
+	for _, svc := range services {
+		writeServiceConnectAdapter(g, file, svc.service, svc.methods)
+	}
+}
+
+func writeServiceConnectAdapter(g *protogen.GeneratedFile, file *protogen.File, svc *protogen.Service, methods []methodMeta) {
+	implName := g.QualifiedGoIdent(toolsImportPath(file).Ident(svc.GoName + "ToolImpl"))
+	unexported := strings.ToLower(svc.GoName[:1]) + svc.GoName[1:]
+	adapterName := unexported + "ToolImpl"
+	errFunc := unexported + "ToolError"
+
+	g.P("// New", svc.GoName, "ToolImpl implements ", implName, " by forwarding")
+	g.P("// every tool call through client. Connect errors are reported as")
+	g.P("// *genkittools.RemoteError.")
+	g.P("func New", svc.GoName, "ToolImpl(client ", svc.GoName, "Client) ", implName, " {")
+	g.P("return &", adapterName, "{client: client}")
+	g.P("}")
+	g.P()
+	g.P("// New", svc.GoName, "ToolImplWithHeaders is New", svc.GoName, "ToolImpl adding headers,")
+	g.P("// read from the context of each tool call, to the request headers of every")
+	g.P("// call, so that backend calls carry the caller's credentials and request IDs.")
+	g.P("func New", svc.GoName, "ToolImplWithHeaders(client ", svc.GoName, "Client, headers ", genkittoolsPackage.Ident("Headers"), ") ", implName, " {")
+	g.P("return &", adapterName, "{client: client, headers: headers}")
+	g.P("}")
+	g.P()
+	g.P("type ", adapterName, " struct {")
+	g.P("client  ", svc.GoName, "Client")
+	g.P("headers ", genkittoolsPackage.Ident("Headers"))
+	g.P("}")
+	g.P()
+
+	for _, m := range methods {
+		writeConnectAdapterMethod(g, adapterName, errFunc, m.method)
+	}
+
+	g.P("// ", errFunc, " strips transport details from Connect errors so the model")
+	g.P("// sees only the RPC code, the backend's message and its error details.")
+	g.P("func ", errFunc, "(err error) error {")
+	g.P("var cerr *", connectPackage.Ident("Error"))
+	g.P("if !", errorsPackage.Ident("As"), "(err, &cerr) {")
+	g.P("return err")
+	g.P("}")
+	g.P("rerr := &", genkittoolsPackage.Ident("RemoteError"), "{Code: cerr.Code().String(), Message: cerr.Message(), Err: err}")
+	g.P("for _, d := range cerr.Details() {")
+	g.P("rerr.AddDetail(d.Type(), d.Bytes())")
+	g.P("}")
+	g.P("return rerr")
+	g.P("}")
+	g.P()
+}
+
+func writeConnectAdapterMethod(g *protogen.GeneratedFile, adapterName, errFunc string, m *protogen.Method) {
+	clientStreaming := m.Desc.IsStreamingClient()
+	serverStreaming := m.Desc.IsStreamingServer()
+	params, results := implMethodParams(g, m, implContextType(g))
+
+	g.P("func (x *", adapterName, ") ", m.GoName, "(", params, ") ", results, " {")
+	switch {
+	case !clientStreaming && !serverStreaming:
+		g.P("r := ", connectPackage.Ident("NewRequest"), "(req)")
+		g.P("x.headers.Apply(ctx, r.Header())")
+		g.P("resp, err := x.client.", m.GoName, "(ctx, r)")
+		g.P("if err != nil {")
+		g.P("return nil, ", errFunc, "(err)")
+		g.P("}")
+		g.P("return resp.Msg, nil")
+	case !clientStreaming:
+		g.P("r := ", connectPackage.Ident("NewRequest"), "(req)")
+		g.P("x.headers.Apply(ctx, r.Header())")
+		g.P("stream, err := x.client.", m.GoName, "(ctx, r)")
+		g.P("if err != nil {")
+		g.P("return ", errFunc, "(err)")
+		g.P("}")
+		g.P("defer stream.Close()")
+		g.P("for stream.Receive() {")
+		g.P("if err := send(stream.Msg()); err != nil {")
+		g.P("return err")
+		g.P("}")
+		g.P("}")
+		g.P("if err := stream.Err(); err != nil {")
+		g.P("return ", errFunc, "(err)")
+		g.P("}")
+		g.P("return nil")
+	case !serverStreaming:
+		// Send reports io.EOF when the server aborted the stream; the status
+		// is then returned by CloseAndReceive.
+		g.P("stream := x.client.", m.GoName, "(ctx)")
+		g.P("x.headers.Apply(ctx, stream.RequestHeader())")
+		g.P("for req := range reqs {")
+		g.P("if err := stream.Send(req); err != nil {")
+		g.P("if ", errorsPackage.Ident("Is"), "(err, ", ioPackage.Ident("EOF"), ") {")
+		g.P("break")
+		g.P("}")
+		g.P("return nil, ", errFunc, "(err)")
+		g.P("}")
+		g.P("}")
+		g.P("resp, err := stream.CloseAndReceive()")
+		g.P("if err != nil {")
+		g.P("return nil, ", errFunc, "(err)")
+		g.P("}")
+		g.P("return resp.Msg, nil")
+	default:
+		g.P("stream := x.client.", m.GoName, "(ctx)")
+		g.P("x.headers.Apply(ctx, stream.RequestHeader())")
+		g.P("defer stream.CloseResponse()")
+		g.P("for req := range reqs {")
+		g.P("if err := stream.Send(req); err != nil {")
+		g.P("if ", errorsPackage.Ident("Is"), "(err, ", ioPackage.Ident("EOF"), ") {")
+		g.P("break")
+		g.P("}")
+		g.P("return ", errFunc, "(err)")
+		g.P("}")
+		g.P("}")
+		g.P("if err := stream.CloseRequest(); err != nil {")
+		g.P("return ", errFunc, "(err)")
+		g.P("}")
+		g.P("for {")
+		g.P("resp, err := stream.Receive()")
+		g.P("if ", errorsPackage.Ident("Is"), "(err, ", ioPackage.Ident("EOF"), ") {")
+		g.P("return nil")
+		g.P("}")
+		g.P("if err != nil {")
+		g.P("return ", errFunc, "(err)")
+		g.P("}")
+		g.P("if err := send(resp); err != nil {")
+		g.P("return err")
+		g.P("}")
+		g.P("}")
+	}
+	g.P("}")
+	g.P()
+}
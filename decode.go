@@ -0,0 +1,257 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const structpbPackage = protogen.GoImportPath("google.golang.org/protobuf/types/known/structpb")
+
+// decodedMessages returns the request messages of methods and every message
+// reachable from their fields, in discovery order. Well-known types are left
+// out: they are decoded with protojson.
+func decodedMessages(methods []methodMeta) []*protogen.Message {
+	var out []*protogen.Message
+	seen := make(map[protoreflect.FullName]bool)
+	var visit func(msg *protogen.Message)
+	visit = func(msg *protogen.Message) {
+		if seen[msg.Desc.FullName()] || isWellKnown(msg) {
+			return
+		}
+		seen[msg.Desc.FullName()] = true
+		out = append(out, msg)
+		for _, field := range msg.Fields {
+			switch {
+			case field.Desc.IsMap():
+				if value := field.Message.Fields[1]; value.Message != nil {
+					visit(value.Message)
+				}
+			case field.Message != nil:
+				visit(field.Message)
+			}
+		}
+	}
+	for _, m := range methods {
+		visit(m.method.Input)
+	}
+	return out
+}
+
+func isWellKnown(msg *protogen.Message) bool {
+	return strings.HasPrefix(string(msg.Desc.FullName()), "google.protobuf.")
+}
+
+// decoderFuncName names the decoder of msg generated for svc.
+func decoderFuncName(svc *protogen.Service, msg *protogen.Message) string {
+	var b strings.Builder
+	b.WriteString("decode")
+	b.WriteString(svc.GoName)
+	for _, part := range strings.FieldsFunc(string(msg.Desc.FullName()), func(r rune) bool { return r == '.' || r == '_' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// writeMessageDecoder emits a function decoding model input held in a
+// map[string]any into msg field by field, in key order, accepting the same
+// JSON as protojson without re-encoding the input.
+func writeMessageDecoder(g *protogen.GeneratedFile, svc *protogen.Service, msg *protogen.Message) {
+	name := decoderFuncName(svc, msg)
+	g.P("// ", name, " decodes the JSON object v into msg.")
+	g.P("func ", name, "(v any, path string, msg *", msg.GoIdent, ") error {")
+	g.P("obj, err := ", genkittoolsPackage.Ident("DecodeObject"), "(v, path)")
+	g.P("if err != nil {")
+	g.P("return err")
+	g.P("}")
+	// Keys are visited in order, so that the error reported for input with
+	// several invalid fields does not change from call to call.
+	g.P("for _, key := range ", slicesPackage.Ident("Sorted"), "(", mapsPackage.Ident("Keys"), "(obj)) {")
+	g.P("val := obj[key]")
+	g.P("if val == nil {")
+	// Only google.protobuf.Value keeps an explicit null.
+	var nullable []*protogen.Field
+	for _, field := range msg.Fields {
+		if !field.Desc.IsList() && !field.Desc.IsMap() && field.Message != nil && field.Message.Desc.FullName() == "google.protobuf.Value" {
+			nullable = append(nullable, field)
+		}
+	}
+	if len(nullable) > 0 {
+		g.P("switch key {")
+		for _, field := range nullable {
+			g.P("case ", fieldKeys(field), ":")
+			writeFieldAssign(g, field, "msg", g.QualifiedGoIdent(structpbPackage.Ident("NewNullValue"))+"()")
+		}
+		g.P("}")
+	}
+	g.P("continue")
+	g.P("}")
+	g.P("switch key {")
+	for _, field := range msg.Fields {
+		if isOutputOnly(field.Desc) {
+			g.P("case ", fieldKeys(field), ": // Output only: set by the server, so ignored.")
+			continue
+		}
+		g.P("case ", fieldKeys(field), ":")
+		path := "path+" + strconv.Quote("/"+string(field.Desc.Name()))
+		switch {
+		case field.Desc.IsMap():
+			writeMapDecode(g, svc, field, path)
+		case field.Desc.IsList():
+			g.P("if err := ", genkittoolsPackage.Ident("DecodeList"), "(val, ", path, ", func(item any, path string) error {")
+			writeValueDecode(g, svc, field, "item", "path")
+			g.P("msg.", field.GoName, " = append(msg.", field.GoName, ", x)")
+			g.P("return nil")
+			g.P("}); err != nil {")
+			g.P("return err")
+			g.P("}")
+		default:
+			if field.Oneof != nil && !field.Oneof.Desc.IsSynthetic() {
+				g.P("if msg.", field.Oneof.GoName, " != nil {")
+				g.P("return ", genkittoolsPackage.Ident("OneofConflict"), "(path, ", strconv.Quote(string(field.Oneof.Desc.Name())), ")")
+				g.P("}")
+			}
+			writeValueDecode(g, svc, field, "val", path)
+			writeFieldAssign(g, field, "msg", "x")
+		}
+	}
+	g.P("default:")
+	g.P("return ", genkittoolsPackage.Ident("UnknownField"), "(path, key)")
+	g.P("}")
+	g.P("}")
+	g.P("return nil")
+	g.P("}")
+	g.P()
+}
+
+// fieldKeys renders the JSON keys protojson accepts for field: its JSON name
+// and its proto name.
+func fieldKeys(field *protogen.Field) string {
+	keys := strconv.Quote(field.Desc.JSONName())
+	if name := string(field.Desc.Name()); name != field.Desc.JSONName() {
+		keys += ", " + strconv.Quote(name)
+	}
+	return keys
+}
+
+// writeFieldAssign emits the assignment of the singular value x to field of
+// the message held in msg.
+func writeFieldAssign(g *protogen.GeneratedFile, field *protogen.Field, msg, x string) {
+	switch {
+	case field.Oneof != nil && !field.Oneof.Desc.IsSynthetic():
+		g.P(msg, ".", field.Oneof.GoName, " = &", field.GoIdent, "{", field.GoName, ": ", x, "}")
+	case field.Desc.HasPresence() && field.Message == nil:
+		g.P(msg, ".", field.GoName, " = &", x)
+	default:
+		g.P(msg, ".", field.GoName, " = ", x)
+	}
+}
+
+// writeValueDecode emits the decoding of the single value of field held in v
+// at path into x, returning any error.
+func writeValueDecode(g *protogen.GeneratedFile, svc *protogen.Service, field *protogen.Field, v, path string) {
+	if field.Message != nil {
+		g.P("x := &", field.Message.GoIdent, "{}")
+		if isWellKnown(field.Message) {
+			g.P("if err := ", genkittoolsPackage.Ident("DecodeMessage"), "(", v, ", ", path, ", x); err != nil {")
+		} else {
+			g.P("if err := ", decoderFuncName(svc, field.Message), "(", v, ", ", path, ", x); err != nil {")
+		}
+		g.P("return err")
+		g.P("}")
+		return
+	}
+	g.P("x, err := ", scalarDecode(g, field, v, path))
+	g.P("if err != nil {")
+	g.P("return err")
+	g.P("}")
+}
+
+// writeMapDecode emits the decoding of the map field held in val.
+func writeMapDecode(g *protogen.GeneratedFile, svc *protogen.Service, field *protogen.Field, path string) {
+	key, value := field.Message.Fields[0], field.Message.Fields[1]
+	g.P("if msg.", field.GoName, " == nil {")
+	g.P("msg.", field.GoName, " = make(map[", goFieldType(g, key), "]", goFieldType(g, value), ")")
+	g.P("}")
+	g.P("if err := ", genkittoolsPackage.Ident("DecodeMap"), "(val, ", path, ", func(key string, item any, path string) error {")
+	switch key.Desc.Kind() {
+	case protoreflect.StringKind:
+		g.P("k := key")
+	case protoreflect.BoolKind:
+		g.P("k, err := ", genkittoolsPackage.Ident("DecodeBoolKey"), "(key, path)")
+		g.P("if err != nil {")
+		g.P("return err")
+		g.P("}")
+	default:
+		g.P("k, err := ", scalarDecode(g, key, "key", "path"))
+		g.P("if err != nil {")
+		g.P("return err")
+		g.P("}")
+	}
+	writeValueDecode(g, svc, value, "item", "path")
+	g.P("msg.", field.GoName, "[k] = x")
+	g.P("return nil")
+	g.P("}); err != nil {")
+	g.P("return err")
+	g.P("}")
+}
+
+// scalarDecode renders the call decoding the value v at path of the
+// non-message field.
+func scalarDecode(g *protogen.GeneratedFile, field *protogen.Field, v, path string) string {
+	var name string
+	switch field.Desc.Kind() {
+	case protoreflect.EnumKind:
+		values := protogen.GoIdent{GoName: field.Enum.GoIdent.GoName + "_value", GoImportPath: field.Enum.GoIdent.GoImportPath}
+		return g.QualifiedGoIdent(genkittoolsPackage.Ident("DecodeEnum")) + "[" + g.QualifiedGoIdent(field.Enum.GoIdent) + "](" + v + ", " + path + ", " + g.QualifiedGoIdent(values) + ")"
+	case protoreflect.BoolKind:
+		name = "DecodeBool"
+	case protoreflect.StringKind:
+		name = "DecodeString"
+	case protoreflect.BytesKind:
+		name = "DecodeBytes"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		name = "DecodeInt32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		name = "DecodeInt64"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		name = "DecodeUint32"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		name = "DecodeUint64"
+	case protoreflect.FloatKind:
+		name = "DecodeFloat32"
+	case protoreflect.DoubleKind:
+		name = "DecodeFloat64"
+	}
+	return g.QualifiedGoIdent(genkittoolsPackage.Ident(name)) + "(" + v + ", " + path + ")"
+}
+
+// goFieldType renders the Go type of a single value of field.
+func goFieldType(g *protogen.GeneratedFile, field *protogen.Field) string {
+	switch field.Desc.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return "*" + g.QualifiedGoIdent(field.Message.GoIdent)
+	case protoreflect.EnumKind:
+		return g.QualifiedGoIdent(field.Enum.GoIdent)
+	case protoreflect.BoolKind:
+		return "bool"
+	case protoreflect.StringKind:
+		return "string"
+	case protoreflect.BytesKind:
+		return "[]byte"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "int32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "int64"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "uint32"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "uint64"
+	case protoreflect.FloatKind:
+		return "float32"
+	default:
+		return "float64"
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	wirePackage = protogen.GoImportPath("github.com/google/wire")
+	fxPackage   = protogen.GoImportPath("go.uber.org/fx")
+)
+
+// diFrameworks parses the di option into the set of frameworks to emit
+// providers for.
+func diFrameworks(value string) (map[string]bool, error) {
+	frameworks := make(map[string]bool)
+	if value == "" {
+		return frameworks, nil
+	}
+	for _, name := range strings.Split(value, "+") {
+		if name != "wire" && name != "fx" {
+			return nil, fmt.Errorf("invalid di=%q: want wire, fx or wire+fx", value)
+		}
+		frameworks[name] = true
+	}
+	return frameworks, nil
+}
+
+// generateDIFile emits dependency-injection providers for the tools of each
+// service into a companion _genkit.tools_di.go file: a wire ProviderSet,
+// an fx.Module, or both.
+func generateDIFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta, frameworks map[string]bool) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_di.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		writeServiceProvider(g, svc.service)
+		if frameworks["wire"] {
+			writeServiceWireSet(g, svc.service)
+		}
+		if frameworks["fx"] {
+			writeServiceFxModule(g, svc.service)
+		}
+	}
+}
+
+// writeServiceProvider emits <Service>Tools, a type distinct per service so
+// containers can tell the tool sets of several services apart, and its
+// provider.
+func writeServiceProvider(g *protogen.GeneratedFile, svc *protogen.Service) {
+	implName := svc.GoName + "ToolImpl"
+	setName := svc.GoName + "Tools"
+
+	if *lazyTools {
+		g.P("// ", setName, " are the unregistered tools of ", svc.GoName, ", as provided to")
+		g.P("// dependency-injection containers.")
+		g.P("type ", setName, " []", genkitAIPackage.Ident("ToolRef"))
+		g.P()
+		g.P("// Provide", setName, " binds impl to the tools of ", svc.GoName, " with New", svc.GoName, "Tools.")
+		g.P("func Provide", setName, "(impl ", implName, ") ", setName, " {")
+		g.P("return New", svc.GoName, "Tools(impl)")
+		g.P("}")
+		g.P()
+		return
+	}
+	g.P("// ", setName, " are the registered tools of ", svc.GoName, ", as provided to")
+	g.P("// dependency-injection containers.")
+	g.P("type ", setName, " []", genkitAIPackage.Ident("Tool"))
+	g.P()
+	g.P("// Provide", setName, " registers the tools of ", svc.GoName, " with g, backed by impl,")
+	g.P("// with Register", svc.GoName, "Tools. Apps passing options call that directly from")
+	g.P("// a provider of their own.")
+	g.P("func Provide", setName, "(g *", genkitPackage.Ident("Genkit"), ", impl ", implName, ") (", setName, ", error) {")
+	g.P("tools, err := Register", svc.GoName, "Tools(g, impl)")
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("return tools, nil")
+	g.P("}")
+	g.P()
+}
+
+func writeServiceWireSet(g *protogen.GeneratedFile, svc *protogen.Service) {
+	g.P("// ", svc.GoName, "ToolProviderSet provides ", svc.GoName, "Tools to wire injectors. Bind")
+	g.P("// ", svc.GoName, "ToolImpl to the implementation next to it, e.g.")
+	g.P("// wire.Bind(new(", svc.GoName, "ToolImpl), new(*server)).")
+	g.P("var ", svc.GoName, "ToolProviderSet = ", wirePackage.Ident("NewSet"), "(Provide", svc.GoName, "Tools)")
+	g.P()
+}
+
+func writeServiceFxModule(g *protogen.GeneratedFile, svc *protogen.Service) {
+	implName := svc.GoName + "ToolImpl"
+	setName := svc.GoName + "Tools"
+	elem := "Tool"
+	requires := "*genkit.Genkit and a "
+	if *lazyTools {
+		elem = "ToolRef"
+		requires = "a "
+	}
+
+	g.P("// ", setName, "Module provides ", setName, " and builds them on startup. It")
+	g.P("// also adds each tool to the \"genkit.tools\" value group, so one consumer can")
+	g.P("// collect the tools of every module as []ai.", elem, " `group:\"genkit.tools\"`.")
+	g.P("// The app supplies ", requires, implName, ",")
+	g.P("// e.g. with Provide", implName, ".")
+	g.P("var ", setName, "Module = ", fxPackage.Ident("Module"), "(", strconv.Quote(string(svc.Desc.FullName())), ",")
+	g.P(fxPackage.Ident("Provide"), "(Provide", setName, "),")
+	g.P(fxPackage.Ident("Provide"), "(", fxPackage.Ident("Annotate"), "(func(tools ", setName, ") []", genkitAIPackage.Ident(elem), " { return tools }, ", fxPackage.Ident("ResultTags"), "(`group:\"genkit.tools,flatten\"`))),")
+	g.P(fxPackage.Ident("Invoke"), "(func(", setName, ") {}),")
+	g.P(")")
+	g.P()
+
+	g.P("// Provide", implName, " provides the result of constructor, a function")
+	g.P("// returning an implementation of ", implName, " and optionally an error, as the")
+	g.P("// ", implName, " of ", setName, "Module.")
+	g.P("func Provide", implName, "(constructor any) ", fxPackage.Ident("Option"), " {")
+	g.P("return ", fxPackage.Ident("Provide"), "(", fxPackage.Ident("Annotate"), "(constructor, ", fxPackage.Ident("As"), "(new(", implName, "))))")
+	g.P("}")
+	g.P()
+}
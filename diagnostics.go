@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// diagnostic is a problem found while collecting the tools of a file: a
+// method left out, or a type the tool schemas cannot describe. With
+// strict=true, diagnostics fail generation.
+type diagnostic struct {
+	desc   protoreflect.Descriptor
+	reason string
+}
+
+func (d diagnostic) String() string {
+	return fmt.Sprintf("%s (%s): %s", d.desc.FullName(), sourcePosition(d.desc), d.reason)
+}
+
+// unsupportedTypes reports the parts of msg, and of the messages it
+// reaches, that a JSON Schema cannot describe: google.protobuf.Any fields,
+// whose contents depend on their type URL, and extension ranges, whose
+// extensions are left out. Messages in seen are not walked again, so types
+// shared between tools are reported once.
+func unsupportedTypes(msg protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) []diagnostic {
+	if seen[msg.FullName()] || wellKnownSchema(msg.FullName()) != nil {
+		return nil
+	}
+	seen[msg.FullName()] = true
+
+	var out []diagnostic
+	if msg.ExtensionRanges().Len() > 0 {
+		out = append(out, diagnostic{msg, "extensions are left out of the tool schemas"})
+	}
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		if getFieldDoc(field).GetContextKey() != "" {
+			continue
+		}
+		value := field
+		if field.IsMap() {
+			value = field.MapValue()
+		}
+		switch {
+		case value.Message() == nil:
+		case value.Message().FullName() == "google.protobuf.Any":
+			out = append(out, diagnostic{field, "the tool schemas cannot describe the message a google.protobuf.Any holds"})
+		default:
+			out = append(out, unsupportedTypes(value.Message(), seen)...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// generateDotpromptFiles emits a Dotprompt scaffold per service into a
+// companion _<service>.prompt file, declaring the tools of the service and
+// leaving placeholders for the instructions. It is a starting point meant to
+// be copied and edited, so it is not marked as generated code.
+func generateDotpromptFiles(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	for _, svc := range services {
+		filename := file.GeneratedFilenamePrefix + "_" + strings.ToLower(svc.service.GoName) + ".prompt"
+		g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+
+		g.P("---")
+		g.P("# Scaffolded by protoc-gen-go-genkit-tools from ", file.Desc.Path(), " as a")
+		g.P("# starting point for prompts using the ", svc.service.GoName, " tools. Regenerating")
+		g.P("# overwrites this file: copy it into your prompt directory before editing.")
+		g.P("model: googleai/gemini-2.5-flash")
+		g.P("tools:")
+		for _, m := range svc.methods {
+			g.P("  - ", strconv.Quote(m.toolName))
+		}
+		g.P("input:")
+		g.P("  schema:")
+		g.P("    request: string")
+		g.P("---")
+		g.P(`{{role "system"}}`)
+		g.P("TODO: describe the task of the assistant and when it should use each tool.")
+		g.P()
+		g.P("You can call these tools:")
+		for _, m := range svc.methods {
+			g.P("- ", m.toolName, ": ", promptText(m.description))
+		}
+		g.P()
+		g.P(`{{role "user"}}`)
+		g.P("{{request}}")
+	}
+}
+
+// promptText flattens s onto one line and escapes Handlebars expressions, so
+// it renders verbatim in a prompt template.
+func promptText(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "{{", `\{{`)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// evalCase is a line of a Genkit evaluation dataset.
+type evalCase struct {
+	TestCaseID string         `json:"testCaseId"`
+	Input      map[string]any `json:"input"`
+	Reference  any            `json:"reference"`
+}
+
+// generateEvalDatasets emits an evaluation dataset skeleton per tool into a
+// companion _<tool>.eval.jsonl file. Its single test case takes the field
+// examples and defaults as input and leaves the reference output empty, to
+// be filled in and extended before running Genkit evaluators.
+func generateEvalDatasets(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			input := exampleMessageValue(m.method.Desc.Input(), nil)
+			if m.method.Desc.IsStreamingClient() {
+				input = map[string]any{batchField: []any{input}}
+			}
+			raw, err := json.Marshal(evalCase{
+				TestCaseID: m.toolName + "-1",
+				Input:      input,
+			})
+			if err != nil {
+				// Inputs only hold maps, slices and scalars, so this cannot fail.
+				panic(err)
+			}
+
+			filename := file.GeneratedFilenamePrefix + "_" + m.toolName + ".eval.jsonl"
+			g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+			g.P(string(raw))
+		}
+	}
+}
+
+// exampleMessageValue builds a tool input for msg holding only the fields
+// with an example or a default value, keyed like the input schema. Examples
+// that do not parse for the field's kind are ignored, and so are maps and
+// well-known types. Recursive messages are cut off at the first repetition.
+func exampleMessageValue(msg protoreflect.MessageDescriptor, visiting map[protoreflect.FullName]bool) map[string]any {
+	out := make(map[string]any)
+	if visiting[msg.FullName()] {
+		return out
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
+	oneofs := make(map[protoreflect.FullName]bool)
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		if getFieldDoc(field).GetContextKey() != "" || isOutputOnly(field) || field.IsMap() {
+			continue
+		}
+		oneof := field.ContainingOneof()
+		if oneof != nil && !oneof.IsSynthetic() && oneofs[oneof.FullName()] {
+			continue
+		}
+
+		value, ok := exampleSingularValue(field, visiting)
+		if !ok {
+			continue
+		}
+		if field.IsList() {
+			value = []any{value}
+		}
+		out[string(field.Name())] = value
+		if oneof != nil && !oneof.IsSynthetic() {
+			oneofs[oneof.FullName()] = true
+		}
+	}
+	return out
+}
+
+func exampleSingularValue(field protoreflect.FieldDescriptor, visiting map[protoreflect.FullName]bool) (any, bool) {
+	if field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind {
+		if wellKnownSchema(field.Message().FullName()) != nil {
+			return nil, false
+		}
+		value := exampleMessageValue(field.Message(), visiting)
+		return value, len(value) > 0
+	}
+
+	example := getFieldDoc(field).GetExample()
+	if example == "" {
+		if !field.HasDefault() {
+			return nil, false
+		}
+		return defaultValue(field), true
+	}
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(example)
+		return b, err == nil
+	case protoreflect.StringKind:
+		return example, true
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString([]byte(example)), true
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(example, 64)
+		return jsonFloat(f), err == nil
+	case protoreflect.EnumKind:
+		return example, field.Enum().Values().ByName(protoreflect.Name(example)) != nil
+	default:
+		_, err := strconv.ParseInt(example, 10, 64)
+		return json.Number(example), err == nil
+	}
+}
+
+// defaultValue returns the explicit default of a proto2 or editions field in
+// its JSON form.
+func defaultValue(field protoreflect.FieldDescriptor) any {
+	v := field.Default()
+	switch field.Kind() {
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	case protoreflect.EnumKind:
+		return string(field.DefaultEnumValue().Name())
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		return jsonFloat(v.Float())
+	default:
+		return v.Interface()
+	}
+}
+
+// jsonFloat returns f, or the string protojson uses for it when JSON numbers
+// cannot represent it.
+func jsonFloat(f float64) any {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return f
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strconv"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	genkitPackage   = protogen.GoImportPath("github.com/firebase/genkit/go/genkit")
+	genkitAIPackage = protogen.GoImportPath("github.com/firebase/genkit/go/ai")
+	fmtPackage      = protogen.GoImportPath("fmt")
+	logPackage      = protogen.GoImportPath("log")
+)
+
+// generateExampleFile emits Example functions per service showing how to
+// implement <Service>ToolImpl, register the tools and pass them to
+// genkit.Generate, into a companion _genkit.tools_example_test.go file in the
+// external test package.
+func generateExampleFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_example_test.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file)+"_test")
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file), "_test")
+	g.P()
+
+	for _, svc := range services {
+		writeServiceExamples(g, file, svc.service, svc.methods)
+	}
+}
+
+func writeServiceExamples(g *protogen.GeneratedFile, file *protogen.File, svc *protogen.Service, methods []methodMeta) {
+	implType := "example" + svc.GoName
+	ident := func(name string) string {
+		return g.QualifiedGoIdent(toolsImportPath(file).Ident(name))
+	}
+
+	g.P("// ", implType, " implements ", ident(svc.GoName+"ToolImpl"), " for the examples.")
+	g.P("// A real implementation calls the backend serving ", svc.GoName, ".")
+	g.P("type ", implType, " struct{}")
+	g.P()
+	for _, m := range methods {
+		params, results := implMethodParams(g, m.method, implContextType(g))
+		resp := g.QualifiedGoIdent(m.method.Output.GoIdent)
+		g.P("func (", implType, ") ", m.method.GoName, "(", params, ") ", results, " {")
+		if m.method.Desc.IsStreamingServer() {
+			g.P("return send(&", resp, "{})")
+		} else {
+			g.P("return &", resp, "{}, nil")
+		}
+		g.P("}")
+		g.P()
+	}
+
+	if *lazyTools {
+		g.P("func ExampleNew", svc.GoName, "Tools() {")
+		g.P("for _, ref := range ", ident("New"+svc.GoName+"Tools"), "(", implType, "{}) {")
+		g.P(fmtPackage.Ident("Println"), "(ref.Name())")
+		g.P("}")
+	} else {
+		g.P("func ExampleRegister", svc.GoName, "Tools() {")
+		g.P("g := ", genkitPackage.Ident("Init"), "(", contextPackage.Ident("Background"), "())")
+		g.P("tools, err := ", ident("Register"+svc.GoName+"Tools"), "(g, ", implType, "{})")
+		g.P("if err != nil {")
+		g.P(logPackage.Ident("Fatal"), "(err)")
+		g.P("}")
+		g.P("for _, tool := range tools {")
+		g.P(fmtPackage.Ident("Println"), "(tool.Name())")
+		g.P("}")
+	}
+	g.P("// Output:")
+	for _, m := range methods {
+		g.P("// ", m.toolName)
+		if m.toolDoc.GetBatch() {
+			g.P("// ", batchToolName(m))
+		}
+	}
+	g.P("}")
+	g.P()
+
+	// Generating needs a model, so this example is compiled but not run.
+	if *lazyTools {
+		g.P("func ExampleNew", svc.GoName, "Tools_generate() {")
+		g.P("ctx := ", contextPackage.Ident("Background"), "()")
+		g.P("g := ", genkitPackage.Ident("Init"), "(ctx)")
+		g.P("refs := ", ident("New"+svc.GoName+"Tools"), "(", implType, "{})")
+	} else {
+		g.P("func ExampleRegister", svc.GoName, "ToolRefs() {")
+		g.P("ctx := ", contextPackage.Ident("Background"), "()")
+		g.P("g := ", genkitPackage.Ident("Init"), "(ctx)")
+		g.P("refs, err := ", ident("Register"+svc.GoName+"ToolRefs"), "(g, ", implType, "{})")
+		g.P("if err != nil {")
+		g.P(logPackage.Ident("Fatal"), "(err)")
+		g.P("}")
+	}
+	g.P("resp, err := ", genkitPackage.Ident("Generate"), "(ctx, g,")
+	g.P(genkitAIPackage.Ident("WithModelName"), `("googleai/gemini-2.5-flash"),`)
+	g.P(genkitAIPackage.Ident("WithPrompt"), "(", strconv.Quote("Which "+svc.GoName+" tools can you use?"), "),")
+	g.P(genkitAIPackage.Ident("WithTools"), "(refs...),")
+	g.P(")")
+	g.P("if err != nil {")
+	g.P(logPackage.Ident("Fatal"), "(err)")
+	g.P("}")
+	g.P(fmtPackage.Ident("Println"), "(resp.Text())")
+	g.P("}")
+	g.P()
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const protojsonPackage = protogen.GoImportPath("google.golang.org/protobuf/encoding/protojson")
+
+// generateFakeFile emits Fake<Service>ToolImpl types returning canned,
+// schema-valid responses into a companion _genkit.tools_fake.go file.
+func generateFakeFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_fake.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		writeServiceFake(g, svc.service, svc.methods)
+	}
+}
+
+func writeServiceFake(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	fakeName := "Fake" + implName
+
+	g.P("// ", fakeName, " implements ", implName, " with deterministic")
+	g.P("// responses built from field examples, for demos and prompt iteration")
+	g.P("// before a real backend exists.")
+	g.P("type ", fakeName, " struct{}")
+	g.P()
+	g.P("var _ ", implName, " = ", fakeName, "{}")
+	g.P()
+
+	for _, m := range methods {
+		respName := g.QualifiedGoIdent(m.method.Output.GoIdent)
+		raw, err := json.Marshal(fakeMessageValue(m.method.Output.Desc, nil))
+		if err != nil {
+			// Only plain maps, slices and scalars are produced, so this cannot fail.
+			panic(err)
+		}
+
+		reqType := implRequestType(g, m.method)
+		if m.method.Desc.IsStreamingServer() {
+			g.P("// ", m.method.GoName, " sends a single canned ", m.method.Output.GoIdent.GoName, ".")
+			g.P("func (", fakeName, ") ", m.method.GoName, "(_ ", implContextType(g), ", _ ", reqType, ", send func(*", respName, ") error) error {")
+			g.P("resp := &", respName, "{}")
+			g.P("if err := ", protojsonPackage.Ident("Unmarshal"), "([]byte(", strconv.Quote(string(raw)), "), resp); err != nil {")
+			g.P("return err")
+			g.P("}")
+			g.P("return send(resp)")
+			g.P("}")
+		} else {
+			g.P("// ", m.method.GoName, " returns a canned ", m.method.Output.GoIdent.GoName, ".")
+			params := implContextType(g) + ", " + reqType
+			if reportsProgress(m.method) {
+				params += ", " + g.QualifiedGoIdent(genkittoolsPackage.Ident("ProgressFunc"))
+			}
+			g.P("func (", fakeName, ") ", m.method.GoName, "(", params, ") (*", respName, ", error) {")
+			g.P("resp := &", respName, "{}")
+			g.P("if err := ", protojsonPackage.Ident("Unmarshal"), "([]byte(", strconv.Quote(string(raw)), "), resp); err != nil {")
+			g.P("return nil, err")
+			g.P("}")
+			g.P("return resp, nil")
+			g.P("}")
+		}
+		g.P()
+	}
+}
+
+// fakeMessageValue builds the protojson form of a populated msg. Field
+// examples are used where they parse for the field's kind; other fields get
+// deterministic placeholders. Recursive messages are cut off at the first
+// repetition.
+func fakeMessageValue(msg protoreflect.MessageDescriptor, visiting map[protoreflect.FullName]bool) map[string]any {
+	out := make(map[string]any)
+	if visiting[msg.FullName()] {
+		return out
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
+	oneofs := make(map[protoreflect.FullName]bool)
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			if oneofs[oneof.FullName()] {
+				continue
+			}
+			oneofs[oneof.FullName()] = true
+		}
+
+		example := ""
+		if fd := getFieldDoc(field); fd != nil {
+			example = fd.GetExample()
+		}
+
+		var value any
+		var ok bool
+		switch {
+		case field.IsMap():
+			var v any
+			if v, ok = fakeSingularValue(field.MapValue(), example, visiting); ok {
+				key := "key"
+				if field.MapKey().Kind() == protoreflect.BoolKind {
+					key = "true"
+				} else if field.MapKey().Kind() != protoreflect.StringKind {
+					key = "1"
+				}
+				value = map[string]any{key: v}
+			}
+		case field.IsList():
+			var v any
+			if v, ok = fakeSingularValue(field, example, visiting); ok {
+				value = []any{v}
+			}
+		default:
+			value, ok = fakeSingularValue(field, example, visiting)
+		}
+		if ok {
+			out[field.JSONName()] = value
+		}
+	}
+	return out
+}
+
+func fakeSingularValue(field protoreflect.FieldDescriptor, example string, visiting map[protoreflect.FullName]bool) (any, bool) {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		if b, err := strconv.ParseBool(example); err == nil {
+			return b, true
+		}
+		return true, true
+	case protoreflect.StringKind:
+		if example != "" {
+			return example, true
+		}
+		return string(field.Name()), true
+	case protoreflect.BytesKind:
+		data := example
+		if data == "" {
+			data = string(field.Name())
+		}
+		return base64.StdEncoding.EncodeToString([]byte(data)), true
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		if f, err := strconv.ParseFloat(example, 64); err == nil {
+			return f, true
+		}
+		return 1.5, true
+	case protoreflect.EnumKind:
+		values := field.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			if string(values.Get(i).Name()) == example {
+				return example, true
+			}
+		}
+		if values.Len() > 1 {
+			return string(values.Get(1).Name()), true
+		}
+		return string(values.Get(0).Name()), true
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return fakeWellKnownValue(field.Message(), example, visiting)
+	default:
+		if _, err := strconv.ParseInt(example, 10, 64); err == nil {
+			return json.Number(example), true
+		}
+		return 1, true
+	}
+}
+
+// fakeWellKnownValue returns values in the special JSON forms protojson uses
+// for well-known types, falling back to a populated object for other messages.
+func fakeWellKnownValue(msg protoreflect.MessageDescriptor, example string, visiting map[protoreflect.FullName]bool) (any, bool) {
+	switch msg.FullName() {
+	case "google.protobuf.Timestamp":
+		return "1970-01-01T00:00:00Z", true
+	case "google.protobuf.Duration":
+		return "1s", true
+	case "google.protobuf.FieldMask":
+		return "", true
+	case "google.protobuf.Struct":
+		return map[string]any{}, true
+	case "google.protobuf.Value", "google.protobuf.ListValue", "google.protobuf.Any", "google.protobuf.Empty":
+		return nil, false
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue", "google.protobuf.BoolValue",
+		"google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int32Value", "google.protobuf.Int64Value",
+		"google.protobuf.UInt32Value", "google.protobuf.UInt64Value":
+		return fakeSingularValue(msg.Fields().ByName("value"), example, visiting)
+	default:
+		return fakeMessageValue(msg, visiting), true
+	}
+}
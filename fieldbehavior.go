@@ -0,0 +1,143 @@
+package main
+
+import (
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/internal/schemautil"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldBehaviorFieldNumber is the number of the google.api.field_behavior
+// option. Like google.api.http, it is decoded from the raw option bytes so
+// the plugin does not depend on the googleapis Go packages.
+const fieldBehaviorFieldNumber = 1052
+
+// fieldBehaviorOutputOnly is google.api.FieldBehavior.OUTPUT_ONLY.
+const fieldBehaviorOutputOnly = 3
+
+// isOutputOnly reports whether field is annotated with
+// (google.api.field_behavior) = OUTPUT_ONLY: set by the server in
+// responses, such as ids and timestamps, and ignored in requests.
+func isOutputOnly(field protoreflect.FieldDescriptor) bool {
+	opts := field.Options()
+	if opts == nil {
+		return false
+	}
+	for b := opts.ProtoReflect().GetUnknown(); len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return false
+		}
+		if num == fieldBehaviorFieldNumber {
+			switch typ {
+			case protowire.VarintType:
+				if v, _ := protowire.ConsumeVarint(b); v == fieldBehaviorOutputOnly {
+					return true
+				}
+			case protowire.BytesType:
+				// Packed.
+				packed, _ := protowire.ConsumeBytes(b)
+				for len(packed) > 0 {
+					v, k := protowire.ConsumeVarint(packed)
+					if k < 0 {
+						break
+					}
+					if v == fieldBehaviorOutputOnly {
+						return true
+					}
+					packed = packed[k:]
+				}
+			}
+		}
+		b = b[m:]
+	}
+	return false
+}
+
+// omitOutputOnly removes the output-only fields of msg and of the messages
+// below it from schema, built by buildMessageSchema for msg, and returns it.
+// The model never sets them in requests, while responses keep them.
+func omitOutputOnly(schema map[string]any, msg protoreflect.MessageDescriptor) map[string]any {
+	omitOutputOnlyFields(schema, msg)
+	for _, keyword := range []string{"$defs", "definitions"} {
+		defs, _ := schema[keyword].(map[string]any)
+		for name, def := range defs {
+			if defMsg := schemautil.FindMessage(msg, protoreflect.FullName(name), nil); defMsg != nil {
+				omitOutputOnlyFields(def.(map[string]any), defMsg)
+			}
+		}
+	}
+	return schema
+}
+
+// omitOutputOnlyFields removes the output-only fields from the object
+// schema of msg and the schemas of its message fields. Recursive references
+// hold no properties and are left alone.
+func omitOutputOnlyFields(schema map[string]any, msg protoreflect.MessageDescriptor) {
+	if wellKnownSchema(msg.FullName()) != nil {
+		return
+	}
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	var omitted []string
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		prop, ok := props[string(field.Name())].(map[string]any)
+		if !ok {
+			continue
+		}
+		if isOutputOnly(field) {
+			omitted = append(omitted, string(field.Name()))
+			continue
+		}
+		switch {
+		case field.IsMap():
+			if value := field.MapValue(); value.Message() != nil {
+				if item, ok := prop["additionalProperties"].(map[string]any); ok {
+					omitOutputOnlyFields(item, value.Message())
+				}
+			}
+		case field.Message() == nil:
+		case field.IsList():
+			if item, ok := prop["items"].(map[string]any); ok {
+				omitOutputOnlyFields(item, field.Message())
+			}
+		default:
+			omitOutputOnlyFields(prop, field.Message())
+		}
+	}
+	if len(omitted) > 0 {
+		omitSchemaFields(schema, omitted)
+	}
+}
+
+// hasOutputOnlyFields reports whether msg or a message below it has
+// output-only fields.
+func hasOutputOnlyFields(msg protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) bool {
+	if seen[msg.FullName()] {
+		return false
+	}
+	if seen == nil {
+		seen = make(map[protoreflect.FullName]bool)
+	}
+	seen[msg.FullName()] = true
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		if isOutputOnly(field) {
+			return true
+		}
+		if field.IsMap() {
+			field = field.MapValue()
+		}
+		if next := field.Message(); next != nil && hasOutputOnlyFields(next, seen) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const corePackage = protogen.GoImportPath("github.com/firebase/genkit/go/core")
+
+// generateFlowFile emits a Genkit flow per tool-enabled method, taking and
+// returning the proto messages, into a companion _genkit.tools_flows.go
+// file, so the same contract can be run directly from the Dev UI.
+func generateFlowFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_flows.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		writeServiceFlows(g, svc.service, svc.methods)
+	}
+}
+
+func writeServiceFlows(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	genkitType := g.QualifiedGoIdent(genkitPackage.Ident("Genkit"))
+
+	g.P("// Define", svc.GoName, "Flows defines a flow for every tool-enabled method of")
+	g.P("// ", svc.GoName, ", named like its tool and backed by impl. The options apply as")
+	g.P("// they do to the tools of the service.")
+	g.P("func Define", svc.GoName, "Flows(g *", genkitType, ", impl ", implName, ", opts ...", genkittoolsPackage.Ident("Option"), ") {")
+	g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	for _, m := range methods {
+		g.P("if o.Includes(string(", toolConstName(m), ")) {")
+		g.P("define", m.goName, "Flow(g, impl, o)")
+		g.P("}")
+	}
+	g.P("}")
+	g.P()
+
+	for _, m := range methods {
+		writeMethodFlow(g, svc, m)
+	}
+}
+
+// writeMethodFlow emits Define<Service><Method>Flow. Server-streaming
+// methods become streaming flows that stream each response and return them
+// all, and methods declared with progress stream their genkittools.Progress
+// updates; client-streaming methods take their requests as a list.
+func writeMethodFlow(g *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta) {
+	m := meta.method
+	implName := svc.GoName + "ToolImpl"
+	genkitType := g.QualifiedGoIdent(genkitPackage.Ident("Genkit"))
+	ctxType := g.QualifiedGoIdent(contextPackage.Ident("Context"))
+	reqName := g.QualifiedGoIdent(m.Input.GoIdent)
+	respName := g.QualifiedGoIdent(m.Output.GoIdent)
+	infoVar := "flowInfo" + meta.goName
+	inputSchemaVar := schemaVarName(meta)
+	outputSchemaVar := "flowOutputSchema" + meta.goName
+
+	in := "*" + reqName
+	if m.Desc.IsStreamingClient() {
+		in = "[]*" + reqName
+	}
+	out, stream := "*"+respName, "struct{}"
+	if m.Desc.IsStreamingServer() {
+		out, stream = "[]*"+respName, "*"+respName
+	}
+	if reportsProgress(m) {
+		stream = g.QualifiedGoIdent(genkittoolsPackage.Ident("Progress"))
+	}
+
+	if m.Desc.IsStreamingClient() {
+		// The flow takes the requests themselves rather than the tool's
+		// object wrapping them.
+		inputSchemaVar = "flowInputSchema" + meta.goName
+		inputSchema := renderSchema(g, listSchema(omitOutputOnly(buildMessageSchema(m.Desc.Input()), m.Desc.Input())))
+		if runtimeCodegen() {
+			inputSchema = listSchemaExpr(g, inputMessageSchemaExpr(g, m.Input))
+		}
+		writeToolSchemas(g, infoVar, inputSchemaVar, inputSchema, outputSchemaVar, flowOutputSchemaExpr(g, meta))
+	} else {
+		// The flow takes the tool's input, declared with the tool.
+		writeToolSchemas(g, infoVar, inputSchemaVar, "", outputSchemaVar, flowOutputSchemaExpr(g, meta))
+	}
+	writeToolInfo(g, svc, meta, infoVar, inputSchemaVar, outputSchemaVar)
+
+	g.P("// Define", meta.goName, "Flow defines the ", meta.toolName, " flow, running")
+	g.P("// impl.", m.GoName, " with the same options, limits and hooks as the tool.")
+	g.P("func Define", meta.goName, "Flow(g *", genkitType, ", impl ", implName, ", opts ...", genkittoolsPackage.Ident("Option"), ") *", corePackage.Ident("Flow"), "[", in, ", ", out, ", ", stream, "] {")
+	g.P("return define", meta.goName, "Flow(g, impl, ", genkittoolsPackage.Ident("NewOptions"), "(opts...))")
+	g.P("}")
+	g.P()
+
+	g.P("func define", meta.goName, "Flow(g *", genkitType, ", impl ", implName, ", o *", genkittoolsPackage.Ident("Options"), ") *", corePackage.Ident("Flow"), "[", in, ", ", out, ", ", stream, "] {")
+	coerce := coerceFuncName(meta)
+	if m.Desc.IsStreamingClient() {
+		coerce = "func(input any) (" + in + ", error) {\nreturn " + g.QualifiedGoIdent(genkittoolsPackage.Ident("CoerceBatch")) + "(input, " + coerceFuncName(meta) + ")\n}"
+	}
+	// implArgs emits the tool context of impl_context=tool, if any, and
+	// returns the arguments passing it and the request to impl.
+	implArgs := func() string {
+		ctxArg := "ctx"
+		if implToolContext() {
+			ctxArg = writeImplToolContext(g, g.QualifiedGoIdent(genkitAIPackage.Ident("ToolContext")), "")
+		}
+		if m.Desc.IsStreamingClient() {
+			return ctxArg + ", " + g.QualifiedGoIdent(slicesPackage.Ident("Values")) + "(req)"
+		}
+		return ctxArg + ", req"
+	}
+	if m.Desc.IsStreamingServer() {
+		g.P("return ", genkitPackage.Ident("DefineStreamingFlow"), "(g, string(", toolConstName(meta), "), func(ctx ", ctxType, ", input ", in, ", cb ", corePackage.Ident("StreamCallback"), "[", stream, "]) (", out, ", error) {")
+		g.P("return ", genkittoolsPackage.Ident("Invoke"), "(ctx, o, ", infoVar, ", input, ", coerce, ", func(ctx ", ctxType, ", req ", in, ") (", out, ", error) {")
+		g.P("var resps ", out)
+		g.P("err := impl.", m.GoName, "(", implArgs(), ", func(resp *", respName, ") error {")
+		g.P("resps = append(resps, resp)")
+		g.P("return cb(ctx, resp)")
+		g.P("})")
+		g.P("return resps, err")
+		g.P("})")
+		g.P("})")
+	} else if reportsProgress(m) {
+		g.P("return ", genkitPackage.Ident("DefineStreamingFlow"), "(g, string(", toolConstName(meta), "), func(ctx ", ctxType, ", input ", in, ", cb ", corePackage.Ident("StreamCallback"), "[", stream, "]) (", out, ", error) {")
+		g.P("return ", genkittoolsPackage.Ident("Invoke"), "(ctx, o, ", infoVar, ", input, ", coerce, ", func(ctx ", ctxType, ", req ", in, ") (", out, ", error) {")
+		g.P("return impl.", m.GoName, "(", implArgs(), ", func(p ", stream, ") error {")
+		g.P("return cb(ctx, p)")
+		g.P("})")
+		g.P("})")
+		g.P("})")
+	} else {
+		g.P("return ", genkitPackage.Ident("DefineFlow"), "(g, string(", toolConstName(meta), "), func(ctx ", ctxType, ", input ", in, ") (", out, ", error) {")
+		g.P("return ", genkittoolsPackage.Ident("Invoke"), "(ctx, o, ", infoVar, ", input, ", coerce, ", func(ctx ", ctxType, ", req ", in, ") (", out, ", error) {")
+		g.P("return impl.", m.GoName, "(", implArgs(), ")")
+		g.P("})")
+		g.P("})")
+	}
+	g.P("}")
+	g.P()
+}
+
+// flowOutputSchemaExpr renders the output schema of meta's flow: the
+// response, or the list of responses of a server-streaming method.
+func flowOutputSchemaExpr(g *protogen.GeneratedFile, meta methodMeta) string {
+	m := meta.method
+	if runtimeCodegen() {
+		expr := messageSchemaExpr(g, m.Output)
+		if m.Desc.IsStreamingServer() {
+			expr = listSchemaExpr(g, expr)
+		}
+		return describeSchemaExpr(g, expr, meta.toolDoc.GetOutput())
+	}
+	schema := buildMessageSchema(m.Desc.Output())
+	if m.Desc.IsStreamingServer() {
+		schema = listSchema(schema)
+	}
+	if doc := meta.toolDoc.GetOutput(); doc != "" {
+		schema["description"] = doc
+	}
+	return renderSchema(g, schema)
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -21,8 +24,8 @@ func TestPluginGeneratesExpectedTools(t *testing.T) {
 	code := generateForProto(t, "test/proto/catalog.proto")
 
 	// Tool naming and registration.
-	mustContain(t, code, `const ToolCatalogGetWeatherTool genkitai.ToolName = "get_weather"`)
-	mustContain(t, code, "defineToolCatalogGetWeatherTool(g, impl)")
+	mustContain(t, code, `const ToolCatalogGetWeatherTool ai.ToolName = "get_weather"`)
+	mustContain(t, code, "defineToolCatalogGetWeatherTool(g, impl, o)")
 	mustNotContain(t, code, "UndocumentedTool")
 
 	// Input schema renders required fields and descriptions.
@@ -35,17 +38,1521 @@ func TestPluginGeneratesExpectedTools(t *testing.T) {
 	mustContain(t, code, `return impl.GetWeather(ctx, req)`)
 }
 
+func TestRegistrationAcceptsRuntimeOptions(t *testing.T) {
+	code := generateForProto(t, "test/proto/catalog.proto")
+
+	mustContain(t, code, "func RegisterToolCatalogTools(g *genkit.Genkit, impl ToolCatalogToolImpl, opts ...genkittools.Option) ([]ai.Tool, error)")
+	mustContain(t, code, "o := genkittools.NewOptions(opts...)")
+	mustContain(t, code, "var toolInfoToolCatalogGetWeather = &genkittools.ToolInfo{")
+	mustMatch(t, code, `InputSchema:\s+schemaToolCatalogGetWeather,`)
+	mustContain(t, code, "return genkittools.Invoke(ctx, o, toolInfoToolCatalogGetWeather, input, coerceToolCatalogGetWeatherRequest,")
+}
+
 func TestInvoiceGeneration(t *testing.T) {
 	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
 
-	mustContain(t, code, `const InvoiceServiceCreateInvoiceTool genkitai.ToolName = "create_invoice"`)
-	mustContain(t, code, `"required": []string{"invoice"}`)
-	mustContain(t, code, `"description": "info to create invoice"`)
-	mustContain(t, code, `return impl.CreateInvoice(ctx, req)`)
-	mustContain(t, code, `errors.New("create_invoice requires input")`)
-	mustContain(t, code, "\"invoice\": map[string]any{\"description\": \"The invoice to create.\", \"properties\": map[string]any{\"customer_id\": map[string]any{\"type\": \"string\"")
-	mustContain(t, code, "\"line_items\": map[string]any{\"items\": map[string]any{\"properties\": map[string]any{\"line_item_id\": map[string]any{\"type\": \"string\"")
-	mustContain(t, code, "\"tags\": map[string]any{\"properties\": map[string]any{\"tag\": map[string]any{\"items\": map[string]any{\"type\": \"string\"}, \"type\": \"array\"}}, \"type\": \"object\"}")
+	mustContain(t, code, `const InvoiceServiceCreateInvoiceTool ai.ToolName = "create_invoice"`)
+	mustContain(t, code, `"required": []string{"invoice"}`)
+	mustContain(t, code, `"description": "info to create invoice"`)
+	mustContain(t, code, `return impl.CreateInvoice(ctx, req)`)
+	mustContain(t, code, `errors.New("create_invoice requires input")`)
+	mustContain(t, code, "\"invoice\": map[string]any{\"description\": \"The invoice to create.\", \"properties\": map[string]any{\"customer_id\": map[string]any{\"type\": \"string\"")
+	mustContain(t, code, "\"line_items\": map[string]any{\"items\": map[string]any{\"properties\": map[string]any{\"line_item_id\": map[string]any{\"type\": \"string\"")
+	mustContain(t, code, "\"tags\": map[string]any{\"properties\": map[string]any{\"tag\": map[string]any{\"items\": map[string]any{\"type\": \"string\"}, \"type\": \"array\"}}, \"type\": \"object\"}")
+}
+
+func TestSensitiveFieldsAreListedForRedaction(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+
+	mustMatch(t, code, `SensitiveFields:\s+\[\]string\{"/invoice/customer_id"\},`)
+	mustContain(t, code, "\"customer_id\": map[string]any{\"type\": \"string\"}")
+}
+
+func TestUnsanitizedFieldsAreListed(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+	mustMatch(t, code, `UnsanitizedFields:\s+\[\]string\{"/invoice/line_items/\*/product_id"\},`)
+}
+
+func TestFieldErrorMessagesAreListed(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+	mustMatch(t, code, `FieldErrorMessages:\s+map\[string\]string\{\s+"/invoice/line_items/\*/quantity":\s+"quantity must be a whole number of units, e.g. 3",\s+\},`)
+}
+
+func TestIdempotentMethodsAreRetryable(t *testing.T) {
+	catalog := generateForProto(t, "test/proto/catalog.proto")
+	mustContain(t, catalog, "Idempotent:")
+
+	invoice := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+	mustNotContain(t, invoice, "Idempotent:")
+}
+
+func TestToolRefAccessors(t *testing.T) {
+	code := generateForProto(t, "test/proto/catalog.proto")
+
+	mustContain(t, code, "func ToolCatalogToolRefs() []ai.ToolRef {")
+	mustMatch(t, code, `return \[\]ai.ToolRef\{\s+ToolCatalogGetWeatherTool,\s+ToolCatalogStreamForecastTool,\s+\}`)
+}
+
+func TestPromptSection(t *testing.T) {
+	code := generateForProto(t, "test/proto/catalog.proto")
+
+	mustContain(t, code, "func ToolCatalogPromptSection(opts ...genkittools.Option) string {")
+	mustContain(t, code, "if o.Includes(info.Name) {")
+	mustContain(t, code, "return genkittools.PromptSection(tools)")
+}
+
+func TestRegistrationFollowsDeclarationOrder(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+
+	mustContain(t, code, "func RegisterInvoiceServiceToolRefs(g *genkit.Genkit, impl InvoiceServiceToolImpl, opts ...genkittools.Option) ([]ai.ToolRef, error)")
+	create := strings.Index(code, "defineInvoiceServiceCreateInvoiceTool(g, impl, o)")
+	get := strings.Index(code, "defineInvoiceServiceGetInvoiceTool(g, impl, o)")
+	if create < 0 || get < 0 || create > get {
+		t.Fatalf("tools not registered in declaration order (create at %d, get at %d)", create, get)
+	}
+}
+
+func TestRegistrationHonoursToolSelection(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+
+	mustContain(t, code, "if o.Includes(string(InvoiceServiceCreateInvoiceTool)) {")
+	mustContain(t, code, "if o.Includes(string(InvoiceServiceGetInvoiceTool)) {")
+}
+
+func TestToolRegistryMetadata(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+
+	mustContain(t, code, "genkittools.ToolRegistry.Add(\n\t\ttoolInfoInvoiceServiceCreateInvoice,\n\t\ttoolInfoInvoiceServiceGetInvoice,\n\t)")
+	mustMatch(t, code, `Description:\s+"Create a new invoice.",`)
+	mustMatch(t, code, `Tags:\s+\[\]string\{"invoice", "create"\},`)
+	mustMatch(t, code, `Service:\s+"invoice.v1.InvoiceService",`)
+}
+
+func TestOutputSchemaGeneration(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+
+	mustContain(t, code, `var outputSchemaInvoiceServiceCreateInvoice = map[string]any{"description": "id of created invoice", "properties": map[string]any{"invoice_id": map[string]any{"type": "string"}}, "type": "object"}`)
+	mustMatch(t, code, `OutputSchema:\s+outputSchemaInvoiceServiceCreateInvoice,`)
+}
+
+func TestProtovalidateOption(t *testing.T) {
+	plain := generateForProto(t, "test/proto/catalog.proto")
+	mustNotContain(t, plain, "protovalidate")
+
+	code := generateWithOptions(t, "test/proto/catalog.proto", "protovalidate=true")
+	mustContain(t, code, `"buf.build/go/protovalidate"`)
+	mustContain(t, code, "if err := protovalidate.Validate(req); err != nil {")
+	mustContain(t, code, `return nil, &genkittools.ValidationError{Tool: "get_weather", Violations: violations}`)
+}
+
+func TestMocksOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "mocks=true")
+
+	mock, ok := files["catalog_genkit.tools_mock.go"]
+	if !ok {
+		t.Fatalf("missing mock file, got %v", mapKeys(files))
+	}
+	mustContain(t, mock, "type ToolCatalogToolImplMock struct {")
+	mustMatch(t, mock, `GetWeatherFunc\s+func\(context\.Context, \*GetWeatherRequest\) \(\*GetWeatherResponse, error\)`)
+	mustMatch(t, mock, `StreamForecastFunc\s+func\(context\.Context, \*GetForecastRequest, func\(\*ForecastDay\) error\) error`)
+	mustContain(t, mock, "var _ ToolCatalogToolImpl = (*ToolCatalogToolImplMock)(nil)")
+	mustContain(t, mock, "func (m *ToolCatalogToolImplMock) GetWeatherCalls() []*GetWeatherRequest {")
+
+	plain := generateFilesWithOptions(t, "test/proto/catalog.proto")
+	if _, ok := plain["catalog_genkit.tools_mock.go"]; ok {
+		t.Fatal("mock file generated without mocks=true")
+	}
+}
+
+func TestFakesOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "fakes=true")
+
+	fake, ok := files["catalog_genkit.tools_fake.go"]
+	if !ok {
+		t.Fatalf("missing fake file, got %v", mapKeys(files))
+	}
+	mustContain(t, fake, "type FakeToolCatalogToolImpl struct{}")
+	mustContain(t, fake, "var _ ToolCatalogToolImpl = FakeToolCatalogToolImpl{}")
+	mustContain(t, fake, "func (FakeToolCatalogToolImpl) GetWeather(context.Context, *GetWeatherRequest) (*GetWeatherResponse, error) {")
+	mustContain(t, fake, `protojson.Unmarshal([]byte("{\"temperature\":1.5}"), resp)`)
+
+	plain := generateFilesWithOptions(t, "test/proto/catalog.proto")
+	if _, ok := plain["catalog_genkit.tools_fake.go"]; ok {
+		t.Fatal("fake file generated without fakes=true")
+	}
+}
+
+func TestServerStreamingTools(t *testing.T) {
+	code := generateForProto(t, "test/proto/catalog.proto")
+
+	mustContain(t, code, "StreamForecast(context.Context, *GetForecastRequest, func(*ForecastDay) error) error")
+	mustContain(t, code, "tool := genkit.DefineToolWithInputSchema[[]*ForecastDay](")
+	mustContain(t, code, "return genkittools.CollectStream(func(send func(*ForecastDay) error) error {\n\t\t\t\t\treturn impl.StreamForecast(ctx, req, send)")
+	mustContain(t, code, `var outputSchemaToolCatalogStreamForecast = map[string]any{"description": "One entry per day", "items": map[string]any{`)
+
+	forward := generateWithOptions(t, "test/proto/catalog.proto", "streaming=forward")
+	mustContain(t, forward, "tool := genkit.DefineToolWithInputSchema[*ForecastDay](")
+	mustContain(t, forward, "return genkittools.ForwardStream(ctx, string(ToolCatalogStreamForecastTool), func(send func(*ForecastDay) error) error {")
+	mustContain(t, forward, `var outputSchemaToolCatalogStreamForecast = map[string]any{"description": "One entry per day", "properties": map[string]any{`)
+}
+
+func TestClientStreamingPolicy(t *testing.T) {
+	code := generateForProto(t, "test/proto/catalog.proto")
+	mustNotContain(t, code, "CompareCities")
+	mustNotContain(t, code, "WatchWeather")
+
+	array := generateWithOptions(t, "test/proto/catalog.proto", "client_streaming=array")
+	mustContain(t, array, "CompareCities(context.Context, iter.Seq[*GetWeatherRequest]) (*GetWeatherResponse, error)")
+	mustContain(t, array, "WatchWeather(context.Context, iter.Seq[*GetWeatherRequest], func(*GetWeatherResponse) error) error")
+	mustContain(t, array, "return genkittools.CoerceBatch(input, coerceToolCatalogCompareCitiesRequest)")
+	mustContain(t, array, "resp, err := impl.CompareCities(ctx, slices.Values(reqs))")
+	mustContain(t, array, "return impl.WatchWeather(ctx, slices.Values(reqs), send)")
+	mustContain(t, array, `var schemaToolCatalogCompareCities = map[string]any{"properties": map[string]any{"requests": map[string]any{"items": map[string]any{`)
+}
+
+func TestResultFormats(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/catalog.proto", "client_streaming=array")
+
+	mustContain(t, code, `var resultTemplateToolCatalogCompareCities = template.Must(template.New("compare_cities").Parse("Average temperature: {{printf \"%.1f\" .Temperature}}"))`)
+	mustContain(t, code, "tool := genkit.DefineToolWithInputSchema[string](")
+	mustContain(t, code, "resp, err := impl.CompareCities(ctx, slices.Values(reqs))")
+	mustContain(t, code, "return genkittools.RenderTemplate(resultTemplateToolCatalogCompareCities, resp)")
+	mustContain(t, code, "if resp, err = genkittools.RunResponseHooks(ctx, o, toolInfoToolCatalogCompareCities, resp); err != nil {")
+	mustContain(t, code, "return genkittools.RenderJSON(resp)")
+	mustContain(t, code, `var outputSchemaToolCatalogWatchWeather = map[string]any{"type": "string"}`)
+	mustContain(t, code, "tool := genkit.DefineToolWithInputSchema[*GetWeatherResponse](")
+}
+
+func TestTypedDecoders(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+
+	mustContain(t, code, `if err := decodeInvoiceServiceInvoiceV1CreateInvoiceRequest(obj, "", req); err != nil {`)
+	mustContain(t, code, "func decodeInvoiceServiceInvoiceV1LineItem(v any, path string, msg *LineItem) error {")
+	mustContain(t, code, "for _, key := range slices.Sorted(maps.Keys(obj)) {")
+	mustNotContain(t, code, "for key, val := range obj {")
+	mustContain(t, code, `case "lineItems", "line_items":`)
+	mustContain(t, code, `x, err := genkittools.DecodeUint64(val, path+"/quantity")`)
+	mustContain(t, code, "if err := decodeInvoiceServiceTagV1Tags(val, path+\"/tags\", x); err != nil {")
+	mustContain(t, code, "return genkittools.UnknownField(path, key)")
+}
+
+func TestEditionsFeatures(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/order/v1/order.proto")
+
+	// LEGACY_REQUIRED is required; delimited messages are still objects.
+	mustContain(t, code, `"customer_id": map[string]any{"type": "string"}}, "required": []string{"customer_id"}`)
+	mustContain(t, code, `"address": map[string]any{"properties": map[string]any{"country": map[string]any{"type": "string"}`)
+	// Explicit presence decodes into pointer fields.
+	mustContain(t, code, "msg.Coupon = &x")
+}
+
+func TestCollidingServicesAreDisambiguated(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/support/v1/support.proto")
+
+	// Support.TicketStatus and SupportTicket.Status both map to SupportTicketStatus.
+	mustContain(t, code, `const Support_TicketStatusTool ai.ToolName = "support_ticketstatus_2"`)
+	mustContain(t, code, `const SupportTicket_StatusTool ai.ToolName = "support_ticketstatus"`)
+	mustContain(t, code, "func defineSupportTicket_StatusTool(")
+	mustNotContain(t, code, "SupportTicketStatusTool")
+}
+
+func TestDuplicateToolNamesFailGeneration(t *testing.T) {
+	_, err := runGeneration(t, []string{"test/proto/invalid/duplicate_tool.proto"}, nil)
+	if err == nil {
+		t.Fatal("generation succeeded, want duplicate tool name error")
+	}
+	want := `tool name "get" is used by both invalid.Orders.GetOrder (invalid/duplicate_tool.proto:10:3) and invalid.Refunds.GetRefund (invalid/duplicate_tool.proto:16:3)`
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Names must also be unique across the files of a request.
+	_, err = runGeneration(t, []string{"test/proto/catalog.proto", "test/proto/invalid/reused_tool.proto"}, nil)
+	if err == nil {
+		t.Fatal("generation succeeded, want duplicate tool name error")
+	}
+	want = `tool name "get_weather" is used by both catalog.ToolCatalog.GetWeather (catalog.proto:10:3) and invalid.Forecasts.GetForecast (invalid/reused_tool.proto:10:3)`
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConcurrentGenerationMatchesSingleFile(t *testing.T) {
+	targets := []string{
+		"test/proto/catalog.proto",
+		"test/proto/invoice/v1/invoice.proto",
+		"test/proto/order/v1/order.proto",
+		"test/proto/shipping/v1/shipping.proto",
+	}
+	together, err := runGeneration(t, targets, nil)
+	if err != nil {
+		t.Fatalf("generate %v: %v", targets, err)
+	}
+	for _, target := range targets {
+		if alone := generateWithOptions(t, target); together[target] != alone {
+			t.Errorf("%s generated with other files differs from %s generated alone", target, target)
+		}
+	}
+}
+
+func TestStrictOption(t *testing.T) {
+	_, err := runGeneration(t, []string{"test/proto/catalog.proto"}, []string{"strict=true"})
+	if err == nil {
+		t.Fatal("generation succeeded, want skipped methods error")
+	}
+	for _, want := range []string{
+		"strict=true: generation is incomplete:",
+		"catalog.ToolCatalog.CompareCities (catalog.proto:27:3): skipped: client-streaming methods need client_streaming=array",
+		"catalog.ToolCatalog.Undocumented (catalog.proto:44:3): skipped: no (genkit.tool.v1.tool_doc) option",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not contain %q", err, want)
+		}
+	}
+
+	_, err = runGeneration(t, []string{"test/proto/catalog.proto"}, []string{"strict=true", "client_streaming=array"})
+	if err == nil || strings.Contains(err.Error(), "CompareCities") || !strings.Contains(err.Error(), "catalog.ToolCatalog.Undocumented") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	generateWithOptions(t, "test/proto/invoice/v1/invoice.proto", "strict=true")
+}
+
+func TestUnsupportedTypesDiagnostics(t *testing.T) {
+	// Without strict=true, unsupported types are only warned about.
+	generateWithOptions(t, "test/proto/invalid/unsupported.proto")
+
+	_, err := runGeneration(t, []string{"test/proto/invalid/unsupported.proto"}, []string{"strict=true"})
+	if err == nil {
+		t.Fatal("generation succeeded, want unsupported types error")
+	}
+	for _, want := range []string{
+		"invalid.PublishRequest.payload (invalid/unsupported.proto:18:3): the tool schemas cannot describe the message a google.protobuf.Any holds",
+		"invalid.Attributes (invalid/unsupported.proto:22:1): extensions are left out of the tool schemas",
+		"invalid.PublishResponse.details (invalid/unsupported.proto:30:3)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestCrossPackageSchemas(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/shipping/v1/shipping.proto")
+
+	// Fields, docs and enum values of common.v1 types are resolved.
+	mustContain(t, code, `"currency_code": map[string]any{"description": "ISO 4217 currency code", "example": "EUR", "type": "string"}`)
+	mustContain(t, code, `"enum": []string{"SIZE_UNSPECIFIED", "SIZE_SMALL", "SIZE_LARGE"}`)
+	// Well-known types use their JSON form; recursion stops at an open object.
+	mustContain(t, code, `"ship_by": map[string]any{"description": "Latest pickup time", "format": "date-time", "type": "string"}`)
+	mustContain(t, code, `"labels": map[string]any{"type": "object"}`)
+	mustContain(t, code, `"children": map[string]any{"items": map[string]any{"type": "object"}, "type": "array"}`)
+	// Requests from another package are coerced into the imported Go type.
+	mustContain(t, code, "func coerceShippingServiceInsureRequest(input any) (*v1.Parcel, error) {")
+}
+
+func TestMultiPackageOutputs(t *testing.T) {
+	targets := []string{"test/proto/billing/v1/billing.proto", "test/proto/ledger/v1/ledger.proto"}
+	out, err := runGeneration(t, targets, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := out["test/proto/billing/v1/billing.proto"]
+	// The errors package of the messages does not shadow the standard one.
+	mustContain(t, code, "\terrors \"errors\"\n")
+	mustContain(t, code, "\terrors1 \"example.com/test/errors\"\n")
+	mustContain(t, code, "\tai \"github.com/firebase/genkit/go/ai\"\n")
+	mustNotContain(t, code, "import genkitai")
+	mustContain(t, code, "func decodeBillingErrorsProblem(v any, path string, msg *errors1.Problem) error {")
+	mustContain(t, code, "\tv1 \"example.com/test/ledger/v1\"\n")
+	mustContain(t, code, "Adjust(context.Context, *v1.Entry) (*v1.Balance, error)")
+	mustContain(t, code, "func coerceBillingAdjustRequest(input any) (*v1.Entry, error) {")
+	mustContain(t, code, "msg.Memo = &v1.Entry_Reference{Reference: x}")
+	mustContain(t, out["test/proto/ledger/v1/ledger.proto"], "func coerceLedgerPostRequest(input any) (*Entry, error) {")
+
+	// Without paths=source_relative, each file goes to the directory of
+	// its go_package.
+	outDir, err := runBufGenerate(t, targets, []string{"paths=import"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"example.com/test/billing/v1/billing_genkit.tools.go", "example.com/test/ledger/v1/ledger_genkit.tools.go"} {
+		if _, err := os.Stat(filepath.Join(outDir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestVersionStamp(t *testing.T) {
+	code := buildWithOptions(t, "test/proto/invoice/v1/invoice.proto", "lazy=true")
+
+	mustContain(t, code, "// versions:\n// \tprotoc-gen-go-genkit-tools ")
+	mustContain(t, code, "\n// options: ")
+	mustContain(t, code, "// source: invoice/v1/invoice.proto\n\npackage invoicev1")
+	mustContain(t, code, `const InvoiceServiceGeneratedWith = "protoc-gen-go-genkit-tools `)
+	mustContain(t, code, `source invoice/v1/invoice.proto"`)
+	for _, line := range strings.Split(code, "\n") {
+		if strings.HasPrefix(line, "// options: ") && !strings.Contains(line, "lazy=true") {
+			t.Fatalf("options line %q is missing lazy=true", line)
+		}
+	}
+	mustMatch(t, code, `GeneratedWith:\s+InvoiceServiceGeneratedWith,`)
+}
+
+func TestInterruptibleTools(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+
+	mustContain(t, code, "func(tc *ai.ToolContext, input any) (*CreateInvoiceResponse, error) {")
+	mustContain(t, code, "ctx := genkittools.ContextWithResumed(tc, tc.Resumed)")
+	mustContain(t, code, "return out, tc.Interrupt(&ai.InterruptOptions{Metadata: md})")
+	mustContain(t, code, "func ResumeInvoiceServiceCreateInvoice(g *genkit.Genkit, interrupt *ai.Part, resumed map[string]any, opts ...genkittools.Option) (*ai.Part, error) {")
+	mustContain(t, code, "func RespondInvoiceServiceCreateInvoice(g *genkit.Genkit, interrupt *ai.Part, out *CreateInvoiceResponse, opts ...genkittools.Option) (*ai.Part, error) {")
+	mustNotContain(t, code, "func ResumeInvoiceServiceGetInvoice(")
+}
+
+func TestToolDefinitionOptions(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+
+	mustContain(t, code, "o.ToolName(toolInfoInvoiceServiceGetInvoice.Name),\n\t\to.ToolDescription(toolInfoInvoiceServiceGetInvoice.Description),")
+	mustContain(t, code, "toolName := genkittools.NewOptions(opts...).ToolName(string(InvoiceServiceCreateInvoiceTool))")
+	mustContain(t, code, "if !interrupt.IsInterrupt() || interrupt.ToolRequest.Name != toolName {")
+	mustContain(t, code, "tool := genkit.LookupTool(g, toolName)")
+
+	lazy := buildWithOptions(t, "test/proto/invoice/v1/invoice.proto", "lazy=true")
+	mustContain(t, lazy, "return ai.NewTool[any, *Invoice](\n\t\to.ToolName(toolInfoInvoiceServiceGetInvoice.Name),")
+}
+
+func TestDuplicateRegistrationGuard(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/report/v1/report.proto")
+
+	mustContain(t, code, "if err := genkittools.ClaimTool(g, o.ToolName(toolInfoReportsGetReport.Name)); err != nil {\n\t\treturn nil, err\n\t}\n\ttool := genkit.DefineToolWithInputSchema[")
+	mustContain(t, code, "if err := genkittools.ClaimTool(g, o.ToolName(toolInfoReportsGetReportBatch.Name)); err != nil {")
+
+	lazy := buildWithOptions(t, "test/proto/report/v1/report.proto", "lazy=true")
+	mustNotContain(t, lazy, "genkittools.ClaimTool(")
+}
+
+func TestResourceMethods(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/city/v1/city.proto")
+
+	mustContain(t, code, "type CityDirectoryResourceImpl interface {")
+	mustContain(t, code, `const CityDirectoryGetCityResourceURI = "citydirectory://city/{country}/{name}"`)
+	mustContain(t, code, `const CityDirectoryListCapitalsResourceURI = "citydirectory://capitals"`)
+	mustContain(t, code, "func RegisterCityDirectoryResources(g *genkit.Genkit, impl CityDirectoryResourceImpl) []ai.Resource {")
+	mustContain(t, code, `genkit.DefineResource(g, "city", &ai.ResourceOptions{`)
+	mustMatch(t, code, `URI:\s+CityDirectoryListCapitalsResourceURI,`)
+	mustContain(t, code, `if err := genkittools.DecodeURIVariables("city", in.Variables, req); err != nil {`)
+	mustContain(t, code, "text, err := genkittools.RenderTemplate(resultTemplateCityDirectoryListCapitals, resp)")
+	// Resources are not tools.
+	mustNotContain(t, code, "CityDirectoryToolImpl")
+	mustNotContain(t, code, `"encoding/json"`)
+
+	lazy := buildWithOptions(t, "test/proto/city/v1/city.proto", "lazy=true")
+	mustContain(t, lazy, "func NewCityDirectoryResources(impl CityDirectoryResourceImpl) []ai.Resource {")
+	mustContain(t, lazy, `ai.NewResource("city", &ai.ResourceOptions{`)
+
+	files := generateFilesWithOptions(t, "test/proto/city/v1/city.proto", "mcp=true")
+	mcp, ok := files["city/v1/city_genkit.tools_mcp.go"]
+	if !ok {
+		t.Fatalf("missing MCP file, got %v", mapKeys(files))
+	}
+	mustContain(t, mcp, "func AddCityDirectoryMCPResources(s *server.MCPServer, impl CityDirectoryResourceImpl) {")
+	mustContain(t, mcp, `s.AddResourceTemplate(mcp.NewResourceTemplate(CityDirectoryGetCityResourceURI, "city", mcp.WithTemplateDescription("Facts about a city."), mcp.WithTemplateMIMEType("application/json")),`)
+	mustContain(t, mcp, `s.AddResource(mcp.NewResource(CityDirectoryListCapitalsResourceURI, "capitals", mcp.WithResourceDescription("Every capital city."), mcp.WithMIMEType("text/plain")),`)
+	mustContain(t, mcp, `if err := genkittools.DecodeURIVariables("city", genkittools.URIArguments(read.Params.Arguments), req); err != nil {`)
+	mustNotContain(t, mcp, "MCPServer(name, version string")
+
+	_, err := runGeneration(t, []string{"test/proto/invalid/resource.proto"}, nil)
+	if want := "invalid.Notes.DeleteNote: resource methods must be read-only; set option idempotency_level = NO_SIDE_EFFECTS"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestMediaFields(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/chart/v1/chart.proto")
+
+	mustContain(t, code, "tool := genkit.DefineMultipartTool[any](")
+	mustContain(t, code, "func(ctx *ai.ToolContext, input any) (*ai.MultipartToolResponse, error) {")
+	mustContain(t, code, "out, media := genkittools.SplitMedia(toolInfoChartsRenderChart, out)")
+	mustContain(t, code, "parts[i] = ai.NewMediaPart(m.ContentType, m.URL)")
+	mustContain(t, code, "ai.WithInputSchema(schemaChartsRenderChart),")
+	mustMatch(t, code, `"png":\s+"image/png",`)
+	mustMatch(t, code, `"svg_url":\s+"image/svg\+xml",`)
+	// Media fields are left out of the structured output.
+	mustContain(t, code, `var outputSchemaChartsRenderChart = map[string]any{"properties": map[string]any{"caption": map[string]any{"description": "Summary of the chart.", "type": "string"}}, "type": "object"}`)
+
+	runtime := buildWithOptions(t, "test/proto/chart/v1/chart.proto", "codegen=runtime", "lazy=true")
+	mustContain(t, runtime, `genkittools.OmitFields(genkittools.MessageSchema((*Chart)(nil).ProtoReflect().Descriptor()), "png", "svg_url")`)
+	mustContain(t, runtime, "return ai.NewMultipartTool[any](")
+}
+
+func TestProgressOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/report/v1/report.proto", "mocks=true", "fakes=true", "flows=true")
+	code := files["report/v1/report_genkit.tools.go"]
+	mustContain(t, code, "BuildReport(context.Context, *BuildReportRequest, genkittools.ProgressFunc) (*Report, error)")
+	mustContain(t, code, "return impl.BuildReport(ctx, req, genkittools.ProgressReporter(ctx, string(ReportsBuildReportTool)))")
+
+	mock := files["report/v1/report_genkit.tools_mock.go"]
+	mustContain(t, mock, "func (m *ReportsToolImplMock) BuildReport(ctx context.Context, req *BuildReportRequest, progress genkittools.ProgressFunc) (*Report, error) {")
+	mustContain(t, mock, "return fn(ctx, req, progress)")
+	mustContain(t, files["report/v1/report_genkit.tools_fake.go"], "func (FakeReportsToolImpl) BuildReport(context.Context, *BuildReportRequest, genkittools.ProgressFunc) (*Report, error) {")
+
+	// Flows stream the updates through Genkit's streaming callback.
+	flows := files["report/v1/report_genkit.tools_flows.go"]
+	mustContain(t, flows, "*core.Flow[*BuildReportRequest, *Report, genkittools.Progress]")
+	mustContain(t, flows, "return genkit.DefineStreamingFlow(g, string(ReportsBuildReportTool), func(ctx context.Context, input *BuildReportRequest, cb core.StreamCallback[genkittools.Progress]) (*Report, error) {")
+	mustContain(t, flows, "return impl.BuildReport(ctx, req, func(p genkittools.Progress) error {")
+
+	_, err := runGeneration(t, []string{"test/proto/invalid/progress.proto"}, nil)
+	if want := "invalid.Feed.Watch: progress cannot be set on server-streaming methods"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestBatchOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/report/v1/report.proto")
+	mustContain(t, code, `const ReportsGetReportBatchTool ai.ToolName = "get_report_batch"`)
+	mustContain(t, code, "var schemaReportsGetReportBatch = genkittools.BatchSchema(schemaReportsGetReport)")
+	mustContain(t, code, "var outputSchemaReportsGetReportBatch = genkittools.BatchOutputSchema(outputSchemaReportsGetReport)")
+	mustContain(t, code, "if t, err := defineReportsGetReportBatchTool(g, impl, o); err != nil {")
+	mustContain(t, code, "tool := genkit.DefineToolWithInputSchema[*genkittools.BatchOutput[*Report]](")
+	mustContain(t, code, "return genkittools.RunBatch(ctx, o, string(ReportsGetReportBatchTool), input, func(ctx context.Context, input any) (*Report, error) {")
+	mustContain(t, code, "return genkittools.Invoke(ctx, o, toolInfoReportsGetReport, input, coerceReportsGetReportRequest, func(ctx context.Context, req *GetReportRequest) (*Report, error) {")
+	mustMatch(t, code, `ToolRegistry\.Add\(\s+toolInfoReportsBuildReport,\s+toolInfoReportsGetReport,\s+toolInfoReportsGetReportBatch,`)
+	if strings.Contains(code, "defineReportsBuildReportBatchTool") {
+		t.Fatal("batch tool generated for a method without batch")
+	}
+
+	lazy := buildWithOptions(t, "test/proto/report/v1/report.proto", "lazy=true", "codegen=runtime")
+	mustContain(t, lazy, "refs = append(refs, NewReportsGetReportBatchTool(impl, opts...))")
+	mustContain(t, lazy, "return ai.NewTool[any, *genkittools.BatchOutput[*Report]](")
+	mustContain(t, lazy, "schemaReportsGetReportBatch = genkittools.BatchSchema(schemaReportsGetReport)")
+
+	_, err := runGeneration(t, []string{"test/proto/invalid/batch.proto"}, nil)
+	if want := `invalid.Lookup.Get: batch tool name "get_batch" is taken by invalid.Lookup.GetMany`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestToolMetadata(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/invoice/v1/invoice.proto")
+	mustContain(t, code, "Metadata: map[string]any{\n\t\t\"billing_team\": \"finance\",\n\t\t\"cost_units\":   float64(2.5),\n")
+	mustContain(t, code, `"routing":      map[string]any{"pools": []any{"primary", "batch"}, "region": "eu", "sticky": true},`)
+
+	_, err := runGeneration(t, []string{"test/proto/invalid/metadata.proto"}, nil)
+	if want := `invalid.Billing.Charge: metadata "team": value finance is not JSON; strings must be quoted, e.g. "\"finance\""`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestToolSafety(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/gateway/v1/gateway.proto")
+	mustContain(t, code, "Safety:        genkittools.SafetyReadOnly,")
+	mustContain(t, code, "Safety:        genkittools.SafetyDestructive,")
+	mustContain(t, code, "err := o.Confirm(ctx, toolInfoProfileServiceUpdateProfile)")
+	mustContain(t, code, "func ConfirmProfileServiceUpdateProfile(g *genkit.Genkit, interrupt *ai.Part, opts ...genkittools.Option) (*ai.Part, error) {")
+	mustContain(t, code, "return ResumeProfileServiceUpdateProfile(g, interrupt, map[string]any{genkittools.ConfirmedKey: true}, opts...)")
+	mustNotContain(t, code, "func ConfirmProfileServiceGetProfile")
+
+	lazy := buildWithOptions(t, "test/proto/gateway/v1/gateway.proto", "lazy=true")
+	mustContain(t, lazy, "err := o.Confirm(ctx, toolInfoProfileServiceUpdateProfile)")
+	mustNotContain(t, lazy, "func ConfirmProfileServiceUpdateProfile")
+
+	_, err := runGeneration(t, []string{"test/proto/invalid/safety.proto"}, nil)
+	if want := "invalid.Accounts.DeleteAccount: batch cannot be set on destructive methods, whose calls are confirmed one by one"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestOutputOnlyFields(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/gateway/v1/gateway.proto")
+	mustContain(t, code, `var schemaProfileServiceUpdateProfile = map[string]any{"properties": map[string]any{"display_name": map[string]any{"description": "Name shown to other users.", "type": "string"}, "user_id": map[string]any{"description": "User ID", "type": "string"}}, "type": "object"}`)
+	mustContain(t, code, `"update_time": map[string]any{"format": "date-time", "type": "string"}`)
+	mustContain(t, code, `case "updateTime", "update_time": // Output only: set by the server, so ignored.`)
+
+	runtime := buildWithOptions(t, "test/proto/gateway/v1/gateway.proto", "codegen=runtime")
+	mustContain(t, runtime, "schemaProfileServiceUpdateProfile = genkittools.OmitOutputOnly(genkittools.MessageSchema((*Profile)(nil).ProtoReflect().Descriptor()), (*Profile)(nil).ProtoReflect().Descriptor())")
+	mustNotContain(t, runtime, "schemaProfileServiceGetProfile = genkittools.OmitOutputOnly")
+}
+
+func TestResponseLimits(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/report/v1/report.proto")
+	mustContain(t, code, "ResponseLimits: genkittools.ResponseLimits{MaxBytes: 16384, MaxItems: 50},")
+	mustContain(t, code, "resp = genkittools.TruncateResponse(toolInfoReportArchiveListReports, resp)")
+	mustNotContain(t, code, "TruncateResponse(toolInfoReportsGetReport,")
+
+	_, err := runGeneration(t, []string{"test/proto/invalid/limits.proto"}, nil)
+	if want := "invalid.Search.Find: response limits need a bool truncated field in invalid.FindResponse to mark cut responses"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestGRPCOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "grpc=true", "client_streaming=array")
+
+	adapter, ok := files["catalog_genkit.tools_grpc.go"]
+	if !ok {
+		t.Fatalf("missing gRPC adapter file, got %v", mapKeys(files))
+	}
+	mustContain(t, adapter, "func NewToolCatalogGRPCImpl(conn grpc.ClientConnInterface, opts ...grpc.CallOption) ToolCatalogToolImpl {")
+	mustContain(t, adapter, "return &toolCatalogGRPCImpl{client: NewToolCatalogClient(conn), opts: opts}")
+	mustContain(t, adapter, "return x.client.GetWeather(x.outgoing(ctx), req, x.opts...)")
+	mustContain(t, adapter, "stream, err := x.client.StreamForecast(x.outgoing(ctx), req, x.opts...)")
+	mustContain(t, adapter, "return stream.CloseAndRecv()")
+	mustContain(t, adapter, "if err := stream.CloseSend(); err != nil {")
+	mustContain(t, adapter, "func NewToolCatalogGRPCImplWithHeaders(conn grpc.ClientConnInterface, headers genkittools.Headers, opts ...grpc.CallOption) ToolCatalogToolImpl {")
+	mustContain(t, adapter, "return &toolCatalogGRPCImpl{client: NewToolCatalogClient(conn), opts: opts, headers: headers}")
+	mustContain(t, adapter, "pairs := x.headers.Pairs(ctx)")
+	mustContain(t, adapter, "return metadata.AppendToOutgoingContext(ctx, pairs...)")
+
+	plain := generateFilesWithOptions(t, "test/proto/catalog.proto")
+	if _, ok := plain["catalog_genkit.tools_grpc.go"]; ok {
+		t.Fatal("gRPC adapter generated without grpc=true")
+	}
+}
+
+func TestConnectOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "connect=true")
+
+	adapter, ok := files["catalogconnect/catalog_genkit.tools_connect.go"]
+	if !ok {
+		t.Fatalf("missing Connect adapter file, got %v", mapKeys(files))
+	}
+	mustContain(t, adapter, "package catalogconnect")
+	mustContain(t, adapter, "func NewToolCatalogToolImpl(client ToolCatalogClient) catalog.ToolCatalogToolImpl {")
+	mustContain(t, adapter, "r := connect.NewRequest(req)\n\tx.headers.Apply(ctx, r.Header())\n\tresp, err := x.client.GetWeather(ctx, r)")
+	mustContain(t, adapter, "func NewToolCatalogToolImplWithHeaders(client ToolCatalogClient, headers genkittools.Headers) catalog.ToolCatalogToolImpl {")
+	mustContain(t, adapter, "return nil, toolCatalogToolError(err)")
+	mustContain(t, adapter, "rerr := &genkittools.RemoteError{Code: cerr.Code().String(), Message: cerr.Message(), Err: err}")
+	mustContain(t, adapter, "rerr.AddDetail(d.Type(), d.Bytes())")
+}
+
+func TestPackageSuffixOption(t *testing.T) {
+	files := buildFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "package_suffix=tools", "mocks=true")
+
+	code, ok := files["invoice/v1/invoicev1tools/invoice_genkit.tools.go"]
+	if !ok {
+		t.Fatalf("missing tools file in the sub-package, got %v", mapKeys(files))
+	}
+	mustContain(t, code, "package invoicev1tools")
+	mustContain(t, code, `v1 "example.com/test/invoice/v1"`)
+	mustContain(t, code, "GetInvoice(context.Context, *v1.GetInvoiceRequest) (*v1.Invoice, error)")
+	mustContain(t, code, "func coerceInvoiceServiceGetInvoiceRequest(input any) (*v1.GetInvoiceRequest, error) {")
+	mustContain(t, files["invoice/v1/invoicev1tools/invoice_genkit.tools_mock.go"], "package invoicev1tools")
+	if _, ok := files["invoice/v1/invoice_genkit.tools.go"]; ok {
+		t.Error("tools file also generated into the message package")
+	}
+
+	// Adapters refer to the stubs next to the messages and to the tools
+	// in the sub-package. They are not built, since the stubs come from
+	// plugins the test workspace does not run.
+	files = generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "package_suffix=tools", "grpc=true", "connect=true")
+	grpcAdapter := files["invoice/v1/invoicev1tools/invoice_genkit.tools_grpc.go"]
+	mustContain(t, grpcAdapter, "return &invoiceServiceGRPCImpl{client: v1.NewInvoiceServiceClient(conn), opts: opts}")
+	mustMatch(t, grpcAdapter, `client\s+v1\.InvoiceServiceClient`)
+	connectAdapter := files["invoice/v1/invoicev1connect/invoice_genkit.tools_connect.go"]
+	mustContain(t, connectAdapter, "func NewInvoiceServiceToolImpl(client InvoiceServiceClient) invoicev1tools.InvoiceServiceToolImpl {")
+
+	_, err := runGeneration(t, []string{"test/proto/invoice/v1/invoice.proto"}, []string{"package_suffix=Tools"})
+	if want := `invalid package_suffix="Tools"`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestRESTOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "rest=true")
+
+	adapter, ok := files["invoice/v1/invoice_genkit.tools_rest.go"]
+	if !ok {
+		t.Fatalf("missing REST adapter file, got %v", mapKeys(files))
+	}
+	mustContain(t, adapter, "func NewInvoiceServiceRESTImpl(baseURL string, client *http.Client) InvoiceServiceToolImpl {")
+	mustMatch(t, adapter, `Method:\s+"POST",\s+Pattern:\s+"/v1/invoices",\s+Body:\s+"invoice",`)
+	mustMatch(t, adapter, `Method:\s+"GET",\s+Pattern:\s+"/v1/invoices/\{invoice_id\}",\s+\}`)
+	mustContain(t, adapter, "if err := genkittools.CallREST(ctx, x.client, x.baseURL, rule, req, resp); err != nil {")
+
+	catalog := generateFilesWithOptions(t, "test/proto/catalog.proto", "rest=true")
+	mustContain(t, catalog["catalog_genkit.tools_rest.go"], `return nil, errors.New("catalog.ToolCatalog.GetWeather: no google.api.http binding")`)
+	mustContain(t, catalog["catalog_genkit.tools_rest.go"], `return errors.New("catalog.ToolCatalog.StreamForecast: streaming methods cannot be called over REST")`)
+}
+
+func TestLazyOption(t *testing.T) {
+	code := buildWithOptions(t, "test/proto/invoice/v1/invoice.proto", "lazy=true")
+
+	mustContain(t, code, "func NewInvoiceServiceTools(impl InvoiceServiceToolImpl, opts ...genkittools.Option) []ai.ToolRef {")
+	mustContain(t, code, "refs = append(refs, NewInvoiceServiceGetInvoiceTool(impl, opts...))")
+	mustContain(t, code, "func NewInvoiceServiceGetInvoiceTool(impl InvoiceServiceToolImpl, opts ...genkittools.Option) ai.Tool {")
+	mustContain(t, code, "return ai.NewTool[any, *Invoice](")
+	mustContain(t, code, "ai.WithInputSchema(schemaInvoiceServiceGetInvoice),")
+	mustContain(t, code, "genkittools.ToolRegistry.Add(")
+	mustNotContain(t, code, "func RegisterInvoiceServiceTools(")
+	mustNotContain(t, code, `"github.com/firebase/genkit/go/genkit"`)
+}
+
+func TestInputStructsOption(t *testing.T) {
+	code := buildWithOptions(t, "test/proto/invoice/v1/invoice.proto", "input_structs=true")
+
+	mustContain(t, code, "// InvoiceServiceGetInvoiceInput is the input of the get_invoice tool, mirroring GetInvoiceRequest.")
+	mustMatch(t, code, `InvoiceId string\s+`+"`"+`json:"invoice_id" jsonschema_description:"ID of the invoice to fetch."`+"`")
+	mustMatch(t, code, `Invoice\s+\*InvoiceServiceCreateInvoiceInputInvoice\s+`+"`"+`json:"invoice" jsonschema_description:"The invoice to create."`+"`")
+	mustMatch(t, code, `LineItems\s+\[\]InvoiceServiceCreateInvoiceInputLineItem\s+`+"`"+`json:"line_items,omitempty"`+"`")
+	mustContain(t, code, "// InvoiceServiceCreateInvoiceInputLineItem mirrors invoice.v1.LineItem in the input of the create_invoice tool.")
+	mustContain(t, code, "tool := genkit.DefineTool[InvoiceServiceGetInvoiceInput, *Invoice](")
+	mustContain(t, code, "func(ctx *ai.ToolContext, in InvoiceServiceGetInvoiceInput) (*Invoice, error) {\n\t\t\tinput := genkittools.StructInput(in)")
+	mustContain(t, code, "func(tc *ai.ToolContext, in InvoiceServiceCreateInvoiceInput) (*CreateInvoiceResponse, error) {")
+	mustNotContain(t, code, "genkit.DefineToolWithInputSchema[")
+
+	billing := buildWithOptions(t, "test/proto/billing/v1/billing.proto", "input_structs=true")
+	mustMatch(t, billing, `Kind\s+string\s+`+"`"+`json:"kind,omitempty" jsonschema:"enum=ENTRY_KIND_UNSPECIFIED,enum=ENTRY_KIND_DEBIT,enum=ENTRY_KIND_CREDIT"`+"`")
+	mustMatch(t, billing, `Note\s+\*string\s+`+"`"+`json:"note,omitempty"`+"`")
+	mustMatch(t, billing, `Links\s+map\[string\]BillingChargeInputReference\s+`)
+
+	lazy := buildWithOptions(t, "test/proto/invoice/v1/invoice.proto", "input_structs=true", "lazy=true")
+	mustContain(t, lazy, "return ai.NewTool[InvoiceServiceGetInvoiceInput, *Invoice](")
+	mustNotContain(t, lazy, "ai.WithInputSchema(")
+}
+
+func TestGenkitAPIOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/invoice/v1/invoice.proto", "genkit_api=compat")
+
+	mustContain(t, code, `"github.com/nemo1105/protoc-gen-go-genkit-tools/genkittools/genkitcompat"`)
+	mustContain(t, code, "tool := genkitcompat.DefineTool[any, *Invoice](\n\t\tg,\n\t\to.ToolName(toolInfoInvoiceServiceGetInvoice.Name),\n\t\to.ToolDescription(toolInfoInvoiceServiceGetInvoice.Description),\n\t\tschemaInvoiceServiceGetInvoice,")
+	mustContain(t, code, "tool := genkitcompat.LookupTool(g, toolName)")
+	mustNotContain(t, code, "genkit.DefineToolWithInputSchema[")
+	mustNotContain(t, code, "genkit.LookupTool(")
+
+	typed := buildWithOptions(t, "test/proto/invoice/v1/invoice.proto", "genkit_api=compat", "input_structs=true", "lazy=true")
+	mustContain(t, typed, "return genkitcompat.NewTool[InvoiceServiceGetInvoiceInput, *Invoice](\n\t\to.ToolName(toolInfoInvoiceServiceGetInvoice.Name),\n\t\to.ToolDescription(toolInfoInvoiceServiceGetInvoice.Description),\n\t\tnil,")
+	mustNotContain(t, typed, "ai.WithInputSchema(")
+
+	city := generateWithOptions(t, "test/proto/city/v1/city.proto", "genkit_api=compat")
+	mustContain(t, city, `genkitcompat.DefineResource(g, "city", &ai.ResourceOptions{`)
+
+	_, err := runGeneration(t, []string{"test/proto/invoice/v1/invoice.proto"}, []string{"genkit_api=v2"})
+	if err == nil {
+		t.Fatal("generation succeeded, want invalid genkit_api error")
+	}
+	if want := `invalid genkit_api="v2": want direct or compat`; !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCodegenRuntimeOption(t *testing.T) {
+	code := buildWithOptions(t, "test/proto/catalog.proto", "codegen=runtime")
+
+	mustContain(t, code, `return genkittools.Coerce(input, "get_weather", &GetWeatherRequest{})`)
+	mustContain(t, code, `schemaToolCatalogGetWeather = genkittools.DescribeSchema(genkittools.MessageSchema((*GetWeatherRequest)(nil).ProtoReflect().Descriptor()), "City and optional units")`)
+	mustContain(t, code, "toolInfoToolCatalogGetWeather.InputSchema = schemaToolCatalogGetWeather")
+	mustNotContain(t, code, "func decodeToolCatalog")
+	mustNotContain(t, code, `"encoding/json"`)
+
+	_, err := runGeneration(t, []string{"test/proto/catalog.proto"}, []string{"codegen=reflect"})
+	if err == nil {
+		t.Fatal("generation succeeded, want invalid codegen error")
+	}
+	if want := `invalid codegen="reflect": want inline or runtime`; !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSplitSchemasOption(t *testing.T) {
+	files := buildFilesWithOptions(t, "test/proto/catalog.proto", "split_schemas=true")
+
+	schemas, ok := files["catalog_genkit.schemas.go"]
+	if !ok {
+		t.Fatalf("missing schemas file, got %v", mapKeys(files))
+	}
+	mustContain(t, schemas, "package catalog")
+	mustContain(t, schemas, "var schemaToolCatalogGetWeather = map[string]any{")
+	mustContain(t, schemas, "var outputSchemaToolCatalogGetWeather = map[string]any{")
+
+	code := files["catalog_genkit.tools.go"]
+	mustMatch(t, code, `InputSchema:\s+schemaToolCatalogGetWeather,`)
+	mustNotContain(t, code, "var schemaToolCatalogGetWeather")
+}
+
+func TestFlowsOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "flows=true", "client_streaming=array")
+
+	flows, ok := files["catalog_genkit.tools_flows.go"]
+	if !ok {
+		t.Fatalf("missing flows file, got %v", mapKeys(files))
+	}
+	mustContain(t, flows, "func DefineToolCatalogFlows(g *genkit.Genkit, impl ToolCatalogToolImpl, opts ...genkittools.Option) {")
+	mustContain(t, flows, "func DefineToolCatalogGetWeatherFlow(g *genkit.Genkit, impl ToolCatalogToolImpl, opts ...genkittools.Option) *core.Flow[*GetWeatherRequest, *GetWeatherResponse, struct{}] {")
+	mustContain(t, flows, "return genkit.DefineFlow(g, string(ToolCatalogGetWeatherTool), func(ctx context.Context, input *GetWeatherRequest) (*GetWeatherResponse, error) {")
+	mustContain(t, flows, "*core.Flow[*GetForecastRequest, []*ForecastDay, *ForecastDay]")
+	mustContain(t, flows, "return cb(ctx, resp)")
+	mustContain(t, flows, "*core.Flow[[]*GetWeatherRequest, *GetWeatherResponse, struct{}]")
+	// Flows return the proto message even for tools rendering text.
+	mustContain(t, flows, `var flowOutputSchemaToolCatalogCompareCities = map[string]any{"properties": map[string]any{"temperature"`)
+
+	plain := generateFilesWithOptions(t, "test/proto/catalog.proto")
+	if _, ok := plain["catalog_genkit.tools_flows.go"]; ok {
+		t.Fatal("flows file generated without flows=true")
+	}
+}
+
+func TestDotpromptOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "dotprompt=true")
+
+	prompt, ok := files["invoice/v1/invoice_invoiceservice.prompt"]
+	if !ok {
+		t.Fatalf("missing prompt file, got %v", mapKeys(files))
+	}
+	mustContain(t, prompt, "---\n# Scaffolded by protoc-gen-go-genkit-tools from invoice/v1/invoice.proto as a\n")
+	mustContain(t, prompt, "tools:\n  - \"create_invoice\"\n  - \"get_invoice\"\n")
+	mustContain(t, prompt, "{{role \"system\"}}\nTODO: describe the task")
+	mustContain(t, prompt, "- create_invoice: Create a new invoice.\n")
+	mustContain(t, prompt, "{{role \"user\"}}\n{{request}}")
+	mustNotContain(t, prompt, "DO NOT EDIT")
+}
+
+func TestMCPOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "mcp=true")
+
+	server, ok := files["catalog_genkit.tools_mcp.go"]
+	if !ok {
+		t.Fatalf("missing MCP server file, got %v", mapKeys(files))
+	}
+	mustContain(t, server, "func NewToolCatalogMCPServer(name, version string, impl ToolCatalogToolImpl, opts ...genkittools.Option) *server.MCPServer {")
+	mustContain(t, server, "func AddToolCatalogMCPTools(s *server.MCPServer, impl ToolCatalogToolImpl, opts ...genkittools.Option) {")
+	mustContain(t, server, "if o.Includes(string(ToolCatalogGetWeatherTool)) {")
+	mustContain(t, server, `s.AddTool(mcp.NewToolWithRawSchema(o.ToolName(string(ToolCatalogGetWeatherTool)), o.ToolDescription(toolInfoToolCatalogGetWeather.Description), toolInfoToolCatalogGetWeather.InputSchemaJSON()),`)
+	mustContain(t, server, "out, err := genkittools.Invoke(ctx, o, toolInfoToolCatalogGetWeather, input, coerceToolCatalogGetWeatherRequest,")
+	mustContain(t, server, "return mcp.NewToolResultError(err.Error()), nil")
+	mustContain(t, server, "raw, err := genkittools.MarshalOutput(out)")
+	mustNotContain(t, server, "ToolCatalogCompareCitiesTool")
+}
+
+func TestCompanionFilesCompile(t *testing.T) {
+	catalog := []string{"test/proto/catalog.proto"}
+	// The tools sub-package imports the proto package, so package_suffix
+	// uses a file whose go_package matches its directory.
+	invoice := withProtoImports(t, "test/proto/invoice/v1/invoice.proto")
+	for _, tc := range []struct {
+		targets []string
+		opts    []string
+	}{
+		{catalog, []string{"mcp=true", "cli=true", "flows=true"}},
+		{catalog, []string{"mcp=true", "cli=true", "flows=true", "impl_context=tool", "lazy=true"}},
+		{invoice, []string{"mcp=true", "cli=true", "flows=true", "package_suffix=tools", "split_schemas=true"}},
+		{invoice, []string{"codegen=runtime", "package_suffix=tools", "split_schemas=true"}},
+	} {
+		outDir, err := runBufGenerate(t, tc.targets, tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buildGenerated(t, outDir)
+	}
+}
+
+func TestMCPManifestOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "mcp_manifest=true")
+
+	raw, ok := files["catalog_genkit.tools.mcp.json"]
+	if !ok {
+		t.Fatalf("missing MCP manifest, got %v", mapKeys(files))
+	}
+	var manifest struct {
+		Tools []struct {
+			Name        string         `json:"name"`
+			Description string         `json:"description"`
+			InputSchema map[string]any `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(raw), &manifest); err != nil {
+		t.Fatalf("unmarshal manifest: %v\n%s", err, raw)
+	}
+	if len(manifest.Tools) == 0 || manifest.Tools[0].Name != "get_weather" {
+		t.Fatalf("tools = %+v", manifest.Tools)
+	}
+	tool := manifest.Tools[0]
+	if tool.Description != "Fetch weather by city" || tool.InputSchema["type"] != "object" {
+		t.Fatalf("get_weather = %+v", tool)
+	}
+	for _, tool := range manifest.Tools {
+		if tool.Name == "compare_cities" {
+			t.Fatalf("skipped client-streaming tool listed in manifest")
+		}
+	}
+}
+
+func TestOpenAIOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "openai=true")
+
+	raw, ok := files["catalog_genkit.tools.openai.json"]
+	if !ok {
+		t.Fatalf("missing OpenAI tools, got %v", mapKeys(files))
+	}
+	var tools []struct {
+		Type     string `json:"type"`
+		Function struct {
+			Name        string         `json:"name"`
+			Description string         `json:"description"`
+			Parameters  map[string]any `json:"parameters"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal([]byte(raw), &tools); err != nil {
+		t.Fatalf("unmarshal tools: %v\n%s", err, raw)
+	}
+	if len(tools) == 0 || tools[0].Type != "function" || tools[0].Function.Name != "get_weather" {
+		t.Fatalf("tools = %+v", tools)
+	}
+	fn := tools[0].Function
+	if fn.Description != "Fetch weather by city" || fn.Parameters["type"] != "object" {
+		t.Fatalf("get_weather = %+v", fn)
+	}
+	units := fn.Parameters["properties"].(map[string]any)["units"].(map[string]any)
+	if units["description"] != "Units metric/imperial" {
+		t.Fatalf("units = %v", units)
+	}
+	if _, ok := units["example"]; ok {
+		t.Fatalf("unsupported example keyword kept: %v", units)
+	}
+}
+
+func TestAnthropicOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "anthropic=true")
+
+	raw, ok := files["catalog_toolcatalog.anthropic.json"]
+	if !ok {
+		t.Fatalf("missing Anthropic tools, got %v", mapKeys(files))
+	}
+	var tools []struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		InputSchema map[string]any `json:"input_schema"`
+	}
+	if err := json.Unmarshal([]byte(raw), &tools); err != nil {
+		t.Fatalf("unmarshal tools: %v\n%s", err, raw)
+	}
+	if len(tools) == 0 || tools[0].Name != "get_weather" {
+		t.Fatalf("tools = %+v", tools)
+	}
+	tool := tools[0]
+	if tool.Description != "Fetch weather by city" || tool.InputSchema["type"] != "object" {
+		t.Fatalf("get_weather = %+v", tool)
+	}
+	units := tool.InputSchema["properties"].(map[string]any)["units"].(map[string]any)
+	if _, ok := units["example"]; ok {
+		t.Fatalf("unsupported example keyword kept: %v", units)
+	}
+}
+
+func TestA2AOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "a2a=true,a2a_url=https://agents.example.com/invoices")
+
+	raw, ok := files["invoice/v1/invoice_invoiceservice.agent.json"]
+	if !ok {
+		t.Fatalf("missing agent card, got %v", mapKeys(files))
+	}
+	var card struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Name            string `json:"name"`
+		URL             string `json:"url"`
+		Skills          []struct {
+			ID          string   `json:"id"`
+			Description string   `json:"description"`
+			Tags        []string `json:"tags"`
+		} `json:"skills"`
+	}
+	if err := json.Unmarshal([]byte(raw), &card); err != nil {
+		t.Fatalf("unmarshal card: %v\n%s", err, raw)
+	}
+	if card.ProtocolVersion == "" || card.Name != "invoice.v1.InvoiceService" || card.URL != "https://agents.example.com/invoices" {
+		t.Fatalf("card = %+v", card)
+	}
+	if len(card.Skills) != 2 || card.Skills[0].ID != "create_invoice" || card.Skills[0].Description != "Create a new invoice." {
+		t.Fatalf("skills = %+v", card.Skills)
+	}
+	if got := card.Skills[1].Tags; len(got) != 1 || got[0] != "invoice" {
+		t.Fatalf("get_invoice tags = %v", got)
+	}
+}
+
+func TestOpenAPIOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "openapi=true")
+
+	raw, ok := files["invoice/v1/invoice_invoiceservice.openapi.json"]
+	if !ok {
+		t.Fatalf("missing OpenAPI document, got %v", mapKeys(files))
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title string `json:"title"`
+		} `json:"info"`
+		Paths map[string]struct {
+			Post struct {
+				OperationID string   `json:"operationId"`
+				Tags        []string `json:"tags"`
+				RequestBody struct {
+					Content map[string]struct {
+						Schema map[string]any `json:"schema"`
+					} `json:"content"`
+				} `json:"requestBody"`
+				Responses map[string]struct {
+					Content map[string]struct {
+						Schema map[string]any `json:"schema"`
+					} `json:"content"`
+				} `json:"responses"`
+			} `json:"post"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("unmarshal document: %v\n%s", err, raw)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "invoice.v1.InvoiceService" {
+		t.Fatalf("document header = %q %q", doc.OpenAPI, doc.Info.Title)
+	}
+	op := doc.Paths["/tools/get_invoice"].Post
+	if op.OperationID != "get_invoice" || len(op.Tags) != 1 || op.Tags[0] != "invoice" {
+		t.Fatalf("get_invoice operation = %+v", op)
+	}
+	if op.RequestBody.Content["application/json"].Schema["type"] != "object" {
+		t.Fatalf("request schema = %v", op.RequestBody.Content)
+	}
+	if op.Responses["200"].Content["application/json"].Schema["type"] != "object" {
+		t.Fatalf("response schema = %v", op.Responses["200"].Content)
+	}
+}
+
+func TestSchemaOutOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "schema_out=schemas")
+
+	for _, name := range []string{"create_invoice", "get_invoice"} {
+		for _, kind := range []string{"input", "output"} {
+			key := "schemas/" + name + "." + kind + ".schema.json"
+			raw, ok := files[key]
+			if !ok {
+				t.Fatalf("missing %s, got %v", key, mapKeys(files))
+			}
+			var schema map[string]any
+			if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+				t.Fatalf("unmarshal %s: %v", key, err)
+			}
+			if schema["$schema"] != "https://json-schema.org/draft/2020-12/schema" || schema["type"] != "object" {
+				t.Fatalf("%s = %v", key, schema)
+			}
+		}
+	}
+	mustContain(t, files["schemas/get_invoice.input.schema.json"], `"invoice_id"`)
+}
+
+func TestSchemaDraftOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/shipping/v1/shipping.proto", "schema_draft=2020-12")
+	// The recursive Category is defined once and referred to.
+	mustContain(t, code, `"$defs": map[string]any{"common.v1.Category": map[string]any{`)
+	mustContain(t, code, `"children": map[string]any{"items": map[string]any{"$ref": "#/$defs/common.v1.Category"}, "type": "array"}`)
+	mustContain(t, code, `"examples": []any{"EUR"}`)
+	mustContain(t, code, `"grams": map[string]any{"type": []string{"integer", "null"}}`)
+	mustNotContain(t, code, `"example":`)
+
+	files := buildFilesWithOptions(t, "test/proto/shipping/v1/shipping.proto", "schema_draft=draft-07,schema_out=schemas,codegen=runtime")
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(files["schemas/shippingservice_quote.input.schema.json"]), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["$schema"] != "http://json-schema.org/draft-07/schema#" || schema["definitions"] == nil {
+		t.Fatalf("draft-07 schema = %v", schema)
+	}
+	mustContain(t, files["shipping/v1/shipping_genkit.tools.go"], "genkittools.MessageSchemaForDraft((*QuoteRequest)(nil).ProtoReflect().Descriptor(), genkittools.Draft07)")
+
+	_, err := runGeneration(t, []string{"test/proto/shipping/v1/shipping.proto"}, []string{"schema_draft=2019-09"})
+	if err == nil {
+		t.Fatal("generation succeeded, want invalid schema_draft error")
+	}
+	if want := `invalid schema_draft="2019-09": want 2020-12 or draft-07`; !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSchemaFormatOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/shipping/v1/shipping.proto", "schema_format=json,flows=true")
+	code := files["shipping/v1/shipping_genkit.tools.go"]
+	mustContain(t, code, "var outputSchemaShippingServiceQuote = genkittools.ParseSchema(`{\n  \"properties\": {\n    \"currency_code\": {\n      \"description\": \"ISO 4217 currency code\",")
+	mustNotContain(t, code, "map[string]any{\"")
+	mustContain(t, files["shipping/v1/shipping_genkit.tools_flows.go"], "genkittools.ParseSchema(`{")
+
+	start := strings.Index(code, "ParseSchema(`") + len("ParseSchema(`")
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(code[start:start+strings.Index(code[start:], "`")]), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["type"] != "object" || schema["properties"] == nil {
+		t.Fatalf("schema = %v", schema)
+	}
+
+	code = generateWithOptions(t, "test/proto/shipping/v1/shipping.proto", "schema_format=struct,schema_draft=2020-12")
+	mustContain(t, code, `var schemaShippingServiceQuote = genkittools.Schema{Type: "object", Properties: map[string]*genkittools.Schema{"category": {Type: "object", Properties: map[string]*genkittools.Schema{"children": {Type: "array", Items: &genkittools.Schema{Ref: "#/$defs/common.v1.Category"}}`)
+	mustContain(t, code, `"grams": {Type: "integer", Nullable: true}`)
+	mustContain(t, code, `"currency_code": {Type: "string", Description: "ISO 4217 currency code", Examples: []any{"EUR"}}`)
+	mustContain(t, code, `}.Map()`)
+	mustNotContain(t, code, "map[string]any{\"")
+
+	for opts, want := range map[string]string{
+		"schema_format=yaml":                   `invalid schema_format="yaml": want literal, json or struct`,
+		"schema_format=json,codegen=runtime":   "schema_format=json requires codegen=inline",
+		"schema_format=struct,codegen=runtime": "schema_format=struct requires codegen=inline",
+	} {
+		_, err := runGeneration(t, []string{"test/proto/shipping/v1/shipping.proto"}, strings.Split(opts, ","))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", opts, err, want)
+		}
+	}
+}
+
+func TestBreakingAgainstOption(t *testing.T) {
+	snapshot := t.TempDir()
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "schema_out=schemas")
+	for name, content := range files {
+		if rel, ok := strings.CutPrefix(name, "schemas/"); ok {
+			if err := os.WriteFile(filepath.Join(snapshot, rel), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The unchanged schemas pass.
+	generateWithOptions(t, "test/proto/invoice/v1/invoice.proto", "breaking_against="+snapshot)
+
+	old := `{"type": "object", "properties": {"invoice_id": {"type": "integer"}, "legacy_id": {"type": "string"}}}`
+	if err := os.WriteFile(filepath.Join(snapshot, "get_invoice.input.schema.json"), []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := runGeneration(t, []string{"test/proto/invoice/v1/invoice.proto"}, []string{"breaking_against=" + snapshot})
+	if err == nil {
+		t.Fatal("generation succeeded, want breaking changes error")
+	}
+	for _, want := range []string{
+		"tool schemas have breaking changes:",
+		"get_invoice input /invoice_id: now required",
+		"get_invoice input /invoice_id: type changed from integer to string",
+		"get_invoice input /legacy_id: removed",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestTranslationsOption(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "de.json")
+	translations := `{
+		"invoice.v1.InvoiceService": "Rechnungen verwalten.",
+		"invoice.v1.InvoiceService.GetInvoice": "Eine Rechnung per ID abrufen.",
+		"invoice.v1.GetInvoiceRequest.invoice_id": "ID der abzurufenden Rechnung.",
+		"other.v1.Unknown.Method": "Wird ignoriert."
+	}`
+	if err := os.WriteFile(name, []byte(translations), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "translations="+name, "markdown=true")
+
+	code := files["invoice/v1/invoice_genkit.tools.go"]
+	mustMatch(t, code, `Description:\s+"Eine Rechnung per ID abrufen\.",`)
+	mustContain(t, code, `"description": "ID der abzurufenden Rechnung."`)
+	mustNotContain(t, code, "Fetch an invoice by ID.")
+	// Untranslated descriptions are kept.
+	mustMatch(t, code, `Description:\s+"Create a new invoice\.",`)
+	doc := files["invoice/v1/invoice_invoiceservice.tools.md"]
+	mustContain(t, doc, "\nRechnungen verwalten.\n")
+	mustContain(t, doc, "| `invoice_id` | string | yes | ID der abzurufenden Rechnung. |  |  |")
+
+	for opts, want := range map[string]string{
+		"translations=" + filepath.Join(t.TempDir(), "missing.json"): "translations: open",
+		"translations=" + name + ",codegen=runtime":                  "translations requires codegen=inline",
+	} {
+		_, err := runGeneration(t, []string{"test/proto/invoice/v1/invoice.proto"}, strings.Split(opts, ","))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", opts, err, want)
+		}
+	}
+}
+
+func TestDescFile(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/guide/v1/guide.proto", "desc_root=test/proto", "markdown=true")
+
+	code := files["guide/v1/guide_genkit.tools.go"]
+	mustMatch(t, code, `Description:\s+"Plan a day-by-day itinerary for a trip\.\\n\\nUse this tool once`)
+	mustContain(t, code, `Quote the stops back in the order returned.",`)
+	doc := files["guide/v1/guide_travelguide.tools.md"]
+	mustContain(t, doc, "\n- Do not book anything: the itinerary only lists places to visit.\n")
+
+	// A translation takes precedence.
+	name := filepath.Join(t.TempDir(), "de.json")
+	if err := os.WriteFile(name, []byte(`{"guide.v1.TravelGuide.PlanTrip": "Eine Reise planen."}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code = generateWithOptions(t, "test/proto/guide/v1/guide.proto", "desc_root=test/proto", "translations="+name)
+	mustMatch(t, code, `Description:\s+"Eine Reise planen\.",`)
+
+	for target, want := range map[string]string{
+		"test/proto/guide/v1/guide.proto":    "guide.v1.TravelGuide.PlanTrip: desc_file: open guide/v1/docs/plan_trip.md",
+		"test/proto/invalid/desc_file.proto": "invalid.Docs.Describe: desc and desc_file are mutually exclusive",
+	} {
+		_, err := runGeneration(t, []string{target}, nil)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", target, err, want)
+		}
+	}
+}
+
+func TestGoNameOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/guide/v1/guide.proto", "desc_root=test/proto")
+
+	mustContain(t, code, `const TravelGuideFindStopsTool ai.ToolName = "suggest_stops"`)
+	mustContain(t, code, "func defineTravelGuideFindStopsTool(g *genkit.Genkit, impl TravelGuideToolImpl, o *genkittools.Options) (ai.Tool, error) {")
+	mustContain(t, code, "impl.SuggestStops(ctx, req)")
+	mustNotContain(t, code, "TravelGuideSuggestStops")
+
+	_, err := runGeneration(t, []string{"test/proto/invalid/go_name.proto"}, nil)
+	if want := `invalid.Names.Rename: go_name "renamed" is not an exported Go identifier`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestToolsStructOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/guide/v1/guide.proto", "desc_root=test/proto", "tools_struct=true")
+
+	mustContain(t, code, "type TravelGuideTools struct {")
+	mustContain(t, code, "func NewTravelGuideTools(impl TravelGuideToolImpl, opts ...genkittools.Option) *TravelGuideTools {")
+	mustContain(t, code, "func (s *TravelGuideTools) Register(g *genkit.Genkit) ([]ai.Tool, error) {\n\treturn RegisterTravelGuideTools(g, s.impl, s.opts...)")
+	mustContain(t, code, "func (s *TravelGuideTools) Refs() []ai.ToolRef {")
+	mustContain(t, code, "func (s *TravelGuideTools) PlanTripTool() ai.ToolName {\n\treturn ai.ToolName(s.o.ToolName(string(TravelGuidePlanTripTool)))")
+	// go_name keeps the service prefix out of the method name too.
+	mustContain(t, code, "func (s *TravelGuideTools) FindStopsTool() ai.ToolName {")
+
+	batch := generateWithOptions(t, "test/proto/report/v1/report.proto", "tools_struct=true")
+	mustContain(t, batch, "func (s *ReportsTools) GetReportBatchTool() ai.ToolName {")
+
+	plain := generateWithOptions(t, "test/proto/guide/v1/guide.proto", "desc_root=test/proto")
+	mustNotContain(t, plain, "TravelGuideTools struct")
+
+	_, err := runGeneration(t, []string{"test/proto/guide/v1/guide.proto"}, []string{"desc_root=test/proto", "tools_struct=true", "lazy=true"})
+	if err == nil {
+		t.Fatal("generation succeeded, want tools_struct and lazy error")
+	}
+	if want := "tools_struct=true requires lazy=false"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestImplContextOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/report/v1/report.proto", "impl_context=tool", "mocks=true")
+	code := files["report/v1/report_genkit.tools.go"]
+
+	mustContain(t, code, "GetReport(*ai.ToolContext, *GetReportRequest) (*Report, error)")
+	mustContain(t, code, "tc := ctx\n\t\t\treturn genkittools.Invoke(ctx, o, toolInfoReportsGetReport, input, coerceReportsGetReportRequest, func(ctx context.Context, req *GetReportRequest) (*Report, error) {\n\t\t\t\ttoolCtx := &ai.ToolContext{Context: ctx, Resumed: tc.Resumed, OriginalInput: tc.OriginalInput}\n\t\t\t\treturn impl.GetReport(toolCtx, req)")
+	// The batch tool keeps the tool context RunBatch shadows.
+	mustContain(t, code, "tc := ctx\n\t\t\treturn genkittools.RunBatch(")
+	mustMatch(t, files["report/v1/report_genkit.tools_mock.go"], `GetReportFunc\s+func\(\*ai\.ToolContext, \*GetReportRequest\) \(\*Report, error\)`)
+
+	plain := generateWithOptions(t, "test/proto/report/v1/report.proto")
+	mustContain(t, plain, "GetReport(context.Context, *GetReportRequest) (*Report, error)")
+	mustContain(t, plain, "return impl.GetReport(ctx, req)")
+	mustNotContain(t, plain, "toolCtx")
+
+	_, err := runGeneration(t, []string{"test/proto/report/v1/report.proto"}, []string{"impl_context=genkit"})
+	if err == nil {
+		t.Fatal("generation succeeded, want invalid impl_context error")
+	}
+	if want := `invalid impl_context="genkit": want context or tool`; !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestImplFuncsOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/catalog.proto", "impl_funcs=true", "client_streaming=array")
+
+	mustMatch(t, code, `type ToolCatalogImplFuncs struct \{\n\tGetWeatherFunc\s+func\(context.Context, \*GetWeatherRequest\) \(\*GetWeatherResponse, error\)`)
+	mustContain(t, code, "var _ ToolCatalogToolImpl = ToolCatalogImplFuncs{}")
+	mustContain(t, code, "func (f ToolCatalogImplFuncs) GetWeather(ctx context.Context, req *GetWeatherRequest) (*GetWeatherResponse, error) {")
+	mustContain(t, code, `return nil, errors.New("ToolCatalogImplFuncs.GetWeather: GetWeatherFunc is not set")`)
+	mustContain(t, code, "return f.GetWeatherFunc(ctx, req)")
+	mustContain(t, code, "return f.StreamForecastFunc(ctx, req, send)")
+	mustContain(t, code, "return f.CompareCitiesFunc(ctx, reqs)")
+
+	plain := generateWithOptions(t, "test/proto/catalog.proto")
+	mustNotContain(t, plain, "ToolCatalogImplFuncs")
+}
+
+func TestHandlersOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/invoice/v1/invoice.proto", "handlers=true")
+
+	mustContain(t, code, "type InvoiceServiceGetInvoiceHandler interface {\n\tGetInvoice(context.Context, *GetInvoiceRequest) (*Invoice, error)\n}")
+	mustContain(t, code, "func RegisterInvoiceServiceGetInvoiceTool(g *genkit.Genkit, h InvoiceServiceGetInvoiceHandler, opts ...genkittools.Option) (ai.Tool, error) {\n\treturn defineInvoiceServiceGetInvoiceTool(g, h, genkittools.NewOptions(opts...))")
+	mustContain(t, code, "func defineInvoiceServiceGetInvoiceTool(g *genkit.Genkit, impl InvoiceServiceGetInvoiceHandler, o *genkittools.Options) (ai.Tool, error) {")
+	mustMatch(t, code, `type InvoiceServiceHandlers struct \{\n\tCreateInvoiceHandler\s+InvoiceServiceCreateInvoiceHandler\n\tGetInvoiceHandler\s+InvoiceServiceGetInvoiceHandler\n\}`)
+	mustContain(t, code, "var _ InvoiceServiceToolImpl = InvoiceServiceHandlers{}")
+	mustContain(t, code, `return nil, errors.New("InvoiceServiceHandlers.GetInvoice: GetInvoiceHandler is not set")`)
+	mustContain(t, code, "return h.GetInvoiceHandler.GetInvoice(ctx, req)")
+
+	lazy := buildWithOptions(t, "test/proto/invoice/v1/invoice.proto", "handlers=true", "lazy=true")
+	mustContain(t, lazy, "func NewInvoiceServiceGetInvoiceTool(impl InvoiceServiceGetInvoiceHandler, opts ...genkittools.Option) ai.Tool {")
+	mustNotContain(t, lazy, "func RegisterInvoiceServiceGetInvoiceTool(")
+
+	batch := generateWithOptions(t, "test/proto/report/v1/report.proto", "handlers=true")
+	mustContain(t, batch, "func defineReportsGetReportBatchTool(g *genkit.Genkit, impl ReportsGetReportHandler, o *genkittools.Options) (ai.Tool, error) {")
+
+	plain := generateWithOptions(t, "test/proto/invoice/v1/invoice.proto")
+	mustNotContain(t, plain, "InvoiceServiceGetInvoiceHandler")
+}
+
+func TestToolNameCaseOption(t *testing.T) {
+	snake := generateWithOptions(t, "test/proto/gateway/v1/gateway.proto", "tool_name_case=snake")
+	mustContain(t, snake, `const ProfileServiceGetProfileTool ai.ToolName = "profile_service_get_profile"`)
+
+	camel := generateWithOptions(t, "test/proto/gateway/v1/gateway.proto", "tool_name_case=camel")
+	mustContain(t, camel, `const ProfileServiceGetProfileTool ai.ToolName = "profileServiceGetProfile"`)
+
+	kebab := generateWithOptions(t, "test/proto/gateway/v1/gateway.proto", "tool_name_case=kebab")
+	mustContain(t, kebab, `const ProfileServiceUpdateProfileTool ai.ToolName = "profile-service-update-profile"`)
+
+	// Explicit tool_doc names are kept as written.
+	support := generateWithOptions(t, "test/proto/support/v1/support.proto", "tool_name_case=camel")
+	mustContain(t, support, `const Support_TicketStatusTool ai.ToolName = "supportTicketStatus"`)
+	mustContain(t, support, `const SupportTicket_StatusTool ai.ToolName = "support_ticketstatus"`)
+
+	_, err := runGeneration(t, []string{"test/proto/gateway/v1/gateway.proto"}, []string{"tool_name_case=pascal"})
+	if err == nil {
+		t.Fatal("generation succeeded, want invalid tool_name_case error")
+	}
+	if want := `invalid tool_name_case="pascal": want snake, camel or kebab`; !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMarkdownOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "markdown=true")
+
+	doc, ok := files["invoice/v1/invoice_invoiceservice.tools.md"]
+	if !ok {
+		t.Fatalf("missing Markdown page, got %v", mapKeys(files))
+	}
+	mustContain(t, doc, "# invoice.v1.InvoiceService tools")
+	mustContain(t, doc, "## get_invoice")
+	mustContain(t, doc, "- Tags: `invoice`")
+	mustContain(t, doc, "| `invoice_id` | string | yes | ID of the invoice to fetch. |  |  |")
+	mustContain(t, doc, "| `invoice.line_items[].quantity` | integer | no |  |  |  |")
+	mustContain(t, doc, "- Sensitive fields (`/invoice/customer_id`) are redacted from logs.")
+}
+
+func TestCLIOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "cli=true")
+
+	cli, ok := files["catalog_genkit.tools_cli.go"]
+	if !ok {
+		t.Fatalf("missing CLI file, got %v", mapKeys(files))
+	}
+	mustContain(t, cli, "func RunToolCatalogCLI(ctx context.Context, impl ToolCatalogToolImpl, args []string, opts ...genkittools.Option) error {")
+	mustContain(t, cli, "//\ttoolcatalog-tools call <tool> -input '{...}'")
+	mustMatch(t, cli, `Info:\s+toolInfoToolCatalogGetWeather,`)
+	mustContain(t, cli, "return genkittools.Invoke(ctx, o, toolInfoToolCatalogGetWeather, input, coerceToolCatalogGetWeatherRequest,")
+	mustContain(t, cli, `return genkittools.RunCLI(ctx, "toolcatalog-tools", tools, args, os.Stdin, os.Stdout)`)
+}
+
+func TestLangChainGoOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "langchaingo=true")
+
+	adapter, ok := files["catalog_genkit.tools_langchaingo.go"]
+	if !ok {
+		t.Fatalf("missing langchaingo file, got %v", mapKeys(files))
+	}
+	mustContain(t, adapter, `tools "github.com/tmc/langchaingo/tools"`)
+	mustContain(t, adapter, "func NewToolCatalogLangChainTools(impl ToolCatalogToolImpl, opts ...genkittools.Option) []tools.Tool {")
+	mustContain(t, adapter, "list = append(list, &genkittools.LangChainTool{")
+	mustMatch(t, adapter, `Info:\s+toolInfoToolCatalogGetWeather,`)
+	mustContain(t, adapter, "return genkittools.Invoke(ctx, o, toolInfoToolCatalogGetWeather, input, coerceToolCatalogGetWeatherRequest,")
+}
+
+func TestDIOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/report/v1/report.proto", "di=wire+fx")
+
+	di, ok := files["report/v1/report_genkit.tools_di.go"]
+	if !ok {
+		t.Fatalf("missing di file, got %v", mapKeys(files))
+	}
+	mustContain(t, di, "type ReportsTools []ai.Tool")
+	mustContain(t, di, "func ProvideReportsTools(g *genkit.Genkit, impl ReportsToolImpl) (ReportsTools, error) {")
+	mustContain(t, di, "var ReportsToolProviderSet = wire.NewSet(ProvideReportsTools)")
+	mustContain(t, di, `var ReportsToolsModule = fx.Module("report.v1.Reports",`)
+	mustContain(t, di, "fx.Invoke(func(ReportsTools) {}),")
+	mustContain(t, di, "return fx.Provide(fx.Annotate(constructor, fx.As(new(ReportsToolImpl))))")
+
+	files = buildFilesWithOptions(t, "test/proto/report/v1/report.proto", "di=fx,lazy=true")
+	di = files["report/v1/report_genkit.tools_di.go"]
+	mustContain(t, di, "func ProvideReportsTools(impl ReportsToolImpl) ReportsTools {")
+	mustContain(t, di, "func(tools ReportsTools) []ai.ToolRef { return tools }")
+	mustNotContain(t, di, "wire.NewSet")
+
+	_, err := runGeneration(t, []string{"test/proto/report/v1/report.proto"}, []string{"di=dig"})
+	if err == nil {
+		t.Fatal("generation succeeded, want invalid di error")
+	}
+	if want := `invalid di="dig": want wire, fx or wire+fx`; !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestEvalDatasetOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "eval_dataset=true,client_streaming=array")
+
+	cases := map[string]string{
+		// Field examples are parsed for their field's kind.
+		"catalog_get_weather.eval.jsonl":     `{"testCaseId":"get_weather-1","input":{"units":"metric"},"reference":null}`,
+		"catalog_stream_forecast.eval.jsonl": `{"testCaseId":"stream_forecast-1","input":{"days":3},"reference":null}`,
+		"catalog_compare_cities.eval.jsonl":  `{"testCaseId":"compare_cities-1","input":{"requests":[{"units":"metric"}]},"reference":null}`,
+	}
+	for name, want := range cases {
+		got, ok := files[name]
+		if !ok {
+			t.Fatalf("missing %s, got %v", name, mapKeys(files))
+		}
+		if strings.TrimSpace(got) != want {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+
+	// Explicit defaults fill in fields without an example.
+	files = generateFilesWithOptions(t, "test/proto/order/v1/order.proto", "eval_dataset=true")
+	mustContain(t, files["order/v1/order_orderservice_placeorder.eval.jsonl"], `"input":{"channel":"web"}`)
+}
+
+func TestGoldenTestsOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "golden_tests=true")
+
+	test, ok := files["catalog_genkit.tools_schema_test.go"]
+	if !ok {
+		t.Fatalf("missing golden test file, got %v", mapKeys(files))
+	}
+	mustContain(t, test, "func TestToolCatalogToolSchemas(t *testing.T) {")
+	mustContain(t, test, "toolInfoToolCatalogGetWeather,")
+	mustContain(t, test, `genkittools.CompareGolden(filepath.Join("testdata", info.Name+".input.schema.json"), info.InputSchema)`)
+	mustContain(t, test, `genkittools.CompareGolden(filepath.Join("testdata", info.Name+".output.schema.json"), info.OutputSchema)`)
+}
+
+func TestCoercionTestsOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "coercion_tests=true")
+
+	test, ok := files["invoice/v1/invoice_genkit.tools_coercion_test.go"]
+	if !ok {
+		t.Fatalf("missing coercion test file, got %v", mapKeys(files))
+	}
+	mustContain(t, test, "func TestInvoiceServiceToolCoercion(t *testing.T) {")
+	mustContain(t, test, "t.Run(string(InvoiceServiceCreateInvoiceTool), func(t *testing.T) {")
+	mustContain(t, test, "want := &CreateInvoiceRequest{}")
+	mustContain(t, test, "genkittools.CheckRoundTrip(want, coerceInvoiceServiceCreateInvoiceRequest)")
+	// Every field the model sends is populated; context-bound ones are not.
+	mustContain(t, test, `\"tags\":{`)
+	mustNotContain(t, test, `\"userId\"`)
+}
+
+func TestFuzzTestsOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "fuzz_tests=true")
+
+	test, ok := files["invoice/v1/invoice_genkit.tools_fuzz_test.go"]
+	if !ok {
+		t.Fatalf("missing fuzz test file, got %v", mapKeys(files))
+	}
+	mustContain(t, test, "func FuzzCoerceInvoiceServiceCreateInvoiceInput(f *testing.F) {")
+	mustContain(t, test, "func FuzzCoerceInvoiceServiceGetInvoiceInput(f *testing.F) {")
+	mustContain(t, test, `f.Add([]byte("null"))`)
+	mustContain(t, test, "genkittools.CheckCoerce(input, coerceInvoiceServiceCreateInvoiceRequest)")
+}
+
+func TestExamplesOption(t *testing.T) {
+	files := generateFilesWithOptions(t, "test/proto/catalog.proto", "examples=true")
+
+	example, ok := files["catalog_genkit.tools_example_test.go"]
+	if !ok {
+		t.Fatalf("missing example file, got %v", mapKeys(files))
+	}
+	mustContain(t, example, "package catalog_test")
+	mustContain(t, example, "func (exampleToolCatalog) GetWeather(ctx context.Context, req *catalog.GetWeatherRequest) (*catalog.GetWeatherResponse, error) {")
+	mustMatch(t, example, `func ExampleRegisterToolCatalogTools\(\) \{(?s:.*)// Output:\s+// get_weather\s+// stream_forecast\s+\}`)
+	mustContain(t, example, "refs, err := catalog.RegisterToolCatalogToolRefs(g, exampleToolCatalog{})")
+	mustContain(t, example, "ai.WithTools(refs...),")
+
+	// Built from invoice.proto, whose go_package, unlike that of
+	// catalog.proto, matches its directory, so that the example compiles.
+	lazy := buildFilesWithOptions(t, "test/proto/invoice/v1/invoice.proto", "examples=true", "lazy=true")
+	mustContain(t, lazy["invoice/v1/invoice_genkit.tools_example_test.go"], "func ExampleNewInvoiceServiceTools_generate() {")
+
+	// Batch tools are registered after their tool.
+	batch := generateFilesWithOptions(t, "test/proto/report/v1/report.proto", "examples=true")
+	mustMatch(t, batch["report/v1/report_genkit.tools_example_test.go"], `// Output:\s+// build_report\s+// get_report\s+// get_report_batch\s+\}`)
+}
+
+func TestOpenAPIv2DescriptionFallbacks(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/gateway/v1/gateway.proto")
+
+	// tool_doc and field_doc descriptions win over the openapiv2 options,
+	// whose summary and field description win over description and title.
+	mustMatch(t, code, `Description:\s+"Fetch a user profile.",`)
+	mustMatch(t, code, `Description:\s+"Replace the profile of a user.",`)
+	mustContain(t, code, `"user_id": map[string]any{"description": "ID of the user.", "type": "string"}`)
+	mustContain(t, code, `"user_id": map[string]any{"description": "User ID", "type": "string"}`)
+	mustContain(t, code, `"display_name": map[string]any{"description": "Name shown to other users.", "type": "string"}`)
+	mustNotContain(t, code, "Display name.")
+}
+
+func TestContextFieldsAreHiddenFromModel(t *testing.T) {
+	code := generateForProto(t, "test/proto/invoice/v1/invoice.proto")
+
+	mustMatch(t, code, `ContextFields:\s+map\[string\]string\{\s+"user_id":\s+"user_id",\s+\},`)
+	mustNotContain(t, code, `"user_id": map[string]any{`)
+}
+
+func TestCallConfigOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/invoice/v1/invoice.proto", "call_config=true")
+
+	mustMatch(t, code, `type InvoiceServiceCallConfig struct \{\n\t// Locale is attached under the context_key "locale".\n\tLocale string\n\t// UserId is attached under the context_key "user_id".\n\tUserId string\n\}`)
+	mustContain(t, code, "func WithInvoiceServiceCallConfig(ctx context.Context, cfg InvoiceServiceCallConfig) context.Context {")
+	mustContain(t, code, "if cfg.UserId != \"\" {\n\t\tmd[\"user_id\"] = cfg.UserId\n\t}")
+	mustContain(t, code, "return genkittools.ContextWithMetadata(ctx, md)")
+	mustContain(t, code, `cfg.Locale, _ = genkittools.MetadataFromContext(ctx, "locale")`)
+
+	// Services without context keys have nothing to configure.
+	catalog := generateWithOptions(t, "test/proto/catalog.proto", "call_config=true")
+	mustNotContain(t, catalog, "CallConfig")
+
+	plain := generateWithOptions(t, "test/proto/invoice/v1/invoice.proto")
+	mustNotContain(t, plain, "InvoiceServiceCallConfig")
+}
+
+func TestHealthOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/catalog.proto", "health=true")
+	mustContain(t, code, `const ToolCatalogHealthTool ai.ToolName = "toolcatalog_health"`)
+	mustContain(t, code, "type ToolCatalogPinger interface {\n\tPing(ctx context.Context) error\n}")
+	mustContain(t, code, "var schemaToolCatalogHealth = genkittools.HealthInputSchema()")
+	mustContain(t, code, "if pinger, ok := impl.(ToolCatalogPinger); ok && o.Includes(string(ToolCatalogHealthTool)) {")
+	mustContain(t, code, "return genkittools.CheckHealth(ctx, o, toolInfoToolCatalogHealth, input, pinger.Ping)")
+	mustMatch(t, code, `toolInfoToolCatalogHealth,\n\t\)`)
+
+	lazy := buildWithOptions(t, "test/proto/catalog.proto", "health=true", "lazy=true", "tool_name_case=snake")
+	mustContain(t, lazy, `const ToolCatalogHealthTool ai.ToolName = "tool_catalog_health"`)
+	mustContain(t, lazy, "refs = append(refs, NewToolCatalogHealthTool(pinger, opts...))")
+
+	plain := generateWithOptions(t, "test/proto/catalog.proto")
+	mustNotContain(t, plain, "Pinger")
+}
+
+func TestListToolsOption(t *testing.T) {
+	code := generateWithOptions(t, "test/proto/catalog.proto", "list_tools=true")
+	mustContain(t, code, `const ToolCatalogListToolsTool ai.ToolName = "toolcatalog_list_tools"`)
+	mustContain(t, code, "var schemaToolCatalogListTools = genkittools.ListToolsInputSchema()")
+	mustContain(t, code, "if t, err := defineToolCatalogListToolsTool(g, o); err != nil {")
+	mustContain(t, code, "return genkittools.ListTools(ctx, o, toolInfoToolCatalogListTools, []*genkittools.ToolInfo{\n\t\t\t\ttoolInfoToolCatalogGetWeather,")
+	mustMatch(t, code, `ToolCatalogListToolsTool,\n\t\}\n\}`)
+
+	lazy := buildWithOptions(t, "test/proto/catalog.proto", "list_tools=true", "lazy=true", "tool_name_case=camel")
+	mustContain(t, lazy, `const ToolCatalogListToolsTool ai.ToolName = "toolCatalogListTools"`)
+	mustContain(t, lazy, "refs = append(refs, NewToolCatalogListToolsTool(opts...))")
+
+	plain := generateWithOptions(t, "test/proto/catalog.proto")
+	mustNotContain(t, plain, "ListToolsTool")
 }
 
 func generateForProto(t *testing.T, targetProto string) string {
@@ -55,7 +1562,7 @@ func generateForProto(t *testing.T, targetProto string) string {
 		generatedCode, generateErr = runGeneration(t, []string{
 			"test/proto/catalog.proto",
 			"test/proto/invoice/v1/invoice.proto",
-		})
+		}, nil)
 	})
 
 	if generateErr != nil {
@@ -68,44 +1575,180 @@ func generateForProto(t *testing.T, targetProto string) string {
 	return code
 }
 
-func runGeneration(t *testing.T, targets []string) (map[string]string, error) {
+// generateWithOptions runs a fresh generation of targetProto with extra
+// plugin options appended to the genkit plugin's opt list.
+func generateWithOptions(t *testing.T, targetProto string, pluginOpts ...string) string {
 	t.Helper()
 
-	tempDir, err := os.MkdirTemp("", "bufgen")
+	out, err := runGeneration(t, []string{targetProto}, pluginOpts)
+	if err != nil {
+		t.Fatalf("generate %s with %v: %v", targetProto, pluginOpts, err)
+	}
+	return out[targetProto]
+}
+
+func runGeneration(t *testing.T, targets []string, pluginOpts []string) (map[string]string, error) {
+	t.Helper()
+
+	outDir, err := runBufGenerate(t, targets, pluginOpts)
 	if err != nil {
 		return nil, err
 	}
+
+	out := make(map[string]string)
+	for _, p := range targets {
+		relProto := strings.TrimPrefix(p, "test/proto/")
+		outFile := filepath.Join(outDir, strings.TrimSuffix(relProto, ".proto")+"_genkit.tools.go")
+		content, err := os.ReadFile(outFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(pluginOpts) == 0 {
+			copyBack := filepath.Join("test", "out", strings.TrimSuffix(relProto, ".proto")+"_genkit.tools.go.txt")
+			if err := copyFile(t, outFile, copyBack); err != nil {
+				return nil, err
+			}
+		}
+		out[p] = string(content)
+	}
+
+	return out, nil
+}
+
+// generateFilesWithOptions runs a fresh generation of targetProto and returns
+// every file written to the output directory, keyed by its relative path.
+func generateFilesWithOptions(t *testing.T, targetProto string, pluginOpts ...string) map[string]string {
+	t.Helper()
+
+	outDir, err := runBufGenerate(t, []string{targetProto}, pluginOpts)
+	if err != nil {
+		t.Fatalf("generate %s with %v: %v", targetProto, pluginOpts, err)
+	}
+	return readGeneratedFiles(t, outDir)
+}
+
+// buildWithOptions is generateWithOptions for options changing the layout
+// or package of the generated code: it also generates the test protos
+// targetProto imports and compiles the output.
+func buildWithOptions(t *testing.T, targetProto string, pluginOpts ...string) string {
+	t.Helper()
+
+	files := buildFilesWithOptions(t, targetProto, pluginOpts...)
+	return files[strings.TrimSuffix(strings.TrimPrefix(targetProto, "test/proto/"), ".proto")+"_genkit.tools.go"]
+}
+
+// buildFilesWithOptions is generateFilesWithOptions compiling the output,
+// as buildWithOptions does. The files of the imported protos are included.
+func buildFilesWithOptions(t *testing.T, targetProto string, pluginOpts ...string) map[string]string {
+	t.Helper()
+
+	outDir, err := runBufGenerate(t, withProtoImports(t, targetProto), pluginOpts)
+	if err != nil {
+		t.Fatalf("generate %s with %v: %v", targetProto, pluginOpts, err)
+	}
+	buildGenerated(t, outDir)
+	return readGeneratedFiles(t, outDir)
+}
+
+// withProtoImports returns targetProto and the test protos it imports,
+// directly or not, whose Go packages the generated code needs to compile.
+// Imports with a go_package outside the test module, such as the
+// googleapis and grpc-gateway copies, are left to their published modules.
+func withProtoImports(t *testing.T, targetProto string) []string {
+	t.Helper()
+
+	targets := []string{targetProto}
+	seen := map[string]bool{targetProto: true}
+	for i := 0; i < len(targets); i++ {
+		content, err := os.ReadFile(targets[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range protoImportPattern.FindAllStringSubmatch(string(content), -1) {
+			dep := "test/proto/" + m[1]
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			depContent, err := os.ReadFile(dep)
+			if err != nil || !strings.Contains(string(depContent), `go_package = "example.com/test/`) {
+				continue
+			}
+			targets = append(targets, dep)
+		}
+	}
+	return targets
+}
+
+var protoImportPattern = regexp.MustCompile(`(?m)^import "([^"]+)";`)
+
+// readGeneratedFiles returns every file under outDir, keyed by its path
+// relative to it.
+func readGeneratedFiles(t *testing.T, outDir string) map[string]string {
+	t.Helper()
+
+	files := make(map[string]string)
+	err := filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(outDir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read generated files: %v", err)
+	}
+	return files
+}
+
+// runBufGenerate prepares a self-contained Buf workspace, runs buf generate
+// for targets and returns the output directory.
+func runBufGenerate(t *testing.T, targets []string, pluginOpts []string) (string, error) {
+	t.Helper()
+
+	tempDir, err := os.MkdirTemp("", "bufgen")
+	if err != nil {
+		return "", err
+	}
 	t.Cleanup(func() { _ = os.RemoveAll(tempDir) })
 
 	binDir := filepath.Join(tempDir, "bin")
 	workspace := filepath.Join(tempDir, "workspace")
 	outDir := filepath.Join(workspace, "out")
 	if err := os.MkdirAll(binDir, 0o755); err != nil {
-		return nil, err
+		return "", err
 	}
 	if err := os.MkdirAll(workspace, 0o755); err != nil {
-		return nil, err
+		return "", err
 	}
 
 	if err := buildBinary(t, binDir, "protoc-gen-go-genkit-tools", "."); err != nil {
-		return nil, err
+		return "", err
 	}
 	if err := buildBinary(t, binDir, "protoc-gen-go", "google.golang.org/protobuf/cmd/protoc-gen-go"); err != nil {
-		return nil, err
+		return "", err
 	}
 
 	// Prepare a self-contained Buf workspace in temp.
 	if err := copyFile(t, "proto/genkit/tool/v1/tool_metadata.proto", filepath.Join(workspace, "test/proto/genkit/tool/v1/tool_metadata.proto")); err != nil {
-		return nil, err
+		return "", err
 	}
 	if err := copyDir(t, "test/proto", filepath.Join(workspace, "test/proto")); err != nil {
-		return nil, err
+		return "", err
 	}
 	if err := copyFile(t, "test/buf.yaml", filepath.Join(workspace, "buf.yaml")); err != nil {
-		return nil, err
+		return "", err
 	}
-	if err := copyFile(t, "test/buf.gen.yaml", filepath.Join(workspace, "buf.gen.yaml")); err != nil {
-		return nil, err
+	if err := writeBufGenConfig("test/buf.gen.yaml", filepath.Join(workspace, "buf.gen.yaml"), pluginOpts); err != nil {
+		return "", err
 	}
 
 	args := []string{"generate"}
@@ -116,25 +1759,66 @@ func runGeneration(t *testing.T, targets []string) (map[string]string, error) {
 	bufGen.Dir = workspace
 	bufGen.Env = prependPath(os.Environ(), binDir)
 	if err := runCmd(bufGen); err != nil {
-		return nil, err
+		return "", err
 	}
+	return outDir, nil
+}
 
-	out := make(map[string]string)
-	for _, p := range targets {
-		relProto := strings.TrimPrefix(p, "test/proto/")
-		outFile := filepath.Join(outDir, strings.TrimSuffix(relProto, ".proto")+"_genkit.tools.go")
-		content, err := os.ReadFile(outFile)
-		if err != nil {
-			return nil, err
+// writeBufGenConfig copies the Buf generation config, appending pluginOpts
+// to the opt list of the protoc-gen-go-genkit-tools plugin. A paths option
+// replaces the default paths=source_relative.
+func writeBufGenConfig(src, dst string, pluginOpts []string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	config := string(data)
+	if len(pluginOpts) > 0 {
+		const marker = "  - local: protoc-gen-go-genkit-tools\n    out: out\n    opt:\n"
+		idx := strings.Index(config, marker)
+		if idx < 0 {
+			return fmt.Errorf("%s: protoc-gen-go-genkit-tools plugin block not found", src)
 		}
-		copyBack := filepath.Join("test", "out", strings.TrimSuffix(relProto, ".proto")+"_genkit.tools.go.txt")
-		if err := copyFile(t, outFile, copyBack); err != nil {
-			return nil, err
+		var extra strings.Builder
+		rest := config[idx+len(marker):]
+		for _, opt := range pluginOpts {
+			extra.WriteString("      - " + opt + "\n")
+			if strings.HasPrefix(opt, "paths=") {
+				rest = strings.Replace(rest, "      - paths=source_relative\n", "", 1)
+			}
 		}
-		out[p] = string(content)
+		config = config[:idx+len(marker)] + extra.String() + rest
 	}
+	return os.WriteFile(dst, []byte(config), 0o644)
+}
 
-	return out, nil
+// buildGenerated builds the Go files generated into outDir as the module
+// example.com/test, resolving the genkittools runtime from this checkout.
+func buildGenerated(t *testing.T, outDir string) {
+	t.Helper()
+
+	root, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The options of the gateway test proto live in a package of the
+	// grpc-gateway module rather than a module of their own; requiring it
+	// spares go mod tidy looking the package path up as a module.
+	goMod := "module example.com/test\n\ngo 1.24\n\n" +
+		"require github.com/nemo1105/protoc-gen-go-genkit-tools v0.0.0\n" +
+		"require github.com/grpc-ecosystem/grpc-gateway/v2 v2.27.8\n\n" +
+		"replace github.com/nemo1105/protoc-gen-go-genkit-tools => " + root + "\n"
+	if err := os.WriteFile(filepath.Join(outDir, "go.mod"), []byte(goMod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"mod", "tidy"}, {"vet", "./..."}} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = outDir
+		cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local", "GOFLAGS=-mod=mod")
+		if err := runCmd(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
 }
 
 func buildBinary(t *testing.T, binDir, name, target string) error {
@@ -169,6 +1853,22 @@ func mustContain(t *testing.T, haystack, needle string) {
 	}
 }
 
+func mapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func mustMatch(t *testing.T, haystack, pattern string) {
+	t.Helper()
+	if !regexp.MustCompile(pattern).MatchString(haystack) {
+		t.Fatalf("expected generated code to match %q", pattern)
+	}
+}
+
 func mustNotContain(t *testing.T, haystack, needle string) {
 	t.Helper()
 	if strings.Contains(haystack, needle) {
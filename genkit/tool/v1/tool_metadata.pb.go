@@ -22,16 +22,134 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// What a call of a tool does to the backend.
+type Safety int32
+
+const (
+	Safety_SAFETY_UNSPECIFIED Safety = 0 // Not declared
+	Safety_SAFETY_READ_ONLY   Safety = 1 // Only reads
+	Safety_SAFETY_MUTATING    Safety = 2 // Changes state, in ways that can be undone
+	Safety_SAFETY_DESTRUCTIVE Safety = 3 // Deletes or irreversibly changes state; calls wait for confirmation through an interrupt
+)
+
+// Enum value maps for Safety.
+var (
+	Safety_name = map[int32]string{
+		0: "SAFETY_UNSPECIFIED",
+		1: "SAFETY_READ_ONLY",
+		2: "SAFETY_MUTATING",
+		3: "SAFETY_DESTRUCTIVE",
+	}
+	Safety_value = map[string]int32{
+		"SAFETY_UNSPECIFIED": 0,
+		"SAFETY_READ_ONLY":   1,
+		"SAFETY_MUTATING":    2,
+		"SAFETY_DESTRUCTIVE": 3,
+	}
+)
+
+func (x Safety) Enum() *Safety {
+	p := new(Safety)
+	*p = x
+	return p
+}
+
+func (x Safety) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Safety) Descriptor() protoreflect.EnumDescriptor {
+	return file_genkit_tool_v1_tool_metadata_proto_enumTypes[0].Descriptor()
+}
+
+func (Safety) Type() protoreflect.EnumType {
+	return &file_genkit_tool_v1_tool_metadata_proto_enumTypes[0]
+}
+
+func (x Safety) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Safety.Descriptor instead.
+func (Safety) EnumDescriptor() ([]byte, []int) {
+	return file_genkit_tool_v1_tool_metadata_proto_rawDescGZIP(), []int{0}
+}
+
+// How a tool returns the RPC response to the model.
+type ResultFormat int32
+
+const (
+	ResultFormat_RESULT_FORMAT_UNSPECIFIED ResultFormat = 0 // Same as RESULT_FORMAT_STRUCTURED
+	ResultFormat_RESULT_FORMAT_STRUCTURED  ResultFormat = 1 // The response message as structured output
+	ResultFormat_RESULT_FORMAT_JSON        ResultFormat = 2 // The response encoded with protojson, as a string
+	ResultFormat_RESULT_FORMAT_TEMPLATE    ResultFormat = 3 // The response rendered with result_template, as a string
+)
+
+// Enum value maps for ResultFormat.
+var (
+	ResultFormat_name = map[int32]string{
+		0: "RESULT_FORMAT_UNSPECIFIED",
+		1: "RESULT_FORMAT_STRUCTURED",
+		2: "RESULT_FORMAT_JSON",
+		3: "RESULT_FORMAT_TEMPLATE",
+	}
+	ResultFormat_value = map[string]int32{
+		"RESULT_FORMAT_UNSPECIFIED": 0,
+		"RESULT_FORMAT_STRUCTURED":  1,
+		"RESULT_FORMAT_JSON":        2,
+		"RESULT_FORMAT_TEMPLATE":    3,
+	}
+)
+
+func (x ResultFormat) Enum() *ResultFormat {
+	p := new(ResultFormat)
+	*p = x
+	return p
+}
+
+func (x ResultFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResultFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_genkit_tool_v1_tool_metadata_proto_enumTypes[1].Descriptor()
+}
+
+func (ResultFormat) Type() protoreflect.EnumType {
+	return &file_genkit_tool_v1_tool_metadata_proto_enumTypes[1]
+}
+
+func (x ResultFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResultFormat.Descriptor instead.
+func (ResultFormat) EnumDescriptor() ([]byte, []int) {
+	return file_genkit_tool_v1_tool_metadata_proto_rawDescGZIP(), []int{1}
+}
+
 // Custom option: metadata for tools to aid code/document generation.
 type ToolDoc struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`     // Tool name (overrides RPC name)
-	Desc          string                 `protobuf:"bytes,2,opt,name=desc,proto3" json:"desc,omitempty"`     // Tool description
-	Tags          []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`     // Tags, e.g. "demo" or "safety"
-	Input         string                 `protobuf:"bytes,4,opt,name=input,proto3" json:"input,omitempty"`   // Input description
-	Output        string                 `protobuf:"bytes,5,opt,name=output,proto3" json:"output,omitempty"` // Output description
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                                                                    // Tool name (overrides RPC name)
+	Desc             string                 `protobuf:"bytes,2,opt,name=desc,proto3" json:"desc,omitempty"`                                                                                    // Tool description
+	Tags             []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`                                                                                    // Tags, e.g. "demo" or "safety"
+	Input            string                 `protobuf:"bytes,4,opt,name=input,proto3" json:"input,omitempty"`                                                                                  // Input description
+	Output           string                 `protobuf:"bytes,5,opt,name=output,proto3" json:"output,omitempty"`                                                                                // Output description
+	ResultFormat     ResultFormat           `protobuf:"varint,6,opt,name=result_format,json=resultFormat,proto3,enum=genkit.tool.v1.ResultFormat" json:"result_format,omitempty"`              // How the response is returned to the model
+	ResultTemplate   string                 `protobuf:"bytes,7,opt,name=result_template,json=resultTemplate,proto3" json:"result_template,omitempty"`                                          // Go text/template over the response, for RESULT_FORMAT_TEMPLATE
+	Interruptible    bool                   `protobuf:"varint,8,opt,name=interruptible,proto3" json:"interruptible,omitempty"`                                                                 // Implementation may pause the call with genkittools.Interrupt
+	Resource         bool                   `protobuf:"varint,9,opt,name=resource,proto3" json:"resource,omitempty"`                                                                           // Expose the read-only method as a Genkit resource instead of a tool
+	Progress         bool                   `protobuf:"varint,10,opt,name=progress,proto3" json:"progress,omitempty"`                                                                          // Implementation receives a genkittools.ProgressFunc to report intermediate status
+	Batch            bool                   `protobuf:"varint,11,opt,name=batch,proto3" json:"batch,omitempty"`                                                                                // Also generate a <name>_batch tool calling the method for each of a list of requests
+	Metadata         map[string]string      `protobuf:"bytes,12,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Custom attributes copied into ToolInfo.Metadata, e.g. for routing or billing; each value is JSON
+	Safety           Safety                 `protobuf:"varint,13,opt,name=safety,proto3,enum=genkit.tool.v1.Safety" json:"safety,omitempty"`                                                   // What a call does to the backend; SAFETY_DESTRUCTIVE tools ask for confirmation
+	MaxResponseBytes uint32                 `protobuf:"varint,14,opt,name=max_response_bytes,json=maxResponseBytes,proto3" json:"max_response_bytes,omitempty"`                                // Truncate the lists of larger responses, as encoded with protojson, to fit; the response needs a bool truncated field
+	MaxResponseItems uint32                 `protobuf:"varint,15,opt,name=max_response_items,json=maxResponseItems,proto3" json:"max_response_items,omitempty"`                                // Truncate every list of the response to this many items; the response needs a bool truncated field
+	DescFile         string                 `protobuf:"bytes,16,opt,name=desc_file,json=descFile,proto3" json:"desc_file,omitempty"`                                                           // Markdown file holding the tool description instead of desc, relative to the proto file
+	GoName           string                 `protobuf:"bytes,17,opt,name=go_name,json=goName,proto3" json:"go_name,omitempty"`                                                                 // Prefix of the generated Go identifiers of the method instead of <Service><Method>, e.g. to keep them across a proto rename
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *ToolDoc) Reset() {
@@ -99,12 +217,101 @@ func (x *ToolDoc) GetOutput() string {
 	return ""
 }
 
+func (x *ToolDoc) GetResultFormat() ResultFormat {
+	if x != nil {
+		return x.ResultFormat
+	}
+	return ResultFormat_RESULT_FORMAT_UNSPECIFIED
+}
+
+func (x *ToolDoc) GetResultTemplate() string {
+	if x != nil {
+		return x.ResultTemplate
+	}
+	return ""
+}
+
+func (x *ToolDoc) GetInterruptible() bool {
+	if x != nil {
+		return x.Interruptible
+	}
+	return false
+}
+
+func (x *ToolDoc) GetResource() bool {
+	if x != nil {
+		return x.Resource
+	}
+	return false
+}
+
+func (x *ToolDoc) GetProgress() bool {
+	if x != nil {
+		return x.Progress
+	}
+	return false
+}
+
+func (x *ToolDoc) GetBatch() bool {
+	if x != nil {
+		return x.Batch
+	}
+	return false
+}
+
+func (x *ToolDoc) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ToolDoc) GetSafety() Safety {
+	if x != nil {
+		return x.Safety
+	}
+	return Safety_SAFETY_UNSPECIFIED
+}
+
+func (x *ToolDoc) GetMaxResponseBytes() uint32 {
+	if x != nil {
+		return x.MaxResponseBytes
+	}
+	return 0
+}
+
+func (x *ToolDoc) GetMaxResponseItems() uint32 {
+	if x != nil {
+		return x.MaxResponseItems
+	}
+	return 0
+}
+
+func (x *ToolDoc) GetDescFile() string {
+	if x != nil {
+		return x.DescFile
+	}
+	return ""
+}
+
+func (x *ToolDoc) GetGoName() string {
+	if x != nil {
+		return x.GoName
+	}
+	return ""
+}
+
 // Custom option: extra documentation for fields.
 type ToolFieldDoc struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Desc          string                 `protobuf:"bytes,1,opt,name=desc,proto3" json:"desc,omitempty"`          // Field description
-	Example       string                 `protobuf:"bytes,2,opt,name=example,proto3" json:"example,omitempty"`    // Example value
-	Required      bool                   `protobuf:"varint,3,opt,name=required,proto3" json:"required,omitempty"` // Mark as required in generated JSON Schema
+	Desc          string                 `protobuf:"bytes,1,opt,name=desc,proto3" json:"desc,omitempty"`                                         // Field description
+	Example       string                 `protobuf:"bytes,2,opt,name=example,proto3" json:"example,omitempty"`                                   // Example value
+	Required      bool                   `protobuf:"varint,3,opt,name=required,proto3" json:"required,omitempty"`                                // Mark as required in generated JSON Schema
+	Sensitive     bool                   `protobuf:"varint,4,opt,name=sensitive,proto3" json:"sensitive,omitempty"`                              // Redact value in logs and other diagnostics
+	ContextKey    string                 `protobuf:"bytes,5,opt,name=context_key,json=contextKey,proto3" json:"context_key,omitempty"`           // Fill from caller metadata under this key; hidden from the model
+	MediaType     string                 `protobuf:"bytes,6,opt,name=media_type,json=mediaType,proto3" json:"media_type,omitempty"`              // Return the bytes (or URL string) response field as a media part of this MIME type
+	SkipSanitizer bool                   `protobuf:"varint,7,opt,name=skip_sanitizer,json=skipSanitizer,proto3" json:"skip_sanitizer,omitempty"` // Pass the string field to the implementation without running the input sanitizers
+	ErrorMessage  string                 `protobuf:"bytes,8,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`     // Explain to the model how to fix the field when its value cannot be decoded or fails validation
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -160,6 +367,41 @@ func (x *ToolFieldDoc) GetRequired() bool {
 	return false
 }
 
+func (x *ToolFieldDoc) GetSensitive() bool {
+	if x != nil {
+		return x.Sensitive
+	}
+	return false
+}
+
+func (x *ToolFieldDoc) GetContextKey() string {
+	if x != nil {
+		return x.ContextKey
+	}
+	return ""
+}
+
+func (x *ToolFieldDoc) GetMediaType() string {
+	if x != nil {
+		return x.MediaType
+	}
+	return ""
+}
+
+func (x *ToolFieldDoc) GetSkipSanitizer() bool {
+	if x != nil {
+		return x.SkipSanitizer
+	}
+	return false
+}
+
+func (x *ToolFieldDoc) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
 var file_genkit_tool_v1_tool_metadata_proto_extTypes = []protoimpl.ExtensionInfo{
 	{
 		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
@@ -195,17 +437,50 @@ var File_genkit_tool_v1_tool_metadata_proto protoreflect.FileDescriptor
 
 const file_genkit_tool_v1_tool_metadata_proto_rawDesc = "" +
 	"\n" +
-	"\"genkit/tool/v1/tool_metadata.proto\x12\x0egenkit.tool.v1\x1a google/protobuf/descriptor.proto\"s\n" +
+	"\"genkit/tool/v1/tool_metadata.proto\x12\x0egenkit.tool.v1\x1a google/protobuf/descriptor.proto\"\x95\x05\n" +
 	"\aToolDoc\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
 	"\x04desc\x18\x02 \x01(\tR\x04desc\x12\x12\n" +
 	"\x04tags\x18\x03 \x03(\tR\x04tags\x12\x14\n" +
 	"\x05input\x18\x04 \x01(\tR\x05input\x12\x16\n" +
-	"\x06output\x18\x05 \x01(\tR\x06output\"X\n" +
+	"\x06output\x18\x05 \x01(\tR\x06output\x12A\n" +
+	"\rresult_format\x18\x06 \x01(\x0e2\x1c.genkit.tool.v1.ResultFormatR\fresultFormat\x12'\n" +
+	"\x0fresult_template\x18\a \x01(\tR\x0eresultTemplate\x12$\n" +
+	"\rinterruptible\x18\b \x01(\bR\rinterruptible\x12\x1a\n" +
+	"\bresource\x18\t \x01(\bR\bresource\x12\x1a\n" +
+	"\bprogress\x18\n" +
+	" \x01(\bR\bprogress\x12\x14\n" +
+	"\x05batch\x18\v \x01(\bR\x05batch\x12A\n" +
+	"\bmetadata\x18\f \x03(\v2%.genkit.tool.v1.ToolDoc.MetadataEntryR\bmetadata\x12.\n" +
+	"\x06safety\x18\r \x01(\x0e2\x16.genkit.tool.v1.SafetyR\x06safety\x12,\n" +
+	"\x12max_response_bytes\x18\x0e \x01(\rR\x10maxResponseBytes\x12,\n" +
+	"\x12max_response_items\x18\x0f \x01(\rR\x10maxResponseItems\x12\x1b\n" +
+	"\tdesc_file\x18\x10 \x01(\tR\bdescFile\x12\x17\n" +
+	"\ago_name\x18\x11 \x01(\tR\x06goName\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x82\x02\n" +
 	"\fToolFieldDoc\x12\x12\n" +
 	"\x04desc\x18\x01 \x01(\tR\x04desc\x12\x18\n" +
 	"\aexample\x18\x02 \x01(\tR\aexample\x12\x1a\n" +
-	"\brequired\x18\x03 \x01(\bR\brequired:T\n" +
+	"\brequired\x18\x03 \x01(\bR\brequired\x12\x1c\n" +
+	"\tsensitive\x18\x04 \x01(\bR\tsensitive\x12\x1f\n" +
+	"\vcontext_key\x18\x05 \x01(\tR\n" +
+	"contextKey\x12\x1d\n" +
+	"\n" +
+	"media_type\x18\x06 \x01(\tR\tmediaType\x12%\n" +
+	"\x0eskip_sanitizer\x18\a \x01(\bR\rskipSanitizer\x12#\n" +
+	"\rerror_message\x18\b \x01(\tR\ferrorMessage*c\n" +
+	"\x06Safety\x12\x16\n" +
+	"\x12SAFETY_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10SAFETY_READ_ONLY\x10\x01\x12\x13\n" +
+	"\x0fSAFETY_MUTATING\x10\x02\x12\x16\n" +
+	"\x12SAFETY_DESTRUCTIVE\x10\x03*\x7f\n" +
+	"\fResultFormat\x12\x1d\n" +
+	"\x19RESULT_FORMAT_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18RESULT_FORMAT_STRUCTURED\x10\x01\x12\x16\n" +
+	"\x12RESULT_FORMAT_JSON\x10\x02\x12\x1a\n" +
+	"\x16RESULT_FORMAT_TEMPLATE\x10\x03:T\n" +
 	"\btool_doc\x12\x1e.google.protobuf.MethodOptions\x18ц\x03 \x01(\v2\x17.genkit.tool.v1.ToolDocR\atoolDoc:Z\n" +
 	"\tfield_doc\x12\x1d.google.protobuf.FieldOptions\x18҆\x03 \x01(\v2\x1c.genkit.tool.v1.ToolFieldDocR\bfieldDocBDZBgithub.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1;toolb\x06proto3"
 
@@ -221,23 +496,30 @@ func file_genkit_tool_v1_tool_metadata_proto_rawDescGZIP() []byte {
 	return file_genkit_tool_v1_tool_metadata_proto_rawDescData
 }
 
-var file_genkit_tool_v1_tool_metadata_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_genkit_tool_v1_tool_metadata_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_genkit_tool_v1_tool_metadata_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_genkit_tool_v1_tool_metadata_proto_goTypes = []any{
-	(*ToolDoc)(nil),                    // 0: genkit.tool.v1.ToolDoc
-	(*ToolFieldDoc)(nil),               // 1: genkit.tool.v1.ToolFieldDoc
-	(*descriptorpb.MethodOptions)(nil), // 2: google.protobuf.MethodOptions
-	(*descriptorpb.FieldOptions)(nil),  // 3: google.protobuf.FieldOptions
+	(Safety)(0),                        // 0: genkit.tool.v1.Safety
+	(ResultFormat)(0),                  // 1: genkit.tool.v1.ResultFormat
+	(*ToolDoc)(nil),                    // 2: genkit.tool.v1.ToolDoc
+	(*ToolFieldDoc)(nil),               // 3: genkit.tool.v1.ToolFieldDoc
+	nil,                                // 4: genkit.tool.v1.ToolDoc.MetadataEntry
+	(*descriptorpb.MethodOptions)(nil), // 5: google.protobuf.MethodOptions
+	(*descriptorpb.FieldOptions)(nil),  // 6: google.protobuf.FieldOptions
 }
 var file_genkit_tool_v1_tool_metadata_proto_depIdxs = []int32{
-	2, // 0: genkit.tool.v1.tool_doc:extendee -> google.protobuf.MethodOptions
-	3, // 1: genkit.tool.v1.field_doc:extendee -> google.protobuf.FieldOptions
-	0, // 2: genkit.tool.v1.tool_doc:type_name -> genkit.tool.v1.ToolDoc
-	1, // 3: genkit.tool.v1.field_doc:type_name -> genkit.tool.v1.ToolFieldDoc
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	2, // [2:4] is the sub-list for extension type_name
-	0, // [0:2] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: genkit.tool.v1.ToolDoc.result_format:type_name -> genkit.tool.v1.ResultFormat
+	4, // 1: genkit.tool.v1.ToolDoc.metadata:type_name -> genkit.tool.v1.ToolDoc.MetadataEntry
+	0, // 2: genkit.tool.v1.ToolDoc.safety:type_name -> genkit.tool.v1.Safety
+	5, // 3: genkit.tool.v1.tool_doc:extendee -> google.protobuf.MethodOptions
+	6, // 4: genkit.tool.v1.field_doc:extendee -> google.protobuf.FieldOptions
+	2, // 5: genkit.tool.v1.tool_doc:type_name -> genkit.tool.v1.ToolDoc
+	3, // 6: genkit.tool.v1.field_doc:type_name -> genkit.tool.v1.ToolFieldDoc
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	5, // [5:7] is the sub-list for extension type_name
+	3, // [3:5] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_genkit_tool_v1_tool_metadata_proto_init() }
@@ -250,13 +532,14 @@ func file_genkit_tool_v1_tool_metadata_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_genkit_tool_v1_tool_metadata_proto_rawDesc), len(file_genkit_tool_v1_tool_metadata_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      2,
+			NumMessages:   3,
 			NumExtensions: 2,
 			NumServices:   0,
 		},
 		GoTypes:           file_genkit_tool_v1_tool_metadata_proto_goTypes,
 		DependencyIndexes: file_genkit_tool_v1_tool_metadata_proto_depIdxs,
+		EnumInfos:         file_genkit_tool_v1_tool_metadata_proto_enumTypes,
 		MessageInfos:      file_genkit_tool_v1_tool_metadata_proto_msgTypes,
 		ExtensionInfos:    file_genkit_tool_v1_tool_metadata_proto_extTypes,
 	}.Build()
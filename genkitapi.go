@@ -0,0 +1,98 @@
+package main
+
+import "google.golang.org/protobuf/compiler/protogen"
+
+const genkitCompatPackage = protogen.GoImportPath("github.com/nemo1105/protoc-gen-go-genkit-tools/genkittools/genkitcompat")
+
+// compatAPI reports whether generated code calls the Genkit SDK through the
+// genkittools/genkitcompat shim under genkit_api=compat.
+func compatAPI() bool {
+	return *genkitAPI == "compat"
+}
+
+// toolDefinition describes the Genkit call defining a tool, or creating it
+// unregistered with lazy=true.
+type toolDefinition struct {
+	inType  string
+	outType string
+	// multipart is set for tools returning media parts.
+	multipart bool
+	// schemaVar holds the input schema, or is empty when Genkit derives the
+	// schema from inType.
+	schemaVar string
+}
+
+// writeDefinitionStart emits the start of d's definition call, up to its
+// name and description arguments taken from infoVar under the options in o.
+// Defined tools are first claimed with genkittools.ClaimTool, so that a
+// second registration in the same Genkit instance fails with an error
+// instead of a panic. The caller emits the tool function, then calls
+// writeDefinitionEnd.
+func writeDefinitionStart(g *protogen.GeneratedFile, d toolDefinition, infoVar string) {
+	if !*lazyTools {
+		g.P("if err := ", genkittoolsPackage.Ident("ClaimTool"), "(g, o.ToolName(", infoVar, ".Name)); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+	}
+	typeArgs := "[" + d.inType + ", " + d.outType + "]"
+	if d.multipart {
+		typeArgs = "[" + d.inType + "]"
+	}
+	switch {
+	case compatAPI():
+		fn := "Tool"
+		if d.multipart {
+			fn = "MultipartTool"
+		}
+		if *lazyTools {
+			g.P("return ", genkitCompatPackage.Ident("New"+fn), typeArgs, "(")
+		} else {
+			g.P("tool := ", genkitCompatPackage.Ident("Define"+fn), typeArgs, "(")
+			g.P("g,")
+		}
+	case *lazyTools && d.multipart:
+		g.P("return ", genkitAIPackage.Ident("NewMultipartTool"), typeArgs, "(")
+	case *lazyTools:
+		g.P("return ", genkitAIPackage.Ident("NewTool"), typeArgs, "(")
+	case d.multipart:
+		g.P("tool := genkit.DefineMultipartTool", typeArgs, "(")
+		g.P("g,")
+	case d.schemaVar == "":
+		g.P("tool := genkit.DefineTool", typeArgs, "(")
+		g.P("g,")
+	default:
+		g.P("tool := genkit.DefineToolWithInputSchema[", d.outType, "](")
+		g.P("g,")
+	}
+	g.P("o.ToolName(", infoVar, ".Name),")
+	g.P("o.ToolDescription(", infoVar, ".Description),")
+	switch {
+	case compatAPI() && d.schemaVar == "":
+		g.P("nil,")
+	case compatAPI():
+		g.P(d.schemaVar, ",")
+	case !*lazyTools && !d.multipart && d.schemaVar != "":
+		g.P(d.schemaVar, ",")
+	}
+}
+
+// writeDefinitionEnd emits the end of d's definition call, after its tool
+// function, and, for defined tools, returns the tool.
+func writeDefinitionEnd(g *protogen.GeneratedFile, d toolDefinition) {
+	if !compatAPI() && (*lazyTools || d.multipart) && d.schemaVar != "" {
+		g.P(genkitAIPackage.Ident("WithInputSchema"), "(", d.schemaVar, "),")
+	}
+	g.P(")")
+	if !*lazyTools {
+		g.P("return tool, nil")
+	}
+}
+
+// lookupToolExpr returns the expression looking up the tool called by the
+// string expression name in g.
+func lookupToolExpr(g *protogen.GeneratedFile, name string) string {
+	if compatAPI() {
+		return g.QualifiedGoIdent(genkitCompatPackage.Ident("LookupTool")) + "(g, " + name + ")"
+	}
+	return "genkit.LookupTool(g, " + name + ")"
+}
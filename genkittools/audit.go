@@ -0,0 +1,125 @@
+package genkittools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// AuditOutcome classifies how an audited tool call ended.
+type AuditOutcome string
+
+const (
+	// AuditOK is a call that returned a response.
+	AuditOK AuditOutcome = "ok"
+	// AuditDenied is a call rejected by the Authorizer set with
+	// WithAuthorizer.
+	AuditDenied AuditOutcome = "denied"
+	// AuditRejected is a call whose input exceeded the input limits or was
+	// rejected by an input sanitizer.
+	AuditRejected AuditOutcome = "rejected"
+	// AuditPanic is a call whose implementation panicked.
+	AuditPanic AuditOutcome = "panic"
+	// AuditError is a call that failed for any other reason.
+	AuditError AuditOutcome = "error"
+)
+
+// AuditEvent records one tool invocation for compliance pipelines.
+type AuditEvent struct {
+	// Tool is the name the tool is registered under, including any
+	// WithNamePrefix prefix.
+	Tool string
+	// Service is the fully-qualified name of the proto service declaring the
+	// tool.
+	Service string
+	// Caller is the caller metadata read under the key passed to WithAudit,
+	// such as the authenticated user, or empty when it is not set.
+	Caller string
+	// InputHash is the hex SHA-256 of the input as JSON, with sensitive
+	// fields redacted so that their values cannot be recovered by guessing.
+	// Equal inputs have equal hashes.
+	InputHash string
+	Outcome   AuditOutcome
+	// Error is the message of the error the call failed with, with sensitive
+	// values redacted.
+	Error   string
+	Start   time.Time
+	Latency time.Duration
+}
+
+// AuditSink receives an AuditEvent for every tool invocation. RecordToolCall
+// runs synchronously at the end of each call with the context of the call,
+// so ToolMetadata is available to it; sinks writing to slow destinations
+// should buffer.
+type AuditSink interface {
+	RecordToolCall(ctx context.Context, event AuditEvent)
+}
+
+// AuditSinkFunc adapts a function to an AuditSink.
+type AuditSinkFunc func(ctx context.Context, event AuditEvent)
+
+// RecordToolCall implements AuditSink.
+func (f AuditSinkFunc) RecordToolCall(ctx context.Context, event AuditEvent) {
+	f(ctx, event)
+}
+
+// WithAudit reports every tool invocation to sink, including denied and
+// rejected ones. AuditEvent.Caller is read under callerKey, such as
+// "user_id", with the MetadataExtractor set with WithMetadataExtractor, or
+// from the metadata attached with ContextWithMetadata; an empty callerKey
+// leaves it empty.
+func WithAudit(sink AuditSink, callerKey string) Option {
+	return func(o *Options) {
+		o.audit = sink
+		o.auditCallerKey = callerKey
+	}
+}
+
+func (o *Options) recordAudit(ctx context.Context, info *ToolInfo, input any, start time.Time, elapsed time.Duration, err error) {
+	event := AuditEvent{
+		Tool:      o.ToolName(info.Name),
+		Service:   info.Service,
+		InputHash: hashInput(input, info.SensitiveFields),
+		Outcome:   auditOutcome(err),
+		Start:     start,
+		Latency:   elapsed,
+	}
+	if o.auditCallerKey != "" {
+		extract := MetadataFromContext
+		if o.extractMetadata != nil {
+			extract = o.extractMetadata
+		}
+		event.Caller, _ = extract(ctx, o.auditCallerKey)
+	}
+	if err != nil {
+		event.Error = redactText(err.Error(), input, info.SensitiveFields)
+	}
+	o.audit.RecordToolCall(ctx, event)
+}
+
+func hashInput(input any, sensitive []string) string {
+	raw, err := json.Marshal(Redact(input, sensitive))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func auditOutcome(err error) AuditOutcome {
+	switch {
+	case err == nil:
+		return AuditOK
+	case errors.Is(err, ErrToolDenied):
+		return AuditDenied
+	case errors.Is(err, ErrInputTooLarge), errors.Is(err, ErrInputRejected):
+		return AuditRejected
+	case errors.Is(err, ErrToolPanic):
+		return AuditPanic
+	default:
+		return AuditError
+	}
+}
@@ -0,0 +1,64 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestInvokeAudits(t *testing.T) {
+	var events []AuditEvent
+	o := NewOptions(
+		WithAudit(AuditSinkFunc(func(_ context.Context, e AuditEvent) { events = append(events, e) }), "user_id"),
+		WithNamePrefix("billing_"),
+		WithAuthorizer(func(_ context.Context, _ string, req any) error {
+			if req.(map[string]any)["amount"] == 0 {
+				return errors.New("nothing to pay")
+			}
+			return nil
+		}),
+	)
+	info := &ToolInfo{Name: "pay", Service: "billing.v1.Billing", SensitiveFields: []string{"/card"}}
+	coerce := func(input any) (map[string]any, error) { return input.(map[string]any), nil }
+	call := func(_ context.Context, req map[string]any) (bool, error) {
+		if req["amount"] == 2 {
+			return false, fmt.Errorf("card %s declined", req["card"])
+		}
+		if req["amount"] == 3 {
+			panic("boom")
+		}
+		return true, nil
+	}
+
+	ctx := ContextWithMetadata(context.Background(), map[string]string{"user_id": "u-1"})
+	for amount := range 4 {
+		_, _ = Invoke(ctx, o, info, map[string]any{"card": "4111", "amount": amount}, coerce, call)
+	}
+	_, _ = Invoke(ctx, o, info, map[string]any{"card": "5500", "amount": 1}, coerce, call)
+
+	wantOutcomes := []AuditOutcome{AuditDenied, AuditOK, AuditError, AuditPanic, AuditOK}
+	if len(events) != len(wantOutcomes) {
+		t.Fatalf("got %d events, want %d", len(events), len(wantOutcomes))
+	}
+	for i, e := range events {
+		if e.Outcome != wantOutcomes[i] {
+			t.Errorf("event %d outcome = %s, want %s", i, e.Outcome, wantOutcomes[i])
+		}
+		if e.Tool != "billing_pay" || e.Service != "billing.v1.Billing" || e.Caller != "u-1" || len(e.InputHash) != 64 || e.Start.IsZero() {
+			t.Errorf("event %d = %+v", i, e)
+		}
+		if strings.Contains(e.Error, "4111") {
+			t.Errorf("event %d leaks sensitive value: %s", i, e.Error)
+		}
+	}
+	if events[2].Error != "card [REDACTED] declined" {
+		t.Errorf("error = %q", events[2].Error)
+	}
+	// The card is redacted before hashing, so only the amount tells inputs
+	// apart.
+	if events[1].InputHash != events[4].InputHash || events[1].InputHash == events[0].InputHash {
+		t.Errorf("input hashes = %s, %s, %s", events[0].InputHash, events[1].InputHash, events[4].InputHash)
+	}
+}
@@ -0,0 +1,126 @@
+package genkittools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/internal/schemautil"
+)
+
+// DefaultBatchConcurrency is the number of requests a batch tool runs at a
+// time unless WithBatchConcurrency says otherwise.
+const DefaultBatchConcurrency = 4
+
+// BatchResult is the outcome of one request of a batch tool: the output of
+// the wrapped tool, or the error it failed with.
+type BatchResult[Out any] struct {
+	Output Out    `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchOutput is the output of a batch tool, with a result per request in
+// request order.
+type BatchOutput[Out any] struct {
+	Results []BatchResult[Out] `json:"results"`
+}
+
+// BatchOutputSchema returns the schema of a BatchOutput whose outputs follow
+// output.
+func BatchOutputSchema(output map[string]any) map[string]any {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []string{"results"},
+	}
+	result := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"output": schemautil.HoistDefinitions(schema, output),
+			"error":  map[string]any{"type": "string", "description": "Why the request failed; output is unset."},
+		},
+	}
+	schema["properties"] = map[string]any{"results": ListSchema(result)}
+	return schema
+}
+
+// WithBatchConcurrency lets the batch tools it configures run at most n of
+// their requests at a time, instead of DefaultBatchConcurrency. Values below
+// 1 run them one at a time. WithMaxConcurrency and WithToolMaxConcurrency
+// still apply to every request.
+func WithBatchConcurrency(n int) Option {
+	if n < 1 {
+		n = 1
+	}
+	return func(o *Options) {
+		o.batchConcurrency = n
+	}
+}
+
+// RunBatch runs call on every element of the BatchField property of input,
+// the input of the batch tool called tool, with bounded concurrency. A
+// request that fails yields a result holding its error rather than failing
+// the batch; only an input that is not a list of requests does.
+func RunBatch[Out any](ctx context.Context, o *Options, tool string, input any, call func(ctx context.Context, input any) (Out, error)) (*BatchOutput[Out], error) {
+	inputs, err := splitBatch(input)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tool, err)
+	}
+
+	n := DefaultBatchConcurrency
+	if o != nil && o.batchConcurrency > 0 {
+		n = o.batchConcurrency
+	}
+	sem := make(chan struct{}, n)
+	out := &BatchOutput[Out]{Results: make([]BatchResult[Out], len(inputs))}
+	var wg sync.WaitGroup
+	for i, in := range inputs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			// Requests not started yet fail with the context.
+			for j := i; j < len(inputs); j++ {
+				out.Results[j].Error = err.Error()
+			}
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			resp, err := call(ctx, in)
+			if err != nil {
+				out.Results[i].Error = err.Error()
+				return
+			}
+			out.Results[i].Output = resp
+		}()
+	}
+	wg.Wait()
+	return out, nil
+}
+
+// splitBatch returns the requests listed in the BatchField property of
+// input, each decoded as a generic JSON value.
+func splitBatch(input any) ([]any, error) {
+	if obj, ok := input.(map[string]any); ok {
+		if reqs, ok := obj[BatchField].([]any); ok {
+			return reqs, nil
+		}
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("marshal batch input: %w", err)
+	}
+	var batch struct {
+		Requests []any `json:"requests"`
+	}
+	if err := json.Unmarshal(raw, &batch); err != nil {
+		return nil, fmt.Errorf("unmarshal batch input: %w", err)
+	}
+	return batch.Requests, nil
+}
@@ -0,0 +1,80 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBatch(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	call := func(_ context.Context, input any) (string, error) {
+		n := inFlight.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+		city := input.(map[string]any)["city"].(string)
+		if city == "" {
+			return "", errors.New("city is required")
+		}
+		return "sunny in " + city, nil
+	}
+
+	input := map[string]any{"requests": []any{
+		map[string]any{"city": "Paris"},
+		map[string]any{"city": ""},
+		map[string]any{"city": "Rome"},
+		map[string]any{"city": "Oslo"},
+	}}
+	out, err := RunBatch(context.Background(), NewOptions(WithBatchConcurrency(2)), "weather_batch", input, call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BatchResult[string]{{Output: "sunny in Paris"}, {Error: "city is required"}, {Output: "sunny in Rome"}, {Output: "sunny in Oslo"}}
+	if len(out.Results) != len(want) {
+		t.Fatalf("results = %v", out.Results)
+	}
+	for i := range want {
+		if out.Results[i] != want[i] {
+			t.Errorf("results[%d] = %v, want %v", i, out.Results[i], want[i])
+		}
+	}
+	if p := peak.Load(); p > 2 {
+		t.Fatalf("ran %d requests at a time, want at most 2", p)
+	}
+
+	// Structs are decoded through JSON.
+	type batch struct {
+		Requests []map[string]any `json:"requests"`
+	}
+	out, err = RunBatch(context.Background(), nil, "weather_batch", batch{Requests: []map[string]any{{"city": "Lima"}}}, call)
+	if err != nil || len(out.Results) != 1 || out.Results[0].Output != "sunny in Lima" {
+		t.Fatalf("RunBatch = %v, %v", out, err)
+	}
+
+	_, err = RunBatch(context.Background(), nil, "weather_batch", map[string]any{"requests": "Paris"}, call)
+	if err == nil || !strings.HasPrefix(err.Error(), "weather_batch: unmarshal batch input") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestRunBatchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	input := map[string]any{"requests": []any{1, 2, 3}}
+	out, err := RunBatch(ctx, NewOptions(WithBatchConcurrency(1)), "count_batch", input, func(context.Context, any) (int, error) {
+		return 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range out.Results {
+		if r.Error != context.Canceled.Error() {
+			t.Fatalf("results[%d] = %v", i, r)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package genkittools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Cache stores the responses of idempotent tools for WithCache. Values are
+// shared between the calls that hit them and must not be modified.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, if it has not expired.
+	Get(ctx context.Context, key string) (any, bool)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value any, ttl time.Duration)
+}
+
+// WithCache serves repeated calls of idempotent tools with identical input
+// from c for ttl, so an agent loop asking the same question twice does not
+// hit the backend again. Tools whose RPC declares idempotency_level
+// IDEMPOTENT or NO_SIDE_EFFECTS are cached; others always call the
+// implementation. Keys cover the tool name and the decoded request,
+// including context-bound fields, and calls are still authorized before
+// the cache is consulted. Only successful responses are stored.
+func WithCache(c Cache, ttl time.Duration) Option {
+	return func(o *Options) {
+		o.cache = c
+		o.cacheTTL = ttl
+	}
+}
+
+// cacheKey returns the key of the call of the tool named name with req, or
+// false when req cannot be encoded.
+func cacheKey(name string, req any) (string, bool) {
+	var raw []byte
+	var err error
+	if msg, ok := req.(proto.Message); ok {
+		raw, err = proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	} else {
+		raw, err = json.Marshal(req)
+	}
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(raw)
+	return name + ":" + hex.EncodeToString(sum[:]), true
+}
+
+// cached runs call through the configured cache when the tool described by
+// info is idempotent.
+func cached[Resp any](ctx context.Context, o *Options, info *ToolInfo, req any, call func() (Resp, error)) (Resp, error) {
+	if o == nil || o.cache == nil || !info.Idempotent {
+		return call()
+	}
+	key, ok := cacheKey(o.ToolName(info.Name), req)
+	if !ok {
+		return call()
+	}
+	if v, ok := o.cache.Get(ctx, key); ok {
+		if resp, ok := v.(Resp); ok {
+			return resp, nil
+		}
+	}
+	resp, err := call()
+	if err == nil {
+		o.cache.Set(ctx, key, resp, o.cacheTTL)
+	}
+	return resp, err
+}
+
+// MemoryCache is an in-process Cache. Expired entries are dropped when they
+// are next read or when Set finds them. The zero value is ready to use.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value   any
+	expires time.Time
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(_ context.Context, key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set implements Cache. A ttl of zero or less stores nothing.
+func (c *MemoryCache) Set(_ context.Context, key string, value any, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]memoryEntry)
+	}
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = memoryEntry{value: value, expires: now.Add(ttl)}
+}
@@ -0,0 +1,90 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestInvokeCachesIdempotentTools(t *testing.T) {
+	cache := NewMemoryCache()
+	o := NewOptions(WithCache(cache, time.Minute))
+	coerce := func(input any) (*wrapperspb.StringValue, error) { return wrapperspb.String(input.(string)), nil }
+
+	for _, tc := range []struct {
+		idempotent bool
+		wantCalls  int
+	}{
+		{idempotent: true, wantCalls: 2},
+		{idempotent: false, wantCalls: 3},
+	} {
+		calls := 0
+		lookup := func(_ context.Context, req *wrapperspb.StringValue) (string, error) {
+			calls++
+			return "weather in " + req.GetValue(), nil
+		}
+
+		info := &ToolInfo{Name: "get_weather", Idempotent: tc.idempotent}
+		for _, city := range []string{"Paris", "Paris", "Oslo"} {
+			got, err := Invoke(context.Background(), o, info, city, coerce, lookup)
+			if err != nil || got != "weather in "+city {
+				t.Fatalf("idempotent=%v: Invoke(%q) = %q, %v", tc.idempotent, city, got, err)
+			}
+		}
+		if calls != tc.wantCalls {
+			t.Errorf("idempotent=%v: calls = %d, want %d", tc.idempotent, calls, tc.wantCalls)
+		}
+	}
+}
+
+func TestInvokeDoesNotCacheErrors(t *testing.T) {
+	o := NewOptions(WithCache(NewMemoryCache(), time.Minute))
+	info := &ToolInfo{Name: "get_weather", Idempotent: true}
+	coerce := func(input any) (int, error) { return input.(int), nil }
+
+	calls := 0
+	failing := func(context.Context, int) (int, error) { calls++; return 0, errors.New("unavailable") }
+	for range 2 {
+		if _, err := Invoke(context.Background(), o, info, 1, coerce, failing); err == nil {
+			t.Fatal("want error")
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+func TestInvokeCacheSeparatesNamePrefixes(t *testing.T) {
+	cache := NewMemoryCache()
+	info := &ToolInfo{Name: "get_weather", Idempotent: true}
+	coerce := func(input any) (string, error) { return input.(string), nil }
+
+	for _, backend := range []string{"staging", "prod"} {
+		o := NewOptions(WithCache(cache, time.Minute), WithNamePrefix(backend+"_"))
+		lookup := func(_ context.Context, city string) (string, error) { return backend + " weather in " + city, nil }
+		got, err := Invoke(context.Background(), o, info, "Paris", coerce, lookup)
+		if err != nil || got != backend+" weather in Paris" {
+			t.Fatalf("%s: Invoke = %q, %v", backend, got, err)
+		}
+	}
+}
+
+func TestMemoryCacheExpires(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+	c.Set(ctx, "a", 1, time.Millisecond)
+	c.Set(ctx, "b", 2, 0)
+	if v, ok := c.Get(ctx, "a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %v, %v", v, ok)
+	}
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Fatal("zero ttl stored a value")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.Get(ctx, "a"); ok {
+		t.Fatal("expired entry returned")
+	}
+}
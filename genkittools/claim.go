@@ -0,0 +1,72 @@
+package genkittools
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"weak"
+)
+
+// ErrDuplicateTool is wrapped by the error ClaimTool returns for a tool
+// already defined in the same Genkit instance, which Genkit would otherwise
+// reject with a panic.
+var ErrDuplicateTool = errors.New("tool already registered")
+
+var claims struct {
+	sync.Mutex
+	// sites maps a weak pointer to a Genkit instance to the call site that
+	// registered each of its tools.
+	sites map[any]map[string]string
+}
+
+// ClaimTool records that the tool called name is about to be defined in the
+// Genkit instance g, so that generated Register functions and the dynamic
+// package fail with an error wrapping ErrDuplicateTool, naming both
+// registration sites, instead of panicking when a service is registered
+// twice in one instance. Claims are dropped when g is garbage collected.
+func ClaimTool[G any](g *G, name string) error {
+	site := registrationSite()
+	key := weak.Make(g)
+
+	claims.Lock()
+	defer claims.Unlock()
+	if claims.sites == nil {
+		claims.sites = make(map[any]map[string]string)
+	}
+	tools, ok := claims.sites[key]
+	if !ok {
+		tools = make(map[string]string)
+		claims.sites[key] = tools
+		runtime.AddCleanup(g, func(key weak.Pointer[G]) {
+			claims.Lock()
+			defer claims.Unlock()
+			delete(claims.sites, key)
+		}, key)
+	}
+	if first, ok := tools[name]; ok {
+		return fmt.Errorf("%w: %s registered at %s was already registered in this Genkit instance at %s; register each service once per instance, or use genkittools.WithNamePrefix", ErrDuplicateTool, name, site, first)
+	}
+	tools[name] = site
+	return nil
+}
+
+// registrationSite returns the file, line and function of the innermost
+// caller outside generated files and this module's runtime packages, or
+// in a test.
+func registrationSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		generated := strings.HasPrefix(frame.Function, "github.com/nemo1105/protoc-gen-go-genkit-tools/genkittools") ||
+			strings.Contains(frame.File, "_genkit.tools")
+		if !generated || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d (%s)", frame.File, frame.Line, frame.Function)
+		}
+		if !more {
+			return "unknown site"
+		}
+	}
+}
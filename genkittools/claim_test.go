@@ -0,0 +1,30 @@
+package genkittools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeGenkit struct{ _ int }
+
+func TestClaimTool(t *testing.T) {
+	g, other := &fakeGenkit{}, &fakeGenkit{}
+	if err := ClaimTool(g, "get_invoice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClaimTool(g, "staging_get_invoice"); err != nil {
+		t.Fatalf("prefixed name: %v", err)
+	}
+	if err := ClaimTool(other, "get_invoice"); err != nil {
+		t.Fatalf("other instance: %v", err)
+	}
+
+	err := ClaimTool(g, "get_invoice")
+	if !errors.Is(err, ErrDuplicateTool) {
+		t.Fatalf("second claim = %v, want ErrDuplicateTool", err)
+	}
+	if got := strings.Count(err.Error(), "claim_test.go:"); got != 2 {
+		t.Errorf("error %q should name both registration sites in claim_test.go", err)
+	}
+}
@@ -0,0 +1,124 @@
+package genkittools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// CLITool is a tool callable from the command line built by RunCLI.
+type CLITool struct {
+	Info *ToolInfo
+	// Call runs the tool on a decoded JSON input, as the generated Genkit
+	// handler would.
+	Call func(ctx context.Context, input any) (any, error)
+}
+
+// RunCLI runs the command line of a generated Run<Service>CLI function:
+//
+//	name list
+//	name schema <tool>
+//	name call <tool> [-input JSON|@file|-]
+//
+// call decodes the input (from stdin with "-", or from a file with "@path";
+// "{}" when omitted), runs the tool and writes its JSON output, or its text
+// output for tools returning a string, to stdout.
+func RunCLI(ctx context.Context, name string, tools []CLITool, args []string, stdin io.Reader, stdout io.Writer) error {
+	usage := fmt.Sprintf("usage: %[1]s list | %[1]s schema <tool> | %[1]s call <tool> [-input JSON|@file|-]", name)
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "list":
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		for _, t := range tools {
+			fmt.Fprintf(w, "%s\t%s\n", t.Info.Name, t.Info.Description)
+		}
+		return w.Flush()
+	case "schema", "call":
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+
+	fs := flag.NewFlagSet(name+" "+args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	input := fs.String("input", "{}", "tool input as JSON, @file to read it from a file, or - to read it from stdin")
+	if len(args) < 2 {
+		return errors.New(usage)
+	}
+	if err := fs.Parse(args[2:]); err != nil {
+		return fmt.Errorf("%w\n%s", err, usage)
+	}
+	tool, ok := findCLITool(tools, args[1])
+	if !ok {
+		return fmt.Errorf("unknown tool %q; run %q to see the available tools", args[1], name+" list")
+	}
+
+	if args[0] == "schema" {
+		return writeJSON(stdout, map[string]any{
+			"input":  tool.Info.InputSchema,
+			"output": tool.Info.OutputSchema,
+		})
+	}
+	raw, err := readCLIInput(*input, stdin)
+	if err != nil {
+		return err
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("decode input: %w", err)
+	}
+	out, err := tool.Call(ctx, decoded)
+	if err != nil {
+		return err
+	}
+	if text, ok := out.(string); ok {
+		// Text results (RESULT_FORMAT_JSON, RESULT_FORMAT_TEMPLATE) print as is.
+		_, err := fmt.Fprintln(stdout, text)
+		return err
+	}
+	raw, err = MarshalOutput(out)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, raw, "", "  "); err != nil {
+		return err
+	}
+	b.WriteByte('\n')
+	_, err = b.WriteTo(stdout)
+	return err
+}
+
+func findCLITool(tools []CLITool, name string) (CLITool, bool) {
+	for _, t := range tools {
+		if t.Info.Name == name {
+			return t, true
+		}
+	}
+	return CLITool{}, false
+}
+
+func readCLIInput(input string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case input == "-":
+		return io.ReadAll(stdin)
+	case strings.HasPrefix(input, "@"):
+		return os.ReadFile(input[1:])
+	default:
+		return []byte(input), nil
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,74 @@
+package genkittools
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func echoTools() []CLITool {
+	return []CLITool{{
+		Info: &ToolInfo{
+			Name:        "echo",
+			Description: "Echo the input",
+			InputSchema: map[string]any{"type": "object"},
+		},
+		Call: func(_ context.Context, input any) (any, error) {
+			return input, nil
+		},
+	}}
+}
+
+func TestRunCLI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.json")
+	if err := os.WriteFile(path, []byte(`{"from":"file"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		args  []string
+		stdin string
+		want  string
+	}{
+		{name: "list", args: []string{"list"}, want: "echo  Echo the input\n"},
+		{name: "call flag", args: []string{"call", "echo", "-input", `{"a":1}`}, want: "{\n  \"a\": 1\n}\n"},
+		{name: "call default", args: []string{"call", "echo"}, want: "{}\n"},
+		{name: "call text", args: []string{"call", "echo", "-input", `"plain text"`}, want: "plain text\n"},
+		{name: "call stdin", args: []string{"call", "echo", "-input", "-"}, stdin: `{"from":"stdin"}`, want: "{\n  \"from\": \"stdin\"\n}\n"},
+		{name: "call file", args: []string{"call", "echo", "-input", "@" + path}, want: "{\n  \"from\": \"file\"\n}\n"},
+		{name: "schema", args: []string{"schema", "echo"}, want: "{\n  \"input\": {\n    \"type\": \"object\"\n  },\n  \"output\": null\n}\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := RunCLI(context.Background(), "demo-tools", echoTools(), tc.args, strings.NewReader(tc.stdin), &out); err != nil {
+				t.Fatalf("RunCLI: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Fatalf("output = %q, want %q", out.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestRunCLIErrors(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: "usage: demo-tools list"},
+		{args: []string{"run"}, want: `unknown command "run"`},
+		{args: []string{"call"}, want: "usage: demo-tools list"},
+		{args: []string{"call", "nope"}, want: `unknown tool "nope"`},
+		{args: []string{"call", "echo", "-input", "{"}, want: "decode input:"},
+	}
+	for _, tc := range cases {
+		err := RunCLI(context.Background(), "demo-tools", echoTools(), tc.args, strings.NewReader(""), &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("RunCLI(%q) = %v, want %q", tc.args, err, tc.want)
+		}
+	}
+}
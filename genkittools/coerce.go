@@ -0,0 +1,200 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Coerce converts the input of the tool called name into msg, as the
+// coercion functions generated with codegen=inline do: input already of
+// type M is returned as is, JSON objects are decoded with DecodeProto and
+// other values go through protojson.
+func Coerce[M proto.Message](input any, name string, msg M) (M, error) {
+	var zero M
+	if req, ok := input.(M); ok {
+		return req, nil
+	}
+	if input == nil {
+		return zero, errors.New(name + " requires input")
+	}
+	if obj, ok := input.(map[string]any); ok {
+		if err := DecodeProto(obj, "", msg.ProtoReflect()); err != nil {
+			return zero, fmt.Errorf("decode %s input: %w", name, err)
+		}
+		return msg, nil
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return zero, fmt.Errorf("marshal %s input: %w", name, err)
+	}
+	if err := protojson.Unmarshal(raw, msg); err != nil {
+		return zero, fmt.Errorf("unmarshal %s input: %w", name, err)
+	}
+	return msg, nil
+}
+
+// DecodeProto decodes the JSON object v at path into msg field by field, in
+// key order, walking its descriptor the way generated decoders walk their
+// fields.
+func DecodeProto(v any, path string, msg protoreflect.Message) error {
+	obj, err := DecodeObject(v, path)
+	if err != nil {
+		return err
+	}
+	fields := msg.Descriptor().Fields()
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		val := obj[key]
+		field := fields.ByJSONName(key)
+		if field == nil {
+			field = fields.ByName(protoreflect.Name(key))
+		}
+		if field != nil && outputOnly(field) {
+			// Set by the server: a value the model sends is ignored.
+			continue
+		}
+		if val == nil {
+			// Only google.protobuf.Value keeps an explicit null.
+			if field != nil && !field.IsList() && !field.IsMap() && field.Message() != nil && field.Message().FullName() == "google.protobuf.Value" {
+				msg.Set(field, protoreflect.ValueOfMessage(structpb.NewNullValue().ProtoReflect()))
+			}
+			continue
+		}
+		if field == nil {
+			return UnknownField(path, key)
+		}
+		fieldPath := path + "/" + string(field.Name())
+		switch {
+		case field.IsMap():
+			entries := msg.Mutable(field).Map()
+			err = DecodeMap(val, fieldPath, func(key string, item any, path string) error {
+				k, err := decodeMapKey(field.MapKey(), key, path)
+				if err != nil {
+					return err
+				}
+				x, err := decodeValue(field.MapValue(), entries.NewValue, item, path)
+				if err != nil {
+					return err
+				}
+				entries.Set(k, x)
+				return nil
+			})
+		case field.IsList():
+			list := msg.Mutable(field).List()
+			err = DecodeList(val, fieldPath, func(item any, path string) error {
+				x, err := decodeValue(field, list.NewElement, item, path)
+				if err != nil {
+					return err
+				}
+				list.Append(x)
+				return nil
+			})
+		default:
+			if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && msg.WhichOneof(oneof) != nil {
+				return OneofConflict(path, string(oneof.Name()))
+			}
+			var x protoreflect.Value
+			x, err = decodeValue(field, func() protoreflect.Value { return msg.NewField(field) }, val, fieldPath)
+			if err == nil {
+				msg.Set(field, x)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeValue decodes a single value of field. newMessage returns the empty
+// message to decode a message value into.
+func decodeValue(field protoreflect.FieldDescriptor, newMessage func() protoreflect.Value, v any, path string) (protoreflect.Value, error) {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		x := newMessage()
+		var err error
+		if strings.HasPrefix(string(field.Message().FullName()), "google.protobuf.") {
+			err = DecodeMessage(v, path, x.Message().Interface())
+		} else {
+			err = DecodeProto(v, path, x.Message())
+		}
+		return x, err
+	case protoreflect.EnumKind:
+		if name, ok := v.(string); ok {
+			value := field.Enum().Values().ByName(protoreflect.Name(name))
+			if value == nil {
+				return protoreflect.Value{}, fieldError(path, "unknown enum value %q", name)
+			}
+			return protoreflect.ValueOfEnum(value.Number()), nil
+		}
+		n, err := decodeInt(v, path, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.BoolKind:
+		b, err := DecodeBool(v, path)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.StringKind:
+		s, err := DecodeString(v, path)
+		return protoreflect.ValueOfString(s), err
+	case protoreflect.BytesKind:
+		b, err := DecodeBytes(v, path)
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := DecodeInt32(v, path)
+		return protoreflect.ValueOfInt32(n), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := DecodeInt64(v, path)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := DecodeUint32(v, path)
+		return protoreflect.ValueOfUint32(n), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := DecodeUint64(v, path)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := DecodeFloat32(v, path)
+		return protoreflect.ValueOfFloat32(f), err
+	default:
+		f, err := DecodeFloat64(v, path)
+		return protoreflect.ValueOfFloat64(f), err
+	}
+}
+
+func decodeMapKey(field protoreflect.FieldDescriptor, key, path string) (protoreflect.MapKey, error) {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(key).MapKey(), nil
+	case protoreflect.BoolKind:
+		b, err := DecodeBoolKey(key, path)
+		return protoreflect.ValueOfBool(b).MapKey(), err
+	default:
+		x, err := decodeValue(field, nil, key, path)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		return x.MapKey(), nil
+	}
+}
+
+// StructInput returns the JSON value of in, the typed input of a tool
+// generated with input_structs=true, so that generated handlers check and
+// decode it like the input of other tools. Values that cannot be encoded are
+// returned as is, for the coercion to report.
+func StructInput(in any) any {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return in
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return in
+	}
+	return v
+}
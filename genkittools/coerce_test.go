@@ -0,0 +1,92 @@
+package genkittools
+
+import (
+	"strings"
+	"testing"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCoerce(t *testing.T) {
+	input := map[string]any{
+		"name":          "get_weather",
+		"tags":          []any{"weather"},
+		"resultFormat":  "RESULT_FORMAT_JSON",
+		"interruptible": true,
+	}
+	got, err := Coerce(input, "describe", &pb.ToolDoc{})
+	if err != nil {
+		t.Fatalf("Coerce: %v", err)
+	}
+	want := &pb.ToolDoc{Name: "get_weather", Tags: []string{"weather"}, ResultFormat: pb.ResultFormat_RESULT_FORMAT_JSON, Interruptible: true}
+	if !proto.Equal(got, want) {
+		t.Fatalf("Coerce = %v, want %v", got, want)
+	}
+
+	// Proto names, enum numbers and ignored nulls decode as in generated code.
+	got, err = Coerce(map[string]any{"result_format": 3.0, "desc": nil}, "describe", &pb.ToolDoc{})
+	if err != nil || got.GetResultFormat() != pb.ResultFormat_RESULT_FORMAT_TEMPLATE {
+		t.Fatalf("Coerce = %v, %v", got, err)
+	}
+	if got, err := Coerce(want, "describe", &pb.ToolDoc{}); err != nil || got != want {
+		t.Fatalf("Coerce(message) = %v, %v", got, err)
+	}
+	if got, err := Coerce(`{"name":"raw"}`, "describe", &pb.ToolDoc{}); err == nil {
+		t.Fatalf("Coerce(string) = %v, want error", got)
+	}
+}
+
+func TestStructInput(t *testing.T) {
+	type input struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags,omitempty"`
+		Desc *string  `json:"desc,omitempty"`
+	}
+	got, err := Coerce(StructInput(input{Name: "get_weather", Tags: []string{"weather"}}), "describe", &pb.ToolDoc{})
+	if err != nil {
+		t.Fatalf("Coerce: %v", err)
+	}
+	if want := (&pb.ToolDoc{Name: "get_weather", Tags: []string{"weather"}}); !proto.Equal(got, want) {
+		t.Fatalf("Coerce = %v, want %v", got, want)
+	}
+
+	ch := make(chan int)
+	if got := StructInput(ch); got != any(ch) {
+		t.Fatalf("StructInput(chan) = %v, want the value itself", got)
+	}
+}
+
+func TestCoerceErrors(t *testing.T) {
+	cases := []struct {
+		input any
+		want  string
+	}{
+		{nil, "describe requires input"},
+		{map[string]any{"nope": 1}, `decode describe input: input: unknown field "nope"`},
+		{map[string]any{"tags": []any{1.5}}, "decode describe input: /tags/0: expected string, got number"},
+		{map[string]any{"result_format": "RESULT_FORMAT_XML"}, `decode describe input: /result_format: unknown enum value "RESULT_FORMAT_XML"`},
+	}
+	for _, tc := range cases {
+		_, err := Coerce(tc.input, "describe", &pb.ToolDoc{})
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("Coerce(%v) = %v, want %q", tc.input, err, tc.want)
+		}
+	}
+}
+
+func TestDecodeProtoMapsAndOneofs(t *testing.T) {
+	s := &structpb.Struct{}
+	if err := DecodeProto(map[string]any{"fields": map[string]any{"city": "Paris"}}, "", s.ProtoReflect()); err != nil {
+		t.Fatalf("DecodeProto: %v", err)
+	}
+	if s.Fields["city"].GetStringValue() != "Paris" {
+		t.Fatalf("fields = %v", s.Fields)
+	}
+
+	err := DecodeProto(map[string]any{"string_value": "a", "bool_value": true}, "/v", (&structpb.Value{}).ProtoReflect())
+	if err == nil || !strings.Contains(err.Error(), "/v: more than one field of oneof kind is set") {
+		t.Fatalf("DecodeProto oneof err = %v", err)
+	}
+}
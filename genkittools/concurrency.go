@@ -0,0 +1,71 @@
+package genkittools
+
+import (
+	"context"
+	"fmt"
+)
+
+// semaphore bounds the number of concurrent implementation calls.
+type semaphore chan struct{}
+
+// WithMaxConcurrency lets at most n implementation calls run at a time across
+// the tools it configures, protecting backends from agent loops that fan out
+// many parallel tool calls. Further calls wait for a slot or for their
+// context to end. The limit belongs to the returned Option, so passing the
+// same value to several Register calls caps their tools together. Values
+// below 1 remove the limit.
+func WithMaxConcurrency(n int) Option {
+	sem := newSemaphore(n)
+	return func(o *Options) {
+		o.sem = sem
+	}
+}
+
+// WithToolMaxConcurrency lets at most n calls of the tool called name run at
+// a time, in addition to any WithMaxConcurrency limit. Names may be plain
+// strings or the generated genkitai.ToolName constants.
+func WithToolMaxConcurrency[N ~string](name N, n int) Option {
+	sem := newSemaphore(n)
+	return func(o *Options) {
+		if o.toolSems == nil {
+			o.toolSems = make(map[string]semaphore)
+		}
+		o.toolSems[string(name)] = sem
+	}
+}
+
+func newSemaphore(n int) semaphore {
+	if n < 1 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+// acquire waits for a concurrency slot for the tool described by info and
+// returns the function releasing it.
+func (o *Options) acquire(ctx context.Context, info *ToolInfo) (func(), error) {
+	if o == nil || (o.sem == nil && o.toolSems[info.Name] == nil) {
+		return func() {}, nil
+	}
+	// Take the tool's own slot first so a call waiting on it does not hold a
+	// shared one.
+	var held []semaphore
+	release := func() {
+		for _, s := range held {
+			<-s
+		}
+	}
+	for _, s := range []semaphore{o.toolSems[info.Name], o.sem} {
+		if s == nil {
+			continue
+		}
+		select {
+		case s <- struct{}{}:
+			held = append(held, s)
+		case <-ctx.Done():
+			release()
+			return nil, fmt.Errorf("%s: wait for concurrency slot: %w", info.Name, ctx.Err())
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,79 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrency(t *testing.T) {
+	limit := WithMaxConcurrency(2)
+	coerce := func(input any) (int, error) { return input.(int), nil }
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want int32
+	}{
+		{"global", []Option{limit}, 2},
+		{"per tool", []Option{WithToolMaxConcurrency(toolName("lookup"), 1)}, 1},
+		{"unlimited", []Option{WithMaxConcurrency(0)}, 6},
+	} {
+		o := NewOptions(tc.opts...)
+		var inFlight, peak atomic.Int32
+		release := make(chan struct{})
+		call := func(context.Context, int) (int, error) {
+			n := inFlight.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			<-release
+			inFlight.Add(-1)
+			return 0, nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := Invoke(context.Background(), o, &ToolInfo{Name: "lookup"}, i, coerce, call); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		if got := peak.Load(); got != tc.want {
+			t.Errorf("%s: peak concurrency = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMaxConcurrencyHonorsContext(t *testing.T) {
+	o := NewOptions(WithMaxConcurrency(1))
+	info := &ToolInfo{Name: "lookup"}
+	coerce := func(input any) (int, error) { return input.(int), nil }
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go Invoke(context.Background(), o, info, 1, coerce, func(context.Context, int) (int, error) {
+		close(started)
+		<-release
+		return 0, nil
+	})
+	defer close(release)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := Invoke(ctx, o, info, 2, coerce, func(context.Context, int) (int, error) {
+		t.Error("implementation called without a free slot")
+		return 0, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+}
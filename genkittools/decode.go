@@ -0,0 +1,318 @@
+package genkittools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The Decode functions convert model input decoded by encoding/json into the
+// fields of generated request messages, accepting the same JSON as protojson.
+// Generated decoders call them field by field, visiting the keys of each
+// object in order so that errors are deterministic; path is the JSON pointer
+// of v in the input, used in errors. A nil v decodes to the zero value.
+
+// DecodeObject returns v as a JSON object.
+func DecodeObject(v any, path string) (map[string]any, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, decodeError(path, "object", v)
+	}
+	return obj, nil
+}
+
+// DecodeList calls fn with each item of the JSON array v and its path. Null
+// items are rejected, as protojson does for every item type but
+// google.protobuf.Value.
+func DecodeList(v any, path string, fn func(item any, path string) error) error {
+	if v == nil {
+		return nil
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return decodeError(path, "array", v)
+	}
+	for i, item := range list {
+		itemPath := path + "/" + strconv.Itoa(i)
+		if item == nil {
+			return decodeError(itemPath, "value", nil)
+		}
+		if err := fn(item, itemPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeMap calls fn with each entry of the JSON object v in key order, for a
+// map field. Like DecodeList, it rejects null values.
+func DecodeMap(v any, path string, fn func(key string, item any, path string) error) error {
+	if v == nil {
+		return nil
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return decodeError(path, "object", v)
+	}
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		item := obj[key]
+		itemPath := path + "/" + escapePointer(key)
+		if item == nil {
+			return decodeError(itemPath, "value", nil)
+		}
+		if err := fn(key, item, itemPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeMessage decodes v into msg with protojson, for the well-known types
+// that have a special JSON form.
+func DecodeMessage(v any, path string, msg proto.Message) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return &FieldError{Pointer: path, Err: err}
+	}
+	if err := protojson.Unmarshal(raw, msg); err != nil {
+		return &FieldError{Pointer: path, Err: err}
+	}
+	return nil
+}
+
+// UnknownField reports a key of the object at path that names no field.
+func UnknownField(path, key string) error {
+	return fieldError(path, "unknown field %q", key)
+}
+
+// OneofConflict reports a second field set for the oneof called name.
+func OneofConflict(path, name string) error {
+	return fieldError(path, "more than one field of oneof %s is set", name)
+}
+
+// DecodeString decodes a string field.
+func DecodeString(v any, path string) (string, error) {
+	switch s := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return s, nil
+	}
+	return "", decodeError(path, "string", v)
+}
+
+// DecodeBool decodes a bool field.
+func DecodeBool(v any, path string) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, decodeError(path, "boolean", v)
+}
+
+// DecodeBoolKey decodes the key of a map with bool keys.
+func DecodeBoolKey(key, path string) (bool, error) {
+	switch key {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fieldError(path, "invalid bool map key %q", key)
+}
+
+// DecodeBytes decodes a bytes field from standard or URL-safe base64, with or
+// without padding.
+func DecodeBytes(v any, path string) ([]byte, error) {
+	switch s := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		enc := base64.StdEncoding
+		if strings.ContainsAny(s, "-_") {
+			enc = base64.URLEncoding
+		}
+		if len(s)%4 != 0 {
+			enc = enc.WithPadding(base64.NoPadding)
+		}
+		b, err := enc.DecodeString(s)
+		if err != nil {
+			return nil, fieldError(path, "invalid base64: %w", err)
+		}
+		return b, nil
+	}
+	return nil, decodeError(path, "string", v)
+}
+
+// DecodeInt32 decodes an int32, sint32 or sfixed32 field.
+func DecodeInt32(v any, path string) (int32, error) {
+	n, err := decodeInt(v, path, 32)
+	return int32(n), err
+}
+
+// DecodeInt64 decodes an int64, sint64 or sfixed64 field.
+func DecodeInt64(v any, path string) (int64, error) {
+	return decodeInt(v, path, 64)
+}
+
+// DecodeUint32 decodes a uint32 or fixed32 field.
+func DecodeUint32(v any, path string) (uint32, error) {
+	n, err := decodeUint(v, path, 32)
+	return uint32(n), err
+}
+
+// DecodeUint64 decodes a uint64 or fixed64 field.
+func DecodeUint64(v any, path string) (uint64, error) {
+	return decodeUint(v, path, 64)
+}
+
+// DecodeFloat32 decodes a float field.
+func DecodeFloat32(v any, path string) (float32, error) {
+	f, err := DecodeFloat64(v, path)
+	if err == nil && !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+		return 0, fieldError(path, "%v overflows float", f)
+	}
+	return float32(f), err
+}
+
+// DecodeFloat64 decodes a double field. Like protojson, it accepts numeric
+// strings and "NaN", "Infinity" and "-Infinity".
+func DecodeFloat64(v any, path string) (float64, error) {
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		switch n {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, fieldError(path, "invalid number %q", n)
+		}
+		return f, nil
+	}
+	if f, ok := goNumber(v); ok {
+		return f, nil
+	}
+	return 0, decodeError(path, "number", v)
+}
+
+// DecodeEnum decodes an enum field from a value name in values (the
+// generated <Enum>_value map) or a number.
+func DecodeEnum[E ~int32](v any, path string, values map[string]int32) (E, error) {
+	if name, ok := v.(string); ok {
+		n, ok := values[name]
+		if !ok {
+			return 0, fieldError(path, "unknown enum value %q", name)
+		}
+		return E(n), nil
+	}
+	n, err := decodeInt(v, path, 32)
+	return E(n), err
+}
+
+func decodeInt(v any, path string, bits int) (int64, error) {
+	var f float64
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		if i, err := strconv.ParseInt(n, 10, bits); err == nil {
+			return i, nil
+		}
+		parsed, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, fieldError(path, "invalid integer %q", n)
+		}
+		f = parsed
+	case json.Number:
+		return decodeInt(string(n), path, bits)
+	default:
+		parsed, ok := goNumber(v)
+		if !ok {
+			return 0, decodeError(path, "integer", v)
+		}
+		f = parsed
+	}
+	limit := math.Ldexp(1, bits-1)
+	if f != math.Trunc(f) || f < -limit || f >= limit {
+		return 0, fieldError(path, "%v is not an int%d", f, bits)
+	}
+	return int64(f), nil
+}
+
+func decodeUint(v any, path string, bits int) (uint64, error) {
+	var f float64
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		if u, err := strconv.ParseUint(n, 10, bits); err == nil {
+			return u, nil
+		}
+		parsed, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, fieldError(path, "invalid integer %q", n)
+		}
+		f = parsed
+	case json.Number:
+		return decodeUint(string(n), path, bits)
+	default:
+		parsed, ok := goNumber(v)
+		if !ok {
+			return 0, decodeError(path, "integer", v)
+		}
+		f = parsed
+	}
+	if f != math.Trunc(f) || f < 0 || f >= math.Ldexp(1, bits) {
+		return 0, fieldError(path, "%v is not a uint%d", f, bits)
+	}
+	return uint64(f), nil
+}
+
+// goNumber converts the numeric Go types callers may put in an input map.
+func goNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func decodeError(path, want string, v any) error {
+	return fieldError(path, "expected %s, got %s", want, jsonType(v))
+}
+
+func fieldError(path, format string, args ...any) error {
+	return &FieldError{Pointer: path, Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,66 @@
+package genkittools
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type color int32
+
+func TestDecodeScalars(t *testing.T) {
+	if n, err := DecodeInt64("9007199254740993", "/n"); err != nil || n != 9007199254740993 {
+		t.Errorf("DecodeInt64 string = %d, %v", n, err)
+	}
+	if n, err := DecodeInt32(1e3, "/n"); err != nil || n != 1000 {
+		t.Errorf("DecodeInt32 exponent = %d, %v", n, err)
+	}
+	if n, err := DecodeUint64(json.Number("18446744073709551615"), "/n"); err != nil || n != math.MaxUint64 {
+		t.Errorf("DecodeUint64 json.Number = %d, %v", n, err)
+	}
+	if f, err := DecodeFloat64("-Infinity", "/f"); err != nil || !math.IsInf(f, -1) {
+		t.Errorf("DecodeFloat64 = %v, %v", f, err)
+	}
+	if b, err := DecodeBytes("aGk-_w", "/b"); err != nil || !bytes.Equal(b, []byte{'h', 'i', 0x3e, 0xff}) {
+		t.Errorf("DecodeBytes = %v, %v", b, err)
+	}
+	values := map[string]int32{"RED": 1, "BLUE": 2}
+	if c, err := DecodeEnum[color]("BLUE", "/c", values); err != nil || c != 2 {
+		t.Errorf("DecodeEnum name = %d, %v", c, err)
+	}
+	if c, err := DecodeEnum[color](1.0, "/c", values); err != nil || c != 1 {
+		t.Errorf("DecodeEnum number = %d, %v", c, err)
+	}
+	if s, err := DecodeString(nil, "/s"); err != nil || s != "" {
+		t.Errorf("DecodeString(nil) = %q, %v", s, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{second(DecodeInt32(1.5, "/line_items/0/quantity")), "/line_items/0/quantity: 1.5 is not an int32"},
+		{second(DecodeUint32(-1.0, "/n")), "/n: -1 is not a uint32"},
+		{second(DecodeString(true, "/city")), "/city: expected string, got boolean"},
+		{second(DecodeEnum[color]("PURPLE", "/c", map[string]int32{})), `/c: unknown enum value "PURPLE"`},
+		{second(DecodeObject([]any{}, "")), "input: expected object, got array"},
+		{DecodeList([]any{nil}, "/tags", func(any, string) error { return nil }), "/tags/0: expected value, got null"},
+		{UnknownField("", "nope"), `input: unknown field "nope"`},
+	}
+	for _, tc := range cases {
+		if tc.err == nil || tc.err.Error() != tc.want {
+			t.Errorf("err = %v, want %q", tc.err, tc.want)
+		}
+	}
+	if err := DecodeMessage("yesterday", "/at", &timestamppb.Timestamp{}); err == nil || !strings.HasPrefix(err.Error(), "/at: ") {
+		t.Errorf("DecodeMessage err = %v", err)
+	}
+}
+
+func second[T any](_ T, err error) error { return err }
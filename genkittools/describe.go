@@ -0,0 +1,234 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"strings"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// DescribeMethod returns the ToolInfo the plugin would generate for method,
+// for hosts that build tools from descriptors at run time: its name,
+// description, tags, schemas and field annotations follow the same rules.
+// It returns nil for methods without (genkit.tool.v1.tool_doc) and for
+// streaming and resource methods, whose tools depend on plugin options.
+func DescribeMethod(method protoreflect.MethodDescriptor) *ToolInfo {
+	opts, ok := method.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil || !proto.HasExtension(opts, pb.E_ToolDoc) {
+		return nil
+	}
+	doc, _ := proto.GetExtension(opts, pb.E_ToolDoc).(*pb.ToolDoc)
+	if method.IsStreamingClient() || method.IsStreamingServer() || doc.GetResource() {
+		return nil
+	}
+	svc := method.Parent().(protoreflect.ServiceDescriptor)
+
+	info := &ToolInfo{
+		Name:        doc.GetName(),
+		Description: doc.GetDesc(),
+		Tags:        doc.GetTags(),
+		Service:     string(svc.FullName()),
+		InputSchema: OmitOutputOnly(MessageSchema(method.Input()), method.Input()),
+		Idempotent:  opts.GetIdempotencyLevel() == descriptorpb.MethodOptions_IDEMPOTENT || opts.GetIdempotencyLevel() == descriptorpb.MethodOptions_NO_SIDE_EFFECTS,
+	}
+	switch doc.GetSafety() {
+	case pb.Safety_SAFETY_READ_ONLY:
+		info.Safety = SafetyReadOnly
+	case pb.Safety_SAFETY_MUTATING:
+		info.Safety = SafetyMutating
+	case pb.Safety_SAFETY_DESTRUCTIVE:
+		info.Safety = SafetyDestructive
+	}
+	info.ResponseLimits = ResponseLimits{MaxBytes: int(doc.GetMaxResponseBytes()), MaxItems: int(doc.GetMaxResponseItems())}
+	for key, raw := range doc.GetMetadata() {
+		var value any
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			// The plugin rejects such values; keep them as written.
+			value = raw
+		}
+		if info.Metadata == nil {
+			info.Metadata = make(map[string]any)
+		}
+		info.Metadata[key] = value
+	}
+	if info.Name == "" {
+		info.Name = strings.ToLower(goCamelCase(string(svc.Name())) + "_" + goCamelCase(string(method.Name())))
+	}
+	if info.Description == "" {
+		info.Description = openAPIv2MethodDescription(method)
+	}
+	if info.Description == "" {
+		info.Description = "Tool wrapper for " + goCamelCase(string(method.Name()))
+	}
+	if doc.GetInput() != "" {
+		DescribeSchema(info.InputSchema, doc.GetInput())
+	}
+
+	if f := doc.GetResultFormat(); f != pb.ResultFormat_RESULT_FORMAT_UNSPECIFIED && f != pb.ResultFormat_RESULT_FORMAT_STRUCTURED {
+		info.OutputSchema = map[string]any{"type": "string"}
+	} else {
+		info.OutputSchema = MessageSchema(method.Output())
+		fields := method.Output().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			if mediaType := fieldDoc(field).GetMediaType(); mediaType != "" {
+				if info.MediaFields == nil {
+					info.MediaFields = make(map[string]string)
+				}
+				info.MediaFields[string(field.Name())] = mediaType
+				OmitFields(info.OutputSchema, string(field.Name()))
+			}
+		}
+	}
+	if doc.GetOutput() != "" {
+		DescribeSchema(info.OutputSchema, doc.GetOutput())
+	}
+
+	info.SensitiveFields = annotatedFields(method.Input(), "", (*pb.ToolFieldDoc).GetSensitive, nil)
+	info.UnsanitizedFields = annotatedFields(method.Input(), "", (*pb.ToolFieldDoc).GetSkipSanitizer, nil)
+	info.ContextFields = contextFields(method.Input(), "", nil, nil)
+	info.FieldErrorMessages = fieldErrorMessages(method.Input(), "", nil, nil)
+	return info
+}
+
+// annotatedFields lists the locations of the fields of msg whose field_doc
+// satisfies annotated, below prefix, in the pattern syntax accepted by
+// Redact.
+func annotatedFields(msg protoreflect.MessageDescriptor, prefix string, annotated func(*pb.ToolFieldDoc) bool, visiting map[protoreflect.FullName]bool) []string {
+	if visiting[msg.FullName()] {
+		return nil
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
+	var out []string
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		path := prefix + "/" + string(field.Name())
+		if fd := fieldDoc(field); fd != nil && annotated(fd) {
+			out = append(out, path)
+			continue
+		}
+		switch {
+		case field.IsMap():
+			if mv := field.MapValue(); mv.Message() != nil {
+				out = append(out, annotatedFields(mv.Message(), path+"/*", annotated, visiting)...)
+			}
+		case field.Message() != nil:
+			if field.IsList() {
+				path += "/*"
+			}
+			out = append(out, annotatedFields(field.Message(), path, annotated, visiting)...)
+		}
+	}
+	return out
+}
+
+// contextFields maps the dotted paths of the singular fields of msg
+// annotated with a context_key, below prefix, to that key.
+func contextFields(msg protoreflect.MessageDescriptor, prefix string, out map[string]string, visiting map[protoreflect.FullName]bool) map[string]string {
+	if visiting[msg.FullName()] {
+		return out
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		if field.IsList() || field.IsMap() {
+			continue
+		}
+		path := prefix + string(field.Name())
+		if key := fieldDoc(field).GetContextKey(); key != "" {
+			if out == nil {
+				out = make(map[string]string)
+			}
+			out[path] = key
+			continue
+		}
+		if field.Message() != nil {
+			out = contextFields(field.Message(), path+".", out, visiting)
+		}
+	}
+	return out
+}
+
+// fieldErrorMessages maps the locations of the fields of msg annotated with an
+// error_message, below prefix, to that message, in the pattern syntax
+// accepted by Redact.
+func fieldErrorMessages(msg protoreflect.MessageDescriptor, prefix string, out map[string]string, visiting map[protoreflect.FullName]bool) map[string]string {
+	if visiting[msg.FullName()] {
+		return out
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		path := prefix + "/" + string(field.Name())
+		if message := fieldDoc(field).GetErrorMessage(); message != "" {
+			if out == nil {
+				out = make(map[string]string)
+			}
+			out[path] = message
+		}
+		switch {
+		case field.IsMap():
+			if mv := field.MapValue(); mv.Message() != nil {
+				out = fieldErrorMessages(mv.Message(), path+"/*", out, visiting)
+			}
+		case field.Message() != nil:
+			if field.IsList() {
+				path += "/*"
+			}
+			out = fieldErrorMessages(field.Message(), path, out, visiting)
+		}
+	}
+	return out
+}
+
+// goCamelCase converts a proto name to the Go name protoc-gen-go gives it,
+// which derived tool names are built from.
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// Skip over '.' in ".{{lowercase}}".
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// Skip over '_' in "_{{lowercase}}".
+		case c >= '0' && c <= '9':
+			b = append(b, c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isASCIILower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isASCIILower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
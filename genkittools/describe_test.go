@@ -0,0 +1,99 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestDescribeMethod(t *testing.T) {
+	fieldOpts := func(doc *pb.ToolFieldDoc) *descriptorpb.FieldOptions {
+		opts := &descriptorpb.FieldOptions{}
+		proto.SetExtension(opts, pb.E_FieldDoc, doc)
+		return opts
+	}
+	methodOpts := func(doc *pb.ToolDoc) *descriptorpb.MethodOptions {
+		opts := &descriptorpb.MethodOptions{IdempotencyLevel: descriptorpb.MethodOptions_NO_SIDE_EFFECTS.Enum()}
+		proto.SetExtension(opts, pb.E_ToolDoc, doc)
+		return opts
+	}
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	byts := descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("photo.proto"),
+		Package: proto.String("photo.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("GetPhotoRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("id"), Number: proto.Int32(1), Type: str, Label: optional, JsonName: proto.String("id"), Options: fieldOpts(&pb.ToolFieldDoc{Desc: "Photo ID.", Required: true, ErrorMessage: "id must be a photo ID such as p-123"})},
+				{Name: proto.String("token"), Number: proto.Int32(2), Type: str, Label: optional, JsonName: proto.String("token"), Options: fieldOpts(&pb.ToolFieldDoc{Sensitive: true})},
+				{Name: proto.String("user_id"), Number: proto.Int32(3), Type: str, Label: optional, JsonName: proto.String("userId"), Options: fieldOpts(&pb.ToolFieldDoc{ContextKey: "uid"})},
+			},
+		}, {
+			Name: proto.String("Photo"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("jpeg"), Number: proto.Int32(1), Type: byts, Label: optional, JsonName: proto.String("jpeg"), Options: fieldOpts(&pb.ToolFieldDoc{MediaType: "image/jpeg"})},
+				{Name: proto.String("caption"), Number: proto.Int32(2), Type: str, Label: optional, JsonName: proto.String("caption")},
+			},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Photo_store"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("GetPhoto"), InputType: proto.String(".photo.v1.GetPhotoRequest"), OutputType: proto.String(".photo.v1.Photo"), Options: methodOpts(&pb.ToolDoc{Tags: []string{"media"}, Output: "The photo.", Metadata: map[string]string{"cost": "2", "team": `"media"`}, Safety: pb.Safety_SAFETY_READ_ONLY})},
+				{Name: proto.String("WatchPhoto"), InputType: proto.String(".photo.v1.GetPhotoRequest"), OutputType: proto.String(".photo.v1.Photo"), ServerStreaming: proto.Bool(true), Options: methodOpts(&pb.ToolDoc{})},
+				{Name: proto.String("DeletePhoto"), InputType: proto.String(".photo.v1.GetPhotoRequest"), OutputType: proto.String(".photo.v1.Photo")},
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods := fd.Services().Get(0).Methods()
+
+	info := DescribeMethod(methods.ByName("GetPhoto"))
+	if info == nil {
+		t.Fatal("GetPhoto not described")
+	}
+	if info.Name != "photostore_getphoto" || info.Description != "Tool wrapper for GetPhoto" || info.Service != "photo.v1.Photo_store" || !info.Idempotent || !slices.Equal(info.Tags, []string{"media"}) {
+		t.Fatalf("info = %+v", info)
+	}
+	for _, tc := range []struct {
+		name   string
+		schema map[string]any
+		want   string
+	}{
+		{"input", info.InputSchema, `{"properties":{"id":{"description":"Photo ID.","type":"string"},"token":{"type":"string"}},"required":["id"],"type":"object"}`},
+		{"output", info.OutputSchema, `{"description":"The photo.","properties":{"caption":{"type":"string"}},"type":"object"}`},
+	} {
+		raw, err := json.Marshal(tc.schema)
+		if err != nil || string(raw) != tc.want {
+			t.Errorf("%s schema = %s, %v, want %s", tc.name, raw, err, tc.want)
+		}
+	}
+	if !slices.Equal(info.SensitiveFields, []string{"/token"}) || info.ContextFields["user_id"] != "uid" || info.MediaFields["jpeg"] != "image/jpeg" {
+		t.Fatalf("field annotations = %v, %v, %v", info.SensitiveFields, info.ContextFields, info.MediaFields)
+	}
+	if len(info.FieldErrorMessages) != 1 || info.FieldErrorMessages["/id"] != "id must be a photo ID such as p-123" {
+		t.Fatalf("field error messages = %v", info.FieldErrorMessages)
+	}
+	if info.Safety != SafetyReadOnly {
+		t.Fatalf("safety = %q", info.Safety)
+	}
+	if len(info.Metadata) != 2 || info.Metadata["cost"] != 2.0 || info.Metadata["team"] != "media" {
+		t.Fatalf("metadata = %v", info.Metadata)
+	}
+
+	for _, name := range []protoreflect.Name{"WatchPhoto", "DeletePhoto"} {
+		if info := DescribeMethod(methods.ByName(name)); info != nil {
+			t.Errorf("%s described as %+v", name, info)
+		}
+	}
+}
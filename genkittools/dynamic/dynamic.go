@@ -0,0 +1,266 @@
+// Package dynamic builds Genkit tools from service descriptors at run time,
+// without code generation, for plugin systems that load services they were
+// not compiled with, e.g. from a descriptor set. The tools follow the rules
+// of generated code: the same methods become tools, with the same names,
+// descriptions and schemas (see genkittools.DescribeMethod), and the same
+// genkittools options apply. Requests and responses are dynamic messages.
+package dynamic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/template"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/genkittools"
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/genkittools/genkitcompat"
+)
+
+// InvokeFunc calls the unary RPC method, named /package.Service/Method, with
+// req and fills resp. Over gRPC it is
+//
+//	func(ctx context.Context, method string, req, resp proto.Message) error {
+//		return conn.Invoke(ctx, method, req, resp)
+//	}
+type InvokeFunc func(ctx context.Context, method string, req, resp proto.Message) error
+
+// Services returns the services declared by the files of set, in file and
+// declaration order. set must hold every dependency of its files, as
+// written by protoc --include_imports or buf build.
+func Services(set *descriptorpb.FileDescriptorSet) ([]protoreflect.ServiceDescriptor, error) {
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("resolve descriptor set: %w", err)
+	}
+	var out []protoreflect.ServiceDescriptor
+	for _, f := range set.GetFile() {
+		fd, err := files.FindFileByPath(f.GetName())
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < fd.Services().Len(); i++ {
+			out = append(out, fd.Services().Get(i))
+		}
+	}
+	return out, nil
+}
+
+// DefineTools registers a tool with g for every tool-enabled method of
+// services, backed by invoke, and returns them in declaration order. The
+// options apply as they do to generated tools.
+func DefineTools(g *genkit.Genkit, invoke InvokeFunc, services []protoreflect.ServiceDescriptor, opts ...genkittools.Option) ([]ai.Tool, error) {
+	return buildTools(g, invoke, services, opts)
+}
+
+// NewTools is DefineTools for unregistered tools, which Genkit registers
+// dynamically when they are passed to ai.WithTools, like the tools
+// generated with lazy=true.
+func NewTools(invoke InvokeFunc, services []protoreflect.ServiceDescriptor, opts ...genkittools.Option) ([]ai.Tool, error) {
+	return buildTools(nil, invoke, services, opts)
+}
+
+// method is a tool-enabled method and the tool it becomes.
+type method struct {
+	desc protoreflect.MethodDescriptor
+	doc  *pb.ToolDoc
+	info *genkittools.ToolInfo
+}
+
+// collectMethods returns the tool-enabled methods of services. Streaming
+// and resource methods are skipped with a warning on stderr. Derived tool
+// names that are taken are numbered in declaration order, as the plugin
+// does: orders_get, orders_get_2, ...
+func collectMethods(services []protoreflect.ServiceDescriptor) ([]method, error) {
+	var methods []method
+	claimed := make(map[string]protoreflect.MethodDescriptor)
+	for _, svc := range services {
+		for i := 0; i < svc.Methods().Len(); i++ {
+			desc := svc.Methods().Get(i)
+			opts, _ := desc.Options().(*descriptorpb.MethodOptions)
+			if opts == nil || !proto.HasExtension(opts, pb.E_ToolDoc) {
+				continue
+			}
+			info := genkittools.DescribeMethod(desc)
+			if info == nil {
+				fmt.Fprintf(os.Stderr, "genkittools/dynamic: skipping %s: streaming and resource methods need generated code\n", desc.FullName())
+				continue
+			}
+			doc := proto.GetExtension(opts, pb.E_ToolDoc).(*pb.ToolDoc)
+			if doc.GetName() != "" {
+				if prev, ok := claimed[info.Name]; ok {
+					return nil, fmt.Errorf("tool name %q is used by both %s and %s", info.Name, prev.FullName(), desc.FullName())
+				}
+			}
+			methods = append(methods, method{desc: desc, doc: doc, info: info})
+			if doc.GetName() != "" {
+				claimed[info.Name] = desc
+			}
+		}
+	}
+	for i := range methods {
+		m := &methods[i]
+		if m.doc.GetName() != "" {
+			continue
+		}
+		name := m.info.Name
+		for n := 2; claimed[name] != nil; n++ {
+			name = m.info.Name + "_" + strconv.Itoa(n)
+		}
+		m.info.Name = name
+		claimed[name] = m.desc
+	}
+	return methods, nil
+}
+
+func buildTools(g *genkit.Genkit, invoke InvokeFunc, services []protoreflect.ServiceDescriptor, opts []genkittools.Option) ([]ai.Tool, error) {
+	methods, err := collectMethods(services)
+	if err != nil {
+		return nil, err
+	}
+	o := genkittools.NewOptions(opts...)
+	var tools []ai.Tool
+	for _, m := range methods {
+		if !o.Includes(m.info.Name) {
+			continue
+		}
+		t, err := newTool(g, invoke, o, m)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// newTool builds the tool of m, registered with g unless g is nil.
+func newTool(g *genkit.Genkit, invoke InvokeFunc, o *genkittools.Options, m method) (ai.Tool, error) {
+	info := m.info
+	if g != nil {
+		if err := genkittools.ClaimTool(g, o.ToolName(info.Name)); err != nil {
+			return nil, err
+		}
+	}
+	fullMethod := "/" + string(m.desc.Parent().FullName()) + "/" + string(m.desc.Name())
+	call := func(ctx context.Context, input any) (proto.Message, error) {
+		return genkittools.Invoke(ctx, o, info, input, func(input any) (proto.Message, error) {
+			return genkittools.Coerce[proto.Message](input, info.Name, dynamicpb.NewMessage(m.desc.Input()))
+		}, func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			resp := dynamicpb.NewMessage(m.desc.Output())
+			if err := invoke(ctx, fullMethod, req, resp); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		})
+	}
+
+	if len(info.MediaFields) > 0 {
+		fn := func(tc *ai.ToolContext, input any) (*ai.MultipartToolResponse, error) {
+			ctx, err := confirm(tc, o, info)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := call(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			resp, media := genkittools.SplitMedia(info, resp)
+			out, err := outputValue(resp)
+			if err != nil {
+				return nil, err
+			}
+			parts := make([]*ai.Part, len(media))
+			for i, md := range media {
+				parts[i] = ai.NewMediaPart(md.ContentType, md.URL)
+			}
+			return &ai.MultipartToolResponse{Output: out, Content: parts}, nil
+		}
+		if g == nil {
+			return genkitcompat.NewMultipartTool(o.ToolName(info.Name), o.ToolDescription(info.Description), info.InputSchema, fn), nil
+		}
+		return genkitcompat.DefineMultipartTool(g, o.ToolName(info.Name), o.ToolDescription(info.Description), info.InputSchema, fn), nil
+	}
+
+	render, err := renderFunc(m)
+	if err != nil {
+		return nil, err
+	}
+	fn := func(tc *ai.ToolContext, input any) (any, error) {
+		ctx, err := confirm(tc, o, info)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := call(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		return render(resp)
+	}
+	if g == nil {
+		return genkitcompat.NewTool(o.ToolName(info.Name), o.ToolDescription(info.Description), info.InputSchema, fn), nil
+	}
+	return genkitcompat.DefineTool(g, o.ToolName(info.Name), o.ToolDescription(info.Description), info.InputSchema, fn), nil
+}
+
+// renderFunc returns how the tool of m returns its response, following the
+// result_format of the method. Templates see the response in its protojson
+// form, keyed by proto field name, since dynamic messages have no Go fields.
+func renderFunc(m method) (func(proto.Message) (any, error), error) {
+	switch m.doc.GetResultFormat() {
+	case pb.ResultFormat_RESULT_FORMAT_JSON:
+		return func(resp proto.Message) (any, error) {
+			return genkittools.RenderJSON(resp)
+		}, nil
+	case pb.ResultFormat_RESULT_FORMAT_TEMPLATE:
+		tmpl, err := template.New(m.info.Name).Parse(m.doc.GetResultTemplate())
+		if err != nil {
+			return nil, fmt.Errorf("%s: result_template: %w", m.desc.FullName(), err)
+		}
+		return func(resp proto.Message) (any, error) {
+			out, err := outputValue(resp)
+			if err != nil {
+				return nil, err
+			}
+			return genkittools.RenderTemplate(tmpl, out)
+		}, nil
+	default:
+		return outputValue, nil
+	}
+}
+
+// outputValue converts resp to the generic JSON value the model receives,
+// with fields keyed by proto name as in the output schema.
+func outputValue(resp proto.Message) (any, error) {
+	raw, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s: %w", resp.ProtoReflect().Descriptor().FullName(), err)
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// confirm asks for confirmation of calls of destructive tools, as generated
+// handlers do, turning the interrupt of Options.Confirm into a Genkit tool
+// interrupt. It returns the context to run the call with.
+func confirm(tc *ai.ToolContext, o *genkittools.Options, info *genkittools.ToolInfo) (context.Context, error) {
+	ctx := genkittools.ContextWithResumed(tc, tc.Resumed)
+	err := o.Confirm(ctx, info)
+	if md, ok := genkittools.IsInterrupt(err); ok {
+		return nil, tc.Interrupt(&ai.InterruptOptions{Metadata: md})
+	}
+	return ctx, err
+}
@@ -0,0 +1,138 @@
+package dynamic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/firebase/genkit/go/genkit"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/genkittools"
+)
+
+func weatherSet() *descriptorpb.FileDescriptorSet {
+	methodOpts := func(doc *pb.ToolDoc) *descriptorpb.MethodOptions {
+		opts := &descriptorpb.MethodOptions{}
+		proto.SetExtension(opts, pb.E_ToolDoc, doc)
+		return opts
+	}
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("weather.proto"),
+		Package: proto.String("weather.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:  proto.String("GetWeatherRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("city"), Number: proto.Int32(1), Type: str, Label: optional, JsonName: proto.String("city")}},
+		}, {
+			Name:  proto.String("Forecast"),
+			Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("sky_cover"), Number: proto.Int32(1), Type: str, Label: optional, JsonName: proto.String("skyCover")}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Weather"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("GetWeather"), InputType: proto.String(".weather.v1.GetWeatherRequest"), OutputType: proto.String(".weather.v1.Forecast"), Options: methodOpts(&pb.ToolDoc{Name: "get_weather", Desc: "Current weather in a city."})},
+				{Name: proto.String("Summary"), InputType: proto.String(".weather.v1.GetWeatherRequest"), OutputType: proto.String(".weather.v1.Forecast"), Options: methodOpts(&pb.ToolDoc{
+					ResultFormat:   pb.ResultFormat_RESULT_FORMAT_TEMPLATE,
+					ResultTemplate: "Skies are {{.sky_cover}}.",
+				})},
+				{Name: proto.String("Untooled"), InputType: proto.String(".weather.v1.GetWeatherRequest"), OutputType: proto.String(".weather.v1.Forecast")},
+			},
+		}},
+	}}}
+}
+
+// fakeWeather answers every call with the sky over the requested city.
+func fakeWeather(calls *[]string) InvokeFunc {
+	return func(_ context.Context, method string, req, resp proto.Message) error {
+		*calls = append(*calls, method)
+		in, out := req.ProtoReflect(), resp.ProtoReflect()
+		city := in.Get(in.Descriptor().Fields().ByName("city")).String()
+		if city == "" {
+			return errors.New("unknown city")
+		}
+		out.Set(out.Descriptor().Fields().ByName("sky_cover"), protoreflect.ValueOfString("clear over "+city))
+		return nil
+	}
+}
+
+func TestNewTools(t *testing.T) {
+	services, err := Services(weatherSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	tools, err := NewTools(fakeWeather(&calls), services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 2 || tools[0].Name() != "get_weather" || tools[1].Name() != "weather_summary" {
+		t.Fatalf("tools = %v", tools)
+	}
+	def := tools[0].Definition()
+	if def.Description != "Current weather in a city." || def.InputSchema["type"] != "object" {
+		t.Fatalf("definition = %+v", def)
+	}
+
+	out, err := tools[0].RunRaw(context.Background(), map[string]any{"city": "Lima"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := json.Marshal(out); string(raw) != `{"sky_cover":"clear over Lima"}` {
+		t.Fatalf("output = %s", raw)
+	}
+	out, err = tools[1].RunRaw(context.Background(), map[string]any{"city": "Oslo"})
+	if err != nil || out != "Skies are clear over Oslo." {
+		t.Fatalf("templated output = %v, %v", out, err)
+	}
+	if len(calls) != 2 || calls[0] != "/weather.v1.Weather/GetWeather" {
+		t.Fatalf("calls = %v", calls)
+	}
+
+	if _, err := tools[0].RunRaw(context.Background(), map[string]any{"town": "Lima"}); err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+	if _, err := tools[0].RunRaw(context.Background(), map[string]any{"city": ""}); err == nil {
+		t.Fatal("expected the backend error")
+	}
+}
+
+func TestDefineTools(t *testing.T) {
+	services, err := Services(weatherSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := genkit.Init(context.Background())
+	var calls []string
+	tools, err := DefineTools(g, fakeWeather(&calls), services, genkittools.WithOnly("get_weather"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 1 || genkit.LookupTool(g, "get_weather") == nil || genkit.LookupTool(g, "weather_summary") != nil {
+		t.Fatalf("tools = %v", tools)
+	}
+
+	// WithNamePrefix keeps a second registration of the services apart.
+	if _, err := DefineTools(g, fakeWeather(&calls), services, genkittools.WithOnly("get_weather"), genkittools.WithNamePrefix("staging_")); err != nil {
+		t.Fatal(err)
+	}
+	if genkit.LookupTool(g, "staging_get_weather") == nil {
+		t.Fatal("staging_get_weather is not defined")
+	}
+
+	// Registering the same tools again in g fails instead of panicking.
+	if _, err := DefineTools(g, fakeWeather(&calls), services, genkittools.WithOnly("get_weather")); !errors.Is(err, genkittools.ErrDuplicateTool) {
+		t.Fatalf("second DefineTools = %v, want ErrDuplicateTool", err)
+	}
+
+	// The same services twice claim the same explicit names.
+	if _, err := NewTools(fakeWeather(&calls), append(services, services...)); err == nil {
+		t.Fatal("expected a duplicate tool name error")
+	}
+}
@@ -0,0 +1,153 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// GRPCInvoker returns an InvokeFunc calling the methods over conn with
+// opts. gRPC status errors reach the model as *genkittools.RemoteError, as
+// with generated gRPC adapters.
+func GRPCInvoker(conn grpc.ClientConnInterface, opts ...grpc.CallOption) InvokeFunc {
+	return func(ctx context.Context, method string, req, resp proto.Message) error {
+		return conn.Invoke(ctx, method, req, resp, opts...)
+	}
+}
+
+// ReflectServices asks the gRPC server behind conn for the services it
+// serves, through the server reflection API (grpc.reflection.v1), and
+// returns them in the order the server lists them, with their request and
+// response types resolved from the files the server returns. Together with
+// GRPCInvoker this wraps a running third-party service as tools without its
+// protos:
+//
+//	services, err := dynamic.ReflectServices(ctx, conn)
+//	tools, err := dynamic.DefineTools(g, dynamic.GRPCInvoker(conn), services)
+//
+// Only methods whose descriptors carry (genkit.tool.v1.tool_doc) become
+// tools, so the server must be built from annotated protos.
+func ReflectServices(ctx context.Context, conn grpc.ClientConnInterface) ([]protoreflect.ServiceDescriptor, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open server reflection stream: %w", err)
+	}
+	r := &reflector{stream: stream, files: make(map[string]*descriptorpb.FileDescriptorProto)}
+
+	resp, err := r.ask(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_ListServices{}})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, svc := range resp.GetListServicesResponse().GetService() {
+		names = append(names, svc.GetName())
+		resp, err := r.ask(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: svc.GetName()}})
+		if err != nil {
+			return nil, err
+		}
+		if err := r.add(resp); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.fetchDependencies(); err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, f := range r.files {
+		set.File = append(set.File, f)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("resolve reflected files: %w", err)
+	}
+	services := make([]protoreflect.ServiceDescriptor, 0, len(names))
+	for _, name := range names {
+		desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("resolve reflected service %s: %w", name, err)
+		}
+		svc, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("reflected symbol %s is not a service", name)
+		}
+		services = append(services, svc)
+	}
+	return services, nil
+}
+
+// reflector holds a server reflection stream and the files received on it,
+// by name.
+type reflector struct {
+	stream grpc.BidiStreamingClient[rpb.ServerReflectionRequest, rpb.ServerReflectionResponse]
+	files  map[string]*descriptorpb.FileDescriptorProto
+}
+
+// ask sends req and returns the answer, turning an error response into an
+// error.
+func (r *reflector) ask(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := r.stream.Send(req); err != nil {
+		return nil, fmt.Errorf("server reflection: %w", err)
+	}
+	resp, err := r.stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("server reflection: %w", err)
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, fmt.Errorf("server reflection: %s (code %d)", e.GetErrorMessage(), e.GetErrorCode())
+	}
+	return resp, nil
+}
+
+// add records the files of a file descriptor response.
+func (r *reflector) add(resp *rpb.ServerReflectionResponse) error {
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		f := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(raw, f); err != nil {
+			return fmt.Errorf("server reflection: decode file: %w", err)
+		}
+		r.files[f.GetName()] = f
+	}
+	return nil
+}
+
+// fetchDependencies requests the imports of the received files that the
+// server has not sent yet. Servers usually send them along, but need not.
+func (r *reflector) fetchDependencies() error {
+	for {
+		var missing []string
+		for _, f := range r.files {
+			for _, dep := range f.GetDependency() {
+				if r.files[dep] == nil {
+					missing = append(missing, dep)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		for _, name := range missing {
+			if r.files[name] != nil {
+				continue
+			}
+			resp, err := r.ask(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name}})
+			if err != nil {
+				return err
+			}
+			if err := r.add(resp); err != nil {
+				return err
+			}
+			if r.files[name] == nil {
+				return fmt.Errorf("server reflection: server did not return %s", name)
+			}
+		}
+	}
+}
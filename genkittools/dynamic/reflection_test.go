@@ -0,0 +1,113 @@
+package dynamic
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// serveWeather starts a gRPC server for the Weather service of weatherSet,
+// answering GetWeather like fakeWeather, with server reflection, and returns
+// a connection to it.
+func serveWeather(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	files, err := protodesc.NewFiles(weatherSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The reflection service describes itself too.
+	if err := files.RegisterFile(rpb.File_grpc_reflection_v1_reflection_proto); err != nil {
+		t.Fatal(err)
+	}
+	desc, err := files.FindDescriptorByName("weather.v1.Weather")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := desc.(protoreflect.ServiceDescriptor)
+
+	sd := &grpc.ServiceDesc{ServiceName: string(svc.FullName()), HandlerType: (*any)(nil)}
+	for i := 0; i < svc.Methods().Len(); i++ {
+		m := svc.Methods().Get(i)
+		sd.Methods = append(sd.Methods, grpc.MethodDesc{
+			MethodName: string(m.Name()),
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := dynamicpb.NewMessage(m.Input())
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				city := req.Get(m.Input().Fields().ByName("city")).String()
+				if city == "" {
+					return nil, status.Error(codes.InvalidArgument, "city is required")
+				}
+				resp := dynamicpb.NewMessage(m.Output())
+				resp.Set(m.Output().Fields().ByName("sky_cover"), protoreflect.ValueOfString("clear over "+city))
+				return resp, nil
+			},
+		})
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	s.RegisterService(sd, struct{}{})
+	rpb.RegisterServerReflectionServer(s, reflection.NewServerV1(reflection.ServerOptions{Services: s, DescriptorResolver: files}))
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestReflectServices(t *testing.T) {
+	conn := serveWeather(t)
+	ctx := context.Background()
+
+	services, err := ReflectServices(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, svc := range services {
+		names = append(names, string(svc.FullName()))
+	}
+	// The reflection service lists itself; it declares no tools.
+	if len(names) != 2 || names[0] != "grpc.reflection.v1.ServerReflection" || names[1] != "weather.v1.Weather" {
+		t.Fatalf("services = %v", names)
+	}
+
+	tools, err := NewTools(GRPCInvoker(conn), services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 2 || tools[0].Name() != "get_weather" {
+		t.Fatalf("tools = %v", tools)
+	}
+	out, err := tools[0].RunRaw(ctx, map[string]any{"city": "Lima"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := json.Marshal(out); string(raw) != `{"sky_cover":"clear over Lima"}` {
+		t.Fatalf("output = %s", raw)
+	}
+	if _, err := tools[0].RunRaw(ctx, map[string]any{"city": ""}); err == nil || !strings.Contains(err.Error(), "invalid_argument: city is required") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package genkittools
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// explainInputError adds the error_message annotated on the offending fields
+// to err, returned by the coercion function of info's tool: a *FieldError
+// gets the message of its field appended in parentheses, and each violation
+// of a *ValidationError the message of its field.
+func explainInputError(info *ToolInfo, err error) error {
+	if len(info.FieldErrorMessages) == 0 {
+		return err
+	}
+	if verr, ok := err.(*ValidationError); ok {
+		explained := &ValidationError{Tool: verr.Tool, Violations: make([]FieldViolation, len(verr.Violations))}
+		for i, v := range verr.Violations {
+			if msg := info.fieldErrorMessage(violationPointer(v.Field)); msg != "" {
+				v.Message += " (" + msg + ")"
+			}
+			explained.Violations[i] = v
+		}
+		return explained
+	}
+	var ferr *FieldError
+	if errors.As(err, &ferr) {
+		if msg := info.fieldErrorMessage(ferr.Pointer); msg != "" {
+			return fmt.Errorf("%w (%s)", err, msg)
+		}
+	}
+	return err
+}
+
+// fieldErrorMessage returns the message of the most specific pattern of
+// FieldErrorMessages matching pointer or one of its ancestors, so that the
+// message of a field also covers the items and fields below it.
+func (t *ToolInfo) fieldErrorMessage(pointer string) string {
+	var segments []string
+	if pointer != "" {
+		segments = strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	}
+	var msg string
+	longest := -1
+	for pattern, m := range t.FieldErrorMessages {
+		p := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+		if len(p) > len(segments) || len(p) <= longest {
+			continue
+		}
+		matched := true
+		for i, segment := range p {
+			if !segmentMatches(segment, strings.NewReplacer("~1", "/", "~0", "~").Replace(segments[i])) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			msg, longest = m, len(p)
+		}
+	}
+	return msg
+}
+
+// violationPointer converts the dotted field path of a FieldViolation, e.g.
+// invoice.line_items[0].quantity, into a JSON pointer.
+func violationPointer(field string) string {
+	var b strings.Builder
+	for field != "" {
+		switch field[0] {
+		case '.':
+			field = field[1:]
+		case '[':
+			end := strings.IndexByte(field, ']')
+			if end < 0 {
+				end = len(field)
+			}
+			key := field[1:end]
+			if unquoted, err := strconv.Unquote(key); err == nil {
+				key = unquoted
+			}
+			b.WriteString("/" + escapePointer(key))
+			field = field[min(end+1, len(field)):]
+		default:
+			end := strings.IndexAny(field, ".[")
+			if end < 0 {
+				end = len(field)
+			}
+			b.WriteString("/" + field[:end])
+			field = field[end:]
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInvokeExplainsFieldErrors(t *testing.T) {
+	info := &ToolInfo{Name: "plan_trip", FieldErrorMessages: map[string]string{
+		"/city":         "city must be a city name, not coordinates",
+		"/stops":        "stops must list the cities to visit in order",
+		"/stops/*/days": "days must be a whole number of days",
+	}}
+	call := func(context.Context, string) (bool, error) { return true, nil }
+
+	for _, tc := range []struct {
+		name   string
+		coerce func(any) (string, error)
+		want   string
+	}{
+		{
+			name: "decoding",
+			coerce: func(input any) (string, error) {
+				return DecodeString(input.(map[string]any)["city"], "/city")
+			},
+			want: "/city: expected string, got array (city must be a city name, not coordinates)",
+		},
+		{
+			name: "item of an annotated field",
+			coerce: func(any) (string, error) {
+				return "", decodeError("/stops/1", "object", "Paris")
+			},
+			want: "/stops/1: expected object, got string (stops must list the cities to visit in order)",
+		},
+		{
+			name: "most specific pattern",
+			coerce: func(any) (string, error) {
+				_, err := DecodeInt32("two", "/stops/0/days")
+				return "", err
+			},
+			want: `/stops/0/days: invalid integer "two" (days must be a whole number of days)`,
+		},
+		{
+			name: "unannotated field",
+			coerce: func(any) (string, error) {
+				return "", UnknownField("", "country")
+			},
+			want: `input: unknown field "country"`,
+		},
+		{
+			name: "validation",
+			coerce: func(any) (string, error) {
+				return "", &ValidationError{Tool: "plan_trip", Violations: []FieldViolation{
+					{Field: "city", Message: "value length must be at least 2 characters"},
+					{Field: "stops[0].days", Message: "value must be greater than 0"},
+					{Field: "budget", Message: "value is required"},
+				}}
+			},
+			want: "invalid plan_trip input: city: value length must be at least 2 characters (city must be a city name, not coordinates); " +
+				"stops[0].days: value must be greater than 0 (days must be a whole number of days); budget: value is required",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Invoke(context.Background(), nil, info, map[string]any{"city": []any{48.8, 2.3}}, tc.coerce, call)
+			if err == nil || err.Error() != tc.want {
+				t.Fatalf("Invoke error = %v, want %s", err, tc.want)
+			}
+		})
+	}
+
+	_, err := Invoke(context.Background(), nil, info, map[string]any{"city": 1.0}, func(input any) (string, error) {
+		return DecodeString(input.(map[string]any)["city"], "/city")
+	}, call)
+	var ferr *FieldError
+	if !errors.As(err, &ferr) || ferr.Pointer != "/city" {
+		t.Fatalf("Invoke error = %v, want a *FieldError for /city", err)
+	}
+}
+
+func TestViolationPointer(t *testing.T) {
+	for field, want := range map[string]string{
+		"":                               "",
+		"city":                           "/city",
+		"invoice.line_items[0].quantity": "/invoice/line_items/0/quantity",
+		`labels["a/b"].value`:            "/labels/a~1b/value",
+	} {
+		if got := violationPointer(field); got != want {
+			t.Errorf("violationPointer(%q) = %q, want %q", field, got, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package genkittools
+
+import (
+	"sort"
+	"strings"
+)
+
+// FieldViolation explains why a single input field was rejected.
+type FieldViolation struct {
+	// Field is the path of the offending field, e.g. "invoice.line_items[0].quantity".
+	Field string
+	// Message describes the problem in terms a model can act on.
+	Message string
+}
+
+// ValidationError is returned by generated handlers when tool input fails
+// validation. Its message lists every offending field so the model can retry
+// the call with corrected arguments.
+type ValidationError struct {
+	Tool       string
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid ")
+	b.WriteString(e.Tool)
+	b.WriteString(" input")
+	for i, v := range e.Violations {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		if v.Field != "" {
+			b.WriteString(v.Field)
+			b.WriteString(": ")
+		}
+		b.WriteString(v.Message)
+	}
+	return b.String()
+}
+
+// FieldError is returned by the Decode functions and Coerce when a value of
+// the input cannot be decoded into its field. Invoke adds the error_message
+// annotated on the field, if any, so that the model can correct the value on
+// retry.
+type FieldError struct {
+	// Pointer is the JSON pointer of the offending value, "" for the whole
+	// input.
+	Pointer string
+	Err     error
+}
+
+func (e *FieldError) Error() string {
+	return describePointer(e.Pointer) + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// RemoteError is returned by generated client adapters when the backend
+// rejects a call, and by handlers for implementation errors carrying a gRPC
+// status. Its message carries only the RPC code, the backend's message and
+// the BadRequest and ErrorInfo details, without transport details, so the
+// model can act on it.
+type RemoteError struct {
+	// Code is the RPC status code in lower snake case, e.g. "not_found".
+	Code    string
+	Message string
+	// Violations lists the fields rejected by a google.rpc.BadRequest detail,
+	// so the model can correct them on retry.
+	Violations []FieldViolation
+	// Reason, Domain and Metadata come from a google.rpc.ErrorInfo detail.
+	Reason   string
+	Domain   string
+	Metadata map[string]string
+	// Err is the error returned by the client.
+	Err error
+}
+
+func (e *RemoteError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Code)
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+	for _, v := range e.Violations {
+		b.WriteString("; ")
+		if v.Field != "" {
+			b.WriteString(v.Field)
+			b.WriteString(": ")
+		}
+		b.WriteString(v.Message)
+	}
+	if e.Reason != "" {
+		b.WriteString("; reason ")
+		b.WriteString(e.Reason)
+		if e.Domain != "" {
+			b.WriteString(" (")
+			b.WriteString(e.Domain)
+			b.WriteString(")")
+		}
+		keys := make([]string, 0, len(e.Metadata))
+		for k := range e.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString(" ")
+			b.WriteString(k)
+			b.WriteString("=")
+			b.WriteString(e.Metadata[k])
+		}
+	}
+	return b.String()
+}
+
+func (e *RemoteError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,34 @@
+package genkittools
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidationErrorListsFields(t *testing.T) {
+	err := &ValidationError{
+		Tool: "get_weather",
+		Violations: []FieldViolation{
+			{Field: "city", Message: "value is required"},
+			{Field: "days", Message: "value must be less than 15"},
+		},
+	}
+	want := "invalid get_weather input: city: value is required; days: value must be less than 15"
+	if got := err.Error(); got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestRemoteError(t *testing.T) {
+	cause := errors.New("unavailable: dial tcp 10.0.0.1:443: connect: connection refused")
+	err := &RemoteError{Code: "unavailable", Message: "backend is restarting", Err: cause}
+	if got, want := err.Error(), "unavailable: backend is restarting"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, cause) {
+		t.Fatal("RemoteError does not unwrap to the client error")
+	}
+	if got := (&RemoteError{Code: "internal"}).Error(); got != "internal" {
+		t.Fatalf("Error() without message = %q", got)
+	}
+}
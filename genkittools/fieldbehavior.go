@@ -0,0 +1,118 @@
+package genkittools
+
+import (
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/internal/schemautil"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldBehaviorNumber is the number of the google.api.field_behavior
+// option, decoded from the raw option bytes.
+const fieldBehaviorNumber = 1052
+
+// fieldBehaviorOutputOnly is google.api.FieldBehavior.OUTPUT_ONLY.
+const fieldBehaviorOutputOnly = 3
+
+// outputOnly reports whether field is annotated with
+// (google.api.field_behavior) = OUTPUT_ONLY. Requests leave such fields to
+// the server: they are left out of input schemas and ignored when decoding
+// model input.
+func outputOnly(field protoreflect.FieldDescriptor) bool {
+	opts := field.Options()
+	if opts == nil {
+		return false
+	}
+	for b := opts.ProtoReflect().GetUnknown(); len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return false
+		}
+		if num == fieldBehaviorNumber {
+			switch typ {
+			case protowire.VarintType:
+				if v, _ := protowire.ConsumeVarint(b); v == fieldBehaviorOutputOnly {
+					return true
+				}
+			case protowire.BytesType:
+				// Packed.
+				packed, _ := protowire.ConsumeBytes(b)
+				for len(packed) > 0 {
+					v, k := protowire.ConsumeVarint(packed)
+					if k < 0 {
+						break
+					}
+					if v == fieldBehaviorOutputOnly {
+						return true
+					}
+					packed = packed[k:]
+				}
+			}
+		}
+		b = b[m:]
+	}
+	return false
+}
+
+// OmitOutputOnly removes the fields annotated with
+// (google.api.field_behavior) = OUTPUT_ONLY from schema, a schema of msg as
+// built by MessageSchema or MessageSchemaForDraft, including those of the
+// messages below msg, and returns it. Input schemas are built this way:
+// the server sets such fields, so the model is not asked for them.
+func OmitOutputOnly(schema map[string]any, msg protoreflect.MessageDescriptor) map[string]any {
+	omitOutputOnlyFields(schema, msg)
+	for _, keyword := range []string{"$defs", "definitions"} {
+		defs, _ := schema[keyword].(map[string]any)
+		for name, def := range defs {
+			if defMsg := schemautil.FindMessage(msg, protoreflect.FullName(name), nil); defMsg != nil {
+				omitOutputOnlyFields(def.(map[string]any), defMsg)
+			}
+		}
+	}
+	return schema
+}
+
+func omitOutputOnlyFields(schema map[string]any, msg protoreflect.MessageDescriptor) {
+	if wellKnownSchema(msg.FullName()) != nil {
+		return
+	}
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		// A recursive reference.
+		return
+	}
+	var omitted []string
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		prop, ok := props[string(field.Name())].(map[string]any)
+		if !ok {
+			continue
+		}
+		if outputOnly(field) {
+			omitted = append(omitted, string(field.Name()))
+			continue
+		}
+		switch {
+		case field.IsMap():
+			if value := field.MapValue(); value.Message() != nil {
+				if item, ok := prop["additionalProperties"].(map[string]any); ok {
+					omitOutputOnlyFields(item, value.Message())
+				}
+			}
+		case field.Message() == nil:
+		case field.IsList():
+			if item, ok := prop["items"].(map[string]any); ok {
+				omitOutputOnlyFields(item, field.Message())
+			}
+		default:
+			omitOutputOnlyFields(prop, field.Message())
+		}
+	}
+	if len(omitted) > 0 {
+		OmitFields(schema, omitted...)
+	}
+}
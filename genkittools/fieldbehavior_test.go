@@ -0,0 +1,67 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestOutputOnlyFields(t *testing.T) {
+	// google.api.field_behavior is written unpacked by protoc; packed
+	// encodings are accepted too.
+	unpacked := &descriptorpb.FieldOptions{}
+	unpacked.ProtoReflect().SetUnknown(protowire.AppendVarint(protowire.AppendTag(nil, fieldBehaviorNumber, protowire.VarintType), fieldBehaviorOutputOnly))
+	packed := &descriptorpb.FieldOptions{}
+	packed.ProtoReflect().SetUnknown(protowire.AppendBytes(protowire.AppendTag(nil, fieldBehaviorNumber, protowire.BytesType), []byte{2, fieldBehaviorOutputOnly}))
+
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("ticket.proto"),
+		Package: proto.String("ticket.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Ticket"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("id"), Number: proto.Int32(1), Type: str, Label: optional, JsonName: proto.String("id"), Options: unpacked},
+				{Name: proto.String("title"), Number: proto.Int32(2), Type: str, Label: optional, JsonName: proto.String("title")},
+				{Name: proto.String("notes"), Number: proto.Int32(3), Type: msg, TypeName: proto.String(".ticket.v1.Note"), Label: repeated, JsonName: proto.String("notes")},
+			},
+		}, {
+			Name: proto.String("Note"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("text"), Number: proto.Int32(1), Type: str, Label: optional, JsonName: proto.String("text")},
+				{Name: proto.String("author"), Number: proto.Int32(2), Type: str, Label: optional, JsonName: proto.String("author"), Options: packed},
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket := fd.Messages().ByName("Ticket")
+
+	raw, _ := json.Marshal(OmitOutputOnly(MessageSchema(ticket), ticket))
+	if want := `{"properties":{"notes":{"items":{"properties":{"text":{"type":"string"}},"type":"object"},"type":"array"},"title":{"type":"string"}},"type":"object"}`; string(raw) != want {
+		t.Errorf("input schema = %s, want %s", raw, want)
+	}
+
+	// Values the model makes up for them are dropped.
+	got := dynamicpb.NewMessage(ticket)
+	input := map[string]any{"id": "T-1", "title": "Printer jam", "notes": []any{map[string]any{"text": "Tray 2", "author": "bob"}}}
+	if err := DecodeProto(input, "", got); err != nil {
+		t.Fatal(err)
+	}
+	fields := ticket.Fields()
+	note := got.Get(fields.ByName("notes")).List().Get(0).Message()
+	if got.Has(fields.ByName("id")) || got.Get(fields.ByName("title")).String() != "Printer jam" ||
+		note.Has(note.Descriptor().Fields().ByName("author")) || note.Get(note.Descriptor().Fields().ByName("text")).String() != "Tray 2" {
+		t.Errorf("decoded = %v", got)
+	}
+}
@@ -0,0 +1,61 @@
+// Package genkitcompat is the thin layer between code generated with
+// genkit_api=compat and the Genkit Go SDK. Generated code defines, creates
+// and looks up its tools and resources only through these functions, whose signatures stay
+// put while the SDK's APIs shift between minor versions: when they change,
+// this package follows them, and upgrading the module holding genkittools
+// is enough, without regenerating the protos.
+//
+// It targets the Genkit release required by that module.
+package genkitcompat
+
+import (
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// DefineTool defines a tool in g taking inputs described by inputSchema. A
+// nil inputSchema lets Genkit derive the schema from In.
+func DefineTool[In, Out any](g *genkit.Genkit, name, description string, inputSchema map[string]any, fn ai.ToolFunc[In, Out]) ai.Tool {
+	return genkit.DefineTool(g, name, description, fn, toolOptions(inputSchema)...)
+}
+
+// DefineMultipartTool defines a tool in g returning media parts alongside
+// its output, like DefineTool.
+func DefineMultipartTool[In any](g *genkit.Genkit, name, description string, inputSchema map[string]any, fn ai.MultipartToolFunc[In]) ai.Tool {
+	return genkit.DefineMultipartTool(g, name, description, fn, toolOptions(inputSchema)...)
+}
+
+// NewTool returns an unregistered tool, which Genkit registers dynamically
+// when it is passed to ai.WithTools, like DefineTool.
+func NewTool[In, Out any](name, description string, inputSchema map[string]any, fn ai.ToolFunc[In, Out]) ai.Tool {
+	return ai.NewTool(name, description, fn, toolOptions(inputSchema)...)
+}
+
+// NewMultipartTool returns an unregistered tool, like NewTool, returning
+// media parts alongside its output.
+func NewMultipartTool[In any](name, description string, inputSchema map[string]any, fn ai.MultipartToolFunc[In]) ai.Tool {
+	return ai.NewMultipartTool(name, description, fn, toolOptions(inputSchema)...)
+}
+
+// DefineResource defines a resource in g.
+func DefineResource(g *genkit.Genkit, name string, opts *ai.ResourceOptions, fn ai.ResourceFunc) ai.Resource {
+	return genkit.DefineResource(g, name, opts, fn)
+}
+
+// NewResource returns an unregistered resource, which Genkit registers
+// dynamically when it is passed to ai.WithResources.
+func NewResource(name string, opts *ai.ResourceOptions, fn ai.ResourceFunc) ai.Resource {
+	return ai.NewResource(name, opts, fn)
+}
+
+// LookupTool returns the tool called name defined in g, or nil.
+func LookupTool(g *genkit.Genkit, name string) ai.Tool {
+	return genkit.LookupTool(g, name)
+}
+
+func toolOptions(inputSchema map[string]any) []ai.ToolOption {
+	if inputSchema == nil {
+		return nil
+	}
+	return []ai.ToolOption{ai.WithInputSchema(inputSchema)}
+}
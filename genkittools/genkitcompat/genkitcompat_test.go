@@ -0,0 +1,56 @@
+package genkitcompat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+type echoInput struct {
+	Text string `json:"text" jsonschema_description:"Text to echo."`
+}
+
+func TestDefineTool(t *testing.T) {
+	g := genkit.Init(context.Background())
+	schema := map[string]any{"type": "object", "properties": map[string]any{"text": map[string]any{"type": "string"}}}
+	DefineTool(g, "echo", "Echo text.", schema, func(_ *ai.ToolContext, input any) (any, error) {
+		return input, nil
+	})
+	DefineTool(g, "echo_typed", "Echo text.", nil, func(_ *ai.ToolContext, in echoInput) (string, error) {
+		return in.Text, nil
+	})
+
+	tool := LookupTool(g, "echo")
+	if tool == nil {
+		t.Fatal("echo is not defined")
+	}
+	if got := tool.Definition().InputSchema["properties"]; got == nil {
+		t.Errorf("echo schema = %v, want the given schema", tool.Definition().InputSchema)
+	}
+	out, err := LookupTool(g, "echo_typed").RunRaw(context.Background(), map[string]any{"text": "hi"})
+	if err != nil || out != "hi" {
+		t.Fatalf("echo_typed = %v, %v", out, err)
+	}
+	props, _ := LookupTool(g, "echo_typed").Definition().InputSchema["properties"].(map[string]any)
+	if _, ok := props["text"]; !ok {
+		t.Errorf("echo_typed schema = %v, want one derived from echoInput", props)
+	}
+	if LookupTool(g, "missing") != nil {
+		t.Error("LookupTool(missing) != nil")
+	}
+}
+
+func TestNewTool(t *testing.T) {
+	tool := NewTool("echo", "Echo text.", nil, func(_ *ai.ToolContext, in echoInput) (string, error) {
+		return in.Text, nil
+	})
+	if tool.Name() != "echo" {
+		t.Fatalf("Name = %q", tool.Name())
+	}
+	out, err := tool.RunRaw(context.Background(), map[string]any{"text": "hi"})
+	if err != nil || out != "hi" {
+		t.Fatalf("RunRaw = %v, %v", out, err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package genkittools holds the runtime helpers shared by code generated with
+// protoc-gen-go-genkit-tools.
+package genkittools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ValidateAgainstProto reports whether input decodes into msg the way the
+// generated tool handlers decode model input: input is re-encoded as JSON,
+// decoded by encoding/json as it would arrive from the model, and decoded
+// into msg field by field with DecodeProto. Teams that keep hand-written
+// Genkit tools next to generated ones can use it in tests to make sure their
+// custom schemas still produce valid requests.
+func ValidateAgainstProto(input map[string]any, msg proto.Message) error {
+	if msg == nil {
+		return fmt.Errorf("validate input: nil target message")
+	}
+	name := msg.ProtoReflect().Descriptor().FullName()
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("marshal %s input: %w", name, err)
+	}
+	var obj any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return fmt.Errorf("unmarshal %s input: %w", name, err)
+	}
+	if err := DecodeProto(obj, "", msg.ProtoReflect()); err != nil {
+		return fmt.Errorf("decode %s input: %w", name, err)
+	}
+	return nil
+}
+
+// CheckRoundTrip encodes want in the shape its tool input schema describes,
+// protojson keyed by proto field name as decoded by encoding/json, passes it
+// to coerce and reports an error unless the result equals want. The tests
+// generated with coercion_tests=true run it for every tool.
+func CheckRoundTrip[M proto.Message](want M, coerce func(input any) (M, error)) error {
+	raw, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(want)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", want.ProtoReflect().Descriptor().FullName(), err)
+	}
+	var input map[string]any
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return err
+	}
+	got, err := coerce(input)
+	if err != nil {
+		return fmt.Errorf("coerce %s: %w", raw, err)
+	}
+	if !proto.Equal(got, want) {
+		return fmt.Errorf("coerce %s: got %v, want %v", raw, got, want)
+	}
+	return nil
+}
+
+// CheckCoerce passes input, arbitrary JSON as decoded by encoding/json, to
+// coerce and reports an error when coerce misbehaves: a rejection must come
+// with a readable message, valid UTF-8, and an accepted input must yield a
+// message that can be marshaled, e.g. without invalid UTF-8 in proto3
+// strings. Panics are left to the caller. The fuzz targets generated with
+// fuzz_tests=true run it on every input.
+func CheckCoerce[M proto.Message](input any, coerce func(input any) (M, error)) error {
+	msg, err := coerce(input)
+	if err != nil {
+		if text := err.Error(); text == "" || !utf8.ValidString(text) {
+			return fmt.Errorf("coerce rejected the input with the unreadable error %q", text)
+		}
+		return nil
+	}
+	if msg.ProtoReflect() == nil || !msg.ProtoReflect().IsValid() {
+		return errors.New("coerce accepted the input but returned no message")
+	}
+	if _, err := (proto.MarshalOptions{AllowPartial: true}).Marshal(msg); err != nil {
+		return fmt.Errorf("coerce accepted the input but produced an unmarshalable message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package genkittools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestValidateAgainstProto(t *testing.T) {
+	var field descriptorpb.FieldDescriptorProto
+	if err := ValidateAgainstProto(map[string]any{"name": "city", "number": 1, "label": "LABEL_OPTIONAL"}, &field); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if field.GetName() != "city" || field.GetNumber() != 1 {
+		t.Fatalf("decoded %v, want name=city number=1", &field)
+	}
+}
+
+func TestValidateAgainstProtoRejectsUnknownField(t *testing.T) {
+	err := ValidateAgainstProto(map[string]any{"nope": true}, &descriptorpb.FieldDescriptorProto{})
+	if err == nil || !strings.Contains(err.Error(), "google.protobuf.FieldDescriptorProto") {
+		t.Fatalf("expected descriptive error, got %v", err)
+	}
+}
+
+func TestValidateAgainstProtoReportsFirstInvalidField(t *testing.T) {
+	input := map[string]any{"number": "x", "name": 1, "type_name": true}
+	for range 10 {
+		err := ValidateAgainstProto(input, &descriptorpb.FieldDescriptorProto{})
+		if err == nil || err.Error() != "decode google.protobuf.FieldDescriptorProto input: /name: expected string, got integer" {
+			t.Fatalf("err = %v, want the error of the first key", err)
+		}
+	}
+}
+
+func TestCheckRoundTrip(t *testing.T) {
+	want := &descriptorpb.FieldDescriptorProto{Name: proto.String("city"), Number: proto.Int32(1), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()}
+	var input any
+	coerce := func(in any) (*descriptorpb.FieldDescriptorProto, error) {
+		input = in
+		return Coerce(in, "field", &descriptorpb.FieldDescriptorProto{})
+	}
+	if err := CheckRoundTrip(want, coerce); err != nil {
+		t.Fatal(err)
+	}
+	if obj, ok := input.(map[string]any); !ok || obj["label"] != "LABEL_REPEATED" {
+		t.Fatalf("coerce received %#v, want the schema's JSON shape", input)
+	}
+
+	lossy := func(in any) (*descriptorpb.FieldDescriptorProto, error) {
+		return &descriptorpb.FieldDescriptorProto{Name: proto.String("city")}, nil
+	}
+	if err := CheckRoundTrip(want, lossy); err == nil || !strings.Contains(err.Error(), `coerce {"name":"city"`) {
+		t.Fatalf("err = %v, want a mismatch naming the input", err)
+	}
+}
+
+func TestCheckCoerce(t *testing.T) {
+	coerce := func(in any) (*descriptorpb.FieldDescriptorProto, error) {
+		return Coerce(in, "field", &descriptorpb.FieldDescriptorProto{})
+	}
+	for _, input := range []any{
+		map[string]any{"name": "city"},
+		map[string]any{"number": "x"},
+		[]any{1, "two"},
+		nil,
+	} {
+		if err := CheckCoerce(input, coerce); err != nil {
+			t.Errorf("CheckCoerce(%v) = %v", input, err)
+		}
+	}
+
+	silent := func(any) (*descriptorpb.FieldDescriptorProto, error) { return nil, errors.New("") }
+	if err := CheckCoerce(map[string]any{}, silent); err == nil {
+		t.Error("accepted an empty error message")
+	}
+	empty := func(any) (*descriptorpb.FieldDescriptorProto, error) { return nil, nil }
+	if err := CheckCoerce(map[string]any{}, empty); err == nil {
+		t.Error("accepted a nil message")
+	}
+	// Proto3 strings must hold valid UTF-8.
+	invalid := func(any) (*structpb.Value, error) { return structpb.NewStringValue("\xff"), nil }
+	if err := CheckCoerce(map[string]any{}, invalid); err == nil || !strings.Contains(err.Error(), "unmarshalable") {
+		t.Errorf("err = %v, want an unmarshalable message error", err)
+	}
+}
@@ -0,0 +1,45 @@
+package genkittools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// UpdateGoldenEnv names the environment variable that makes CompareGolden,
+// and so the generated schema tests, rewrite golden files instead of checking
+// them.
+const UpdateGoldenEnv = "GENKIT_TOOLS_UPDATE_GOLDEN"
+
+// CompareGolden checks that the indented JSON encoding of v matches the golden
+// file at path. When the UpdateGoldenEnv environment variable is set the file
+// is written instead, creating its directory as needed.
+func CompareGolden(path string, v any) error {
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	got = append(got, '\n')
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, got, 0o644)
+	}
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("golden file %s does not exist; run the test with %s=1 to create it", path, UpdateGoldenEnv)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("%s is out of date; review the change and run the test with %s=1 to accept it\ngot:\n%s\nwant:\n%s", path, UpdateGoldenEnv, got, want)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package genkittools
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "tool.input.schema.json")
+	schema := map[string]any{"type": "object"}
+
+	if err := CompareGolden(path, schema); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("missing golden = %v", err)
+	}
+
+	t.Setenv(UpdateGoldenEnv, "1")
+	if err := CompareGolden(path, schema); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	t.Setenv(UpdateGoldenEnv, "")
+	if err := CompareGolden(path, schema); err != nil {
+		t.Fatalf("matching golden = %v", err)
+	}
+	if err := CompareGolden(path, map[string]any{"type": "string"}); err == nil || !strings.Contains(err.Error(), "out of date") {
+		t.Fatalf("drifted golden = %v", err)
+	}
+}
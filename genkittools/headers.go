@@ -0,0 +1,71 @@
+package genkittools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// HeaderFunc returns the value of an outgoing header for a backend call made
+// in ctx, the context of a tool call, and false to leave the header out.
+type HeaderFunc func(ctx context.Context) (string, bool)
+
+// Headers maps header names to the functions reading their values, for the
+// generated gRPC and Connect adapters to add to every backend call, e.g.
+// authorization tokens and request IDs:
+//
+//	genkittools.Headers{
+//		"authorization": genkittools.HeaderFromMetadata("token"),
+//		"x-request-id":  genkittools.HeaderFromValue(requestIDKey{}),
+//	}
+type Headers map[string]HeaderFunc
+
+// HeaderFromMetadata reads the header from the caller metadata attached with
+// ContextWithMetadata under key.
+func HeaderFromMetadata(key string) HeaderFunc {
+	return func(ctx context.Context) (string, bool) {
+		return MetadataFromContext(ctx, key)
+	}
+}
+
+// HeaderFromValue reads the header from the value stored in the context under
+// key with context.WithValue, which must be a string or a fmt.Stringer.
+func HeaderFromValue(key any) HeaderFunc {
+	return func(ctx context.Context) (string, bool) {
+		switch v := ctx.Value(key).(type) {
+		case string:
+			return v, true
+		case fmt.Stringer:
+			return v.String(), true
+		}
+		return "", false
+	}
+}
+
+// Pairs returns the headers set for ctx as alternating names and values,
+// sorted by name, as grpc/metadata.AppendToOutgoingContext takes them.
+// Headers without a value or with an empty one are left out.
+func (h Headers) Pairs(ctx context.Context) []string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		if v, ok := h[name](ctx); ok && v != "" {
+			pairs = append(pairs, name, v)
+		}
+	}
+	return pairs
+}
+
+// Apply sets the headers set for ctx in header, such as the request header
+// of a Connect call.
+func (h Headers) Apply(ctx context.Context, header http.Header) {
+	pairs := h.Pairs(ctx)
+	for i := 0; i < len(pairs); i += 2 {
+		header.Set(pairs[i], pairs[i+1])
+	}
+}
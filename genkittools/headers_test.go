@@ -0,0 +1,42 @@
+package genkittools
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type requestIDKey struct{}
+
+type requestID string
+
+func (id requestID) String() string { return "req-" + string(id) }
+
+func TestHeaders(t *testing.T) {
+	headers := Headers{
+		"authorization": HeaderFromMetadata("token"),
+		"x-request-id":  HeaderFromValue(requestIDKey{}),
+		"x-tenant":      HeaderFromMetadata("tenant"),
+	}
+
+	ctx := ContextWithMetadata(context.Background(), map[string]string{"token": "Bearer t", "tenant": ""})
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID("7"))
+	want := []string{"authorization", "Bearer t", "x-request-id", "req-7"}
+	if got := headers.Pairs(ctx); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Pairs = %q, want %q", got, want)
+	}
+
+	header := http.Header{}
+	headers.Apply(ctx, header)
+	if header.Get("Authorization") != "Bearer t" || header.Get("X-Request-Id") != "req-7" || len(header) != 2 {
+		t.Fatalf("Apply set %v", header)
+	}
+
+	if got := headers.Pairs(context.Background()); got != nil {
+		t.Fatalf("Pairs without values = %q, want none", got)
+	}
+	if got := Headers(nil).Pairs(ctx); got != nil {
+		t.Fatalf("nil Headers Pairs = %q", got)
+	}
+}
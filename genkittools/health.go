@@ -0,0 +1,59 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// HealthStatus is the output of a generated <service>_health tool.
+type HealthStatus struct {
+	Service string `json:"service"`
+	Healthy bool   `json:"healthy"`
+	// Error is why the backend is unhealthy.
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// HealthInputSchema returns the input schema of a health tool, which takes
+// no arguments.
+func HealthInputSchema() map[string]any {
+	return map[string]any{"type": "object", "properties": map[string]any{}}
+}
+
+// HealthOutputSchema returns the schema of HealthStatus.
+func HealthOutputSchema() map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"service", "healthy", "latency_ms"},
+		"properties": map[string]any{
+			"service":    map[string]any{"type": "string", "description": "The fully-qualified name of the proto service."},
+			"healthy":    map[string]any{"type": "boolean", "description": "Whether the backend answered."},
+			"error":      map[string]any{"type": "string", "description": "Why the backend is unhealthy."},
+			"latency_ms": map[string]any{"type": "integer", "description": "How long the check took, in milliseconds."},
+		},
+	}
+}
+
+// CheckHealth runs the call of the health tool described by info through
+// Invoke, so that it is logged, measured, audited and authorized like any
+// other tool, and reports whether ping succeeded. A failing ping yields an
+// unhealthy status rather than an error, which is kept for denied calls.
+func CheckHealth(ctx context.Context, o *Options, info *ToolInfo, input any, ping func(context.Context) error) (*HealthStatus, error) {
+	start := time.Now()
+	_, err := Invoke(ctx, o, info, input,
+		func(any) (struct{}, error) { return struct{}{}, nil },
+		func(ctx context.Context, _ struct{}) (struct{}, error) { return struct{}{}, ping(ctx) })
+	if errors.Is(err, ErrToolDenied) {
+		return nil, err
+	}
+	status := &HealthStatus{
+		Service:   info.Service,
+		Healthy:   err == nil,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status, nil
+}
@@ -0,0 +1,35 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheckHealth(t *testing.T) {
+	info := &ToolInfo{Name: "catalog_health", Service: "catalog.Catalog"}
+	m := &fakeMetrics{}
+	o := NewOptions(WithMetrics(m))
+
+	status, err := CheckHealth(context.Background(), o, info, map[string]any{}, func(context.Context) error { return nil })
+	if err != nil || !status.Healthy || status.Service != "catalog.Catalog" || status.Error != "" {
+		t.Fatalf("CheckHealth = %+v, %v", status, err)
+	}
+
+	status, err = CheckHealth(context.Background(), o, info, nil, func(context.Context) error { return errors.New("connection refused") })
+	if err != nil || status.Healthy || status.Error != "connection refused" {
+		t.Fatalf("CheckHealth of a failing backend = %+v, %v", status, err)
+	}
+	if len(m.calls) != 2 || m.calls[0].err != nil || m.calls[1].err == nil {
+		t.Fatalf("metrics observed %v", m.calls)
+	}
+
+	deny := NewOptions(WithAuthorizer(func(context.Context, string, any) error { return errors.New("operators only") }))
+	if _, err := CheckHealth(context.Background(), deny, info, nil, func(context.Context) error { return nil }); !errors.Is(err, ErrToolDenied) {
+		t.Fatalf("denied CheckHealth error = %v", err)
+	}
+
+	if errs := Validate(HealthOutputSchema(), status); len(errs) != 0 {
+		t.Fatalf("HealthStatus does not match its schema: %v", errs)
+	}
+}
@@ -0,0 +1,130 @@
+package genkittools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// WithRequestHook runs hook on every decoded request of type Req, after
+// context-bound fields are filled and before the call is authorized, so apps
+// can normalize inputs without touching the implementation. Req is a
+// generated request type such as *catalog.GetWeatherRequest, which limits
+// the hook to the tools taking it, or proto.Message for every tool. The
+// requests of client-streaming tools are passed one by one. A non-nil error
+// fails the call.
+func WithRequestHook[Req proto.Message](hook func(ctx context.Context, tool string, req Req) error) Option {
+	return func(o *Options) {
+		o.requestHooks = append(o.requestHooks, func(ctx context.Context, tool string, msg proto.Message) error {
+			if req, ok := msg.(Req); ok {
+				return hook(ctx, tool, req)
+			}
+			return nil
+		})
+	}
+}
+
+// WithResponseHook runs hook on every response of type Resp returned by the
+// implementation and uses its result instead, e.g. to trim fields the model
+// does not need. Like WithRequestHook, Resp selects the tools it applies to.
+// The messages of server-streaming tools are passed one by one once the
+// stream ends; forwarded chunks are not. Hooks run before results are
+// rendered for result_format and before they are cached. A non-nil error
+// fails the call.
+func WithResponseHook[Resp proto.Message](hook func(ctx context.Context, tool string, resp Resp) (Resp, error)) Option {
+	return func(o *Options) {
+		o.responseHooks = append(o.responseHooks, func(ctx context.Context, tool string, msg proto.Message) (proto.Message, error) {
+			if resp, ok := msg.(Resp); ok {
+				return hook(ctx, tool, resp)
+			}
+			return msg, nil
+		})
+	}
+}
+
+// runRequestHooks applies the request hooks to req, a message or a slice of
+// messages.
+func (o *Options) runRequestHooks(ctx context.Context, info *ToolInfo, req any) error {
+	if o == nil || len(o.requestHooks) == 0 {
+		return nil
+	}
+	for _, msg := range messages(req) {
+		for _, hook := range o.requestHooks {
+			if err := hook(ctx, info.Name, msg); err != nil {
+				return fmt.Errorf("%s: %w", info.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RunResponseHooks applies the hooks set with WithResponseHook to resp, a
+// message or a slice of messages. Invoke calls it on every result;
+// generated handlers also call it before rendering responses as text.
+func RunResponseHooks[Resp any](ctx context.Context, o *Options, info *ToolInfo, resp Resp) (Resp, error) {
+	if o == nil || len(o.responseHooks) == 0 {
+		return resp, nil
+	}
+	if msg, ok := any(resp).(proto.Message); ok {
+		out, err := o.hookResponse(ctx, info, msg)
+		if err != nil {
+			return resp, err
+		}
+		hooked, ok := out.(Resp)
+		if !ok {
+			return resp, fmt.Errorf("%s: response hook returned %T, want %T", info.Name, out, resp)
+		}
+		return hooked, nil
+	}
+	rv := reflect.ValueOf(resp)
+	if rv.Kind() != reflect.Slice {
+		return resp, nil
+	}
+	for i := 0; i < rv.Len(); i++ {
+		msg, ok := rv.Index(i).Interface().(proto.Message)
+		if !ok {
+			continue
+		}
+		out, err := o.hookResponse(ctx, info, msg)
+		if err != nil {
+			return resp, err
+		}
+		ov := reflect.ValueOf(out)
+		if !ov.IsValid() || !ov.Type().AssignableTo(rv.Type().Elem()) {
+			return resp, fmt.Errorf("%s: response hook returned %T, want %s", info.Name, out, rv.Type().Elem())
+		}
+		rv.Index(i).Set(ov)
+	}
+	return resp, nil
+}
+
+func (o *Options) hookResponse(ctx context.Context, info *ToolInfo, msg proto.Message) (proto.Message, error) {
+	for _, hook := range o.responseHooks {
+		var err error
+		if msg, err = hook(ctx, info.Name, msg); err != nil {
+			return nil, fmt.Errorf("%s: %w", info.Name, err)
+		}
+	}
+	return msg, nil
+}
+
+// messages returns v if it is a message, or the messages of v if it is a
+// slice.
+func messages(v any) []proto.Message {
+	if msg, ok := v.(proto.Message); ok {
+		return []proto.Message{msg}
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+	var out []proto.Message
+	for i := 0; i < rv.Len(); i++ {
+		if msg, ok := rv.Index(i).Interface().(proto.Message); ok {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestRequestAndResponseHooks(t *testing.T) {
+	var seen []string
+	o := NewOptions(
+		WithRequestHook(func(_ context.Context, tool string, req *wrapperspb.StringValue) error {
+			req.Value = strings.TrimSpace(req.GetValue())
+			return nil
+		}),
+		WithRequestHook(func(_ context.Context, tool string, req proto.Message) error {
+			seen = append(seen, tool)
+			return nil
+		}),
+		WithResponseHook(func(_ context.Context, tool string, resp *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+			return wrapperspb.String(strings.ToUpper(resp.GetValue())), nil
+		}),
+		// Not a response type of these tools.
+		WithResponseHook(func(context.Context, string, *wrapperspb.Int64Value) (*wrapperspb.Int64Value, error) {
+			return nil, errors.New("unexpected call")
+		}),
+	)
+	info := &ToolInfo{Name: "echo"}
+	coerce := func(input any) (*wrapperspb.StringValue, error) { return wrapperspb.String(input.(string)), nil }
+	echo := func(_ context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) { return req, nil }
+
+	got, err := Invoke(context.Background(), o, info, "  paris ", coerce, echo)
+	if err != nil || got.GetValue() != "PARIS" {
+		t.Fatalf("Invoke = %v, %v", got, err)
+	}
+
+	list, err := Invoke(context.Background(), o, &ToolInfo{Name: "echo_all"}, "oslo", coerce,
+		func(_ context.Context, req *wrapperspb.StringValue) ([]*wrapperspb.StringValue, error) {
+			return []*wrapperspb.StringValue{req, wrapperspb.String("rome")}, nil
+		})
+	if err != nil || len(list) != 2 || list[0].GetValue() != "OSLO" || list[1].GetValue() != "ROME" {
+		t.Fatalf("Invoke list = %v, %v", list, err)
+	}
+	if strings.Join(seen, ",") != "echo,echo_all" {
+		t.Fatalf("proto.Message hook saw %v", seen)
+	}
+}
+
+func TestHookErrorsFailTheCall(t *testing.T) {
+	rejected := errors.New("rejected")
+	info := &ToolInfo{Name: "echo"}
+	coerce := func(input any) (*wrapperspb.StringValue, error) { return wrapperspb.String(input.(string)), nil }
+
+	calls := 0
+	echo := func(_ context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+		calls++
+		return req, nil
+	}
+	o := NewOptions(WithRequestHook(func(context.Context, string, *wrapperspb.StringValue) error { return rejected }))
+	if _, err := Invoke(context.Background(), o, info, "x", coerce, echo); !errors.Is(err, rejected) || calls != 0 {
+		t.Fatalf("request hook: err=%v calls=%d", err, calls)
+	}
+
+	o = NewOptions(WithResponseHook(func(context.Context, string, *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+		return nil, rejected
+	}))
+	if _, err := Invoke(context.Background(), o, info, "x", coerce, echo); !errors.Is(err, rejected) {
+		t.Fatalf("response hook: err=%v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+)
+
+// InterruptError is returned by Interrupt. The generated handler of a tool
+// declared interruptible turns it into a Genkit tool interrupt, handing
+// control back to the caller until the call is resumed or answered.
+type InterruptError struct {
+	// Metadata describes what the call is waiting for. It is attached to the
+	// interrupted tool request part.
+	Metadata map[string]any
+}
+
+func (e *InterruptError) Error() string {
+	return "tool call interrupted"
+}
+
+// Interrupt returns an error pausing the current call of a tool declared
+// with (genkit.tool.v1.tool_doc) = { interruptible: true }, e.g. to await an
+// approval or a slow backend. Implementations return it unchanged or
+// wrapped.
+func Interrupt(metadata map[string]any) error {
+	return &InterruptError{Metadata: metadata}
+}
+
+// IsInterrupt reports whether err pauses the call and returns the metadata
+// passed to Interrupt.
+func IsInterrupt(err error) (map[string]any, bool) {
+	var ierr *InterruptError
+	if !errors.As(err, &ierr) {
+		return nil, false
+	}
+	return ierr.Metadata, true
+}
+
+type resumedKey struct{}
+
+// ContextWithResumed returns a copy of ctx carrying the metadata supplied
+// when an interrupted call is restarted. A nil resumed leaves ctx unchanged.
+func ContextWithResumed(ctx context.Context, resumed map[string]any) context.Context {
+	if resumed == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, resumedKey{}, resumed)
+}
+
+// Resumed returns the metadata supplied when the current call was restarted
+// after an interrupt, and false on the first attempt.
+func Resumed(ctx context.Context) (map[string]any, bool) {
+	resumed, ok := ctx.Value(resumedKey{}).(map[string]any)
+	return resumed, ok
+}
@@ -0,0 +1,38 @@
+package genkittools
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInvokePassesInterruptsThrough(t *testing.T) {
+	o := NewOptions(WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+	info := &ToolInfo{Name: "create_invoice", Idempotent: true}
+	coerce := func(input any) (int, error) { return input.(int), nil }
+
+	calls := 0
+	approve := func(ctx context.Context, amount int) (int, error) {
+		calls++
+		if resumed, ok := Resumed(ctx); ok && resumed["approved"] == true {
+			return amount, nil
+		}
+		return 0, fmt.Errorf("await approval: %w", Interrupt(map[string]any{"amount": amount}))
+	}
+
+	_, err := Invoke(context.Background(), o, info, 42, coerce, approve)
+	md, ok := IsInterrupt(err)
+	if !ok || md["amount"] != 42 {
+		t.Fatalf("err = %v, want interrupt with amount 42", err)
+	}
+	if calls != 1 {
+		t.Fatalf("interrupt retried: calls = %d", calls)
+	}
+
+	ctx := ContextWithResumed(context.Background(), map[string]any{"approved": true})
+	got, err := Invoke(ctx, o, info, 42, coerce, approve)
+	if err != nil || got != 42 {
+		t.Fatalf("resumed call = %v, %v", got, err)
+	}
+}
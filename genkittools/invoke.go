@@ -0,0 +1,279 @@
+package genkittools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrToolPanic is wrapped by the error returned when a tool implementation
+// panics and panic recovery is enabled.
+var ErrToolPanic = errors.New("tool panicked")
+
+// ErrResponseMismatch is wrapped by the error returned in dev mode when a
+// response does not match its output schema under WithResponseValidation.
+var ErrResponseMismatch = errors.New("tool response does not match its output schema")
+
+// ErrToolDenied is wrapped by the error returned when the Authorizer set with
+// WithAuthorizer rejects a call.
+var ErrToolDenied = errors.New("tool call denied")
+
+// maxLoggedInput bounds the size of the input rendered into log records.
+const maxLoggedInput = 512
+
+// ToolInfo describes a generated tool to the runtime.
+type ToolInfo struct {
+	Name        string
+	Description string
+	Tags        []string
+	// Service is the fully-qualified name of the proto service declaring the
+	// tool.
+	Service string
+	// GeneratedWith identifies the plugin build, options and source proto
+	// that generated the tool.
+	GeneratedWith string
+	InputSchema   map[string]any
+	OutputSchema  map[string]any
+	// SensitiveFields lists input locations annotated as sensitive, in the
+	// pattern syntax accepted by Redact.
+	SensitiveFields []string
+	// UnsanitizedFields lists the request fields annotated with
+	// skip_sanitizer, in the same syntax, which the sanitizers set with
+	// WithInputSanitizer leave alone.
+	UnsanitizedFields []string
+	// ContextFields maps the dotted proto paths of request fields annotated
+	// with a context_key to that key. They are left out of InputSchema and
+	// filled from caller metadata instead.
+	ContextFields map[string]string
+	// MediaFields maps the response fields annotated with a media_type to
+	// that MIME type. They are returned to the model as media parts, split
+	// off with SplitMedia, and left out of OutputSchema.
+	MediaFields map[string]string
+	// Idempotent is set for RPCs declaring idempotency_level IDEMPOTENT or
+	// NO_SIDE_EFFECTS, making them eligible for WithRetry.
+	Idempotent bool
+	// Safety is the declared safety level of the tool. Generated handlers
+	// of SafetyDestructive tools ask for confirmation with Options.Confirm.
+	Safety Safety
+	// ResponseLimits bounds the responses returned to the model; see
+	// TruncateResponse.
+	ResponseLimits ResponseLimits
+	// FieldErrorMessages maps the input locations annotated with an
+	// error_message, in the pattern syntax accepted by Redact, to that
+	// message. Invoke adds it to the error returned when the value of the
+	// field, or of an item or field below it, cannot be decoded or fails
+	// validation.
+	FieldErrorMessages map[string]string
+	// Metadata holds the custom attributes of the tool_doc metadata option,
+	// such as routing or billing keys, with each value decoded from JSON.
+	Metadata map[string]any
+
+	schemaOnce sync.Once
+	inputJSON  []byte
+	outputJSON []byte
+}
+
+// InputSchemaJSON returns InputSchema encoded as JSON. It is marshaled once,
+// on first use, and shared by every caller, which must not modify it.
+func (t *ToolInfo) InputSchemaJSON() []byte {
+	t.marshalSchemas()
+	return t.inputJSON
+}
+
+// OutputSchemaJSON returns OutputSchema encoded as JSON, like
+// InputSchemaJSON. It is nil when the tool has no output schema.
+func (t *ToolInfo) OutputSchemaJSON() []byte {
+	t.marshalSchemas()
+	return t.outputJSON
+}
+
+func (t *ToolInfo) marshalSchemas() {
+	t.schemaOnce.Do(func() {
+		t.inputJSON = mustMarshalSchema(t.Name, t.InputSchema)
+		if t.OutputSchema != nil {
+			t.outputJSON = mustMarshalSchema(t.Name, t.OutputSchema)
+		}
+	})
+}
+
+func mustMarshalSchema(name string, schema map[string]any) []byte {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		// Generated schemas only hold maps, slices and scalars.
+		panic(fmt.Sprintf("genkittools: marshal %s schema: %v", name, err))
+	}
+	return raw
+}
+
+// Invoke runs a single tool call on behalf of a generated handler: it checks
+// the input limits, decodes input with coerce, explaining decoding and
+// validation errors with info.FieldErrorMessages, runs the input sanitizers,
+// fills context-bound fields, runs the request hooks, authorizes the
+// request, calls the implementation, runs the response hooks, truncates
+// the response to info.ResponseLimits and reports the outcome to the
+// metrics, logger and audit sink configured in o. The tool's metadata is
+// available to every step through ToolMetadata, and its sensitive fields
+// through RedactInput. Logged and audited errors and logged panic values
+// have the sensitive values of input redacted.
+func Invoke[Req, Resp any](ctx context.Context, o *Options, info *ToolInfo, input any, coerce func(any) (Req, error), call func(context.Context, Req) (Resp, error)) (Resp, error) {
+	start := time.Now()
+	ctx = context.WithValue(ctx, toolMetadataKey{}, o.toolMetadata(info))
+	if len(info.SensitiveFields) > 0 {
+		ctx = context.WithValue(ctx, sensitiveFieldsKey{}, info.SensitiveFields)
+	}
+	err := o.checkInputLimits(info, input)
+	if err == nil && o != nil {
+		o.observeViolations(info, input)
+	}
+
+	var resp Resp
+	if err == nil {
+		err = o.guard(ctx, info, input, func() error {
+			req, err := coerce(input)
+			if err != nil {
+				return explainInputError(info, err)
+			}
+			if err := o.sanitize(ctx, info, input, req); err != nil {
+				return err
+			}
+			if err := o.fillContextFields(ctx, info, req); err != nil {
+				return fmt.Errorf("%s: %w", info.Name, err)
+			}
+			if err := o.runRequestHooks(ctx, info, req); err != nil {
+				return err
+			}
+			if o != nil && o.authorize != nil {
+				if err := o.authorize(ctx, info.Name, req); err != nil {
+					return fmt.Errorf("%w: %s: %w", ErrToolDenied, info.Name, err)
+				}
+			}
+			resp, err = cached(ctx, o, info, req, func() (Resp, error) {
+				resp, err := callWithRetry(ctx, o, info, func() (Resp, error) {
+					release, err := o.acquire(ctx, info)
+					if err != nil {
+						var zero Resp
+						return zero, err
+					}
+					defer release()
+					return call(ctx, req)
+				})
+				if err != nil {
+					return resp, err
+				}
+				return RunResponseHooks(ctx, o, info, resp)
+			})
+			if err == nil {
+				resp = truncateResult(info, resp)
+			}
+			return statusError(err)
+		})
+	}
+	if err == nil && o != nil && o.validateResponses {
+		err = o.checkResponse(ctx, info, resp)
+	}
+
+	if o != nil {
+		elapsed := time.Since(start)
+		if o.metrics != nil {
+			o.metrics.ObserveCall(o.ToolName(info.Name), elapsed, err)
+		}
+		if o.logger != nil {
+			o.logCall(ctx, info, input, elapsed, err)
+		}
+		if o.audit != nil {
+			o.recordAudit(ctx, info, input, start, elapsed, err)
+		}
+	}
+	return resp, err
+}
+
+func (o *Options) logCall(ctx context.Context, info *ToolInfo, input any, elapsed time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.String("tool", o.ToolName(info.Name)),
+		slog.String("input", renderLoggedInput(input, info.SensitiveFields)),
+		slog.Duration("duration", elapsed),
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", redactText(err.Error(), input, info.SensitiveFields)))
+	}
+	o.logger.LogAttrs(ctx, level, "genkit tool call", attrs...)
+}
+
+func renderLoggedInput(input any, sensitive []string) string {
+	raw, err := json.Marshal(Redact(input, sensitive))
+	if err != nil {
+		return fmt.Sprintf("<unrenderable input: %v>", err)
+	}
+	if len(raw) <= maxLoggedInput {
+		return string(raw)
+	}
+	return string(raw[:maxLoggedInput]) + "...(truncated)"
+}
+
+// guard runs fn, converting a panic into an error unless recovery is disabled.
+// Sensitive values of input are redacted from the logged panic value.
+func (o *Options) guard(ctx context.Context, info *ToolInfo, input any, fn func() error) (err error) {
+	if o != nil && o.noRecover {
+		return fn()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrToolPanic, info.Name, r)
+			o.loggerOrDefault().LogAttrs(ctx, slog.LevelError, "genkit tool panicked",
+				slog.String("tool", info.Name),
+				slog.String("panic", redactText(fmt.Sprint(r), input, info.SensitiveFields)),
+				slog.String("stack", string(debug.Stack())),
+			)
+		}
+	}()
+	return fn()
+}
+
+// checkResponse validates resp against the tool's output schema, logging any
+// mismatch and turning it into an error when running in Genkit dev mode.
+func (o *Options) checkResponse(ctx context.Context, info *ToolInfo, resp any) error {
+	if info.OutputSchema == nil {
+		return nil
+	}
+	violations := Validate(info.OutputSchema, resp)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	details := make([]string, len(violations))
+	for i, v := range violations {
+		details[i] = v.String()
+	}
+	o.loggerOrDefault().LogAttrs(ctx, slog.LevelWarn, "genkit tool response does not match its output schema",
+		slog.String("tool", info.Name),
+		slog.Any("violations", details),
+	)
+	if os.Getenv("GENKIT_ENV") != "dev" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s: %s", ErrResponseMismatch, info.Name, strings.Join(details, "; "))
+}
+
+func (o *Options) observeViolations(info *ToolInfo, input any) {
+	vm, ok := o.metrics.(ValidationMetrics)
+	if !ok || info.InputSchema == nil {
+		return
+	}
+	if _, typed := input.(proto.Message); typed {
+		return
+	}
+	for _, v := range Validate(info.InputSchema, input) {
+		vm.ObserveValidationFailure(o.ToolName(info.Name), v.Field)
+	}
+}
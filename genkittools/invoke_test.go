@@ -0,0 +1,180 @@
+package genkittools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordedCall struct {
+	tool string
+	err  error
+}
+
+type fakeMetrics struct {
+	calls    []recordedCall
+	failures []string
+}
+
+func (m *fakeMetrics) ObserveCall(tool string, _ time.Duration, err error) {
+	m.calls = append(m.calls, recordedCall{tool: tool, err: err})
+}
+
+func (m *fakeMetrics) ObserveValidationFailure(tool, field string) {
+	m.failures = append(m.failures, tool+":"+field)
+}
+
+func TestInvokeReportsMetrics(t *testing.T) {
+	m := &fakeMetrics{}
+	o := NewOptions(WithMetrics(m))
+	coerce := func(input any) (string, error) {
+		s, ok := input.(string)
+		if !ok {
+			return "", errors.New("bad input")
+		}
+		return s, nil
+	}
+	call := func(_ context.Context, req string) (string, error) {
+		return "hello " + req, nil
+	}
+
+	info := &ToolInfo{Name: "greet", InputSchema: map[string]any{"type": "string"}}
+
+	got, err := Invoke(context.Background(), o, info, "bob", coerce, call)
+	if err != nil || got != "hello bob" {
+		t.Fatalf("Invoke = %q, %v", got, err)
+	}
+	if _, err := Invoke(context.Background(), o, info, 42, coerce, call); err == nil {
+		t.Fatal("expected coercion error")
+	}
+
+	if len(m.calls) != 2 || m.calls[0].err != nil || m.calls[1].err == nil {
+		t.Fatalf("unexpected observations: %+v", m.calls)
+	}
+	if len(m.failures) != 1 || m.failures[0] != "greet:" {
+		t.Fatalf("unexpected validation failures: %v", m.failures)
+	}
+
+	// Both observations carry the prefixed name the tool is defined under.
+	m = &fakeMetrics{}
+	o = NewOptions(WithMetrics(m), WithNamePrefix("eu_"))
+	if _, err := Invoke(context.Background(), o, info, 42, coerce, call); err == nil {
+		t.Fatal("expected coercion error")
+	}
+	if len(m.calls) != 1 || m.calls[0].tool != "eu_greet" || len(m.failures) != 1 || m.failures[0] != "eu_greet:" {
+		t.Fatalf("unexpected observations: %+v, %v", m.calls, m.failures)
+	}
+}
+
+func TestInvokeNilOptions(t *testing.T) {
+	got, err := Invoke(context.Background(), nil, &ToolInfo{Name: "echo"}, 1,
+		func(input any) (int, error) { return input.(int), nil },
+		func(_ context.Context, req int) (int, error) { return req + 1, nil })
+	if err != nil || got != 2 {
+		t.Fatalf("Invoke = %d, %v", got, err)
+	}
+}
+
+func TestInvokeLogsRedactedInput(t *testing.T) {
+	var buf bytes.Buffer
+	o := NewOptions(WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	info := &ToolInfo{Name: "pay", SensitiveFields: []string{"/card"}}
+
+	_, err := Invoke(context.Background(), o, info, map[string]any{"card": "4111", "amount": 3},
+		func(input any) (map[string]any, error) { return input.(map[string]any), nil },
+		func(context.Context, map[string]any) (bool, error) { return false, errors.New("card 4111 declined") })
+	if err == nil {
+		t.Fatal("expected impl error")
+	}
+
+	line := buf.String()
+	for _, want := range []string{`"tool":"pay"`, `\"card\":\"[REDACTED]\"`, `"error":"card [REDACTED] declined"`, `"level":"ERROR"`} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("log line %s missing %s", line, want)
+		}
+	}
+	if strings.Contains(line, "4111") {
+		t.Fatalf("log line leaks sensitive value: %s", line)
+	}
+}
+
+func TestInvokeRecoversPanics(t *testing.T) {
+	var buf bytes.Buffer
+	o := NewOptions(WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	panicky := func(context.Context, int) (int, error) { panic("kaboom") }
+	coerce := func(input any) (int, error) { return input.(int), nil }
+
+	_, err := Invoke(context.Background(), o, &ToolInfo{Name: "boom"}, 1, coerce, panicky)
+	if !errors.Is(err, ErrToolPanic) || !strings.Contains(err.Error(), "kaboom") {
+		t.Fatalf("expected ErrToolPanic, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "stack=") {
+		t.Fatalf("expected stack in log, got %s", buf.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic to propagate with recovery disabled")
+		}
+	}()
+	_, _ = Invoke(context.Background(), NewOptions(WithPanicRecovery(false)), &ToolInfo{Name: "boom"}, 1, coerce, panicky)
+}
+
+func TestInvokeAuthorizes(t *testing.T) {
+	var calls int
+	call := func(_ context.Context, n int) (int, error) {
+		calls++
+		return n * 2, nil
+	}
+	coerce := func(input any) (int, error) { return input.(int), nil }
+	errForbidden := errors.New("user may not call lookup")
+	o := NewOptions(WithAuthorizer(func(_ context.Context, name string, req any) error {
+		if name != "lookup" || req.(int) > 1 {
+			return errForbidden
+		}
+		return nil
+	}))
+
+	if got, err := Invoke(context.Background(), o, &ToolInfo{Name: "lookup"}, 1, coerce, call); err != nil || got != 2 {
+		t.Fatalf("allowed call = %v, %v", got, err)
+	}
+	_, err := Invoke(context.Background(), o, &ToolInfo{Name: "lookup"}, 2, coerce, call)
+	if !errors.Is(err, ErrToolDenied) || !errors.Is(err, errForbidden) {
+		t.Fatalf("denied call error = %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("implementation called %d times, want 1", calls)
+	}
+}
+
+func TestInvokeValidatesResponses(t *testing.T) {
+	var buf bytes.Buffer
+	o := NewOptions(WithLogger(slog.New(slog.NewTextHandler(&buf, nil))), WithResponseValidation())
+	info := &ToolInfo{
+		Name: "lookup",
+		OutputSchema: map[string]any{
+			"type":       "object",
+			"required":   []string{"id"},
+			"properties": map[string]any{"id": map[string]any{"type": "string"}},
+		},
+	}
+	coerce := func(input any) (int, error) { return input.(int), nil }
+	call := func(context.Context, int) (map[string]any, error) { return map[string]any{}, nil }
+
+	t.Setenv("GENKIT_ENV", "prod")
+	if _, err := Invoke(context.Background(), o, info, 1, coerce, call); err != nil {
+		t.Fatalf("expected mismatch to be logged only, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "/id: required property is missing") {
+		t.Fatalf("expected mismatch in log, got %s", buf.String())
+	}
+
+	t.Setenv("GENKIT_ENV", "dev")
+	if _, err := Invoke(context.Background(), o, info, 1, coerce, call); !errors.Is(err, ErrResponseMismatch) {
+		t.Fatalf("expected ErrResponseMismatch in dev mode, got %v", err)
+	}
+}
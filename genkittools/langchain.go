@@ -0,0 +1,52 @@
+package genkittools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// LangChainTool implements the tools.Tool interface of langchaingo for a
+// generated tool, without this package depending on langchaingo.
+type LangChainTool struct {
+	Info *ToolInfo
+	// Run runs the tool on a decoded JSON input, as the generated Genkit
+	// handler would.
+	Run func(ctx context.Context, input any) (any, error)
+}
+
+// Name returns the tool name.
+func (t *LangChainTool) Name() string {
+	return t.Info.Name
+}
+
+// Description returns the tool description followed by its input schema,
+// since langchaingo agents only learn the expected input from it.
+func (t *LangChainTool) Description() string {
+	schema := "Input is a JSON object matching this JSON Schema: " + string(t.Info.InputSchemaJSON())
+	if t.Info.Description == "" {
+		return schema
+	}
+	return t.Info.Description + "\n" + schema
+}
+
+// Call decodes input as JSON, runs the tool and returns its JSON output, or
+// its text output for tools returning a string.
+func (t *LangChainTool) Call(ctx context.Context, input string) (string, error) {
+	var decoded any
+	if err := json.Unmarshal([]byte(input), &decoded); err != nil {
+		return "", fmt.Errorf("decode %s input: %w", t.Info.Name, err)
+	}
+	out, err := t.Run(ctx, decoded)
+	if err != nil {
+		return "", err
+	}
+	if text, ok := out.(string); ok {
+		return text, nil
+	}
+	raw, err := MarshalOutput(out)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,44 @@
+package genkittools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestLangChainTool(t *testing.T) {
+	tool := &LangChainTool{
+		Info: &ToolInfo{
+			Name:        "greet",
+			Description: "Greet someone",
+			InputSchema: map[string]any{"type": "object"},
+		},
+		Run: func(_ context.Context, input any) (any, error) {
+			name := input.(map[string]any)["name"].(string)
+			if name == "text" {
+				return "plain text", nil
+			}
+			return wrapperspb.String("hello " + name), nil
+		},
+	}
+
+	if tool.Name() != "greet" {
+		t.Fatalf("Name() = %q", tool.Name())
+	}
+	if want := "Greet someone\nInput is a JSON object matching this JSON Schema: {\"type\":\"object\"}"; tool.Description() != want {
+		t.Fatalf("Description() = %q, want %q", tool.Description(), want)
+	}
+	out, err := tool.Call(context.Background(), `{"name":"ada"}`)
+	if err != nil || out != `"hello ada"` {
+		t.Fatalf("Call = %q, %v", out, err)
+	}
+	out, err = tool.Call(context.Background(), `{"name":"text"}`)
+	if err != nil || out != "plain text" {
+		t.Fatalf("Call text = %q, %v", out, err)
+	}
+	if _, err := tool.Call(context.Background(), "ada"); err == nil || !strings.Contains(err.Error(), "decode greet input") {
+		t.Fatalf("Call invalid JSON = %v", err)
+	}
+}
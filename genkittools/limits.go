@@ -0,0 +1,107 @@
+package genkittools
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrInputTooLarge is wrapped by the error returned when a tool input exceeds
+// the limits set with WithInputLimits.
+var ErrInputTooLarge = errors.New("tool input too large")
+
+// InputLimits bounds the size of tool inputs. Zero fields impose no limit.
+type InputLimits struct {
+	// MaxBytes caps the size of the input encoded as JSON.
+	MaxBytes int
+	// MaxArrayLen caps the number of items of every array in the input.
+	MaxArrayLen int
+	// MaxStringLen caps the length in bytes of every string in the input.
+	MaxStringLen int
+}
+
+// WithInputLimits rejects inputs exceeding l before they are decoded into
+// requests, on top of the constraints of the input schema, so an oversized
+// tool call fails with a clear error instead of allocating large messages.
+// Rejected calls fail with an error wrapping ErrInputTooLarge that names the
+// offending location.
+func WithInputLimits(l InputLimits) Option {
+	return func(o *Options) {
+		o.inputLimits = &l
+	}
+}
+
+// checkInputLimits reports the first limit input exceeds.
+func (o *Options) checkInputLimits(info *ToolInfo, input any) error {
+	if o == nil || o.inputLimits == nil {
+		return nil
+	}
+	l := o.inputLimits
+	var size int
+	var err error
+	if msg, ok := input.(proto.Message); ok {
+		// Inputs passed as requests from Go are already decoded; only
+		// their size is checked.
+		size = proto.Size(msg)
+	} else {
+		size, err = l.measure(input, "")
+	}
+	if err == nil && l.MaxBytes > 0 && size > l.MaxBytes {
+		err = fmt.Errorf("input is %d bytes, limit %d", size, l.MaxBytes)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInputTooLarge, info.Name, err)
+	}
+	return nil
+}
+
+// measure returns the JSON-encoded size of v, located at pointer, stopping at
+// the first array or string over its limit. Strings are counted without
+// escapes.
+func (l *InputLimits) measure(v any, pointer string) (int, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		size := 1 + len(val)
+		for key, child := range val {
+			n, err := l.measure(child, pointer+"/"+escapePointer(key))
+			if err != nil {
+				return 0, err
+			}
+			size += len(key) + 3 + n
+		}
+		return max(size, 2), nil
+	case []any:
+		if l.MaxArrayLen > 0 && len(val) > l.MaxArrayLen {
+			return 0, fmt.Errorf("%s has %d items, limit %d", describePointer(pointer), len(val), l.MaxArrayLen)
+		}
+		size := 1 + len(val)
+		for i, child := range val {
+			n, err := l.measure(child, pointer+"/"+strconv.Itoa(i))
+			if err != nil {
+				return 0, err
+			}
+			size += n
+		}
+		return max(size, 2), nil
+	case string:
+		if l.MaxStringLen > 0 && len(val) > l.MaxStringLen {
+			return 0, fmt.Errorf("%s is %d bytes long, limit %d", describePointer(pointer), len(val), l.MaxStringLen)
+		}
+		return len(val) + 2, nil
+	case nil:
+		return 4, nil
+	case bool:
+		return 5, nil
+	default:
+		return len(fmt.Sprint(val)), nil
+	}
+}
+
+func describePointer(pointer string) string {
+	if pointer == "" {
+		return "input"
+	}
+	return pointer
+}
@@ -0,0 +1,41 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInvokeEnforcesInputLimits(t *testing.T) {
+	o := NewOptions(WithInputLimits(InputLimits{MaxBytes: 150, MaxArrayLen: 3, MaxStringLen: 10}))
+	info := &ToolInfo{Name: "create_invoice"}
+
+	cases := []struct {
+		name    string
+		input   any
+		wantErr string
+	}{
+		{"fits", map[string]any{"items": []any{"a", "b"}, "note": "short"}, ""},
+		{"long array", map[string]any{"items": []any{1.0, 2.0, 3.0, 4.0}}, "/items has 4 items, limit 3"},
+		{"long string", map[string]any{"items": []any{map[string]any{"note": "far too long"}}}, "/items/0/note is 12 bytes long, limit 10"},
+		{"too many bytes", map[string]any{"a": "0123456789", "b": "0123456789", "c": "0123456789", "d": "0123456789", "e": "0123456789", "f": "0123456789", "g": "0123456789", "h": "0123456789", "i": "0123456789", "j": "0123456789"}, "input is 171 bytes, limit 150"},
+	}
+	for _, tc := range cases {
+		coerced := false
+		coerce := func(input any) (any, error) { coerced = true; return input, nil }
+		_, err := Invoke(context.Background(), o, info, tc.input, coerce, func(context.Context, any) (any, error) { return nil, nil })
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInputTooLarge) || !strings.HasSuffix(err.Error(), "create_invoice: "+tc.wantErr) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.wantErr)
+		}
+		if coerced {
+			t.Errorf("%s: oversized input was decoded", tc.name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package genkittools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ToolList is the output of a generated list_tools meta-tool.
+type ToolList struct {
+	Tools []ToolSummary `json:"tools"`
+}
+
+// ToolSummary describes one tool listed by a list_tools meta-tool.
+type ToolSummary struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Parameters lists the top-level parameters of the tool, as in
+	// PromptSection.
+	Parameters string `json:"parameters,omitempty"`
+	// InputSchema is the full input schema of the tool, set when the tool
+	// was asked for by name.
+	InputSchema map[string]any `json:"input_schema,omitempty"`
+}
+
+// listToolsRequest is the input of a list_tools meta-tool.
+type listToolsRequest struct {
+	query string
+	tool  string
+}
+
+// ListToolsInputSchema returns the input schema of a list_tools meta-tool.
+func ListToolsInputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{"type": "string", "description": "Only list the tools whose name or description contains this text, ignoring case."},
+			"tool":  map[string]any{"type": "string", "description": "Only describe the tool with this name, including its full input schema."},
+		},
+	}
+}
+
+// ListToolsOutputSchema returns the schema of ToolList.
+func ListToolsOutputSchema() map[string]any {
+	summary := map[string]any{
+		"type":     "object",
+		"required": []string{"name", "description"},
+		"properties": map[string]any{
+			"name":         map[string]any{"type": "string"},
+			"description":  map[string]any{"type": "string"},
+			"parameters":   map[string]any{"type": "string", "description": "The top-level parameters of the tool."},
+			"input_schema": map[string]any{"type": "object", "description": "The input schema of the tool, when it was asked for by name."},
+		},
+	}
+	return map[string]any{
+		"type":       "object",
+		"required":   []string{"tools"},
+		"properties": map[string]any{"tools": ListSchema(summary)},
+	}
+}
+
+// ListTools runs the call of the list_tools meta-tool described by info
+// through Invoke, and summarizes the tools it lists: those of tools that the
+// WithOnly and WithExcept options of o include, under their registered
+// names. Only the first line of each description is kept, so that agents
+// with large tool sets can discover tools on demand and ask for the full
+// schema of the ones they need.
+func ListTools(ctx context.Context, o *Options, info *ToolInfo, tools []*ToolInfo, input any) (*ToolList, error) {
+	return Invoke(ctx, o, info, input, coerceListToolsRequest, func(_ context.Context, req listToolsRequest) (*ToolList, error) {
+		out := &ToolList{Tools: []ToolSummary{}}
+		query := strings.ToLower(req.query)
+		for _, t := range tools {
+			if !o.Includes(t.Name) {
+				continue
+			}
+			name := o.ToolName(t.Name)
+			if req.tool != "" && req.tool != name {
+				continue
+			}
+			desc, _, _ := strings.Cut(strings.TrimSpace(o.ToolDescription(t.Description)), "\n")
+			if query != "" && !strings.Contains(strings.ToLower(name), query) && !strings.Contains(strings.ToLower(desc), query) {
+				continue
+			}
+			summary := ToolSummary{Name: name, Description: desc, Parameters: promptParameters(t.InputSchema)}
+			if req.tool != "" {
+				summary.InputSchema = t.InputSchema
+			}
+			out.Tools = append(out.Tools, summary)
+		}
+		if req.tool != "" && len(out.Tools) == 0 {
+			return nil, fmt.Errorf("%s: no tool called %q", info.Name, req.tool)
+		}
+		return out, nil
+	})
+}
+
+func coerceListToolsRequest(input any) (listToolsRequest, error) {
+	var req listToolsRequest
+	if input == nil {
+		return req, nil
+	}
+	value := normalizeJSON(input)
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return req, fmt.Errorf("list tools input: expected an object, got %s", jsonType(value))
+	}
+	for key, dst := range map[string]*string{"query": &req.query, "tool": &req.tool} {
+		switch v := obj[key].(type) {
+		case nil:
+		case string:
+			*dst = v
+		default:
+			return req, fmt.Errorf("list tools input: %s: expected string, got %s", key, jsonType(v))
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,56 @@
+package genkittools
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestListTools(t *testing.T) {
+	weather := &ToolInfo{
+		Name:        "get_weather",
+		Description: "Get the weather.\nUses the forecast service.",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"required":   []any{"city"},
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		},
+	}
+	alerts := &ToolInfo{Name: "list_alerts", Description: "List weather alerts."}
+	hidden := &ToolInfo{Name: "delete_city", Description: "Delete a city."}
+	info := &ToolInfo{Name: "catalog_list_tools"}
+	tools := []*ToolInfo{weather, alerts, hidden}
+	o := NewOptions(WithNamePrefix("eu_"), WithExcept("delete_city"))
+
+	got, err := ListTools(context.Background(), o, info, tools, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ToolSummary{
+		{Name: "eu_get_weather", Description: "Get the weather.", Parameters: "city (string, required)"},
+		{Name: "eu_list_alerts", Description: "List weather alerts."},
+	}
+	if !reflect.DeepEqual(got.Tools, want) {
+		t.Fatalf("ListTools = %+v, want %+v", got.Tools, want)
+	}
+
+	got, err = ListTools(context.Background(), o, info, tools, map[string]any{"query": "ALERT"})
+	if err != nil || len(got.Tools) != 1 || got.Tools[0].Name != "eu_list_alerts" {
+		t.Fatalf("ListTools with query = %+v, %v", got, err)
+	}
+
+	got, err = ListTools(context.Background(), o, info, tools, map[string]any{"tool": "eu_get_weather"})
+	if err != nil || len(got.Tools) != 1 || !reflect.DeepEqual(got.Tools[0].InputSchema, weather.InputSchema) {
+		t.Fatalf("ListTools with tool = %+v, %v", got, err)
+	}
+	if errs := Validate(ListToolsOutputSchema(), got); len(errs) != 0 {
+		t.Fatalf("ToolList does not match its schema: %v", errs)
+	}
+
+	if _, err := ListTools(context.Background(), o, info, tools, map[string]any{"tool": "delete_city"}); err == nil {
+		t.Fatal("ListTools described an excluded tool")
+	}
+	if _, err := ListTools(context.Background(), o, info, tools, map[string]any{"query": 3}); err == nil {
+		t.Fatal("ListTools accepted a non-string query")
+	}
+}
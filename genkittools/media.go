@@ -0,0 +1,75 @@
+package genkittools
+
+import (
+	"encoding/base64"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Media is a response field returned to the model as a media part instead of
+// in the structured output, where base64 would bloat the context window.
+type Media struct {
+	ContentType string
+	// URL is a data URL for bytes fields, or the URL a string field holds.
+	URL string
+}
+
+// SplitMedia returns a copy of resp without the fields listed in
+// info.MediaFields, and the media they held in field order. Unset fields
+// yield no media. resp is returned as is when it holds none.
+func SplitMedia[M proto.Message](info *ToolInfo, resp M) (M, []Media) {
+	msg := resp.ProtoReflect()
+	if len(info.MediaFields) == 0 || !msg.IsValid() {
+		return resp, nil
+	}
+	var media []Media
+	var rest protoreflect.Message
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		contentType, ok := info.MediaFields[string(field.Name())]
+		if !ok || !msg.Has(field) {
+			continue
+		}
+		m := Media{ContentType: contentType}
+		if field.Kind() == protoreflect.BytesKind {
+			m.URL = "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(msg.Get(field).Bytes())
+		} else {
+			m.URL = msg.Get(field).String()
+		}
+		media = append(media, m)
+		if rest == nil {
+			rest = proto.Clone(resp).ProtoReflect()
+		}
+		rest.Clear(field)
+	}
+	if rest == nil {
+		return resp, nil
+	}
+	return rest.Interface().(M), media
+}
+
+// OmitFields deletes the properties names from the object schema and its
+// required list, and returns it. Tools leave their media fields out of
+// their output schema this way.
+func OmitFields(schema map[string]any, names ...string) map[string]any {
+	props, _ := schema["properties"].(map[string]any)
+	for _, name := range names {
+		delete(props, name)
+	}
+	if required, ok := schema["required"].([]string); ok {
+		var kept []string
+		for _, r := range required {
+			if _, ok := props[r]; ok {
+				kept = append(kept, r)
+			}
+		}
+		if len(kept) == 0 {
+			delete(schema, "required")
+		} else {
+			schema["required"] = kept
+		}
+	}
+	return schema
+}
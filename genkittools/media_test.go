@@ -0,0 +1,45 @@
+package genkittools
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSplitMedia(t *testing.T) {
+	info := &ToolInfo{MediaFields: map[string]string{"value": "image/png"}}
+	resp := wrapperspb.Bytes([]byte("png"))
+
+	rest, media := SplitMedia(info, resp)
+	if len(rest.GetValue()) != 0 || string(resp.GetValue()) != "png" {
+		t.Fatalf("rest = %v, resp = %v: want the field cleared on a copy only", rest, resp)
+	}
+	if want := []Media{{ContentType: "image/png", URL: "data:image/png;base64,cG5n"}}; !reflect.DeepEqual(media, want) {
+		t.Fatalf("media = %v, want %v", media, want)
+	}
+
+	url := wrapperspb.String("https://example.com/chart.png")
+	if _, media := SplitMedia(info, url); len(media) != 1 || media[0].URL != "https://example.com/chart.png" {
+		t.Fatalf("media = %v", media)
+	}
+	if rest, media := SplitMedia(info, &wrapperspb.BytesValue{}); rest == nil || media != nil {
+		t.Fatalf("empty response split into %v, %v", rest, media)
+	}
+}
+
+func TestOmitFields(t *testing.T) {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"image": map[string]any{"type": "string"}, "caption": map[string]any{"type": "string"}},
+		"required":   []string{"caption", "image"},
+	}
+	want := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"caption": map[string]any{"type": "string"}},
+		"required":   []string{"caption"},
+	}
+	if got := OmitFields(schema, "image"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("schema = %v, want %v", got, want)
+	}
+}
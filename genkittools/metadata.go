@@ -0,0 +1,150 @@
+package genkittools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MetadataExtractor returns the caller metadata stored under key in ctx, such
+// as the authenticated user or the session, for request fields annotated with
+// (genkit.tool.v1.field_doc) = { context_key: "..." }.
+type MetadataExtractor func(ctx context.Context, key string) (string, bool)
+
+// WithMetadataExtractor fills context-bound request fields with extract. By
+// default they are filled from the metadata attached with
+// ContextWithMetadata.
+func WithMetadataExtractor(extract MetadataExtractor) Option {
+	return func(o *Options) {
+		o.extractMetadata = extract
+	}
+}
+
+type metadataKey struct{}
+
+// ContextWithMetadata returns a copy of ctx carrying md, read by the default
+// MetadataExtractor. Values attached earlier in ctx remain visible unless md
+// overrides them.
+func ContextWithMetadata(ctx context.Context, md map[string]string) context.Context {
+	merged := make(map[string]string)
+	if parent, ok := ctx.Value(metadataKey{}).(map[string]string); ok {
+		for k, v := range parent {
+			merged[k] = v
+		}
+	}
+	for k, v := range md {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, metadataKey{}, merged)
+}
+
+// MetadataFromContext is the default MetadataExtractor, reading the values
+// attached with ContextWithMetadata.
+func MetadataFromContext(ctx context.Context, key string) (string, bool) {
+	md, _ := ctx.Value(metadataKey{}).(map[string]string)
+	v, ok := md[key]
+	return v, ok
+}
+
+// fillContextFields sets the context-bound fields of req, a message or a
+// slice of messages, from the caller metadata. Any value supplied by the
+// model is discarded, so these fields can only come from the caller.
+func (o *Options) fillContextFields(ctx context.Context, info *ToolInfo, req any) error {
+	if len(info.ContextFields) == 0 {
+		return nil
+	}
+	extract := MetadataFromContext
+	if o != nil && o.extractMetadata != nil {
+		extract = o.extractMetadata
+	}
+	if m, ok := req.(proto.Message); ok {
+		return fillMessage(ctx, extract, info.ContextFields, m.ProtoReflect())
+	}
+	rv := reflect.ValueOf(req)
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+	for i := 0; i < rv.Len(); i++ {
+		m, ok := rv.Index(i).Interface().(proto.Message)
+		if !ok {
+			continue
+		}
+		if err := fillMessage(ctx, extract, info.ContextFields, m.ProtoReflect()); err != nil {
+			return fmt.Errorf("%s[%d]: %w", BatchField, i, err)
+		}
+	}
+	return nil
+}
+
+func fillMessage(ctx context.Context, extract MetadataExtractor, fields map[string]string, msg protoreflect.Message) error {
+	for path, key := range fields {
+		raw, ok := extract(ctx, key)
+		if !ok {
+			clearFieldPath(msg, path)
+			continue
+		}
+		names := strings.Split(path, ".")
+		parent := msg
+		for _, name := range names[:len(names)-1] {
+			fd := parent.Descriptor().Fields().ByName(protoreflect.Name(name))
+			if fd == nil || fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return fmt.Errorf("context field %s: no such message field", path)
+			}
+			parent = parent.Mutable(fd).Message()
+		}
+		fd := parent.Descriptor().Fields().ByName(protoreflect.Name(names[len(names)-1]))
+		if fd == nil || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("context field %s: no such singular field", path)
+		}
+		v, err := parseScalar(fd, raw)
+		if err != nil {
+			return fmt.Errorf("context field %s from metadata %q: %w", path, key, err)
+		}
+		parent.Set(fd, v)
+	}
+	return nil
+}
+
+// parseScalar converts a metadata string into a value of the scalar field fd.
+func parseScalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(s)), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported kind %s", fd.Kind())
+	}
+}
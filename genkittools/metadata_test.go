@@ -0,0 +1,71 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestInvokeFillsContextFields(t *testing.T) {
+	info := &ToolInfo{
+		Name:          "describe",
+		ContextFields: map[string]string{"name": "user", "number": "shard", "options.deprecated": "beta"},
+	}
+	coerce := func(any) (*descriptorpb.FieldDescriptorProto, error) {
+		// The model tries to pick the user itself.
+		return &descriptorpb.FieldDescriptorProto{Name: proto.String("mallory"), Number: proto.Int32(9)}, nil
+	}
+	var got *descriptorpb.FieldDescriptorProto
+	call := func(_ context.Context, req *descriptorpb.FieldDescriptorProto) (bool, error) {
+		got = req
+		return true, nil
+	}
+
+	ctx := ContextWithMetadata(context.Background(), map[string]string{"user": "alice", "shard": "3"})
+	ctx = ContextWithMetadata(ctx, map[string]string{"beta": "true"})
+	if _, err := Invoke(ctx, nil, info, nil, coerce, call); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if got.GetName() != "alice" || got.GetNumber() != 3 || !got.GetOptions().GetDeprecated() {
+		t.Fatalf("request = %v", got)
+	}
+
+	// Fields without metadata are cleared rather than left to the model.
+	if _, err := Invoke(context.Background(), nil, info, nil, coerce, call); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if got.Name != nil || got.Number != nil || got.Options != nil {
+		t.Fatalf("request = %v", got)
+	}
+
+	o := NewOptions(WithMetadataExtractor(func(_ context.Context, key string) (string, bool) {
+		return "not a number", key == "shard"
+	}))
+	_, err := Invoke(context.Background(), o, info, nil, coerce, call)
+	if err == nil || !strings.Contains(err.Error(), `describe: context field number from metadata "shard"`) {
+		t.Fatalf("bad metadata error = %v", err)
+	}
+}
+
+func TestInvokeFillsContextFieldsOfBatches(t *testing.T) {
+	info := &ToolInfo{Name: "batch", ContextFields: map[string]string{"name": "user"}}
+	coerce := func(any) ([]*descriptorpb.FieldDescriptorProto, error) {
+		return []*descriptorpb.FieldDescriptorProto{{}, {Name: proto.String("mallory")}}, nil
+	}
+	call := func(_ context.Context, reqs []*descriptorpb.FieldDescriptorProto) (int, error) {
+		for _, req := range reqs {
+			if req.GetName() != "alice" {
+				return 0, errors.New("unexpected user " + req.GetName())
+			}
+		}
+		return len(reqs), nil
+	}
+	ctx := ContextWithMetadata(context.Background(), map[string]string{"user": "alice"})
+	if n, err := Invoke(ctx, nil, info, nil, coerce, call); err != nil || n != 2 {
+		t.Fatalf("Invoke = %v, %v", n, err)
+	}
+}
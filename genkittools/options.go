@@ -0,0 +1,168 @@
+package genkittools
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Option configures the tools registered by a generated Register function.
+type Option func(*Options)
+
+// Options holds the registration settings shared by the handlers of one
+// Register call. Generated code builds it with NewOptions; a nil *Options is
+// valid and behaves like an empty configuration.
+type Options struct {
+	metrics   Metrics
+	logger    *slog.Logger
+	audit     AuditSink
+	noRecover bool
+	retry     *RetryPolicy
+	cache     Cache
+	cacheTTL  time.Duration
+
+	validateResponses bool
+	inputLimits       *InputLimits
+	authorize         Authorizer
+	extractMetadata   MetadataExtractor
+	auditCallerKey    string
+	requestHooks      []func(ctx context.Context, tool string, req proto.Message) error
+	sanitizers        []InputSanitizer
+	responseHooks     []func(ctx context.Context, tool string, resp proto.Message) (proto.Message, error)
+
+	sem              semaphore
+	toolSems         map[string]semaphore
+	batchConcurrency int
+
+	only   map[string]bool
+	except map[string]bool
+
+	noConfirm    map[string]bool
+	noConfirmAll bool
+
+	namePrefix string
+	descSuffix string
+	metadata   map[string]map[string]any
+}
+
+// NewOptions applies opts in order and returns the resulting configuration.
+func NewOptions(opts ...Option) *Options {
+	o := &Options{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// Metrics receives per-call observations from generated tool handlers.
+type Metrics interface {
+	// ObserveCall records one tool invocation, its latency and its error, if any.
+	ObserveCall(tool string, duration time.Duration, err error)
+}
+
+// ValidationMetrics is optionally implemented by a Metrics to learn which
+// input locations fail schema validation. When it is, handlers validate each
+// input against the tool's schema and report one observation per violation,
+// keyed by Violation.Field.
+type ValidationMetrics interface {
+	ObserveValidationFailure(tool, field string)
+}
+
+// WithMetrics reports every tool invocation to m.
+func WithMetrics(m Metrics) Option {
+	return func(o *Options) {
+		o.metrics = m
+	}
+}
+
+// WithLogger logs every tool invocation to l with the tool name, the input
+// (truncated, with sensitive fields redacted), the duration and the error.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *Options) {
+		o.logger = l
+	}
+}
+
+// WithPanicRecovery controls whether handlers turn a panic in the
+// implementation into a tool error wrapping ErrToolPanic. Recovery is enabled
+// by default; the panic value and stack are logged to the configured logger,
+// or to slog.Default when none is set.
+func WithPanicRecovery(enabled bool) Option {
+	return func(o *Options) {
+		o.noRecover = !enabled
+	}
+}
+
+// WithResponseValidation checks every response against the tool's generated
+// output schema. Mismatches are logged as warnings; when GENKIT_ENV is "dev"
+// the call also fails with an error wrapping ErrResponseMismatch, so drift
+// between the proto contract and the implementation surfaces early.
+func WithResponseValidation() Option {
+	return func(o *Options) {
+		o.validateResponses = true
+	}
+}
+
+// Authorizer decides whether the caller found in ctx may run the tool called
+// name on req, the decoded request (a slice of requests for client-streaming
+// tools). A non-nil error denies the call.
+type Authorizer func(ctx context.Context, name string, req any) error
+
+// WithAuthorizer checks every call with authorize after its input is decoded
+// and before the implementation runs. Denied calls fail with an error wrapping
+// both ErrToolDenied and the authorizer's error, and are never retried.
+func WithAuthorizer(authorize Authorizer) Option {
+	return func(o *Options) {
+		o.authorize = authorize
+	}
+}
+
+// WithOnly restricts registration to the named tools; other tools of the
+// service are skipped. Repeated WithOnly options accumulate. Names may be plain
+// strings or the generated genkitai.ToolName constants.
+func WithOnly[N ~string](names ...N) Option {
+	return func(o *Options) {
+		if o.only == nil {
+			o.only = make(map[string]bool)
+		}
+		for _, n := range names {
+			o.only[string(n)] = true
+		}
+	}
+}
+
+// WithExcept skips registration of the named tools. It takes precedence over
+// WithOnly.
+func WithExcept[N ~string](names ...N) Option {
+	return func(o *Options) {
+		if o.except == nil {
+			o.except = make(map[string]bool)
+		}
+		for _, n := range names {
+			o.except[string(n)] = true
+		}
+	}
+}
+
+// Includes reports whether the tool called name should be registered under
+// the WithOnly and WithExcept options.
+func (o *Options) Includes(name string) bool {
+	if o == nil {
+		return true
+	}
+	if o.except[name] {
+		return false
+	}
+	return o.only == nil || o.only[name]
+}
+
+func (o *Options) loggerOrDefault() *slog.Logger {
+	if o != nil && o.logger != nil {
+		return o.logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,32 @@
+package genkittools
+
+import "testing"
+
+type toolName string
+
+func TestIncludes(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		want map[string]bool
+	}{
+		{"default", nil, map[string]bool{"a": true, "b": true, "c": true}},
+		{"only", []Option{WithOnly("a", "c")}, map[string]bool{"a": true, "b": false, "c": true}},
+		{"only accumulates", []Option{WithOnly("a"), WithOnly(toolName("b"))}, map[string]bool{"a": true, "b": true, "c": false}},
+		{"except", []Option{WithExcept(toolName("b"))}, map[string]bool{"a": true, "b": false, "c": true}},
+		{"except wins", []Option{WithOnly("a", "b"), WithExcept("a")}, map[string]bool{"a": false, "b": true, "c": false}},
+	}
+	for _, tc := range cases {
+		o := NewOptions(tc.opts...)
+		for tool, want := range tc.want {
+			if got := o.Includes(tool); got != want {
+				t.Errorf("%s: Includes(%q) = %v, want %v", tc.name, tool, got, want)
+			}
+		}
+	}
+
+	var nilOpts *Options
+	if !nilOpts.Includes("a") {
+		t.Error("nil Options should include every tool")
+	}
+}
@@ -0,0 +1,54 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// MarshalOutput renders a tool result as JSON for hosts other than Genkit.
+// Proto messages, alone or in a slice, are encoded with protojson; other
+// values use encoding/json.
+func MarshalOutput(v any) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.Marshal(m)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Implements(reflect.TypeFor[proto.Message]()) {
+		items := make([]json.RawMessage, rv.Len())
+		for i := range items {
+			raw, err := protojson.Marshal(rv.Index(i).Interface().(proto.Message))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = raw
+		}
+		return json.Marshal(items)
+	}
+	return json.Marshal(v)
+}
+
+// RenderJSON returns the MarshalOutput encoding of resp as a string, for tools
+// declaring RESULT_FORMAT_JSON.
+func RenderJSON(resp any) (string, error) {
+	raw, err := MarshalOutput(resp)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// RenderTemplate executes t over resp, for tools declaring
+// RESULT_FORMAT_TEMPLATE.
+func RenderTemplate(t *template.Template, resp any) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, resp); err != nil {
+		return "", fmt.Errorf("render %s result: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
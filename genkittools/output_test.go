@@ -0,0 +1,58 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"text/template"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestMarshalOutput(t *testing.T) {
+	field := &descriptorpb.FieldDescriptorProto{Name: proto.String("city"), JsonName: proto.String("city")}
+	cases := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"message", field, `{"name":"city","jsonName":"city"}`},
+		{"message slice", []*descriptorpb.FieldDescriptorProto{field, {Number: proto.Int32(2)}}, `[{"name":"city","jsonName":"city"},{"number":2}]`},
+		{"plain value", map[string]int{"n": 1}, `{"n":1}`},
+	}
+	for _, tc := range cases {
+		raw, err := MarshalOutput(tc.value)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		// protojson output is not byte-stable, so compare decoded values.
+		var got, want any
+		if err := json.Unmarshal(raw, &got); err != nil {
+			t.Fatalf("%s: decode %s: %v", tc.name, raw, err)
+		}
+		_ = json.Unmarshal([]byte(tc.want), &want)
+		gotRaw, _ := json.Marshal(got)
+		wantRaw, _ := json.Marshal(want)
+		if string(gotRaw) != string(wantRaw) {
+			t.Errorf("%s: MarshalOutput = %s, want %s", tc.name, raw, tc.want)
+		}
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	field := &descriptorpb.FieldDescriptorProto{Name: proto.String("city"), Number: proto.Int32(3)}
+	tmpl := template.Must(template.New("describe").Parse("{{.GetName}} is field {{.GetNumber}}"))
+	if got, err := RenderTemplate(tmpl, field); err != nil || got != "city is field 3" {
+		t.Fatalf("RenderTemplate = %q, %v", got, err)
+	}
+
+	bad := template.Must(template.New("describe").Parse("{{.Missing}}"))
+	if _, err := RenderTemplate(bad, field); err == nil || !strings.Contains(err.Error(), "render describe result") {
+		t.Fatalf("RenderTemplate error = %v", err)
+	}
+
+	if got, err := RenderJSON(field); err != nil || !strings.Contains(got, `"name":`) {
+		t.Fatalf("RenderJSON = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,26 @@
+package genkittools
+
+import "context"
+
+// Progress is an intermediate status update of a long-running tool.
+type Progress struct {
+	// Message describes the current step, e.g. "fetched 3 of 10 pages".
+	Message string `json:"message,omitempty"`
+	// Fraction is the share of the work done so far, from 0 to 1, or 0 when
+	// unknown.
+	Fraction float64 `json:"fraction,omitempty"`
+}
+
+// ProgressFunc is handed to the implementation of a tool declared with
+// (genkit.tool.v1.tool_doc).progress to report its status during the call.
+// An error aborts the call.
+type ProgressFunc func(Progress) error
+
+// ProgressReporter returns the ProgressFunc of a call of the tool called
+// name, which passes each update to the ChunkFunc installed in ctx with
+// WithChunkFunc, if any.
+func ProgressReporter(ctx context.Context, tool string) ProgressFunc {
+	return func(p Progress) error {
+		return SendChunk(ctx, tool, p)
+	}
+}
@@ -0,0 +1,37 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var seen []Progress
+	ctx := WithChunkFunc(context.Background(), func(_ context.Context, tool string, chunk any) error {
+		if tool != "build_report" {
+			t.Errorf("tool = %q", tool)
+		}
+		seen = append(seen, chunk.(Progress))
+		return nil
+	})
+
+	report := ProgressReporter(ctx, "build_report")
+	if err := report(Progress{Message: "half way", Fraction: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 || seen[0].Message != "half way" || seen[0].Fraction != 0.5 {
+		t.Fatalf("forwarded %v", seen)
+	}
+
+	// Without a ChunkFunc updates are dropped.
+	if err := ProgressReporter(context.Background(), "build_report")(Progress{Message: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	boom := errors.New("boom")
+	ctx = WithChunkFunc(context.Background(), func(context.Context, string, any) error { return boom })
+	if err := ProgressReporter(ctx, "build_report")(Progress{}); !errors.Is(err, boom) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Package prommetrics exports generated Genkit tool metrics to Prometheus.
+package prommetrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/genkittools"
+)
+
+// Metrics implements genkittools.Metrics with a call counter and a latency
+// histogram, both labelled by tool name, plus a counter of schema validation
+// failures labelled by tool and input path.
+type Metrics struct {
+	calls      *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	violations *prometheus.CounterVec
+}
+
+var (
+	_ genkittools.Metrics           = (*Metrics)(nil)
+	_ genkittools.ValidationMetrics = (*Metrics)(nil)
+)
+
+// New creates the collectors and registers them with reg, or with
+// prometheus.DefaultRegisterer when reg is nil. Collectors registered by an
+// earlier call are reused, so several services can share one registry.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	calls, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "genkit_tool_calls_total",
+		Help: "Number of Genkit tool invocations by tool and outcome.",
+	}, []string{"tool", "outcome"}))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "genkit_tool_duration_seconds",
+		Help:    "Latency of Genkit tool invocations in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"tool"}))
+	if err != nil {
+		return nil, err
+	}
+	violations, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "genkit_tool_validation_failures_total",
+		Help: "Number of tool inputs failing schema validation by tool and JSON pointer path.",
+	}, []string{"tool", "path"}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metrics{calls: calls, duration: duration, violations: violations}, nil
+}
+
+// ObserveCall implements genkittools.Metrics.
+func (m *Metrics) ObserveCall(tool string, duration time.Duration, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.calls.WithLabelValues(tool, outcome).Inc()
+	m.duration.WithLabelValues(tool).Observe(duration.Seconds())
+}
+
+// ObserveValidationFailure implements genkittools.ValidationMetrics.
+func (m *Metrics) ObserveValidationFailure(tool, field string) {
+	m.violations.WithLabelValues(tool, field).Inc()
+}
+
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(C); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
@@ -0,0 +1,50 @@
+package prommetrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveCall(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second registration must reuse the existing collectors.
+	again, err := New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.ObserveCall("get_weather", 10*time.Millisecond, nil)
+	again.ObserveCall("get_weather", 20*time.Millisecond, errors.New("boom"))
+
+	if got := testutil.ToFloat64(m.calls.WithLabelValues("get_weather", "ok")); got != 1 {
+		t.Fatalf("ok calls = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.calls.WithLabelValues("get_weather", "error")); got != 1 {
+		t.Fatalf("error calls = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.duration, "genkit_tool_duration_seconds"); got != 1 {
+		t.Fatalf("duration series = %d, want 1", got)
+	}
+}
+
+func TestObserveValidationFailure(t *testing.T) {
+	m, err := New(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.ObserveValidationFailure("create_invoice", "/invoice/line_items/*/quantity")
+	m.ObserveValidationFailure("create_invoice", "/invoice/line_items/*/quantity")
+
+	if got := testutil.ToFloat64(m.violations.WithLabelValues("create_invoice", "/invoice/line_items/*/quantity")); got != 2 {
+		t.Fatalf("violations = %v, want 2", got)
+	}
+}
@@ -0,0 +1,70 @@
+package genkittools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PromptSection renders tools as a plain-text list for system prompts of
+// models that need explicit tool documentation: one entry per tool with its
+// name, the first line of its description and its top-level parameters,
+// required ones first.
+func PromptSection(tools []*ToolInfo) string {
+	var b strings.Builder
+	b.WriteString("Available tools:\n")
+	for _, info := range tools {
+		desc, _, _ := strings.Cut(strings.TrimSpace(info.Description), "\n")
+		fmt.Fprintf(&b, "- %s: %s\n", info.Name, desc)
+		if params := promptParameters(info.InputSchema); params != "" {
+			fmt.Fprintf(&b, "  Parameters: %s\n", params)
+		}
+	}
+	return b.String()
+}
+
+func promptParameters(schema map[string]any) string {
+	props, _ := schema["properties"].(map[string]any)
+	required := make(map[string]bool)
+	for _, name := range requiredNames(schema["required"]) {
+		required[name] = true
+	}
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if required[names[i]] != required[names[j]] {
+			return required[names[i]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		prop, _ := props[name].(map[string]any)
+		typ := promptType(prop)
+		if required[name] {
+			typ += ", required"
+		}
+		parts[i] = fmt.Sprintf("%s (%s)", name, typ)
+		if desc, ok := prop["description"].(string); ok && desc != "" {
+			parts[i] += ": " + desc
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// promptType names the type of a property schema, e.g. "array of string".
+func promptType(schema map[string]any) string {
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "array":
+		if items, ok := schema["items"].(map[string]any); ok {
+			return "array of " + promptType(items)
+		}
+	case "":
+		return "any"
+	}
+	return typ
+}
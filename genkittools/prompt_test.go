@@ -0,0 +1,30 @@
+package genkittools
+
+import "testing"
+
+func TestPromptSection(t *testing.T) {
+	tools := []*ToolInfo{
+		{
+			Name:        "get_weather",
+			Description: "Fetch weather by city\nUses the public forecast API.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"units": map[string]any{"type": "string", "description": "Units metric/imperial"},
+					"city":  map[string]any{"type": "string", "description": "City name"},
+					"days":  map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+				},
+				"required": []string{"city"},
+			},
+		},
+		{Name: "ping", Description: "Check the service", InputSchema: map[string]any{"type": "object"}},
+	}
+
+	want := "Available tools:\n" +
+		"- get_weather: Fetch weather by city\n" +
+		"  Parameters: city (string, required): City name; days (array of integer); units (string): Units metric/imperial\n" +
+		"- ping: Check the service\n"
+	if got := PromptSection(tools); got != want {
+		t.Fatalf("PromptSection =\n%s\nwant\n%s", got, want)
+	}
+}
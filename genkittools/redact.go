@@ -0,0 +1,167 @@
+package genkittools
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces sensitive values in logged or recorded tool inputs.
+const Redacted = "[REDACTED]"
+
+// Redact returns a copy of value, normalized to its JSON form, in which every
+// location matching one of fields is replaced by Redacted. Fields use the
+// same syntax as Violation.Field: JSON pointers whose "*" segments match any
+// array index or map key. Segments match both the proto field name and its
+// lowerCamelCase JSON name, since protojson accepts either.
+func Redact(value any, fields []string) any {
+	value = normalizeJSON(value)
+	if len(fields) == 0 {
+		return value
+	}
+	patterns := make([][]string, 0, len(fields))
+	for _, f := range fields {
+		patterns = append(patterns, strings.Split(strings.TrimPrefix(f, "/"), "/"))
+	}
+	return redactValue(value, patterns)
+}
+
+type sensitiveFieldsKey struct{}
+
+// RedactInput returns Redact(value, fields) for the sensitive fields of the
+// tool whose call ctx belongs to, so that request hooks, authorizers and
+// tracing code can record the input of a call the way WithLogger does.
+// Outside a tool call, or for tools without sensitive fields, value is only
+// normalized.
+func RedactInput(ctx context.Context, value any) any {
+	fields, _ := ctx.Value(sensitiveFieldsKey{}).([]string)
+	return Redact(value, fields)
+}
+
+// redactText replaces the values that Redact hides in input wherever they
+// appear in text, such as an error message quoting an invalid value or a
+// panic value, before text is logged.
+func redactText(text string, input any, fields []string) string {
+	if len(fields) == 0 {
+		return text
+	}
+	var values []string
+	collectRedacted(normalizeJSON(input), Redact(input, fields), &values)
+	if len(values) == 0 {
+		return text
+	}
+	// Longer values first, so that a value is not partly replaced through
+	// another one it contains.
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		pairs = append(pairs, v, Redacted)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// collectRedacted appends the scalar values of value that redacted replaces
+// with Redacted, walking both in step.
+func collectRedacted(value, redacted any, out *[]string) {
+	if redacted == Redacted {
+		collectScalars(value, out)
+		return
+	}
+	switch val := value.(type) {
+	case map[string]any:
+		r, _ := redacted.(map[string]any)
+		for key, child := range val {
+			collectRedacted(child, r[key], out)
+		}
+	case []any:
+		r, _ := redacted.([]any)
+		for i, child := range val {
+			if i < len(r) {
+				collectRedacted(child, r[i], out)
+			}
+		}
+	}
+}
+
+// collectScalars appends the text of every string and number in value.
+// Booleans and empty strings are skipped, as replacing them would garble
+// the rest of the text without hiding anything.
+func collectScalars(value any, out *[]string) {
+	switch val := value.(type) {
+	case map[string]any:
+		for _, child := range val {
+			collectScalars(child, out)
+		}
+	case []any:
+		for _, child := range val {
+			collectScalars(child, out)
+		}
+	case string:
+		if val != "" && val != Redacted {
+			*out = append(*out, val)
+		}
+	case float64:
+		raw, _ := json.Marshal(val)
+		*out = append(*out, string(raw))
+	}
+}
+
+func redactValue(value any, patterns [][]string) any {
+	switch val := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for key, child := range val {
+			out[key] = redactChild(key, child, patterns)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, child := range val {
+			out[i] = redactChild("*", child, patterns)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func redactChild(key string, child any, patterns [][]string) any {
+	var rest [][]string
+	for _, p := range patterns {
+		if len(p) == 0 || !segmentMatches(p[0], key) {
+			continue
+		}
+		if len(p) == 1 {
+			return Redacted
+		}
+		rest = append(rest, p[1:])
+	}
+	if len(rest) == 0 {
+		return child
+	}
+	return redactValue(child, rest)
+}
+
+func segmentMatches(segment, key string) bool {
+	segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+	return segment == "*" || segment == key || jsonCamelCase(segment) == key
+}
+
+// jsonCamelCase mirrors protoc's default json_name derivation.
+func jsonCamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
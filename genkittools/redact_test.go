@@ -0,0 +1,71 @@
+package genkittools
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	input := map[string]any{
+		"invoice": map[string]any{
+			"customerId": "c-42",
+			"line_items": []any{
+				map[string]any{"card": "4111", "sku": "a"},
+				map[string]any{"card": "5500", "sku": "b"},
+			},
+		},
+		"city": "Berlin",
+	}
+	got := Redact(input, []string{"/invoice/customer_id", "/invoice/line_items/*/card"})
+	want := map[string]any{
+		"invoice": map[string]any{
+			"customerId": Redacted,
+			"line_items": []any{
+				map[string]any{"card": Redacted, "sku": "a"},
+				map[string]any{"card": Redacted, "sku": "b"},
+			},
+		},
+		"city": "Berlin",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Redact() = %v, want %v", got, want)
+	}
+	if input["invoice"].(map[string]any)["customerId"] != "c-42" {
+		t.Fatal("Redact modified its input")
+	}
+}
+
+func TestRedactText(t *testing.T) {
+	input := map[string]any{"card": "4111", "pin": 1234, "nested": map[string]any{"secret": []any{"s3cr3t", true}}, "city": "Berlin"}
+	fields := []string{"/card", "/pin", "/nested"}
+	got := redactText(`card: invalid value "4111" for pin 1234, s3cr3t in Berlin, true`, input, fields)
+	want := `card: invalid value "[REDACTED]" for pin [REDACTED], [REDACTED] in Berlin, true`
+	if got != want {
+		t.Fatalf("redactText() = %q, want %q", got, want)
+	}
+	if got := redactText("4111", input, nil); got != "4111" {
+		t.Fatalf("redactText without fields = %q", got)
+	}
+}
+
+func TestRedactInput(t *testing.T) {
+	info := &ToolInfo{Name: "pay", SensitiveFields: []string{"/card"}}
+	var got any
+	_, err := Invoke(context.Background(), nil, info, map[string]any{"card": "4111", "amount": 3},
+		func(input any) (map[string]any, error) { return input.(map[string]any), nil },
+		func(ctx context.Context, req map[string]any) (bool, error) {
+			got = RedactInput(ctx, req)
+			return true, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"card": Redacted, "amount": float64(3)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RedactInput() = %v, want %v", got, want)
+	}
+	if got := RedactInput(context.Background(), map[string]any{"card": "4111"}); !reflect.DeepEqual(got, map[string]any{"card": "4111"}) {
+		t.Fatalf("RedactInput outside a call = %v", got)
+	}
+}
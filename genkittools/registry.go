@@ -0,0 +1,71 @@
+package genkittools
+
+import (
+	"slices"
+	"sort"
+	"sync"
+)
+
+// ToolRegistry holds the metadata of every generated tool linked into the
+// binary. Generated files add their tools from init, so it is complete before
+// main runs regardless of whether the tools are registered with Genkit.
+var ToolRegistry = &Registry{}
+
+// Registry indexes ToolInfo values by tool name. It is safe for concurrent
+// use.
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]*ToolInfo
+}
+
+// Add records infos, replacing any earlier entry with the same name.
+func (r *Registry) Add(infos ...*ToolInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tools == nil {
+		r.tools = make(map[string]*ToolInfo)
+	}
+	for _, info := range infos {
+		r.tools[info.Name] = info
+	}
+}
+
+// All returns every recorded tool sorted by name.
+func (r *Registry) All() []*ToolInfo {
+	return r.filter(func(*ToolInfo) bool { return true })
+}
+
+// ByTag returns the tools annotated with tag, sorted by name.
+func (r *Registry) ByTag(tag string) []*ToolInfo {
+	return r.filter(func(info *ToolInfo) bool { return slices.Contains(info.Tags, tag) })
+}
+
+// Lookup returns the tool registered under name.
+func (r *Registry) Lookup(name string) (*ToolInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.tools[name]
+	return info, ok
+}
+
+func (r *Registry) filter(keep func(*ToolInfo) bool) []*ToolInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*ToolInfo
+	for _, info := range r.tools {
+		if keep(info) {
+			out = append(out, info)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// All returns every tool in ToolRegistry sorted by name.
+func All() []*ToolInfo { return ToolRegistry.All() }
+
+// ByTag returns the tools in ToolRegistry annotated with tag.
+func ByTag(tag string) []*ToolInfo { return ToolRegistry.ByTag(tag) }
+
+// Lookup returns the tool in ToolRegistry registered under name.
+func Lookup(name string) (*ToolInfo, bool) { return ToolRegistry.Lookup(name) }
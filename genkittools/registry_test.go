@@ -0,0 +1,50 @@
+package genkittools
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := &Registry{}
+	if got := r.All(); len(got) != 0 {
+		t.Fatalf("empty registry All() = %v", got)
+	}
+
+	r.Add(
+		&ToolInfo{Name: "get_weather", Service: "demo.Catalog", Tags: []string{"demo"}},
+		&ToolInfo{Name: "create_invoice", Service: "billing.Invoices", Tags: []string{"billing", "demo"}},
+		&ToolInfo{Name: "delete_invoice", Service: "billing.Invoices", Tags: []string{"billing"}},
+	)
+
+	if got := names(r.All()); !slices.Equal(got, []string{"create_invoice", "delete_invoice", "get_weather"}) {
+		t.Fatalf("All() = %v", got)
+	}
+	if got := names(r.ByTag("demo")); !slices.Equal(got, []string{"create_invoice", "get_weather"}) {
+		t.Fatalf("ByTag(demo) = %v", got)
+	}
+	if got := r.ByTag("missing"); len(got) != 0 {
+		t.Fatalf("ByTag(missing) = %v", got)
+	}
+
+	info, ok := r.Lookup("delete_invoice")
+	if !ok || info.Service != "billing.Invoices" {
+		t.Fatalf("Lookup(delete_invoice) = %+v, %v", info, ok)
+	}
+	if _, ok := r.Lookup("nope"); ok {
+		t.Fatal("Lookup(nope) found a tool")
+	}
+
+	r.Add(&ToolInfo{Name: "get_weather", Service: "demo.Other"})
+	if info, _ := r.Lookup("get_weather"); info.Service != "demo.Other" {
+		t.Fatalf("re-Add did not replace entry: %+v", info)
+	}
+}
+
+func names(infos []*ToolInfo) []string {
+	out := make([]string, len(infos))
+	for i, info := range infos {
+		out[i] = info.Name
+	}
+	return out
+}
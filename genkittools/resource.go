@@ -0,0 +1,57 @@
+package genkittools
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DecodeURIVariables sets the fields of msg named by the variables a
+// resource URI template matched, for the resource called name. Each value is
+// parsed like a JSON map key of the field's type: numbers and enum names as
+// text, bools as "true" or "false".
+func DecodeURIVariables(name string, vars map[string]string, msg proto.Message) error {
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for key, v := range vars {
+		field := fields.ByName(protoreflect.Name(key))
+		if field == nil || field.IsList() || field.IsMap() || field.Message() != nil {
+			return fmt.Errorf("decode %s URI: %w", name, UnknownField("", key))
+		}
+		path := "/" + key
+		var x protoreflect.Value
+		var err error
+		if field.Kind() == protoreflect.BoolKind {
+			var b bool
+			b, err = DecodeBoolKey(v, path)
+			x = protoreflect.ValueOfBool(b)
+		} else {
+			x, err = decodeValue(field, nil, v, path)
+		}
+		if err != nil {
+			return fmt.Errorf("decode %s URI: %w", name, err)
+		}
+		m.Set(field, x)
+	}
+	return nil
+}
+
+// URIArguments converts the variables an MCP server matched in a resource
+// URI, held as strings or lists of strings, into the form
+// DecodeURIVariables takes. The values of a list are joined with commas.
+func URIArguments(args map[string]any) map[string]string {
+	vars := make(map[string]string, len(args))
+	for key, v := range args {
+		switch v := v.(type) {
+		case string:
+			vars[key] = v
+		case []string:
+			vars[key] = strings.Join(v, ",")
+		default:
+			vars[key] = fmt.Sprint(v)
+		}
+	}
+	return vars
+}
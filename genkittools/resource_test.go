@@ -0,0 +1,35 @@
+package genkittools
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestDecodeURIVariables(t *testing.T) {
+	field := &descriptorpb.FieldDescriptorProto{}
+	vars := map[string]string{"name": "city", "number": "2", "label": "LABEL_REPEATED", "proto3_optional": "true"}
+	if err := DecodeURIVariables("get_field", vars, field); err != nil {
+		t.Fatal(err)
+	}
+	if field.GetName() != "city" || field.GetNumber() != 2 || field.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED || !field.GetProto3Optional() {
+		t.Fatalf("decoded %v", field)
+	}
+
+	err := DecodeURIVariables("get_field", map[string]string{"number": "two"}, &descriptorpb.FieldDescriptorProto{})
+	if err == nil || !strings.Contains(err.Error(), `decode get_field URI: /number: invalid integer "two"`) {
+		t.Fatalf("err = %v", err)
+	}
+	err = DecodeURIVariables("get_field", map[string]string{"options": "x"}, &descriptorpb.FieldDescriptorProto{})
+	if err == nil || !strings.Contains(err.Error(), `unknown field "options"`) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestURIArguments(t *testing.T) {
+	got := URIArguments(map[string]any{"name": []string{"city"}, "tags": []string{"a", "b"}, "number": "2"})
+	if len(got) != 3 || got["name"] != "city" || got["tags"] != "a,b" || got["number"] != "2" {
+		t.Fatalf("URIArguments = %v", got)
+	}
+}
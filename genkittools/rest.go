@@ -0,0 +1,351 @@
+package genkittools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxErrorBody bounds how much of a failed REST response is read into a
+// RemoteError message.
+const maxErrorBody = 4096
+
+// HTTPRule is the primary google.api.http binding of an RPC, as used by the
+// generated REST adapters.
+type HTTPRule struct {
+	// Method is the HTTP verb, e.g. "GET", or the kind of a custom binding.
+	Method string
+	// Pattern is the URL path template, e.g. "/v1/{name=invoices/*}".
+	Pattern string
+	// Body names the request field sent as the JSON body: "*" for every field
+	// not bound by the path, empty for none.
+	Body string
+	// ResponseBody names the response field filled from the JSON reply; empty
+	// for the whole response.
+	ResponseBody string
+}
+
+// CallREST issues the REST call described by rule for req against baseURL and
+// decodes the reply into resp. Path variables are filled from req, the body is
+// encoded with protojson, and the remaining populated fields are sent as query
+// parameters. Non-2xx replies are returned as *RemoteError. A nil client uses
+// http.DefaultClient.
+func CallREST(ctx context.Context, client *http.Client, baseURL string, rule HTTPRule, req, resp proto.Message) error {
+	msg := req.ProtoReflect()
+	bound := make(map[string]bool)
+	path, err := expandPath(rule.Pattern, msg, bound)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	switch rule.Body {
+	case "":
+	case "*":
+		rest := proto.Clone(req).ProtoReflect()
+		for field := range bound {
+			clearFieldPath(rest, field)
+		}
+		raw, err := protojson.Marshal(rest.Interface())
+		if err != nil {
+			return fmt.Errorf("marshal %s body: %w", msg.Descriptor().FullName(), err)
+		}
+		body = bytes.NewReader(raw)
+	default:
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(rule.Body))
+		if fd == nil {
+			return fmt.Errorf("%s has no body field %q", msg.Descriptor().FullName(), rule.Body)
+		}
+		raw, err := marshalField(msg, fd)
+		if err != nil {
+			return fmt.Errorf("marshal %s body: %w", msg.Descriptor().FullName(), err)
+		}
+		body = bytes.NewReader(raw)
+		bound[rule.Body] = true
+	}
+
+	target := strings.TrimSuffix(baseURL, "/") + path
+	if rule.Body != "*" {
+		query := url.Values{}
+		addQuery(query, msg, "", bound)
+		if len(query) > 0 {
+			target += "?" + query.Encode()
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, rule.Method, target, body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		raw, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxErrorBody))
+		return restError(httpResp.StatusCode, raw)
+	}
+	raw, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	if rule.ResponseBody != "" {
+		raw = append(append([]byte(`{`+strconv.Quote(rule.ResponseBody)+`:`), raw...), '}')
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(raw, resp); err != nil {
+		return fmt.Errorf("unmarshal %s: %w", resp.ProtoReflect().Descriptor().FullName(), err)
+	}
+	return nil
+}
+
+// expandPath substitutes the {field.path=template} variables of pattern with
+// values from msg, recording each bound field path.
+func expandPath(pattern string, msg protoreflect.Message, bound map[string]bool) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			b.WriteString(pattern)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable in path template %q", pattern)
+		}
+		b.WriteString(pattern[:start])
+		variable := pattern[start+1 : start+end]
+		pattern = pattern[start+end+1:]
+
+		field, template, hasTemplate := strings.Cut(variable, "=")
+		value, ok := fieldPathValue(msg, field)
+		if !ok || value == "" {
+			return "", fmt.Errorf("%s: path field %q is not set", msg.Descriptor().FullName(), field)
+		}
+		bound[field] = true
+		// Multi-segment templates take a resource name whose slashes are
+		// part of the path; everything else is a single escaped segment.
+		if hasTemplate && (strings.Contains(template, "/") || strings.Contains(template, "**")) {
+			segments := strings.Split(value, "/")
+			for i, s := range segments {
+				segments[i] = url.PathEscape(s)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+		} else {
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+}
+
+// fieldPathValue renders the singular field at the dotted path in msg.
+func fieldPathValue(msg protoreflect.Message, path string) (string, bool) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil || fd.IsList() || fd.IsMap() {
+			return "", false
+		}
+		if i < len(names)-1 {
+			if fd.Message() == nil || !msg.Has(fd) {
+				return "", false
+			}
+			msg = msg.Get(fd).Message()
+			continue
+		}
+		return formatScalar(fd, msg.Get(fd)), true
+	}
+	return "", false
+}
+
+func clearFieldPath(msg protoreflect.Message, path string) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return
+		}
+		if i == len(names)-1 {
+			msg.Clear(fd)
+			return
+		}
+		if fd.Message() == nil || !msg.Has(fd) {
+			return
+		}
+		msg = msg.Mutable(fd).Message()
+	}
+}
+
+// marshalField encodes a single field of msg as JSON.
+func marshalField(msg protoreflect.Message, fd protoreflect.FieldDescriptor) ([]byte, error) {
+	if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+		return protojson.Marshal(msg.Get(fd).Message().Interface())
+	}
+	// Encode a message holding only fd and strip the enclosing object.
+	only := msg.Type().New()
+	if msg.Has(fd) {
+		only.Set(fd, msg.Get(fd))
+	}
+	raw, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(only.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields[string(fd.Name())], nil
+}
+
+// addQuery adds the populated scalar fields of msg not bound by the path or
+// body to query, naming nested fields by their dotted path.
+func addQuery(query url.Values, msg protoreflect.Message, prefix string, bound map[string]bool) {
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := prefix + string(fd.Name())
+		if bound[path] || fd.IsMap() || !msg.Has(fd) {
+			continue
+		}
+		switch {
+		case fd.IsList():
+			if fd.Message() != nil {
+				continue
+			}
+			list := msg.Get(fd).List()
+			for j := 0; j < list.Len(); j++ {
+				query.Add(path, formatScalar(fd, list.Get(j)))
+			}
+		case fd.Message() != nil:
+			addQuery(query, msg.Get(fd).Message(), path+".", bound)
+		default:
+			query.Set(path, formatScalar(fd, msg.Get(fd)))
+		}
+	}
+}
+
+func formatScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.BytesKind:
+		return base64.URLEncoding.EncodeToString(v.Bytes())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// restError converts a failed REST reply into a RemoteError, preferring the
+// message and details of a google.rpc.Status body when there is one.
+func restError(status int, body []byte) error {
+	type restStatus struct {
+		Message string       `json:"message"`
+		Details []restDetail `json:"details"`
+	}
+	var reply struct {
+		restStatus
+		Error *restStatus `json:"error"`
+	}
+	rerr := &RemoteError{
+		Code:    httpStatusCode(status),
+		Message: strings.TrimSpace(string(body)),
+		Err:     fmt.Errorf("http status %d", status),
+	}
+	if json.Unmarshal(body, &reply) == nil {
+		st := reply.restStatus
+		if reply.Error != nil {
+			st = *reply.Error
+		}
+		if st.Message != "" {
+			rerr.Message = st.Message
+		}
+		for _, d := range st.Details {
+			d.addTo(rerr)
+		}
+	}
+	if rerr.Message == "" {
+		rerr.Message = http.StatusText(status)
+	}
+	return rerr
+}
+
+// restDetail is a google.rpc.BadRequest or google.rpc.ErrorInfo detail in its
+// JSON form.
+type restDetail struct {
+	Type            string `json:"@type"`
+	FieldViolations []struct {
+		Field       string `json:"field"`
+		Description string `json:"description"`
+	} `json:"fieldViolations"`
+	Reason   string            `json:"reason"`
+	Domain   string            `json:"domain"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+func (d restDetail) addTo(rerr *RemoteError) {
+	switch d.Type[strings.LastIndexByte(d.Type, '/')+1:] {
+	case badRequestType:
+		for _, v := range d.FieldViolations {
+			rerr.Violations = append(rerr.Violations, FieldViolation{Field: v.Field, Message: v.Description})
+		}
+	case errorInfoType:
+		rerr.Reason, rerr.Domain, rerr.Metadata = d.Reason, d.Domain, d.Metadata
+	}
+}
+
+// httpStatusCode maps an HTTP status to the RPC code name it usually
+// transcodes from.
+func httpStatusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_argument"
+	case http.StatusUnauthorized:
+		return "unauthenticated"
+	case http.StatusForbidden:
+		return "permission_denied"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "aborted"
+	case http.StatusTooManyRequests:
+		return "resource_exhausted"
+	case 499:
+		return "canceled"
+	case http.StatusNotImplemented:
+		return "unimplemented"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	case http.StatusGatewayTimeout:
+		return "deadline_exceeded"
+	case http.StatusInternalServerError:
+		return "internal"
+	default:
+		return "unknown"
+	}
+}
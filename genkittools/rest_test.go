@@ -0,0 +1,159 @@
+package genkittools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type recordedRequest struct {
+	method string
+	uri    string
+	body   string
+}
+
+func restServer(t *testing.T, status int, reply string) (*httptest.Server, *recordedRequest) {
+	t.Helper()
+	rec := &recordedRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*rec = recordedRequest{method: r.Method, uri: r.URL.RequestURI(), body: string(body)}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, rec
+}
+
+func sampleField() *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:    proto.String("fields/a b"),
+		Number:  proto.Int32(3),
+		Label:   descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Options: &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)},
+	}
+}
+
+func TestCallRESTQueryAndPath(t *testing.T) {
+	srv, rec := restServer(t, http.StatusOK, `{"name":"created","number":7,"unknownField":1}`)
+
+	resp := &descriptorpb.FieldDescriptorProto{}
+	rule := HTTPRule{Method: http.MethodGet, Pattern: "/v1/{name}"}
+	if err := CallREST(context.Background(), srv.Client(), srv.URL+"/", rule, sampleField(), resp); err != nil {
+		t.Fatalf("CallREST: %v", err)
+	}
+
+	if rec.method != http.MethodGet || rec.body != "" {
+		t.Fatalf("request = %+v", rec)
+	}
+	if want := "/v1/fields%2Fa%20b?label=LABEL_REPEATED&number=3&options.deprecated=true"; rec.uri != want {
+		t.Fatalf("uri = %q, want %q", rec.uri, want)
+	}
+	if resp.GetName() != "created" || resp.GetNumber() != 7 {
+		t.Fatalf("resp = %v", resp)
+	}
+}
+
+func TestCallRESTBodies(t *testing.T) {
+	cases := []struct {
+		name     string
+		rule     HTTPRule
+		wantURI  string
+		wantBody map[string]any
+	}{
+		{
+			name:     "star body excludes path fields",
+			rule:     HTTPRule{Method: http.MethodPost, Pattern: "/v1/{name=fields/*}:create", Body: "*"},
+			wantURI:  "/v1/fields/a%20b:create",
+			wantBody: map[string]any{"number": float64(3), "label": "LABEL_REPEATED", "options": map[string]any{"deprecated": true}},
+		},
+		{
+			name:     "field body",
+			rule:     HTTPRule{Method: http.MethodPatch, Pattern: "/v1/{name=**}", Body: "options"},
+			wantURI:  "/v1/fields/a%20b?label=LABEL_REPEATED&number=3",
+			wantBody: map[string]any{"deprecated": true},
+		},
+		{
+			name:     "scalar field body",
+			rule:     HTTPRule{Method: http.MethodPut, Pattern: "/v1/{name=fields/*}/number", Body: "number"},
+			wantURI:  "/v1/fields/a%20b/number?label=LABEL_REPEATED&options.deprecated=true",
+			wantBody: nil,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, rec := restServer(t, http.StatusOK, `{}`)
+			if err := CallREST(context.Background(), srv.Client(), srv.URL, tc.rule, sampleField(), &descriptorpb.FieldDescriptorProto{}); err != nil {
+				t.Fatalf("CallREST: %v", err)
+			}
+			if rec.method != tc.rule.Method || rec.uri != tc.wantURI {
+				t.Fatalf("request = %s %s, want %s %s", rec.method, rec.uri, tc.rule.Method, tc.wantURI)
+			}
+			if tc.wantBody == nil {
+				if rec.body != "3" {
+					t.Fatalf("body = %q, want 3", rec.body)
+				}
+				return
+			}
+			var got map[string]any
+			if err := json.Unmarshal([]byte(rec.body), &got); err != nil {
+				t.Fatalf("body %q: %v", rec.body, err)
+			}
+			gotRaw, _ := json.Marshal(got)
+			wantRaw, _ := json.Marshal(tc.wantBody)
+			if string(gotRaw) != string(wantRaw) {
+				t.Fatalf("body = %s, want %s", gotRaw, wantRaw)
+			}
+		})
+	}
+}
+
+func TestCallRESTResponseBody(t *testing.T) {
+	srv, _ := restServer(t, http.StatusOK, `{"deprecated":true}`)
+
+	resp := &descriptorpb.FieldDescriptorProto{}
+	rule := HTTPRule{Method: http.MethodGet, Pattern: "/v1/{name=**}/options", ResponseBody: "options"}
+	if err := CallREST(context.Background(), srv.Client(), srv.URL, rule, sampleField(), resp); err != nil {
+		t.Fatalf("CallREST: %v", err)
+	}
+	if !resp.GetOptions().GetDeprecated() {
+		t.Fatalf("resp = %v", resp)
+	}
+}
+
+func TestCallRESTErrors(t *testing.T) {
+	srv, _ := restServer(t, http.StatusNotFound, `{"error":{"code":404,"message":"no such field","status":"NOT_FOUND"}}`)
+
+	rule := HTTPRule{Method: http.MethodGet, Pattern: "/v1/{name=**}"}
+	err := CallREST(context.Background(), srv.Client(), srv.URL, rule, sampleField(), &descriptorpb.FieldDescriptorProto{})
+	var rerr *RemoteError
+	if !errors.As(err, &rerr) || rerr.Code != "not_found" || rerr.Message != "no such field" {
+		t.Fatalf("err = %#v", err)
+	}
+
+	srv, _ = restServer(t, http.StatusBadRequest, `{"error":{"code":400,"message":"bad field","details":[`+
+		`{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":"name","description":"must be lower case"}]},`+
+		`{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"NAME_STYLE","domain":"example.com"}]}}`)
+	err = CallREST(context.Background(), srv.Client(), srv.URL, rule, sampleField(), &descriptorpb.FieldDescriptorProto{})
+	if err == nil || err.Error() != "invalid_argument: bad field; name: must be lower case; reason NAME_STYLE (example.com)" {
+		t.Fatalf("err = %v", err)
+	}
+
+	srv, _ = restServer(t, http.StatusServiceUnavailable, "")
+	err = CallREST(context.Background(), srv.Client(), srv.URL, rule, sampleField(), &descriptorpb.FieldDescriptorProto{})
+	if err == nil || err.Error() != "unavailable: Service Unavailable" {
+		t.Fatalf("err = %v", err)
+	}
+
+	err = CallREST(context.Background(), srv.Client(), srv.URL, rule, &descriptorpb.FieldDescriptorProto{}, &descriptorpb.FieldDescriptorProto{})
+	if err == nil || err.Error() != `google.protobuf.FieldDescriptorProto: path field "name" is not set` {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy configures how idempotent tools retry failed implementation
+// calls. Tools whose RPC declares idempotency_level IDEMPOTENT or
+// NO_SIDE_EFFECTS are eligible; others are always called once.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of calls, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Defaults to 100ms.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each retry. Defaults to 2.
+	Multiplier float64
+	// Retryable reports whether err is transient. By default every error is
+	// retried except context cancellation and deadline errors. Interrupts
+	// are never retried.
+	Retryable func(error) bool
+}
+
+// WithRetry retries idempotent tools according to p.
+func WithRetry(p RetryPolicy) Option {
+	return func(o *Options) {
+		o.retry = &p
+	}
+}
+
+func callWithRetry[Resp any](ctx context.Context, o *Options, info *ToolInfo, call func() (Resp, error)) (Resp, error) {
+	resp, err := call()
+	if err == nil || o == nil || o.retry == nil || !info.Idempotent {
+		return resp, err
+	}
+
+	p := o.retry
+	backoff := p.InitialBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	for attempt := 1; attempt < p.MaxAttempts && p.retryable(err); attempt++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+
+		resp, err = call()
+		if err == nil {
+			return resp, nil
+		}
+		backoff = time.Duration(float64(backoff) * multiplier)
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+	return resp, err
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if _, ok := IsInterrupt(err); ok {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,54 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInvokeRetriesIdempotentTools(t *testing.T) {
+	o := NewOptions(WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+	coerce := func(input any) (int, error) { return input.(int), nil }
+
+	for _, tc := range []struct {
+		idempotent bool
+		wantCalls  int
+		wantErr    bool
+	}{
+		{idempotent: true, wantCalls: 3, wantErr: false},
+		{idempotent: false, wantCalls: 1, wantErr: true},
+	} {
+		calls := 0
+		flaky := func(context.Context, int) (int, error) {
+			calls++
+			if calls < 3 {
+				return 0, errors.New("unavailable")
+			}
+			return 7, nil
+		}
+
+		info := &ToolInfo{Name: "lookup", Idempotent: tc.idempotent}
+		_, err := Invoke(context.Background(), o, info, 1, coerce, flaky)
+		if (err != nil) != tc.wantErr || calls != tc.wantCalls {
+			t.Fatalf("idempotent=%v: calls=%d err=%v", tc.idempotent, calls, err)
+		}
+	}
+}
+
+func TestRetryStopsOnNonRetryableError(t *testing.T) {
+	permanent := errors.New("invalid argument")
+	o := NewOptions(WithRetry(RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+		Retryable:      func(err error) bool { return !errors.Is(err, permanent) },
+	}))
+
+	calls := 0
+	_, err := Invoke(context.Background(), o, &ToolInfo{Name: "lookup", Idempotent: true}, 1,
+		func(input any) (int, error) { return input.(int), nil },
+		func(context.Context, int) (int, error) { calls++; return 0, permanent })
+	if !errors.Is(err, permanent) || calls != 1 {
+		t.Fatalf("calls=%d err=%v", calls, err)
+	}
+}
@@ -0,0 +1,67 @@
+package genkittools
+
+import (
+	"context"
+	"fmt"
+)
+
+// Safety is what a call of a tool does to the backend, as declared with
+// (genkit.tool.v1.tool_doc).safety. It is empty when undeclared.
+type Safety string
+
+const (
+	SafetyReadOnly    Safety = "read_only"
+	SafetyMutating    Safety = "mutating"
+	SafetyDestructive Safety = "destructive"
+)
+
+// ConfirmedKey is the key of the resumed metadata confirming a call of a
+// destructive tool: restart the interrupted call with
+// map[string]any{ConfirmedKey: true} to run it, as the generated
+// Confirm<Service><Method> helpers do.
+const ConfirmedKey = "confirmed"
+
+// WithConfirmation controls whether calls of destructive tools wait for
+// confirmation. It is enabled by default; disable it for hosts that confirm
+// calls themselves or run unattended.
+func WithConfirmation(enabled bool) Option {
+	return func(o *Options) {
+		o.noConfirmAll = !enabled
+	}
+}
+
+// WithoutConfirmation runs the named destructive tools without first asking
+// for confirmation. Repeated options accumulate. Names may be plain strings
+// or the generated genkitai.ToolName constants.
+func WithoutConfirmation[N ~string](names ...N) Option {
+	return func(o *Options) {
+		if o.noConfirm == nil {
+			o.noConfirm = make(map[string]bool)
+		}
+		for _, n := range names {
+			o.noConfirm[string(n)] = true
+		}
+	}
+}
+
+// Confirm decides whether a call of the tool described by info may run.
+// Generated Genkit handlers call it before Invoke. Calls of destructive
+// tools return an *InterruptError pausing the call until it is restarted
+// with ConfirmedKey set to true, and fail with an error wrapping
+// ErrToolDenied when restarted without it. Its metadata holds the tool
+// name under "tool" and the safety level under "safety", for the host to
+// word the question. Other tools pass, as do destructive tools exempted
+// with WithConfirmation or WithoutConfirmation.
+func (o *Options) Confirm(ctx context.Context, info *ToolInfo) error {
+	if info.Safety != SafetyDestructive || (o != nil && (o.noConfirmAll || o.noConfirm[info.Name])) {
+		return nil
+	}
+	resumed, ok := Resumed(ctx)
+	if !ok {
+		return Interrupt(map[string]any{"tool": info.Name, "safety": string(info.Safety)})
+	}
+	if confirmed, _ := resumed[ConfirmedKey].(bool); !confirmed {
+		return fmt.Errorf("%w: %s: the call was not confirmed", ErrToolDenied, info.Name)
+	}
+	return nil
+}
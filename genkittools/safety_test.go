@@ -0,0 +1,44 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	info := &ToolInfo{Name: "delete_account", Safety: SafetyDestructive}
+	ctx := context.Background()
+
+	md, ok := IsInterrupt(NewOptions().Confirm(ctx, info))
+	if !ok || md["tool"] != "delete_account" || md["safety"] != "destructive" {
+		t.Fatalf("first call interrupt = %v, %v", md, ok)
+	}
+	if err := (*Options)(nil).Confirm(ctx, info); err == nil {
+		t.Fatal("nil options ran the call unconfirmed")
+	}
+
+	confirmed := ContextWithResumed(ctx, map[string]any{ConfirmedKey: true})
+	if err := NewOptions().Confirm(confirmed, info); err != nil {
+		t.Fatalf("confirmed call: %v", err)
+	}
+	declined := ContextWithResumed(ctx, map[string]any{"reason": "changed my mind"})
+	if err := NewOptions().Confirm(declined, info); !errors.Is(err, ErrToolDenied) {
+		t.Fatalf("declined call: %v", err)
+	}
+
+	for _, o := range []*Options{
+		NewOptions(WithConfirmation(false)),
+		NewOptions(WithoutConfirmation("delete_account")),
+	} {
+		if err := o.Confirm(ctx, info); err != nil {
+			t.Errorf("exempted call: %v", err)
+		}
+	}
+	if err := NewOptions(WithoutConfirmation("delete_user")).Confirm(ctx, info); err == nil {
+		t.Error("exempting another tool ran the call unconfirmed")
+	}
+	if err := NewOptions().Confirm(ctx, &ToolInfo{Name: "update_account", Safety: SafetyMutating}); err != nil {
+		t.Errorf("mutating call: %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrInputRejected is wrapped by the error returned when an input sanitizer
+// set with WithInputSanitizer rejects a string the model sent.
+var ErrInputRejected = errors.New("tool input rejected")
+
+// InputSanitizer inspects text, a string the model sent to tool at field, a
+// JSON pointer into the request with proto field names such as
+// "/notes/0/text", and returns the text to pass to the implementation
+// instead. A non-nil error rejects the call, e.g. when a detector flags a
+// prompt injection.
+type InputSanitizer func(ctx context.Context, tool, field, text string) (string, error)
+
+// WithInputSanitizer runs s on every string field of the decoded requests,
+// including list items, map values and the fields of nested messages,
+// before context-bound fields are filled and the request hooks run, so
+// prompt-injection defenses live in one place. Fields annotated with
+// (genkit.tool.v1.field_doc) = { skip_sanitizer: true }, listed in
+// ToolInfo.UnsanitizedFields, are left alone, as are map keys, well-known
+// types other than google.protobuf.StringValue and requests passed as
+// messages from Go. Repeated options run in order. Rejected calls fail with
+// an error wrapping ErrInputRejected that names the field.
+func WithInputSanitizer(s InputSanitizer) Option {
+	return func(o *Options) {
+		o.sanitizers = append(o.sanitizers, s)
+	}
+}
+
+// sanitize runs the input sanitizers on req, decoded from input, a message
+// or a slice of messages.
+func (o *Options) sanitize(ctx context.Context, info *ToolInfo, input, req any) error {
+	if o == nil || len(o.sanitizers) == 0 {
+		return nil
+	}
+	if _, typed := input.(proto.Message); typed {
+		return nil
+	}
+	s := &sanitizer{ctx: ctx, o: o, info: info}
+	for _, p := range info.UnsanitizedFields {
+		s.exempt = append(s.exempt, strings.Split(strings.TrimPrefix(p, "/"), "/"))
+	}
+	for _, msg := range messages(req) {
+		if err := s.message(msg.ProtoReflect(), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sanitizer struct {
+	ctx    context.Context
+	o      *Options
+	info   *ToolInfo
+	exempt [][]string
+}
+
+// message sanitizes the string fields of m, located at path.
+func (s *sanitizer) message(m protoreflect.Message, path []string) error {
+	var err error
+	m.Range(func(field protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		fieldPath := append(path[:len(path):len(path)], string(field.Name()))
+		if s.isExempt(fieldPath) {
+			return true
+		}
+		switch {
+		case field.IsMap():
+			if !sanitizable(field.MapValue()) {
+				return true
+			}
+			entries := v.Map()
+			entries.Range(func(k protoreflect.MapKey, item protoreflect.Value) bool {
+				var x protoreflect.Value
+				x, err = s.value(field.MapValue(), item, append(fieldPath, k.String()))
+				if err == nil && field.MapValue().Message() == nil {
+					entries.Set(k, x)
+				}
+				return err == nil
+			})
+		case field.IsList():
+			if !sanitizable(field) {
+				return true
+			}
+			list := v.List()
+			for i := 0; i < list.Len() && err == nil; i++ {
+				var x protoreflect.Value
+				x, err = s.value(field, list.Get(i), append(fieldPath, strconv.Itoa(i)))
+				if err == nil && field.Message() == nil {
+					list.Set(i, x)
+				}
+			}
+		case sanitizable(field):
+			var x protoreflect.Value
+			x, err = s.value(field, v, fieldPath)
+			if err == nil && field.Message() == nil {
+				m.Set(field, x)
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// value sanitizes a single value of field at path: a string, whose
+// sanitized form it returns, or a message, sanitized in place.
+func (s *sanitizer) value(field protoreflect.FieldDescriptor, v protoreflect.Value, path []string) (protoreflect.Value, error) {
+	msg := field.Message()
+	switch {
+	case msg == nil:
+		text, err := s.text(v.String(), path)
+		return protoreflect.ValueOfString(text), err
+	case msg.FullName() == "google.protobuf.StringValue":
+		// Sanitized as the string it stands for in JSON.
+		value := msg.Fields().ByName("value")
+		text, err := s.text(v.Message().Get(value).String(), path)
+		if err == nil {
+			v.Message().Set(value, protoreflect.ValueOfString(text))
+		}
+		return v, err
+	default:
+		return v, s.message(v.Message(), path)
+	}
+}
+
+// text runs the sanitizers on a string at path.
+func (s *sanitizer) text(text string, path []string) (string, error) {
+	field := "/" + strings.Join(path, "/")
+	for _, sanitize := range s.o.sanitizers {
+		var err error
+		text, err = sanitize(s.ctx, s.info.Name, field, text)
+		if err != nil {
+			return text, fmt.Errorf("%w: %s: %s: %w", ErrInputRejected, s.info.Name, field, err)
+		}
+	}
+	return text, nil
+}
+
+func (s *sanitizer) isExempt(path []string) bool {
+	for _, p := range s.exempt {
+		if len(p) != len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range p {
+			if !segmentMatches(segment, path[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizable reports whether the values of field hold strings to
+// sanitize: strings, google.protobuf.StringValue, or messages that are not
+// other well-known types.
+func sanitizable(field protoreflect.FieldDescriptor) bool {
+	if msg := field.Message(); msg != nil {
+		return msg.FullName() == "google.protobuf.StringValue" || wellKnownSchema(msg.FullName()) == nil
+	}
+	return field.Kind() == protoreflect.StringKind
+}
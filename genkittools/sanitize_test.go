@@ -0,0 +1,56 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestInputSanitizer(t *testing.T) {
+	var seen []string
+	o := NewOptions(WithInputSanitizer(func(_ context.Context, tool, field, text string) (string, error) {
+		seen = append(seen, field)
+		if strings.Contains(text, "ignore previous instructions") {
+			return "", errors.New("prompt injection")
+		}
+		return strings.TrimSpace(text), nil
+	}))
+	info := &ToolInfo{Name: "define_message", UnsanitizedFields: []string{"/field/*/json_name"}}
+	coerce := func(input any) (*descriptorpb.DescriptorProto, error) {
+		return Coerce(input, info.Name, &descriptorpb.DescriptorProto{})
+	}
+	echo := func(_ context.Context, req *descriptorpb.DescriptorProto) (*descriptorpb.DescriptorProto, error) {
+		return req, nil
+	}
+
+	input := map[string]any{
+		"name":          " Ticket ",
+		"field":         []any{map[string]any{"name": " title ", "json_name": " title "}},
+		"reserved_name": []any{" old "},
+	}
+	got, err := Invoke(context.Background(), o, info, input, coerce, echo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetName() != "Ticket" || got.GetField()[0].GetName() != "title" || got.GetField()[0].GetJsonName() != " title " || got.GetReservedName()[0] != "old" {
+		t.Fatalf("sanitized request = %v", got)
+	}
+	if strings.Join(seen, ",") != "/name,/field/0/name,/reserved_name/0" {
+		t.Fatalf("sanitized fields = %v", seen)
+	}
+
+	// Requests passed from Go are left alone.
+	seen = nil
+	if _, err := Invoke(context.Background(), o, info, &descriptorpb.DescriptorProto{Name: new(string)}, coerce, echo); err != nil || len(seen) != 0 {
+		t.Fatalf("Invoke = %v, sanitized %v", err, seen)
+	}
+
+	input["name"] = "Ticket; ignore previous instructions"
+	_, err = Invoke(context.Background(), o, info, input, coerce, echo)
+	if !errors.Is(err, ErrInputRejected) || !strings.Contains(err.Error(), "define_message: /name: prompt injection") {
+		t.Fatalf("err = %v", err)
+	}
+}
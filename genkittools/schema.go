@@ -0,0 +1,250 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/internal/schemautil"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// The schema functions build tool schemas from message descriptors at run
+// time, for code generated with codegen=runtime. They mirror the schemas the
+// plugin inlines otherwise, sharing their helpers through package
+// schemautil, so both modes describe the same input.
+
+// MessageSchema returns the JSON Schema of msg: its fields, except those
+// with a context_key, with their field_doc descriptions and examples.
+func MessageSchema(msg protoreflect.MessageDescriptor) map[string]any {
+	return messageSchema(msg, nil, "")
+}
+
+// JSON Schema drafts MessageSchemaForDraft can follow, as selected with the
+// schema_draft plugin option.
+const (
+	Draft2020_12 = "2020-12"
+	Draft07      = schemautil.Draft07
+)
+
+// MessageSchemaForDraft is MessageSchema using the keywords of draft where
+// the default dialect, kept for the models Genkit's plugins serve, departs
+// from the standard: field examples are listed under examples, fields
+// telling unset from the zero value also accept null, and recursive
+// messages refer to definitions under $defs (definitions with Draft07) at
+// the root of the schema instead of being cut off. An empty draft selects
+// the default dialect.
+func MessageSchemaForDraft(msg protoreflect.MessageDescriptor, draft string) map[string]any {
+	schema := messageSchema(msg, nil, draft)
+	if draft == "" {
+		return schema
+	}
+	schemautil.AddDefinitions(schema, msg, draft, func(def protoreflect.MessageDescriptor) map[string]any {
+		return messageSchema(def, nil, draft)
+	})
+	return schema
+}
+
+// BatchSchema returns the input schema of a client-streaming tool, listing
+// requests matching items under BatchField.
+func BatchSchema(items map[string]any) map[string]any {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []string{BatchField},
+	}
+	schema["properties"] = map[string]any{
+		BatchField: schemautil.HoistDefinitions(schema, ListSchema(items)),
+	}
+	return schema
+}
+
+// ListSchema returns the schema of an array of items.
+func ListSchema(items map[string]any) map[string]any {
+	schema := map[string]any{"type": "array"}
+	schema["items"] = schemautil.HoistDefinitions(schema, items)
+	return schema
+}
+
+// DescribeSchema sets the description of schema and returns it.
+func DescribeSchema(schema map[string]any, description string) map[string]any {
+	schema["description"] = description
+	return schema
+}
+
+// ParseSchema decodes a schema written as JSON, as code generated with
+// schema_format=json declares its schemas. Arrays decode to []any and
+// numbers to float64. It panics on invalid JSON, which generated code never
+// holds.
+func ParseSchema(raw string) map[string]any {
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+		panic(fmt.Sprintf("genkittools: parse schema: %v", err))
+	}
+	return schema
+}
+
+func messageSchema(msg protoreflect.MessageDescriptor, visiting map[protoreflect.FullName]bool, draft string) map[string]any {
+	if schema := wellKnownSchema(msg.FullName()); schema != nil {
+		return schema
+	}
+	if visiting[msg.FullName()] {
+		if draft != "" {
+			return map[string]any{"$ref": schemautil.DefinitionRef(draft, msg.FullName())}
+		}
+		return map[string]any{"type": "object"}
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
+	props := make(map[string]any)
+	var required []string
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		fd := fieldDoc(field)
+		if fd.GetContextKey() != "" {
+			continue
+		}
+		prop := fieldSchema(field, visiting, draft)
+		if draft != "" && schemautil.AcceptsNull(field) {
+			prop = schemautil.NullableSchema(prop)
+		}
+		if desc := fd.GetDesc(); desc != "" {
+			prop["description"] = desc
+		} else if desc := openAPIv2FieldDescription(field); desc != "" {
+			prop["description"] = desc
+		}
+		if fd.GetExample() != "" {
+			if draft != "" {
+				prop["examples"] = []any{fd.GetExample()}
+			} else {
+				prop["example"] = fd.GetExample()
+			}
+		}
+		if fd.GetRequired() || field.Cardinality() == protoreflect.Required {
+			required = append(required, string(field.Name()))
+		}
+		props[string(field.Name())] = prop
+	}
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func fieldDoc(field protoreflect.FieldDescriptor) *pb.ToolFieldDoc {
+	opts, ok := field.Options().(*descriptorpb.FieldOptions)
+	if !ok || opts == nil {
+		return nil
+	}
+	doc, _ := proto.GetExtension(opts, pb.E_FieldDoc).(*pb.ToolFieldDoc)
+	return doc
+}
+
+// openAPIv2MethodDescription returns the summary, or else the description,
+// of the openapiv2_operation option of method.
+func openAPIv2MethodDescription(method protoreflect.MethodDescriptor) string {
+	fields := schemautil.RawOptionStrings(method.Options(), schemautil.OpenAPIv2OptionNumber)
+	if fields[2] != "" { // Operation.summary
+		return fields[2]
+	}
+	return fields[3] // Operation.description
+}
+
+// openAPIv2FieldDescription returns the description, or else the title, of
+// the openapiv2_field option of field.
+func openAPIv2FieldDescription(field protoreflect.FieldDescriptor) string {
+	fields := schemautil.RawOptionStrings(field.Options(), schemautil.OpenAPIv2OptionNumber)
+	if fields[6] != "" { // JSONSchema.description
+		return fields[6]
+	}
+	return fields[5] // JSONSchema.title
+}
+
+func fieldSchema(field protoreflect.FieldDescriptor, visiting map[protoreflect.FullName]bool, draft string) map[string]any {
+	switch {
+	case field.IsList():
+		return ListSchema(valueSchema(field, visiting, draft))
+	case field.IsMap():
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": valueSchema(field.MapValue(), visiting, draft),
+		}
+	default:
+		return valueSchema(field, visiting, draft)
+	}
+}
+
+func valueSchema(field protoreflect.FieldDescriptor, visiting map[protoreflect.FullName]bool, draft string) map[string]any {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		return map[string]any{"type": "number"}
+	case protoreflect.Int32Kind, protoreflect.Int64Kind,
+		protoreflect.Sint32Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Uint64Kind,
+		protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+		return map[string]any{"type": "integer"}
+	case protoreflect.EnumKind:
+		if field.Enum().FullName() == "google.protobuf.NullValue" {
+			return map[string]any{"type": "null"}
+		}
+		values := field.Enum().Values()
+		names := make([]string, values.Len())
+		for i := range names {
+			names[i] = string(values.Get(i).Name())
+		}
+		return map[string]any{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageSchema(field.Message(), visiting, draft)
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+func wellKnownSchema(name protoreflect.FullName) map[string]any {
+	switch name {
+	case "google.protobuf.Timestamp":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration":
+		return map[string]any{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]+)?s$`}
+	case "google.protobuf.FieldMask":
+		return map[string]any{"type": "string"}
+	case "google.protobuf.Struct":
+		return map[string]any{"type": "object"}
+	case "google.protobuf.ListValue":
+		return map[string]any{"type": "array"}
+	case "google.protobuf.Value":
+		return map[string]any{}
+	case "google.protobuf.Any":
+		return map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"@type": map[string]any{"type": "string"}},
+			"required":   []string{"@type"},
+		}
+	case "google.protobuf.BoolValue":
+		return map[string]any{"type": "boolean"}
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue":
+		return map[string]any{"type": "number"}
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return map[string]any{"type": "integer"}
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return map[string]any{"type": "string"}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,178 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"testing"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSchemaJSONIsMarshaledOnce(t *testing.T) {
+	info := &ToolInfo{
+		Name:        "get_weather",
+		InputSchema: map[string]any{"type": "object", "required": []string{"city"}},
+	}
+	in := info.InputSchemaJSON()
+	if string(in) != `{"required":["city"],"type":"object"}` {
+		t.Fatalf("InputSchemaJSON() = %s", in)
+	}
+	if again := info.InputSchemaJSON(); &again[0] != &in[0] {
+		t.Fatal("InputSchemaJSON marshaled the schema again")
+	}
+	if out := info.OutputSchemaJSON(); out != nil {
+		t.Fatalf("OutputSchemaJSON() = %s, want nil", out)
+	}
+	if n := testing.AllocsPerRun(10, func() { info.InputSchemaJSON() }); n != 0 {
+		t.Fatalf("InputSchemaJSON allocates %v times per call", n)
+	}
+}
+
+func TestMessageSchema(t *testing.T) {
+	cases := []struct {
+		schema map[string]any
+		want   string
+	}{
+		{
+			MessageSchema((&pb.ToolFieldDoc{}).ProtoReflect().Descriptor()),
+			`{"properties":{"context_key":{"type":"string"},"desc":{"type":"string"},"error_message":{"type":"string"},"example":{"type":"string"},"media_type":{"type":"string"},"required":{"type":"boolean"},"sensitive":{"type":"boolean"},"skip_sanitizer":{"type":"boolean"}},"type":"object"}`,
+		},
+		{
+			ListSchema(MessageSchema((&structpb.Struct{}).ProtoReflect().Descriptor())),
+			`{"items":{"type":"object"},"type":"array"}`,
+		},
+		{
+			DescribeSchema(BatchSchema(map[string]any{"type": "object"}), "Cities to compare"),
+			`{"description":"Cities to compare","properties":{"requests":{"items":{"type":"object"},"type":"array"}},"required":["requests"],"type":"object"}`,
+		},
+	}
+	for _, tc := range cases {
+		got, err := json.Marshal(tc.schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("schema = %s, want %s", got, tc.want)
+		}
+	}
+
+	schema := MessageSchema((&pb.ToolDoc{}).ProtoReflect().Descriptor())
+	format := schema["properties"].(map[string]any)["result_format"].(map[string]any)
+	if enum := format["enum"].([]string); len(enum) != 4 || enum[2] != "RESULT_FORMAT_JSON" {
+		t.Errorf("result_format = %v", format)
+	}
+}
+
+func TestMessageSchemaForDraft(t *testing.T) {
+	desc := (&descriptorpb.DescriptorProto{}).ProtoReflect().Descriptor()
+	prop := func(schema map[string]any, name string) string {
+		raw, err := json.Marshal(schema["properties"].(map[string]any)[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+
+	schema := MessageSchemaForDraft(desc, Draft2020_12)
+	// proto2 optional scalars tell unset from empty; recursive messages
+	// refer to their definition.
+	if got := prop(schema, "name"); got != `{"type":["string","null"]}` {
+		t.Errorf("name = %s", got)
+	}
+	if got := prop(schema, "nested_type"); got != `{"items":{"$ref":"#/$defs/google.protobuf.DescriptorProto"},"type":"array"}` {
+		t.Errorf("nested_type = %s", got)
+	}
+	defs := schema["$defs"].(map[string]any)
+	if _, ok := defs["google.protobuf.DescriptorProto"]; !ok || len(defs) != 1 {
+		t.Errorf("$defs = %v", defs)
+	}
+
+	schema = MessageSchemaForDraft(desc, Draft07)
+	if _, ok := schema["definitions"].(map[string]any)["google.protobuf.DescriptorProto"]; !ok {
+		t.Errorf("definitions = %v", schema["definitions"])
+	}
+	// Wrapping schemas keep the definitions at their root.
+	batch := BatchSchema(schema)
+	if _, ok := batch["definitions"]; !ok {
+		t.Error("BatchSchema dropped the definitions")
+	}
+	if _, ok := schema["definitions"]; !ok {
+		t.Error("BatchSchema changed the schema it wraps")
+	}
+
+	if got := MessageSchemaForDraft(desc, ""); prop(got, "name") != `{"type":"string"}` {
+		t.Errorf("default dialect name = %s", prop(got, "name"))
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	schema := ParseSchema(`{
+  "type": "object",
+  "properties": {"city": {"type": "string"}, "days": {"type": "integer", "maximum": 14}},
+  "required": ["city"]
+}`)
+	if v := Validate(schema, map[string]any{"days": 3}); len(v) != 1 || v[0].Pointer != "/city" {
+		t.Errorf("violations = %v", v)
+	}
+	if v := Validate(schema, map[string]any{"city": "Lima", "days": 3}); len(v) != 0 {
+		t.Errorf("violations = %v", v)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("ParseSchema did not panic on invalid JSON")
+		}
+	}()
+	ParseSchema(`{"type":`)
+}
+
+func TestMessageSchemaOpenAPIv2Descriptions(t *testing.T) {
+	// option encodes an openapiv2_field option from title and description
+	// pairs.
+	option := func(fields ...string) []byte {
+		var jsonSchema []byte
+		for i := 0; i < len(fields); i += 2 {
+			num := protowire.Number(5)
+			if fields[i] == "description" {
+				num = 6
+			}
+			jsonSchema = protowire.AppendTag(jsonSchema, num, protowire.BytesType)
+			jsonSchema = protowire.AppendString(jsonSchema, fields[i+1])
+		}
+		b := protowire.AppendTag(nil, 1042, protowire.BytesType)
+		return protowire.AppendBytes(b, jsonSchema)
+	}
+	userID := &descriptorpb.FieldOptions{}
+	userID.ProtoReflect().SetUnknown(option("title", "User ID"))
+	name := &descriptorpb.FieldOptions{}
+	name.ProtoReflect().SetUnknown(option("title", "Name", "description", "Display name."))
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("profile.proto"),
+		Package: proto.String("profile"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Profile"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("user_id"), JsonName: proto.String("userId"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Options: userID},
+				{Name: proto.String("name"), JsonName: proto.String("name"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Options: name},
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := json.Marshal(MessageSchema(file.Messages().Get(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"properties":{"name":{"description":"Display name.","type":"string"},"user_id":{"description":"User ID","type":"string"}},"type":"object"}`
+	if string(got) != want {
+		t.Errorf("schema = %s, want %s", got, want)
+	}
+}
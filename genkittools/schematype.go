@@ -0,0 +1,250 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Schema is the typed form of a tool schema, with the keywords the plugin
+// writes. Code generated with schema_format=struct declares its schemas as
+// Schema values, so a malformed schema fails to compile, and SchemaFromMap
+// reads any tool schema back into one to inspect it.
+type Schema struct {
+	Type string
+	// Nullable makes the schema accept null too, as schema_draft writes for
+	// fields with explicit presence: the type becomes [Type, "null"] and
+	// null joins Enum.
+	Nullable    bool
+	Description string
+	Format      string
+	Pattern     string
+	Enum        []string
+	// Example is written by the default dialect, Examples with
+	// schema_draft.
+	Example  any
+	Examples []any
+
+	Properties           map[string]*Schema
+	Required             []string
+	AdditionalProperties *Schema
+	Items                *Schema
+
+	// Ref refers to a schema under Defs, or Definitions with draft-07, at
+	// the root.
+	Ref         string
+	Defs        map[string]*Schema
+	Definitions map[string]*Schema
+}
+
+// Map returns s as the map[string]any tool schemas are passed around as,
+// holding the same Go types as the plugin's schema literals.
+func (s Schema) Map() map[string]any {
+	m := make(map[string]any)
+	if s.Type != "" {
+		if s.Nullable {
+			m["type"] = []string{s.Type, "null"}
+		} else {
+			m["type"] = s.Type
+		}
+	}
+	if s.Description != "" {
+		m["description"] = s.Description
+	}
+	if s.Format != "" {
+		m["format"] = s.Format
+	}
+	if s.Pattern != "" {
+		m["pattern"] = s.Pattern
+	}
+	if s.Enum != nil {
+		if s.Nullable {
+			values := make([]any, 0, len(s.Enum)+1)
+			for _, v := range s.Enum {
+				values = append(values, v)
+			}
+			m["enum"] = append(values, nil)
+		} else {
+			m["enum"] = s.Enum
+		}
+	}
+	if s.Example != nil {
+		m["example"] = s.Example
+	}
+	if s.Examples != nil {
+		m["examples"] = s.Examples
+	}
+	if s.Properties != nil {
+		m["properties"] = schemaMaps(s.Properties)
+	}
+	if s.Required != nil {
+		m["required"] = s.Required
+	}
+	if s.AdditionalProperties != nil {
+		m["additionalProperties"] = s.AdditionalProperties.Map()
+	}
+	if s.Items != nil {
+		m["items"] = s.Items.Map()
+	}
+	if s.Ref != "" {
+		m["$ref"] = s.Ref
+	}
+	if s.Defs != nil {
+		m["$defs"] = schemaMaps(s.Defs)
+	}
+	if s.Definitions != nil {
+		m["definitions"] = schemaMaps(s.Definitions)
+	}
+	return m
+}
+
+// MarshalJSON encodes s as the JSON Schema it describes.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Map())
+}
+
+func schemaMaps(schemas map[string]*Schema) map[string]any {
+	out := make(map[string]any, len(schemas))
+	for name, s := range schemas {
+		out[name] = s.Map()
+	}
+	return out
+}
+
+// SchemaFromMap reads a tool schema, as built by the plugin in any mode or
+// decoded from JSON, into a Schema. It fails on keywords Schema does not
+// hold.
+func SchemaFromMap(m map[string]any) (*Schema, error) {
+	s := &Schema{}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var err error
+		switch v := m[k]; k {
+		case "type":
+			err = s.setType(v)
+		case "description":
+			s.Description, err = schemaString(v)
+		case "format":
+			s.Format, err = schemaString(v)
+		case "pattern":
+			s.Pattern, err = schemaString(v)
+		case "enum":
+			err = s.setEnum(v)
+		case "example":
+			s.Example = v
+		case "examples":
+			examples, ok := v.([]any)
+			if !ok {
+				err = fmt.Errorf("got %T, want a list", v)
+			}
+			s.Examples = examples
+		case "properties":
+			s.Properties, err = schemasFromMap(v)
+		case "required":
+			s.Required, err = schemaStrings(v)
+		case "additionalProperties":
+			s.AdditionalProperties, err = schemaFromAny(v)
+		case "items":
+			s.Items, err = schemaFromAny(v)
+		case "$ref":
+			s.Ref, err = schemaString(v)
+		case "$defs":
+			s.Defs, err = schemasFromMap(v)
+		case "definitions":
+			s.Definitions, err = schemasFromMap(v)
+		default:
+			err = errors.New("unsupported keyword")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return s, nil
+}
+
+// setType reads a type keyword: a type, or a type and "null".
+func (s *Schema) setType(v any) error {
+	types, err := schemaStrings(v)
+	if typ, ok := v.(string); ok {
+		types, err = []string{typ}, nil
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(types) == 1:
+		s.Type = types[0]
+	case len(types) == 2 && types[1] == "null":
+		s.Type, s.Nullable = types[0], true
+	default:
+		return fmt.Errorf("unsupported type list %q", types)
+	}
+	return nil
+}
+
+// setEnum reads an enum keyword: strings, ending with null when the schema
+// is nullable.
+func (s *Schema) setEnum(v any) error {
+	if values, ok := v.([]any); ok && len(values) > 0 && values[len(values)-1] == nil {
+		s.Nullable = true
+		v = values[:len(values)-1]
+	}
+	enum, err := schemaStrings(v)
+	s.Enum = enum
+	return err
+}
+
+func schemaString(v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("got %T, want a string", v)
+	}
+	return s, nil
+}
+
+func schemaStrings(v any) ([]string, error) {
+	switch list := v.(type) {
+	case []string:
+		return list, nil
+	case []any:
+		out := make([]string, len(list))
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("got %T at %d, want a string", item, i)
+			}
+			out[i] = s
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("got %T, want a list of strings", v)
+}
+
+func schemaFromAny(v any) (*Schema, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("got %T, want a schema", v)
+	}
+	return SchemaFromMap(m)
+}
+
+func schemasFromMap(v any) (map[string]*Schema, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("got %T, want schemas by name", v)
+	}
+	out := make(map[string]*Schema, len(m))
+	for name, item := range m {
+		s, err := schemaFromAny(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		out[name] = s
+	}
+	return out, nil
+}
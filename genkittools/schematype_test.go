@@ -0,0 +1,68 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaMap(t *testing.T) {
+	s := Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"city":  {Type: "string", Description: "City name", Example: "Lima"},
+			"units": {Type: "string", Nullable: true, Enum: []string{"METRIC", "IMPERIAL"}},
+			"days":  {Type: "array", Items: &Schema{Type: "integer"}},
+			"next":  {Ref: "#/$defs/weather.v1.Forecast"},
+		},
+		Required: []string{"city"},
+		Defs:     map[string]*Schema{"weather.v1.Forecast": {Type: "object"}},
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"city":  map[string]any{"type": "string", "description": "City name", "example": "Lima"},
+			"units": map[string]any{"type": []string{"string", "null"}, "enum": []any{"METRIC", "IMPERIAL", nil}},
+			"days":  map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+			"next":  map[string]any{"$ref": "#/$defs/weather.v1.Forecast"},
+		},
+		"required": []string{"city"},
+		"$defs":    map[string]any{"weather.v1.Forecast": map[string]any{"type": "object"}},
+	}
+	if got := s.Map(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Map =\n%#v\nwant\n%#v", got, want)
+	}
+
+	// The map and its JSON form read back into the same Schema.
+	back, err := SchemaFromMap(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*back, s) {
+		t.Fatalf("SchemaFromMap = %+v", back)
+	}
+	raw, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := SchemaFromMap(ParseSchema(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Properties["units"]; !got.Nullable || !reflect.DeepEqual(got.Enum, []string{"METRIC", "IMPERIAL"}) {
+		t.Fatalf("decoded units = %+v", got)
+	}
+}
+
+func TestSchemaFromMapRejectsUnknownKeywords(t *testing.T) {
+	_, err := SchemaFromMap(map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"city": map[string]any{"type": "string", "minLength": 1}},
+	})
+	if err == nil || err.Error() != "properties: city: minLength: unsupported keyword" {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := SchemaFromMap(map[string]any{"type": []any{"string", "integer"}}); err == nil {
+		t.Fatal("expected an error for a type union")
+	}
+}
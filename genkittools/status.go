@@ -0,0 +1,186 @@
+package genkittools
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Fully-qualified names of the google.rpc error details surfaced in
+// RemoteError. They are decoded from the wire format so the runtime does not
+// depend on gRPC or the googleapis Go packages.
+const (
+	badRequestType = "google.rpc.BadRequest"
+	errorInfoType  = "google.rpc.ErrorInfo"
+)
+
+// rpcCodes names the google.rpc.Code values in lower snake case.
+var rpcCodes = []string{
+	"ok", "canceled", "unknown", "invalid_argument", "deadline_exceeded",
+	"not_found", "already_exists", "permission_denied", "resource_exhausted",
+	"failed_precondition", "aborted", "out_of_range", "unimplemented",
+	"internal", "unavailable", "data_loss", "unauthenticated",
+}
+
+// AddDetail records a google.rpc error detail given by its message type name
+// (or type URL) and its wire encoding. google.rpc.BadRequest field violations and
+// google.rpc.ErrorInfo are kept; other details are ignored.
+func (e *RemoteError) AddDetail(typeName string, value []byte) {
+	switch typeName[strings.LastIndexByte(typeName, '/')+1:] {
+	case badRequestType:
+		for _, raw := range wireBytes(value, 1) {
+			fields := wireStrings(raw)
+			e.Violations = append(e.Violations, FieldViolation{Field: fields[1], Message: fields[2]})
+		}
+	case errorInfoType:
+		fields := wireStrings(value)
+		e.Reason, e.Domain = fields[1], fields[2]
+		for _, entry := range wireBytes(value, 3) {
+			kv := wireStrings(entry)
+			if e.Metadata == nil {
+				e.Metadata = make(map[string]string)
+			}
+			e.Metadata[kv[1]] = kv[2]
+		}
+	}
+}
+
+// statusError converts an error carrying a gRPC status, as returned by gRPC
+// clients or built with status.Error, into a *RemoteError listing its
+// BadRequest and ErrorInfo details. Other errors, and errors already
+// converted, are returned unchanged.
+func statusError(err error) error {
+	var rerr *RemoteError
+	if err == nil || errors.As(err, &rerr) {
+		return err
+	}
+	raw, ok := grpcStatusBytes(err)
+	if !ok {
+		return err
+	}
+
+	// google.rpc.Status: code = 1, message = 2, details = 3 (google.protobuf.Any
+	// with type_url = 1 and value = 2).
+	rerr = &RemoteError{Code: "unknown", Message: wireStrings(raw)[2], Err: err}
+	if code, ok := wireVarint(raw, 1); ok && code < uint64(len(rpcCodes)) {
+		rerr.Code = rpcCodes[code]
+	}
+	for _, detail := range wireBytes(raw, 3) {
+		fields := wireStrings(detail)
+		rerr.AddDetail(fields[1], []byte(fields[2]))
+	}
+	return rerr
+}
+
+// grpcStatusBytes returns the wire encoding of the google.rpc.Status of the
+// first error in err's chain with a GRPCStatus method. The method is found by
+// reflection to avoid importing gRPC.
+func grpcStatusBytes(err error) ([]byte, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		method := reflect.ValueOf(err).MethodByName("GRPCStatus")
+		if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+			continue
+		}
+		st := method.Call(nil)[0]
+		if st.Kind() == reflect.Pointer && st.IsNil() {
+			continue
+		}
+		toProto := st.MethodByName("Proto")
+		if !toProto.IsValid() || toProto.Type().NumIn() != 0 || toProto.Type().NumOut() != 1 {
+			continue
+		}
+		msg, ok := toProto.Call(nil)[0].Interface().(proto.Message)
+		if !ok {
+			continue
+		}
+		raw, merr := proto.Marshal(msg)
+		if merr != nil {
+			continue
+		}
+		return raw, true
+	}
+	return nil, false
+}
+
+// wireStrings returns the last value of each length-delimited field of a
+// wire-encoded message, by field number.
+func wireStrings(b []byte) map[protowire.Number]string {
+	out := make(map[protowire.Number]string)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return out
+		}
+		b = b[n:]
+		if typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return out
+			}
+			out[num] = string(v)
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return out
+		}
+		b = b[n:]
+	}
+	return out
+}
+
+// wireBytes returns every value of the length-delimited field num of a
+// wire-encoded message.
+func wireBytes(b []byte, num protowire.Number) [][]byte {
+	var out [][]byte
+	for len(b) > 0 {
+		got, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return out
+		}
+		b = b[n:]
+		n = protowire.ConsumeFieldValue(got, typ, b)
+		if n < 0 {
+			return out
+		}
+		if got == num && typ == protowire.BytesType {
+			v, _ := protowire.ConsumeBytes(b)
+			out = append(out, v)
+		}
+		b = b[n:]
+	}
+	return out
+}
+
+// wireVarint returns the last value of the varint field num of a wire-encoded
+// message.
+func wireVarint(b []byte, num protowire.Number) (uint64, bool) {
+	var v uint64
+	found := false
+	for len(b) > 0 {
+		got, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return v, found
+		}
+		b = b[n:]
+		if got == num && typ == protowire.VarintType {
+			x, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return v, found
+			}
+			v, found = x, true
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(got, typ, b)
+		if n < 0 {
+			return v, found
+		}
+		b = b[n:]
+	}
+	return v, found
+}
@@ -0,0 +1,81 @@
+package genkittools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeStatus mimics *status.Status from google.golang.org/grpc/status.
+type fakeStatus struct{ raw []byte }
+
+func (s *fakeStatus) Proto() proto.Message {
+	// Any message carries the google.rpc.Status fields as unknown fields.
+	msg := &emptypb.Empty{}
+	msg.ProtoReflect().SetUnknown(s.raw)
+	return msg
+}
+
+type fakeStatusError struct{ st *fakeStatus }
+
+func (e *fakeStatusError) Error() string           { return "rpc error" }
+func (e *fakeStatusError) GRPCStatus() *fakeStatus { return e.st }
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func anyDetail(typeName string, value []byte) []byte {
+	return appendMessage(appendString(nil, 1, "type.googleapis.com/"+typeName), 2, value)
+}
+
+func sampleStatus() []byte {
+	violation := appendString(appendString(nil, 1, "city"), 2, "must not be empty")
+	badRequest := appendMessage(nil, 1, violation)
+	errorInfo := appendString(appendString(nil, 1, "QUOTA_EXCEEDED"), 2, "weather.example.com")
+	errorInfo = appendMessage(errorInfo, 3, appendString(appendString(nil, 1, "limit"), 2, "10"))
+
+	st := protowire.AppendTag(nil, 1, protowire.VarintType)
+	st = protowire.AppendVarint(st, 3)
+	st = appendString(st, 2, "bad request")
+	st = appendMessage(st, 3, anyDetail("google.rpc.BadRequest", badRequest))
+	st = appendMessage(st, 3, anyDetail("google.rpc.ErrorInfo", errorInfo))
+	st = appendMessage(st, 3, anyDetail("google.rpc.RetryInfo", nil))
+	return st
+}
+
+func TestInvokeSurfacesStatusDetails(t *testing.T) {
+	cause := &fakeStatusError{st: &fakeStatus{raw: sampleStatus()}}
+	coerce := func(input any) (int, error) { return input.(int), nil }
+	call := func(context.Context, int) (int, error) { return 0, fmt.Errorf("lookup: %w", cause) }
+
+	_, err := Invoke(context.Background(), nil, &ToolInfo{Name: "get_weather"}, 1, coerce, call)
+	var rerr *RemoteError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("err = %#v, want *RemoteError", err)
+	}
+	want := "invalid_argument: bad request; city: must not be empty; reason QUOTA_EXCEEDED (weather.example.com) limit=10"
+	if rerr.Error() != want {
+		t.Fatalf("Error() = %q, want %q", rerr.Error(), want)
+	}
+	if !errors.Is(err, cause) {
+		t.Fatal("RemoteError does not unwrap to the implementation error")
+	}
+
+	plain := errors.New("plain")
+	_, err = Invoke(context.Background(), nil, &ToolInfo{Name: "get_weather"}, 1, coerce, func(context.Context, int) (int, error) { return 0, plain })
+	if err != plain {
+		t.Fatalf("plain error = %#v", err)
+	}
+}
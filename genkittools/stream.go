@@ -0,0 +1,101 @@
+package genkittools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// BatchField is the input property listing the requests of a tool generated
+// for a client-streaming RPC.
+const BatchField = "requests"
+
+// ChunkFunc receives the messages of a server-streaming tool as the
+// implementation sends them.
+type ChunkFunc func(ctx context.Context, tool string, chunk any) error
+
+type chunkFuncKey struct{}
+
+// WithChunkFunc returns a copy of ctx whose server-streaming tools forward
+// each message to fn. Tool contexts derive from the context passed to
+// genkit.Generate, so this is typically used to relay tool progress through a
+// flow's streaming callback.
+func WithChunkFunc(ctx context.Context, fn ChunkFunc) context.Context {
+	return context.WithValue(ctx, chunkFuncKey{}, fn)
+}
+
+// SendChunk passes chunk to the ChunkFunc installed in ctx, if any.
+func SendChunk(ctx context.Context, tool string, chunk any) error {
+	if fn, ok := ctx.Value(chunkFuncKey{}).(ChunkFunc); ok && fn != nil {
+		return fn(ctx, tool, chunk)
+	}
+	return nil
+}
+
+// CollectStream runs stream and returns every message it sends, in order.
+func CollectStream[T any](stream func(send func(T) error) error) ([]T, error) {
+	var out []T
+	err := stream(func(chunk T) error {
+		out = append(out, chunk)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ForwardStream runs stream, passing every message it sends to SendChunk, and
+// returns the last one as the tool output.
+func ForwardStream[T any](ctx context.Context, tool string, stream func(send func(T) error) error) (T, error) {
+	var last T
+	err := stream(func(chunk T) error {
+		last = chunk
+		return SendChunk(ctx, tool, chunk)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return last, nil
+}
+
+// CoerceBatch decodes the input of a client-streaming tool, an object whose
+// BatchField property lists the requests to stream, decoding each element
+// with coerce. Validation errors name the offending element.
+func CoerceBatch[Req any](input any, coerce func(any) (Req, error)) ([]Req, error) {
+	if reqs, ok := input.([]Req); ok {
+		return reqs, nil
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("marshal batch input: %w", err)
+	}
+	var batch struct {
+		Requests []json.RawMessage `json:"requests"`
+	}
+	if err := json.Unmarshal(raw, &batch); err != nil {
+		return nil, fmt.Errorf("unmarshal batch input: %w", err)
+	}
+
+	reqs := make([]Req, len(batch.Requests))
+	for i, elem := range batch.Requests {
+		req, err := coerce(elem)
+		if err != nil {
+			prefix := fmt.Sprintf("%s[%d]", BatchField, i)
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				violations := make([]FieldViolation, len(verr.Violations))
+				for j, v := range verr.Violations {
+					v.Field = prefix + "." + v.Field
+					violations[j] = v
+				}
+				return nil, &ValidationError{Tool: verr.Tool, Violations: violations}
+			}
+			return nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+		reqs[i] = req
+	}
+	return reqs, nil
+}
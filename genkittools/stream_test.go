@@ -0,0 +1,113 @@
+package genkittools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func countTo(n int) func(send func(int) error) error {
+	return func(send func(int) error) error {
+		for i := 1; i <= n; i++ {
+			if err := send(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestCollectStream(t *testing.T) {
+	got, err := CollectStream(countTo(3))
+	if err != nil || !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("CollectStream = %v, %v", got, err)
+	}
+
+	boom := errors.New("boom")
+	got, err = CollectStream(func(send func(int) error) error {
+		_ = send(1)
+		return boom
+	})
+	if !errors.Is(err, boom) || got != nil {
+		t.Fatalf("CollectStream on error = %v, %v", got, err)
+	}
+}
+
+func TestForwardStream(t *testing.T) {
+	var seen []any
+	ctx := WithChunkFunc(context.Background(), func(_ context.Context, tool string, chunk any) error {
+		if tool != "count" {
+			t.Errorf("tool = %q", tool)
+		}
+		seen = append(seen, chunk)
+		return nil
+	})
+
+	last, err := ForwardStream(ctx, "count", countTo(3))
+	if err != nil || last != 3 {
+		t.Fatalf("ForwardStream = %v, %v", last, err)
+	}
+	if !slices.Equal(seen, []any{1, 2, 3}) {
+		t.Fatalf("forwarded %v", seen)
+	}
+
+	// Without a ChunkFunc messages are dropped but the stream still runs.
+	if last, err := ForwardStream(context.Background(), "count", countTo(2)); err != nil || last != 2 {
+		t.Fatalf("ForwardStream without callback = %v, %v", last, err)
+	}
+
+	// A failing ChunkFunc aborts the stream.
+	stop := errors.New("stop")
+	ctx = WithChunkFunc(context.Background(), func(context.Context, string, any) error { return stop })
+	if _, err := ForwardStream(ctx, "count", countTo(3)); !errors.Is(err, stop) {
+		t.Fatalf("ForwardStream error = %v, want %v", err, stop)
+	}
+}
+
+func TestCoerceBatch(t *testing.T) {
+	coerce := func(input any) (string, error) {
+		raw, err := json.Marshal(input)
+		if err != nil {
+			return "", err
+		}
+		var v struct {
+			City string `json:"city"`
+		}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return "", err
+		}
+		if v.City == "" {
+			return "", &ValidationError{Tool: "t", Violations: []FieldViolation{{Field: "city", Message: "value is required"}}}
+		}
+		return v.City, nil
+	}
+
+	got, err := CoerceBatch(map[string]any{"requests": []any{
+		map[string]any{"city": "Paris"},
+		map[string]any{"city": "Oslo"},
+	}}, coerce)
+	if err != nil || !slices.Equal(got, []string{"Paris", "Oslo"}) {
+		t.Fatalf("CoerceBatch = %v, %v", got, err)
+	}
+
+	if got, err := CoerceBatch([]string{"direct"}, coerce); err != nil || !slices.Equal(got, []string{"direct"}) {
+		t.Fatalf("CoerceBatch of typed slice = %v, %v", got, err)
+	}
+
+	_, err = CoerceBatch(map[string]any{"requests": []any{
+		map[string]any{"city": "Paris"},
+		map[string]any{},
+	}}, coerce)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Violations[0].Field != "requests[1].city" {
+		t.Fatalf("CoerceBatch validation error = %v", err)
+	}
+
+	_, err = CoerceBatch(map[string]any{"requests": []any{"not an object"}}, coerce)
+	if err == nil || !strings.HasPrefix(err.Error(), "requests[0]: ") {
+		t.Fatalf("CoerceBatch decode error = %v", err)
+	}
+}
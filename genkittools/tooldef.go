@@ -0,0 +1,93 @@
+package genkittools
+
+import (
+	"context"
+	"maps"
+)
+
+// WithNamePrefix prepends prefix to the name each tool is defined under, so
+// that one service can be registered several times in a Genkit instance,
+// e.g. once per backend with "staging_" and "prod_". Cache keys, metrics and
+// logs use the prefixed names; options keyed by tool name, such as WithOnly
+// or WithToolMaxConcurrency, still take the unprefixed ones.
+func WithNamePrefix(prefix string) Option {
+	return func(o *Options) {
+		o.namePrefix = prefix
+	}
+}
+
+// WithToolNamePrefix is the former name of WithNamePrefix.
+//
+// Deprecated: Use WithNamePrefix.
+func WithToolNamePrefix(prefix string) Option {
+	return WithNamePrefix(prefix)
+}
+
+// WithDescriptionSuffix appends suffix, separated by a space, to the
+// description each tool is defined with, e.g. to add deployment-specific
+// guidance for the model.
+func WithDescriptionSuffix(suffix string) Option {
+	return func(o *Options) {
+		o.descSuffix = suffix
+	}
+}
+
+// WithToolMetadata overrides the custom metadata of the named tool, merging
+// md over the attributes declared in its tool_doc metadata option. Repeated
+// options accumulate, later keys winning. The merged attributes are available
+// to hooks, authorizers and implementations through ToolMetadata.
+func WithToolMetadata[N ~string](name N, md map[string]any) Option {
+	return func(o *Options) {
+		if o.metadata == nil {
+			o.metadata = make(map[string]map[string]any)
+		}
+		if o.metadata[string(name)] == nil {
+			o.metadata[string(name)] = make(map[string]any, len(md))
+		}
+		maps.Copy(o.metadata[string(name)], md)
+	}
+}
+
+// ToolName returns the name the tool called name is defined under.
+func (o *Options) ToolName(name string) string {
+	if o == nil {
+		return name
+	}
+	return o.namePrefix + name
+}
+
+// ToolDescription returns the description a tool documented as desc is
+// defined with.
+func (o *Options) ToolDescription(desc string) string {
+	if o == nil || o.descSuffix == "" {
+		return desc
+	}
+	if desc == "" {
+		return o.descSuffix
+	}
+	return desc + " " + o.descSuffix
+}
+
+// toolMetadata returns info.Metadata with the WithToolMetadata overrides of
+// the tool applied. The result must not be modified.
+func (o *Options) toolMetadata(info *ToolInfo) map[string]any {
+	if o == nil || o.metadata[info.Name] == nil {
+		return info.Metadata
+	}
+	md := maps.Clone(info.Metadata)
+	if md == nil {
+		md = make(map[string]any)
+	}
+	maps.Copy(md, o.metadata[info.Name])
+	return md
+}
+
+type toolMetadataKey struct{}
+
+// ToolMetadata returns the custom metadata of the tool whose call ctx
+// belongs to, including any WithToolMetadata overrides, and false outside a
+// tool call. The map must not be modified.
+func ToolMetadata(ctx context.Context) (map[string]any, bool) {
+	md, ok := ctx.Value(toolMetadataKey{}).(map[string]any)
+	return md, ok
+}
@@ -0,0 +1,59 @@
+package genkittools
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestToolDefinitionOptions(t *testing.T) {
+	var nilOpts *Options
+	if got := nilOpts.ToolName("get_invoice"); got != "get_invoice" {
+		t.Errorf("nil ToolName = %q", got)
+	}
+	if got := nilOpts.ToolDescription("Get an invoice."); got != "Get an invoice." {
+		t.Errorf("nil ToolDescription = %q", got)
+	}
+
+	o := NewOptions(WithNamePrefix("billing_"), WithDescriptionSuffix("Amounts are in EUR."))
+	if got := o.ToolName("get_invoice"); got != "billing_get_invoice" {
+		t.Errorf("ToolName = %q, want billing_get_invoice", got)
+	}
+	if got := o.ToolDescription("Get an invoice."); got != "Get an invoice. Amounts are in EUR." {
+		t.Errorf("ToolDescription = %q", got)
+	}
+	if got := o.ToolDescription(""); got != "Amounts are in EUR." {
+		t.Errorf("ToolDescription of empty description = %q", got)
+	}
+}
+
+func TestToolMetadata(t *testing.T) {
+	info := &ToolInfo{Name: "get_invoice", Metadata: map[string]any{"team": "billing", "cost": 1.0}}
+	o := NewOptions(
+		WithToolMetadata(toolName("get_invoice"), map[string]any{"cost": 2.0}),
+		WithToolMetadata("get_invoice", map[string]any{"region": "eu"}),
+		WithToolMetadata("other", map[string]any{"team": "other"}),
+	)
+
+	var got map[string]any
+	_, err := Invoke(context.Background(), o, info, "x", func(input any) (any, error) {
+		return input, nil
+	}, func(ctx context.Context, req any) (any, error) {
+		got, _ = ToolMetadata(ctx)
+		return req, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"team": "billing", "cost": 2.0, "region": "eu"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToolMetadata = %v, want %v", got, want)
+	}
+	if info.Metadata["cost"] != 1.0 {
+		t.Errorf("override modified ToolInfo.Metadata: %v", info.Metadata)
+	}
+
+	if _, ok := ToolMetadata(context.Background()); ok {
+		t.Error("ToolMetadata outside a tool call reported ok")
+	}
+}
@@ -0,0 +1,156 @@
+package genkittools
+
+import (
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TruncatedField is the bool response field set on responses cut down to
+// their tool's ResponseLimits, so the model knows results are missing.
+const TruncatedField = "truncated"
+
+// ResponseLimits bounds the responses of a tool returned to the model, as
+// declared with the max_response_bytes and max_response_items options of
+// (genkit.tool.v1.tool_doc). Zero fields impose no limit.
+type ResponseLimits struct {
+	// MaxBytes caps the size of the response encoded with protojson. The
+	// longest lists are cut until it fits; a response still larger once
+	// they are empty is returned as is.
+	MaxBytes int
+	// MaxItems caps the number of items of every list in the response.
+	MaxItems int
+}
+
+// TruncateResponse returns a copy of resp cut down to info.ResponseLimits,
+// with its TruncatedField set, or resp itself when it fits. Invoke applies
+// it to every structured result; generated handlers also call it before
+// rendering responses as text.
+func TruncateResponse[M proto.Message](info *ToolInfo, resp M) M {
+	l := info.ResponseLimits
+	if l.MaxBytes <= 0 && l.MaxItems <= 0 || !resp.ProtoReflect().IsValid() {
+		return resp
+	}
+	out := proto.Clone(resp).ProtoReflect()
+	truncated := false
+	if l.MaxItems > 0 {
+		truncated = capLists(out, l.MaxItems)
+	}
+	if l.MaxBytes > 0 {
+		truncated = fitBytes(out, l.MaxBytes, truncated) || truncated
+	}
+	if !truncated {
+		return resp
+	}
+	markTruncated(out)
+	return out.Interface().(M)
+}
+
+// truncateResult applies TruncateResponse to resp when it is a message.
+func truncateResult[Resp any](info *ToolInfo, resp Resp) Resp {
+	if msg, ok := any(resp).(proto.Message); ok {
+		return TruncateResponse(info, msg).(Resp)
+	}
+	return resp
+}
+
+// capLists cuts every list below m to max items and reports whether any
+// was longer.
+func capLists(m protoreflect.Message, max int) bool {
+	truncated := false
+	m.Range(func(field protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case field.IsMap():
+			if field.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, item protoreflect.Value) bool {
+					truncated = capLists(item.Message(), max) || truncated
+					return true
+				})
+			}
+		case field.IsList():
+			list := v.List()
+			if list.Len() > max {
+				list.Truncate(max)
+				truncated = true
+			}
+			if field.Message() != nil {
+				for i := 0; i < list.Len(); i++ {
+					truncated = capLists(list.Get(i).Message(), max) || truncated
+				}
+			}
+		case field.Message() != nil:
+			truncated = capLists(v.Message(), max) || truncated
+		}
+		return true
+	})
+	return truncated
+}
+
+// fitBytes cuts the longest list below m, in proportion to the excess,
+// until the protojson encoding of m fits in max bytes, and reports whether
+// it cut any. marked tells whether m is already marked as truncated; the
+// marker is counted once cutting starts.
+func fitBytes(m protoreflect.Message, max int, marked bool) bool {
+	truncated := false
+	for {
+		raw, err := protojson.Marshal(m.Interface())
+		if err != nil || len(raw) <= max {
+			return truncated
+		}
+		list := longestList(m)
+		if list == nil {
+			return truncated
+		}
+		n := list.Len() * max / len(raw)
+		if n >= list.Len() {
+			n = list.Len() - 1
+		}
+		list.Truncate(n)
+		truncated = true
+		if !marked {
+			markTruncated(m)
+			marked = true
+		}
+	}
+}
+
+// longestList returns the non-empty list below m with the most items, or
+// nil.
+func longestList(m protoreflect.Message) protoreflect.List {
+	var longest protoreflect.List
+	consider := func(list protoreflect.List) {
+		if list != nil && list.Len() > 0 && (longest == nil || list.Len() > longest.Len()) {
+			longest = list
+		}
+	}
+	m.Range(func(field protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case field.IsMap():
+			if field.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, item protoreflect.Value) bool {
+					consider(longestList(item.Message()))
+					return true
+				})
+			}
+		case field.IsList():
+			consider(v.List())
+			if field.Message() != nil {
+				for i := 0; i < v.List().Len(); i++ {
+					consider(longestList(v.List().Get(i).Message()))
+				}
+			}
+		case field.Message() != nil:
+			consider(longestList(v.Message()))
+		}
+		return true
+	})
+	return longest
+}
+
+// markTruncated sets the TruncatedField of m, if it declares one.
+func markTruncated(m protoreflect.Message) {
+	field := m.Descriptor().Fields().ByName(TruncatedField)
+	if field != nil && field.Kind() == protoreflect.BoolKind && !field.IsList() {
+		m.Set(field, protoreflect.ValueOfBool(true))
+	}
+}
@@ -0,0 +1,75 @@
+package genkittools
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestTruncateResponse(t *testing.T) {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("listing.proto"),
+		Package: proto.String("listing.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Listing"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("items"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: repeated, JsonName: proto.String("items")},
+				{Name: proto.String("truncated"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum(), Label: optional, JsonName: proto.String("truncated")},
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listing := fd.Messages().ByName("Listing")
+	newListing := func(n int) *dynamicpb.Message {
+		m := dynamicpb.NewMessage(listing)
+		items := m.Mutable(listing.Fields().ByName("items")).List()
+		for i := 0; i < n; i++ {
+			items.Append(protoreflect.ValueOfString(fmt.Sprintf("item-%03d", i)))
+		}
+		return m
+	}
+	count := func(m *dynamicpb.Message) int {
+		return m.Get(listing.Fields().ByName("items")).List().Len()
+	}
+	marked := func(m *dynamicpb.Message) bool {
+		return m.Get(listing.Fields().ByName(TruncatedField)).Bool()
+	}
+
+	resp := newListing(100)
+	got := TruncateResponse(&ToolInfo{ResponseLimits: ResponseLimits{MaxItems: 10}}, resp)
+	if count(got) != 10 || !marked(got) {
+		t.Errorf("MaxItems: got %d items, truncated %v; want 10 items, truncated", count(got), marked(got))
+	}
+	if count(resp) != 100 || marked(resp) {
+		t.Error("MaxItems: the response was modified in place")
+	}
+
+	got = TruncateResponse(&ToolInfo{ResponseLimits: ResponseLimits{MaxBytes: 256}}, newListing(100))
+	raw, err := protojson.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) > 256 || count(got) == 0 || !marked(got) {
+		t.Errorf("MaxBytes: got %d bytes with %d items, truncated %v; want at most 256 bytes, some items, truncated", len(raw), count(got), marked(got))
+	}
+
+	// Responses within the limits are returned as is.
+	resp = newListing(3)
+	if got := TruncateResponse(&ToolInfo{ResponseLimits: ResponseLimits{MaxBytes: 1024, MaxItems: 10}}, resp); got != resp || marked(got) {
+		t.Error("a response within the limits was changed")
+	}
+	if got := TruncateResponse(&ToolInfo{}, newListing(100)); count(got) != 100 {
+		t.Errorf("no limits: got %d items, want 100", count(got))
+	}
+}
@@ -0,0 +1,212 @@
+package genkittools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Violation is a single mismatch between a value and its JSON Schema.
+type Violation struct {
+	// Pointer is the JSON pointer of the offending value, e.g. "/line_items/2/quantity".
+	Pointer string
+	// Field is Pointer with array indices and map keys replaced by "*", so
+	// violations of the same schema location aggregate under one key.
+	Field string
+	// Message describes the mismatch.
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Pointer == "" {
+		return v.Message
+	}
+	return v.Pointer + ": " + v.Message
+}
+
+// Validate checks value against the subset of JSON Schema emitted by the
+// generator (type, properties, required, items, additionalProperties) and
+// returns every violation found, ordered by pointer. Like protojson, it
+// accepts decimal strings for integers and "NaN"/"Infinity" for numbers.
+func Validate(schema map[string]any, value any) []Violation {
+	var out []Violation
+	validateValue(schema, normalizeJSON(value), "", "", &out)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Pointer < out[j].Pointer })
+	return out
+}
+
+func validateValue(schema map[string]any, value any, pointer, field string, out *[]Violation) {
+	if schema == nil {
+		return
+	}
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !slices.ContainsFunc(types, func(typ string) bool { return matchesType(typ, value) }) {
+		*out = append(*out, Violation{
+			Pointer: pointer,
+			Field:   field,
+			Message: fmt.Sprintf("expected %s, got %s", strings.Join(types, " or "), jsonType(value)),
+		})
+		return
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		for _, name := range requiredNames(schema["required"]) {
+			if _, ok := val[name]; !ok {
+				*out = append(*out, Violation{
+					Pointer: pointer + "/" + escapePointer(name),
+					Field:   field + "/" + escapePointer(name),
+					Message: "required property is missing",
+				})
+			}
+		}
+		extra, _ := schema["additionalProperties"].(map[string]any)
+		for key, child := range val {
+			if propSchema, ok := props[key].(map[string]any); ok {
+				validateValue(propSchema, child, pointer+"/"+escapePointer(key), field+"/"+escapePointer(key), out)
+			} else if extra != nil {
+				validateValue(extra, child, pointer+"/"+escapePointer(key), field+"/*", out)
+			}
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		for i, child := range val {
+			validateValue(items, child, pointer+"/"+strconv.Itoa(i), field+"/*", out)
+		}
+	}
+}
+
+// schemaTypes returns the types a type keyword allows: a single type, or a
+// list of them as schema_draft writes for nullable fields.
+func schemaTypes(v any) []string {
+	switch typ := v.(type) {
+	case string:
+		return []string{typ}
+	case []string:
+		return typ
+	case []any:
+		var types []string
+		for _, t := range typ {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesType(typ string, value any) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		switch v := value.(type) {
+		case float64:
+			return true
+		case string:
+			return v == "NaN" || v == "Infinity" || v == "-Infinity"
+		}
+		return false
+	case "integer":
+		switch v := value.(type) {
+		case float64:
+			return v == math.Trunc(v)
+		case string:
+			_, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				_, err = strconv.ParseUint(v, 10, 64)
+			}
+			return err == nil
+		}
+		return false
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func requiredNames(v any) []string {
+	switch names := v.(type) {
+	case []string:
+		return names
+	case []any:
+		out := make([]string, 0, len(names))
+		for _, n := range names {
+			if s, ok := n.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// normalizeJSON converts value into the shapes produced by encoding/json
+// (map[string]any, []any, float64, ...) by round-tripping it through JSON, so
+// nested Go ints, structs or typed slices validate like decoded model output.
+// Proto messages are encoded with protojson using proto field names.
+func normalizeJSON(value any) any {
+	var raw []byte
+	var err error
+	switch v := value.(type) {
+	case nil, string, bool, float64:
+		return value
+	case proto.Message:
+		raw, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(v)
+	default:
+		raw, err = json.Marshal(value)
+	}
+	if err != nil {
+		return value
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return value
+	}
+	return out
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
@@ -0,0 +1,93 @@
+package genkittools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []string{"city", "invoice"},
+		"properties": map[string]any{
+			"city": map[string]any{"type": "string"},
+			"invoice": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"line_items": map[string]any{
+						"type": "array",
+						"items": map[string]any{
+							"type":       "object",
+							"properties": map[string]any{"quantity": map[string]any{"type": "integer"}},
+						},
+					},
+				},
+			},
+			"labels": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+			},
+		},
+	}
+
+	input := map[string]any{
+		"invoice": map[string]any{
+			"line_items": []any{
+				map[string]any{"quantity": 1},
+				map[string]any{"quantity": 1.5},
+			},
+		},
+		"labels": map[string]any{"team": 7},
+	}
+
+	got := Validate(schema, input)
+	want := []Violation{
+		{Pointer: "/city", Field: "/city", Message: "required property is missing"},
+		{Pointer: "/invoice/line_items/1/quantity", Field: "/invoice/line_items/*/quantity", Message: "expected integer, got number"},
+		{Pointer: "/labels/team", Field: "/labels/*", Message: "expected string, got integer"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Validate() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestValidateAcceptsConformingInput(t *testing.T) {
+	schema := map[string]any{
+		"type":       "object",
+		"required":   []string{"city"},
+		"properties": map[string]any{"city": map[string]any{"type": "string"}},
+	}
+	if got := Validate(schema, map[string]any{"city": "Paris", "extra": true}); len(got) != 0 {
+		t.Fatalf("unexpected violations: %v", got)
+	}
+}
+
+func TestValidateAcceptsProtojsonScalars(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id":    map[string]any{"type": "integer"},
+			"ratio": map[string]any{"type": "number"},
+		},
+	}
+	if got := Validate(schema, map[string]any{"id": "18446744073709551615", "ratio": "NaN"}); len(got) != 0 {
+		t.Fatalf("unexpected violations: %v", got)
+	}
+	if got := Validate(schema, map[string]any{"id": "12abc"}); len(got) != 1 {
+		t.Fatalf("expected a violation for a non-numeric string, got %v", got)
+	}
+}
+
+func TestValidateAcceptsTypeLists(t *testing.T) {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"coupon": map[string]any{"type": []string{"string", "null"}}},
+	}
+	if got := Validate(schema, map[string]any{"coupon": nil}); len(got) != 0 {
+		t.Fatalf("unexpected violations: %v", got)
+	}
+	want := []Violation{{Pointer: "/coupon", Field: "/coupon", Message: "expected string or null, got boolean"}}
+	if got := Validate(schema, map[string]any{"coupon": true}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Validate() = %v, want %v", got, want)
+	}
+}
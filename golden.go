@@ -0,0 +1,46 @@
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	testingPackage  = protogen.GoImportPath("testing")
+	filepathPackage = protogen.GoImportPath("path/filepath")
+)
+
+// generateGoldenTestFile emits a test per service that snapshots the input
+// and output schema of each tool under testdata, into a companion
+// _genkit.tools_schema_test.go file.
+func generateGoldenTestFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_schema_test.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		name := svc.service.GoName
+		g.P("// Test", name, "ToolSchemas fails when a tool schema of ", name, " differs from")
+		g.P("// its golden file under testdata. Run it with GENKIT_TOOLS_UPDATE_GOLDEN=1")
+		g.P("// set in the environment to create the files or accept an intended change.")
+		g.P("func Test", name, "ToolSchemas(t *", testingPackage.Ident("T"), ") {")
+		g.P("for _, info := range []*", genkittoolsPackage.Ident("ToolInfo"), "{")
+		for _, m := range svc.methods {
+			g.P(toolInfoVarName(m), ",")
+		}
+		g.P("} {")
+		g.P("t.Run(info.Name, func(t *", testingPackage.Ident("T"), ") {")
+		g.P("if err := ", genkittoolsPackage.Ident("CompareGolden"), "(", filepathPackage.Ident("Join"), `("testdata", info.Name+".input.schema.json"), info.InputSchema); err != nil {`)
+		g.P("t.Error(err)")
+		g.P("}")
+		g.P("if err := ", genkittoolsPackage.Ident("CompareGolden"), "(", filepathPackage.Ident("Join"), `("testdata", info.Name+".output.schema.json"), info.OutputSchema); err != nil {`)
+		g.P("t.Error(err)")
+		g.P("}")
+		g.P("})")
+		g.P("}")
+		g.P("}")
+		g.P()
+	}
+}
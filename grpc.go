@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	grpcPackage         = protogen.GoImportPath("google.golang.org/grpc")
+	grpcMetadataPackage = protogen.GoImportPath("google.golang.org/grpc/metadata")
+	ioPackage           = protogen.GoImportPath("io")
+)
+
+// generateGRPCFile emits New<Service>GRPCImpl adapters backed by the
+// protoc-gen-go-grpc client stubs into a companion _genkit.tools_grpc.go file.
+func generateGRPCFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_grpc.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		writeServiceGRPCAdapter(g, file, svc.service, svc.methods)
+	}
+}
+
+func writeServiceGRPCAdapter(g *protogen.GeneratedFile, file *protogen.File, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	adapterName := strings.ToLower(svc.GoName[:1]) + svc.GoName[1:] + "GRPCImpl"
+	callOption := g.QualifiedGoIdent(grpcPackage.Ident("CallOption"))
+	// The client lives next to the messages, which package_suffix moves the
+	// adapter away from.
+	client := g.QualifiedGoIdent(file.GoImportPath.Ident(svc.GoName + "Client"))
+	newClient := g.QualifiedGoIdent(file.GoImportPath.Ident("New" + svc.GoName + "Client"))
+
+	g.P("// New", svc.GoName, "GRPCImpl implements ", implName, " by forwarding every")
+	g.P("// tool call to the ", svc.GoName, " service behind conn, using the client")
+	g.P("// generated by protoc-gen-go-grpc. opts are applied to every call.")
+	g.P("func New", svc.GoName, "GRPCImpl(conn ", grpcPackage.Ident("ClientConnInterface"), ", opts ...", callOption, ") ", implName, " {")
+	g.P("return &", adapterName, "{client: ", newClient, "(conn), opts: opts}")
+	g.P("}")
+	g.P()
+	g.P("// New", svc.GoName, "GRPCImplWithHeaders is New", svc.GoName, "GRPCImpl adding headers,")
+	g.P("// read from the context of each tool call, to the outgoing metadata of every")
+	g.P("// call, so that backend calls carry the caller's credentials and request IDs.")
+	g.P("func New", svc.GoName, "GRPCImplWithHeaders(conn ", grpcPackage.Ident("ClientConnInterface"), ", headers ", genkittoolsPackage.Ident("Headers"), ", opts ...", callOption, ") ", implName, " {")
+	g.P("return &", adapterName, "{client: ", newClient, "(conn), opts: opts, headers: headers}")
+	g.P("}")
+	g.P()
+	g.P("type ", adapterName, " struct {")
+	g.P("client  ", client)
+	g.P("opts    []", callOption)
+	g.P("headers ", genkittoolsPackage.Ident("Headers"))
+	g.P("}")
+	g.P()
+	g.P("// outgoing returns ctx carrying the headers of x as outgoing metadata.")
+	g.P("func (x *", adapterName, ") outgoing(ctx ", contextPackage.Ident("Context"), ") ", contextPackage.Ident("Context"), " {")
+	g.P("pairs := x.headers.Pairs(ctx)")
+	g.P("if len(pairs) == 0 {")
+	g.P("return ctx")
+	g.P("}")
+	g.P("return ", grpcMetadataPackage.Ident("AppendToOutgoingContext"), "(ctx, pairs...)")
+	g.P("}")
+	g.P()
+
+	for _, m := range methods {
+		writeGRPCAdapterMethod(g, adapterName, m.method)
+	}
+}
+
+func writeGRPCAdapterMethod(g *protogen.GeneratedFile, adapterName string, m *protogen.Method) {
+	clientStreaming := m.Desc.IsStreamingClient()
+	serverStreaming := m.Desc.IsStreamingServer()
+
+	errReturn := "return nil, err"
+	if serverStreaming {
+		errReturn = "return err"
+	}
+
+	params, results := implMethodParams(g, m, implContextType(g))
+
+	g.P("func (x *", adapterName, ") ", m.GoName, "(", params, ") ", results, " {")
+	if !clientStreaming && !serverStreaming {
+		g.P("return x.client.", m.GoName, "(x.outgoing(ctx), req, x.opts...)")
+		g.P("}")
+		g.P()
+		return
+	}
+
+	if clientStreaming {
+		g.P("stream, err := x.client.", m.GoName, "(x.outgoing(ctx), x.opts...)")
+	} else {
+		g.P("stream, err := x.client.", m.GoName, "(x.outgoing(ctx), req, x.opts...)")
+	}
+	g.P("if err != nil {")
+	g.P(errReturn)
+	g.P("}")
+	if clientStreaming {
+		// Send reports io.EOF when the server aborted the stream; the status
+		// is then returned by the following receive.
+		g.P("for req := range reqs {")
+		g.P("if err := stream.Send(req); err != nil {")
+		g.P("if ", errorsPackage.Ident("Is"), "(err, ", ioPackage.Ident("EOF"), ") {")
+		g.P("break")
+		g.P("}")
+		g.P(errReturn)
+		g.P("}")
+		g.P("}")
+		if !serverStreaming {
+			g.P("return stream.CloseAndRecv()")
+			g.P("}")
+			g.P()
+			return
+		}
+		g.P("if err := stream.CloseSend(); err != nil {")
+		g.P("return err")
+		g.P("}")
+	}
+	g.P("for {")
+	g.P("resp, err := stream.Recv()")
+	g.P("if ", errorsPackage.Ident("Is"), "(err, ", ioPackage.Ident("EOF"), ") {")
+	g.P("return nil")
+	g.P("}")
+	g.P("if err != nil {")
+	g.P("return err")
+	g.P("}")
+	g.P("if err := send(resp); err != nil {")
+	g.P("return err")
+	g.P("}")
+	g.P("}")
+	g.P("}")
+	g.P()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// handlerName returns the name of the one-method interface implementing the
+// tool of meta alone under handlers=true.
+func handlerName(meta methodMeta) string {
+	return meta.goName + "Handler"
+}
+
+// implParamType returns the type of the impl parameter of the functions
+// defining the tool of meta: its handler interface under handlers=true, so
+// that a single tool can be registered without the rest of the service.
+func implParamType(svc *protogen.Service, meta methodMeta) string {
+	if *generateHandlers {
+		return handlerName(meta)
+	}
+	return svc.GoName + "ToolImpl"
+}
+
+// writeHandlers emits a handler interface per method of svc, with a
+// Register<Service><Method>Tool function unless tools are lazy, and the
+// <Service>Handlers struct composing a <Service>ToolImpl from them.
+func writeHandlers(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	composite := svc.GoName + "Handlers"
+
+	for _, m := range methods {
+		name := handlerName(m)
+		g.P("// ", name, " implements the ", m.toolName, " tool alone, so that it can be")
+		g.P("// registered without implementing the rest of ", implName, ".")
+		g.P("type ", name, " interface {")
+		g.P(m.method.GoName, implMethodSignature(g, m.method, implContextType(g)))
+		g.P("}")
+		g.P()
+
+		if !*lazyTools {
+			register := "Register" + m.goName + "Tool"
+			g.P("// ", register, " registers the ", m.toolName, " tool, implemented by h.")
+			g.P("func ", register, "(g *genkit.Genkit, h ", name, ", opts ...", genkittoolsPackage.Ident("Option"), ") (", genkitAIPackage.Ident("Tool"), ", error) {")
+			g.P("return ", defineFuncName(m), "(g, h, ", genkittoolsPackage.Ident("NewOptions"), "(opts...))")
+			g.P("}")
+			g.P()
+		}
+	}
+
+	g.P("// ", composite, " composes a ", implName, " from one handler per method,")
+	g.P("// so that an app can implement some of the tools of ", svc.GoName, " and select")
+	g.P("// them with genkittools.WithOnly. Methods without a handler fail.")
+	g.P("type ", composite, " struct {")
+	for _, m := range methods {
+		g.P(m.method.GoName, "Handler ", handlerName(m))
+	}
+	g.P("}")
+	g.P()
+	g.P("var _ ", implName, " = ", composite, "{}")
+	g.P()
+
+	for _, m := range methods {
+		name := m.method.GoName
+		params, results := implMethodParams(g, m.method, implContextType(g))
+		g.P("// ", name, " delegates to ", name, "Handler.")
+		g.P("func (h ", composite, ") ", name, "(", params, ") ", results, " {")
+		g.P("if h.", name, "Handler == nil {")
+		if m.method.Desc.IsStreamingServer() {
+			g.P("return ", errorsPackage.Ident("New"), `("`, composite, ".", name, ": ", name, `Handler is not set")`)
+		} else {
+			g.P("return nil, ", errorsPackage.Ident("New"), `("`, composite, ".", name, ": ", name, `Handler is not set")`)
+		}
+		g.P("}")
+		g.P("return h.", name, "Handler.", name, "(", implMethodArgs(m.method), ")")
+		g.P("}")
+		g.P()
+	}
+}
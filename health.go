@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// writeHealthTool emits the <service>_health tool of svc, which calls the
+// Ping method of implementations that have one to report whether their
+// backend is reachable.
+func writeHealthTool(g, schemas *protogen.GeneratedFile, svc *protogen.Service) {
+	pinger := healthPingerName(svc)
+	infoVar := "toolInfo" + healthGoName(svc)
+	schemaVar := "schema" + healthGoName(svc)
+	outputSchemaVar := "outputSchema" + healthGoName(svc)
+	outType := "*" + g.QualifiedGoIdent(genkittoolsPackage.Ident("HealthStatus"))
+	description := fmt.Sprintf("Check whether the backend of the %s tools is reachable before calling them. Takes no arguments.", svc.Desc.Name())
+
+	g.P("// ", pinger, " is implemented by ", svc.GoName, "ToolImpl implementations that can")
+	g.P("// check their backend. Registering one also registers the ", healthToolName(svc), " tool,")
+	g.P("// which reports whether Ping succeeded.")
+	g.P("type ", pinger, " interface {")
+	g.P("Ping(ctx ", contextPackage.Ident("Context"), ") error")
+	g.P("}")
+	g.P()
+
+	writeToolSchemas(schemas, infoVar, schemaVar,
+		schemas.QualifiedGoIdent(genkittoolsPackage.Ident("HealthInputSchema"))+"()", outputSchemaVar,
+		schemas.QualifiedGoIdent(genkittoolsPackage.Ident("HealthOutputSchema"))+"()")
+	g.P("var ", infoVar, " = &", genkittoolsPackage.Ident("ToolInfo"), "{")
+	g.P("Name: string(", healthToolConstName(svc), "),")
+	g.P("Description: ", strconv.Quote(description), ",")
+	g.P("Service: ", strconv.Quote(string(svc.Desc.FullName())), ",")
+	g.P("GeneratedWith: ", svc.GoName, "GeneratedWith,")
+	if !runtimeCodegen() {
+		g.P("InputSchema: ", schemaVar, ",")
+		g.P("OutputSchema: ", outputSchemaVar, ",")
+	}
+	g.P("}")
+	g.P()
+
+	if *lazyTools {
+		newName := "New" + healthGoName(svc) + "Tool"
+		g.P("// ", newName, " binds pinger to an unregistered ", healthToolName(svc), " tool.")
+		g.P("func ", newName, "(pinger ", pinger, ", opts ...", genkittoolsPackage.Ident("Option"), ") ", genkitAIPackage.Ident("Tool"), " {")
+		g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	} else {
+		funcName := "define" + healthGoName(svc) + "Tool"
+		g.P("// ", funcName, " defines the ", healthToolName(svc), " tool, calling pinger.Ping.")
+		g.P("func ", funcName, "(g *genkit.Genkit, pinger ", pinger, ", o *", genkittoolsPackage.Ident("Options"), ") (", genkitAIPackage.Ident("Tool"), ", error) {")
+	}
+	def := toolDefinition{inType: "any", outType: outType, schemaVar: schemaVar}
+	writeDefinitionStart(g, def, infoVar)
+	g.P("func(ctx *", genkitAIPackage.Ident("ToolContext"), ", input any) (", outType, ", error) {")
+	g.P("return ", genkittoolsPackage.Ident("CheckHealth"), "(ctx, o, ", infoVar, ", input, pinger.Ping)")
+	g.P("},")
+	writeDefinitionEnd(g, def)
+	g.P("}")
+	g.P()
+}
+
+// writeHealthRegistration emits the registration of svc's health tool in
+// Register<Service>Tools, or its construction in New<Service>Tools, for
+// implementations with a Ping method.
+func writeHealthRegistration(g *protogen.GeneratedFile, svc *protogen.Service) {
+	g.P("if pinger, ok := impl.(", healthPingerName(svc), "); ok && o.Includes(string(", healthToolConstName(svc), ")) {")
+	if *lazyTools {
+		g.P("refs = append(refs, New", healthGoName(svc), "Tool(pinger, opts...))")
+	} else {
+		g.P("if t, err := define", healthGoName(svc), "Tool(g, pinger, o); err != nil {")
+		g.P("return nil, err")
+		g.P("} else {")
+		g.P("tools = append(tools, t)")
+		g.P("}")
+	}
+	g.P("}")
+}
+
+func healthToolName(svc *protogen.Service) string {
+	return derivedToolName(svc.GoName, "Health")
+}
+
+func healthGoName(svc *protogen.Service) string {
+	return svc.GoName + "Health"
+}
+
+func healthToolConstName(svc *protogen.Service) string {
+	return healthGoName(svc) + "Tool"
+}
+
+func healthPingerName(svc *protogen.Service) string {
+	return svc.GoName + "Pinger"
+}
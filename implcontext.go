@@ -0,0 +1,37 @@
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// implToolContext reports whether impl_context=tool makes the methods of
+// <Service>ToolImpl receive Genkit's tool context instead of a
+// context.Context.
+func implToolContext() bool {
+	return *implContext == "tool"
+}
+
+// implContextType returns the type of the context parameter of the
+// <Service>ToolImpl methods in the file g.
+func implContextType(g *protogen.GeneratedFile) string {
+	if implToolContext() {
+		return "*" + g.QualifiedGoIdent(genkitAIPackage.Ident("ToolContext"))
+	}
+	return g.QualifiedGoIdent(contextPackage.Ident("Context"))
+}
+
+// writeImplToolContext emits, under impl_context=tool, the toolCtx variable
+// passed to an implementation in place of ctx: a tool context of type typ
+// wrapping ctx, carrying the resume state of the Genkit tool context outer,
+// if any. It returns the name of the context argument of the implementation.
+func writeImplToolContext(g *protogen.GeneratedFile, typ, outer string) string {
+	if !implToolContext() {
+		return "ctx"
+	}
+	if outer == "" {
+		g.P("toolCtx := &", typ, "{Context: ctx}")
+	} else {
+		g.P("toolCtx := &", typ, "{Context: ctx, Resumed: ", outer, ".Resumed, OriginalInput: ", outer, ".OriginalInput}")
+	}
+	return "toolCtx"
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// writeImplFuncs emits <Service>ImplFuncs, a <Service>ToolImpl whose methods
+// call the function held in its <Method>Func fields.
+func writeImplFuncs(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	funcsName := svc.GoName + "ImplFuncs"
+
+	g.P("// ", funcsName, " implements ", implName, " with a function per method, so")
+	g.P("// that prototypes and tests can provide closures instead of a named type.")
+	g.P("// Methods whose function is nil fail.")
+	g.P("type ", funcsName, " struct {")
+	for _, m := range methods {
+		g.P(m.method.GoName, "Func func", implMethodSignature(g, m.method, implContextType(g)))
+	}
+	g.P("}")
+	g.P()
+	g.P("var _ ", implName, " = ", funcsName, "{}")
+	g.P()
+
+	for _, m := range methods {
+		name := m.method.GoName
+		params, results := implMethodParams(g, m.method, implContextType(g))
+		g.P("// ", name, " calls ", name, "Func.")
+		g.P("func (f ", funcsName, ") ", name, "(", params, ") ", results, " {")
+		g.P("if f.", name, "Func == nil {")
+		if m.method.Desc.IsStreamingServer() {
+			g.P("return ", errorsPackage.Ident("New"), `("`, funcsName, ".", name, ": ", name, `Func is not set")`)
+		} else {
+			g.P("return nil, ", errorsPackage.Ident("New"), `("`, funcsName, ".", name, ": ", name, `Func is not set")`)
+		}
+		g.P("}")
+		g.P("return f.", name, "Func(", implMethodArgs(m.method), ")")
+		g.P("}")
+		g.P()
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// typedInput reports whether meta's tool is defined with a generated input
+// struct under input_structs=true. Client-streaming tools take a list of
+// requests and keep the generic handler.
+func typedInput(meta methodMeta) bool {
+	return *inputStructs && !meta.method.Desc.IsStreamingClient()
+}
+
+// inputStructName returns the name of the input struct of meta's tool.
+func inputStructName(meta methodMeta) string {
+	return meta.goName + "Input"
+}
+
+// toolInputType returns the type of the input of meta's tool handler: its
+// input struct, or any when the tool decodes the model input itself.
+func toolInputType(meta methodMeta) string {
+	if typedInput(meta) {
+		return inputStructName(meta)
+	}
+	return "any"
+}
+
+// writeHandlerInput emits the signature of a tool function of meta's tool,
+// with the given context parameter and result type, and turns a typed input
+// back into the JSON value held in input, which the generated handlers
+// check and decode.
+func writeHandlerInput(g *protogen.GeneratedFile, meta methodMeta, ctxParam, result string) {
+	if !typedInput(meta) {
+		g.P("func(", ctxParam, " *", genkitAIPackage.Ident("ToolContext"), ", input any) (", result, ", error) {")
+		return
+	}
+	g.P("func(", ctxParam, " *", genkitAIPackage.Ident("ToolContext"), ", in ", inputStructName(meta), ") (", result, ", error) {")
+	g.P("input := ", genkittoolsPackage.Ident("StructInput"), "(in)")
+}
+
+// writeInputStructs emits the input struct of meta's tool, mirroring its
+// input schema with JSON tags, and a struct for each message below it.
+// Descriptions and enum values are carried in jsonschema tags so that
+// Genkit derives a descriptive schema from the struct.
+func writeInputStructs(g *protogen.GeneratedFile, meta methodMeta) {
+	w := &inputStructWriter{
+		g:     g,
+		meta:  meta,
+		names: map[protoreflect.FullName]string{meta.method.Input.Desc.FullName(): inputStructName(meta)},
+		taken: map[string]bool{inputStructName(meta): true},
+	}
+	w.queue = []*protogen.Message{meta.method.Input}
+	for len(w.queue) > 0 {
+		msg := w.queue[0]
+		w.queue = w.queue[1:]
+		w.writeStruct(msg)
+	}
+}
+
+type inputStructWriter struct {
+	g     *protogen.GeneratedFile
+	meta  methodMeta
+	names map[protoreflect.FullName]string
+	taken map[string]bool
+	queue []*protogen.Message
+}
+
+func (w *inputStructWriter) writeStruct(msg *protogen.Message) {
+	name := w.names[msg.Desc.FullName()]
+	if msg == w.meta.method.Input {
+		w.g.P("// ", name, " is the input of the ", w.meta.toolName, " tool, mirroring ", msg.GoIdent.GoName, ".")
+	} else {
+		w.g.P("// ", name, " mirrors ", msg.Desc.FullName(), " in the input of the ", w.meta.toolName, " tool.")
+	}
+	w.g.P("type ", name, " struct {")
+	for _, field := range msg.Fields {
+		if getFieldDoc(field.Desc).GetContextKey() != "" || isOutputOnly(field.Desc) {
+			continue
+		}
+		w.g.P(field.GoName, " ", w.fieldType(field), " ", inputStructTag(field.Desc))
+	}
+	w.g.P("}")
+	w.g.P()
+}
+
+// structName returns the name of the struct mirroring msg, queueing it on
+// first use.
+func (w *inputStructWriter) structName(msg *protogen.Message) string {
+	if name, ok := w.names[msg.Desc.FullName()]; ok {
+		return name
+	}
+	base := inputStructName(w.meta) + msg.GoIdent.GoName
+	name := base
+	for i := 2; w.taken[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	w.names[msg.Desc.FullName()] = name
+	w.taken[name] = true
+	w.queue = append(w.queue, msg)
+	return name
+}
+
+func (w *inputStructWriter) fieldType(field *protogen.Field) string {
+	switch {
+	case field.Desc.IsMap():
+		// JSON object keys are strings whatever the proto key type.
+		return "map[string]" + w.valueType(field.Message.Fields[1])
+	case field.Desc.IsList():
+		return "[]" + w.valueType(field)
+	}
+	typ := w.valueType(field)
+	switch {
+	case field.Message != nil:
+		if wellKnownGoType(field.Message.Desc.FullName()) == "" || isWrapperType(field.Message.Desc.FullName()) {
+			return "*" + typ
+		}
+		return typ
+	case field.Desc.HasPresence() && typ != "any":
+		return "*" + typ
+	}
+	return typ
+}
+
+// isWrapperType reports whether name is one of the google.protobuf wrapper
+// messages, whose fields keep their presence in input structs.
+func isWrapperType(name protoreflect.FullName) bool {
+	switch name {
+	case "google.protobuf.BoolValue", "google.protobuf.BytesValue",
+		"google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int32Value", "google.protobuf.Int64Value",
+		"google.protobuf.StringValue", "google.protobuf.UInt32Value",
+		"google.protobuf.UInt64Value":
+		return true
+	}
+	return false
+}
+
+// valueType returns the Go type of a single value of field, ignoring its
+// cardinality.
+func (w *inputStructWriter) valueType(field *protogen.Field) string {
+	switch field.Desc.Kind() {
+	case protoreflect.BoolKind:
+		return "bool"
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		return "float64"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "int32"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "uint32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "int64"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "uint64"
+	case protoreflect.BytesKind:
+		return "[]byte"
+	case protoreflect.EnumKind:
+		if field.Enum.Desc.FullName() == "google.protobuf.NullValue" {
+			return "any"
+		}
+		return "string"
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if typ := wellKnownGoType(field.Message.Desc.FullName()); typ != "" {
+			return typ
+		}
+		return w.structName(field.Message)
+	default:
+		return "string"
+	}
+}
+
+// wellKnownGoType returns the Go type of the JSON form protojson gives the
+// well-known type name, or "" for other messages.
+func wellKnownGoType(name protoreflect.FullName) string {
+	switch name {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration",
+		"google.protobuf.FieldMask", "google.protobuf.StringValue":
+		return "string"
+	case "google.protobuf.Struct", "google.protobuf.Any":
+		return "map[string]any"
+	case "google.protobuf.ListValue":
+		return "[]any"
+	case "google.protobuf.Value":
+		return "any"
+	case "google.protobuf.BoolValue":
+		return "bool"
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue":
+		return "float64"
+	case "google.protobuf.Int32Value":
+		return "int32"
+	case "google.protobuf.UInt32Value":
+		return "uint32"
+	case "google.protobuf.Int64Value":
+		return "int64"
+	case "google.protobuf.UInt64Value":
+		return "uint64"
+	case "google.protobuf.BytesValue":
+		return "[]byte"
+	}
+	return ""
+}
+
+// inputStructTag returns the struct tag of field: its JSON name, omitempty
+// unless the field is required, and its description and enum values for
+// Genkit's schema inference.
+func inputStructTag(field protoreflect.FieldDescriptor) string {
+	jsonTag := string(field.Name())
+	if !getFieldDoc(field).GetRequired() && field.Cardinality() != protoreflect.Required {
+		jsonTag += ",omitempty"
+	}
+	tag := fmt.Sprintf("json:%q", jsonTag)
+	if enum := field.Enum(); enum != nil && enum.FullName() != "google.protobuf.NullValue" && !field.IsMap() {
+		values := make([]string, enum.Values().Len())
+		for i := range values {
+			values[i] = "enum=" + string(enum.Values().Get(i).Name())
+		}
+		tag += fmt.Sprintf(" jsonschema:%q", strings.Join(values, ","))
+	}
+	if desc := fieldDescription(field); desc != "" {
+		tag += fmt.Sprintf(" jsonschema_description:%q", desc)
+	}
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
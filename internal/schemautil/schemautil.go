@@ -0,0 +1,193 @@
+// Package schemautil holds the JSON Schema helpers shared by the plugin,
+// which inlines tool schemas at generation time, and genkittools, which
+// builds them at run time for codegen=runtime, so that both modes describe
+// the same input.
+package schemautil
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Draft07 is the schema_draft value selecting JSON Schema draft-07.
+const Draft07 = "draft-07"
+
+// DefinitionsKeyword returns the keyword holding the definitions of
+// recursive messages under draft: $defs, or definitions before 2019-09.
+func DefinitionsKeyword(draft string) string {
+	if draft == Draft07 {
+		return "definitions"
+	}
+	return "$defs"
+}
+
+// DefinitionRef returns the reference to the definition of the message name
+// under draft.
+func DefinitionRef(draft string, name protoreflect.FullName) string {
+	return "#/" + DefinitionsKeyword(draft) + "/" + string(name)
+}
+
+// AddDefinitions defines the messages referred to by schema, the schema of
+// msg under draft, at its root. messageSchema builds the schema of each
+// definition.
+func AddDefinitions(schema map[string]any, msg protoreflect.MessageDescriptor, draft string, messageSchema func(protoreflect.MessageDescriptor) map[string]any) {
+	defs := make(map[string]any)
+	for refs := SchemaRefs(schema, draft, nil); len(refs) > 0; refs = refs[1:] {
+		name := refs[0]
+		if _, ok := defs[name]; ok {
+			continue
+		}
+		def := messageSchema(FindMessage(msg, protoreflect.FullName(name), nil))
+		defs[name] = def
+		refs = SchemaRefs(def, draft, refs)
+	}
+	if len(defs) > 0 {
+		schema[DefinitionsKeyword(draft)] = defs
+	}
+}
+
+// SchemaRefs appends the names of the messages v refers to to names.
+func SchemaRefs(v any, draft string, names []string) []string {
+	switch val := v.(type) {
+	case map[string]any:
+		if ref, ok := val["$ref"].(string); ok {
+			names = append(names, strings.TrimPrefix(ref, "#/"+DefinitionsKeyword(draft)+"/"))
+		}
+		for _, sub := range val {
+			names = SchemaRefs(sub, draft, names)
+		}
+	case []any:
+		for _, sub := range val {
+			names = SchemaRefs(sub, draft, names)
+		}
+	}
+	return names
+}
+
+// FindMessage returns the message called name among msg and the messages
+// its fields reach.
+func FindMessage(msg protoreflect.MessageDescriptor, name protoreflect.FullName, seen map[protoreflect.FullName]bool) protoreflect.MessageDescriptor {
+	if msg.FullName() == name {
+		return msg
+	}
+	if seen == nil {
+		seen = make(map[protoreflect.FullName]bool)
+	}
+	seen[msg.FullName()] = true
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		if field.IsMap() {
+			field = field.MapValue()
+		}
+		if next := field.Message(); next != nil && !seen[next.FullName()] {
+			if found := FindMessage(next, name, seen); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// HoistDefinitions moves the definitions of inner to outer, the schema
+// inner becomes part of, so that its references still resolve, and returns
+// inner without them. inner is copied rather than changed, since schemas are
+// shared between tools.
+func HoistDefinitions(outer, inner map[string]any) map[string]any {
+	var found bool
+	for _, key := range []string{"$defs", "definitions"} {
+		if defs, ok := inner[key]; ok {
+			outer[key] = defs
+			found = true
+		}
+	}
+	if !found {
+		return inner
+	}
+	out := make(map[string]any, len(inner))
+	for k, v := range inner {
+		if k != "$defs" && k != "definitions" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// AcceptsNull reports whether field tells unset from the zero value, so
+// that null, which the decoders read as unset, is meaningful: scalars with
+// explicit presence and the wrapper types.
+func AcceptsNull(field protoreflect.FieldDescriptor) bool {
+	if field.IsList() || field.IsMap() || !field.HasPresence() {
+		return false
+	}
+	if msg := field.Message(); msg != nil {
+		return strings.HasPrefix(string(msg.FullName()), "google.protobuf.") && strings.HasSuffix(string(msg.Name()), "Value") &&
+			msg.FullName() != "google.protobuf.Value" && msg.FullName() != "google.protobuf.ListValue"
+	}
+	return field.Enum() == nil || field.Enum().FullName() != "google.protobuf.NullValue"
+}
+
+// NullableSchema returns schema also accepting null.
+func NullableSchema(schema map[string]any) map[string]any {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+	}
+	if enum, ok := schema["enum"].([]string); ok {
+		values := make([]any, 0, len(enum)+1)
+		for _, v := range enum {
+			values = append(values, v)
+		}
+		schema["enum"] = append(values, nil)
+	}
+	return schema
+}
+
+// OpenAPIv2OptionNumber is the number of the grpc-gateway
+// openapiv2_operation and openapiv2_field options. Like google.api.http,
+// they are decoded from the raw option bytes so as not to depend on the
+// grpc-gateway Go packages.
+const OpenAPIv2OptionNumber = 1042
+
+// RawOptionStrings decodes the message-typed option num from the unknown
+// fields of opts and returns its top-level string fields by number. Later
+// occurrences win, as when merging the option.
+func RawOptionStrings(opts proto.Message, num protowire.Number) map[protowire.Number]string {
+	out := make(map[protowire.Number]string)
+	if opts == nil {
+		return out
+	}
+	for b := opts.ProtoReflect().GetUnknown(); len(b) > 0; {
+		n, typ, m := protowire.ConsumeTag(b)
+		if m < 0 {
+			return out
+		}
+		b = b[m:]
+		m = protowire.ConsumeFieldValue(n, typ, b)
+		if m < 0 {
+			return out
+		}
+		if n == num && typ == protowire.BytesType {
+			v, _ := protowire.ConsumeBytes(b)
+			for len(v) > 0 {
+				fn, ftyp, fm := protowire.ConsumeTag(v)
+				if fm < 0 {
+					break
+				}
+				v = v[fm:]
+				fm = protowire.ConsumeFieldValue(fn, ftyp, v)
+				if fm < 0 {
+					break
+				}
+				if ftyp == protowire.BytesType {
+					s, _ := protowire.ConsumeBytes(v)
+					out[fn] = string(s)
+				}
+				v = v[fm:]
+			}
+		}
+		b = b[m:]
+	}
+	return out
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const langchainToolsPackage = protogen.GoImportPath("github.com/tmc/langchaingo/tools")
+
+// generateLangChainFile emits New<Service>LangChainTools functions adapting
+// the tools of each service to langchaingo into a companion
+// _genkit.tools_langchaingo.go file.
+func generateLangChainFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_langchaingo.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		writeServiceLangChain(g, svc.service, svc.methods)
+	}
+}
+
+func writeServiceLangChain(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+
+	g.P("// New", svc.GoName, "LangChainTools returns the tools of ", svc.GoName, " as langchaingo")
+	g.P("// tools backed by impl, for agents built with langchaingo. They take and")
+	g.P("// return JSON and reuse the schemas and coercion of the Genkit tools; the")
+	g.P("// options apply as they do to the Genkit tools of the service.")
+	g.P("func New", svc.GoName, "LangChainTools(impl ", implName, ", opts ...", genkittoolsPackage.Ident("Option"), ") []", langchainToolsPackage.Ident("Tool"), " {")
+	g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	g.P("var list []", langchainToolsPackage.Ident("Tool"))
+	for _, m := range methods {
+		g.P("if o.Includes(string(", toolConstName(m), ")) {")
+		g.P("list = append(list, &", genkittoolsPackage.Ident("LangChainTool"), "{")
+		g.P("Info: ", toolInfoVarName(m), ",")
+		g.P("Run: func(ctx ", contextPackage.Ident("Context"), ", input any) (any, error) {")
+		writeInvoke(g, svc, m, "return ", "")
+		g.P("},")
+		g.P("})")
+		g.P("}")
+	}
+	g.P("return list")
+	g.P("}")
+	g.P()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// writeListToolsTool emits the <service>_list_tools meta-tool of svc, which
+// summarizes the other tools of svc on demand.
+func writeListToolsTool(g, schemas *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	goName := listToolsGoName(svc)
+	infoVar := "toolInfo" + goName
+	schemaVar := "schema" + goName
+	outputSchemaVar := "outputSchema" + goName
+	outType := "*" + g.QualifiedGoIdent(genkittoolsPackage.Ident("ToolList"))
+	description := fmt.Sprintf("List the %s tools with their descriptions and parameters. Pass query to search them, or tool to get the full input schema of one tool before calling it.", svc.Desc.Name())
+
+	writeToolSchemas(schemas, infoVar, schemaVar,
+		schemas.QualifiedGoIdent(genkittoolsPackage.Ident("ListToolsInputSchema"))+"()", outputSchemaVar,
+		schemas.QualifiedGoIdent(genkittoolsPackage.Ident("ListToolsOutputSchema"))+"()")
+	g.P("var ", infoVar, " = &", genkittoolsPackage.Ident("ToolInfo"), "{")
+	g.P("Name: string(", listToolsToolConstName(svc), "),")
+	g.P("Description: ", strconv.Quote(description), ",")
+	g.P("Service: ", strconv.Quote(string(svc.Desc.FullName())), ",")
+	g.P("GeneratedWith: ", svc.GoName, "GeneratedWith,")
+	if !runtimeCodegen() {
+		g.P("InputSchema: ", schemaVar, ",")
+		g.P("OutputSchema: ", outputSchemaVar, ",")
+	}
+	g.P("}")
+	g.P()
+
+	if *lazyTools {
+		newName := "New" + goName + "Tool"
+		g.P("// ", newName, " returns an unregistered ", listToolsToolName(svc), " tool.")
+		g.P("func ", newName, "(opts ...", genkittoolsPackage.Ident("Option"), ") ", genkitAIPackage.Ident("Tool"), " {")
+		g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	} else {
+		funcName := "define" + goName + "Tool"
+		g.P("// ", funcName, " defines the ", listToolsToolName(svc), " tool, summarizing the")
+		g.P("// tools of ", svc.GoName, " included by o.")
+		g.P("func ", funcName, "(g *genkit.Genkit, o *", genkittoolsPackage.Ident("Options"), ") (", genkitAIPackage.Ident("Tool"), ", error) {")
+	}
+	def := toolDefinition{inType: "any", outType: outType, schemaVar: schemaVar}
+	writeDefinitionStart(g, def, infoVar)
+	g.P("func(ctx *", genkitAIPackage.Ident("ToolContext"), ", input any) (", outType, ", error) {")
+	g.P("return ", genkittoolsPackage.Ident("ListTools"), "(ctx, o, ", infoVar, ", []*", genkittoolsPackage.Ident("ToolInfo"), "{")
+	for _, m := range methods {
+		g.P(toolInfoVarName(m), ",")
+	}
+	for _, m := range batchMethods(methods) {
+		g.P(toolInfoVarName(m), "Batch,")
+	}
+	g.P("}, input)")
+	g.P("},")
+	writeDefinitionEnd(g, def)
+	g.P("}")
+	g.P()
+}
+
+// writeListToolsRegistration emits the registration of svc's list_tools
+// tool in Register<Service>Tools, or its construction in New<Service>Tools.
+func writeListToolsRegistration(g *protogen.GeneratedFile, svc *protogen.Service) {
+	g.P("if o.Includes(string(", listToolsToolConstName(svc), ")) {")
+	if *lazyTools {
+		g.P("refs = append(refs, New", listToolsGoName(svc), "Tool(opts...))")
+	} else {
+		g.P("if t, err := define", listToolsGoName(svc), "Tool(g, o); err != nil {")
+		g.P("return nil, err")
+		g.P("} else {")
+		g.P("tools = append(tools, t)")
+		g.P("}")
+	}
+	g.P("}")
+}
+
+// listToolsToolName returns the name of svc's list_tools tool, e.g.
+// toolcatalog_list_tools, or tool_catalog_list_tools with
+// tool_name_case=snake.
+func listToolsToolName(svc *protogen.Service) string {
+	if *toolNameCase == "" {
+		return strings.ToLower(svc.GoName) + "_list_tools"
+	}
+	return derivedToolName(svc.GoName, "ListTools")
+}
+
+func listToolsGoName(svc *protogen.Service) string {
+	return svc.GoName + "ListTools"
+}
+
+func listToolsToolConstName(svc *protogen.Service) string {
+	return listToolsGoName(svc) + "Tool"
+}
@@ -1,227 +1,1330 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 
 	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/internal/schemautil"
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
+const (
+	genkittoolsPackage   = protogen.GoImportPath("github.com/nemo1105/protoc-gen-go-genkit-tools/genkittools")
+	protovalidatePackage = protogen.GoImportPath("buf.build/go/protovalidate")
+	iterPackage          = protogen.GoImportPath("iter")
+	mapsPackage          = protogen.GoImportPath("maps")
+	slicesPackage        = protogen.GoImportPath("slices")
+	stringsPackage       = protogen.GoImportPath("strings")
+	templatePackage      = protogen.GoImportPath("text/template")
+)
+
+// batchField mirrors genkittools.BatchField, the input property listing the
+// requests of a client-streaming tool.
+const batchField = "requests"
+
+var (
+	flags             flag.FlagSet
+	useProtovalidate  = flags.Bool("protovalidate", false, "validate coerced requests with protovalidate before calling the implementation")
+	generateMocks     = flags.Bool("mocks", false, "emit a <Service>ToolImplMock for each service into a companion _genkit.tools_mock.go file")
+	generateFakes     = flags.Bool("fakes", false, "emit a Fake<Service>ToolImpl returning canned responses into a companion _genkit.tools_fake.go file")
+	streamingMode     = flags.String("streaming", "aggregate", "how server-streaming methods return: aggregate (all messages) or forward (each message to the genkittools chunk callback, then the last)")
+	clientStreaming   = flags.String("client_streaming", "skip", "how client- and bidi-streaming methods are handled: skip (with a diagnostic) or array (the tool takes a list of requests to stream)")
+	generateGRPC      = flags.Bool("grpc", false, "emit New<Service>GRPCImpl adapters backed by protoc-gen-go-grpc clients into a companion _genkit.tools_grpc.go file")
+	generateConnect   = flags.Bool("connect", false, "emit New<Service>ToolImpl adapters wrapping protoc-gen-connect-go clients into the <package>connect sub-package")
+	generateREST      = flags.Bool("rest", false, "emit New<Service>RESTImpl adapters calling the google.api.http bindings into a companion _genkit.tools_rest.go file")
+	generateMCP       = flags.Bool("mcp", false, "emit New<Service>MCPServer and Add<Service>MCPTools serving the tools over the Model Context Protocol into a companion _genkit.tools_mcp.go file")
+	generateLangChain = flags.Bool("langchaingo", false, "emit New<Service>LangChainTools adapting the tools to langchaingo's tools.Tool interface into a companion _genkit.tools_langchaingo.go file")
+	openAITools       = flags.Bool("openai", false, "emit the tools of each file as an OpenAI function-calling tools array into a companion _genkit.tools.openai.json file")
+	anthropicTools    = flags.Bool("anthropic", false, "emit the tools of each service in Anthropic tool format into a companion _<service>.anthropic.json file")
+	mcpManifest       = flags.Bool("mcp_manifest", false, "emit the tools of each file in MCP tools/list format into a companion _genkit.tools.mcp.json file")
+	generateA2A       = flags.Bool("a2a", false, "emit an A2A agent card advertising the tools of each service as skills into a companion _<service>.agent.json file")
+	a2aURL            = flags.String("a2a_url", "", "the URL the agent cards written by a2a advertise")
+	generateOpenAPI   = flags.Bool("openapi", false, "emit an OpenAPI 3.1 document per service, with one operation per tool, into a companion _<service>.openapi.json file")
+	evalDatasets      = flags.Bool("eval_dataset", false, "emit a Genkit evaluation dataset skeleton per tool, with an input built from field examples and defaults, into a companion _<tool>.eval.jsonl file")
+	coercionTests     = flags.Bool("coercion_tests", false, "emit Test<Service>ToolCoercion tests round-tripping a populated request of each tool through its coercion into a companion _genkit.tools_coercion_test.go file")
+	fuzzTests         = flags.Bool("fuzz_tests", false, "emit FuzzCoerce<Service><Method>Input fuzz targets feeding arbitrary JSON to the input coercion of each tool into a companion _genkit.tools_fuzz_test.go file")
+	goldenTests       = flags.Bool("golden_tests", false, "emit Test<Service>ToolSchemas tests comparing each tool schema with a golden file under testdata into a companion _genkit.tools_schema_test.go file")
+	generateDotprompt = flags.Bool("dotprompt", false, "emit a Dotprompt scaffold declaring the tools of each service, with placeholders for instructions, into a companion _<service>.prompt file")
+	generateMarkdown  = flags.Bool("markdown", false, "emit a Markdown page documenting the tools of each service into a companion _<service>.tools.md file")
+	schemaOut         = flags.String("schema_out", "", "write each tool's input and output schema as standalone .schema.json files into this directory, relative to the output directory")
+	generateExamples  = flags.Bool("examples", false, "emit Example functions showing how to implement, register and use the tools of each service into a companion _genkit.tools_example_test.go file")
+	generateFlows     = flags.Bool("flows", false, "emit Define<Service>Flows defining a Genkit flow per tool-enabled method, taking and returning the proto messages, into a companion _genkit.tools_flows.go file")
+	generateCLI       = flags.Bool("cli", false, "emit Run<Service>CLI functions calling the tools from the command line into a companion _genkit.tools_cli.go file")
+	codegen           = flags.String("codegen", "inline", "how tool schemas and request decoding are generated: inline (schema literals and a decoder per message) or runtime (derived from the message descriptors by genkittools)")
+	splitSchemas      = flags.Bool("split_schemas", false, "emit the tool schemas into a companion _genkit.schemas.go file instead of next to the handlers")
+	breakingAgainst   = flags.String("breaking_against", "", "fail generation when tool schemas break the snapshot that schema_out wrote into this directory, relative to the working directory")
+	strict            = flags.Bool("strict", false, "fail generation when a method is skipped, listing every such method with its location, instead of silently leaving it out")
+	lazyTools         = flags.Bool("lazy", false, "emit New<Service>Tools constructors returning unregistered tools instead of Register<Service>Tools")
+	schemaDraft       = flags.String("schema_draft", "", "make tool schemas follow a JSON Schema draft, 2020-12 or draft-07: examples instead of example, null types for fields with explicit presence and $defs (definitions) for recursive messages")
+	diProviders       = flags.String("di", "", "emit dependency-injection providers for the tools of each service into a companion _genkit.tools_di.go file: wire (a ProviderSet), fx (an fx.Module) or wire+fx")
+	schemaFormat      = flags.String("schema_format", "literal", "how inlined tool schemas are written: literal (map[string]any literals), json (indented JSON in raw strings, parsed once at init) or struct (typed genkittools.Schema literals)")
+	translationsFile  = flags.String("translations", "", "override tool and field descriptions with the localized ones of this JSON file, relative to the working directory, mapping proto full names to text")
+	packageSuffix     = flags.String("package_suffix", "", "generate the Go code of each file into a <package><suffix> sub-package of its go_package, e.g. tools, keeping genkit imports out of the message package")
+	inputStructs      = flags.Bool("input_structs", false, "emit a plain Go <Service><Method>Input struct with JSON tags mirroring the input of each tool and define the tool with the typed genkit.DefineTool, letting Genkit derive its schema")
+	callConfig        = flags.Bool("call_config", false, "emit a <Service>CallConfig struct with a field per context_key of the requests of each service, and With<Service>CallConfig and <Service>CallConfigFromContext attaching it to and reading it from a context")
+	implContext       = flags.String("impl_context", "context", "what the methods of <Service>ToolImpl receive as their first parameter: context (a context.Context) or tool (Genkit's *ai.ToolContext, for interrupts and resume state)")
+	implFuncs         = flags.Bool("impl_funcs", false, "emit a <Service>ImplFuncs struct implementing <Service>ToolImpl with a <Method>Func field per method, for closures in prototypes and tests")
+	generateHandlers  = flags.Bool("handlers", false, "emit a one-method <Service><Method>Handler interface per tool, with Register<Service><Method>Tool registering that tool alone, and a <Service>Handlers struct composing a <Service>ToolImpl from handlers")
+	toolsStruct       = flags.Bool("tools_struct", false, "emit a <Service>Tools struct holding an implementation and its options, with Register, Refs and a method per tool, as an alternative to the package-level functions")
+	toolNameCase      = flags.String("tool_name_case", "", "case of the tool names derived for methods without tool_doc name: snake (travel_guide_get_weather), camel (travelGuideGetWeather) or kebab (travel-guide-get-weather); by default <service>_<method> in lower case")
+	genkitAPI         = flags.String("genkit_api", "direct", "how generated code calls the Genkit SDK to define tools: direct (the genkit and ai packages) or compat (the genkittools/genkitcompat shim, which follows SDK API changes when the runtime module is upgraded, without regenerating)")
+	healthTools       = flags.Bool("health", false, "emit a <service>_health tool per service, registered for implementations with a Ping(ctx) error method, reporting whether their backend is reachable")
+	listTools         = flags.Bool("list_tools", false, "emit a <service>_list_tools meta-tool per service summarizing its tools, with their parameters, and the full input schema of one tool on request, so agents with large tool sets can discover tools on demand")
+	descRoot          = flags.String("desc_root", ".", "the directory, relative to the working directory, holding the protos by import path, against which the desc_file of (genkit.tool.v1.tool_doc) is resolved")
+)
+
 func main() {
-	opts := protogen.Options{}
-	opts.Run(func(plugin *protogen.Plugin) error {
-		for _, file := range plugin.Files {
-			if file.Generate {
-				generateFile(plugin, file)
-			}
+	if len(os.Args) == 2 && os.Args[1] == "--version" {
+		fmt.Println("protoc-gen-go-genkit-tools", pluginVersion())
+		return
+	}
+
+	opts := protogen.Options{ParamFunc: flags.Set}
+	if err := run(opts, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", filepath.Base(os.Args[0]), err)
+		os.Exit(1)
+	}
+}
+
+// prepare validates the plugin options and returns the files of the request
+// to generate with their tool-enabled services.
+func prepare(plugin *protogen.Plugin) ([]fileMeta, error) {
+	if *streamingMode != "aggregate" && *streamingMode != "forward" {
+		return nil, fmt.Errorf("invalid streaming=%q: want aggregate or forward", *streamingMode)
+	}
+	if *genkitAPI != "direct" && *genkitAPI != "compat" {
+		return nil, fmt.Errorf("invalid genkit_api=%q: want direct or compat", *genkitAPI)
+	}
+	if *toolsStruct && *lazyTools {
+		return nil, fmt.Errorf("tools_struct=true requires lazy=false: lazy tools are not registered")
+	}
+	if *implContext != "context" && *implContext != "tool" {
+		return nil, fmt.Errorf("invalid impl_context=%q: want context or tool", *implContext)
+	}
+	switch *toolNameCase {
+	case "", "snake", "camel", "kebab":
+	default:
+		return nil, fmt.Errorf("invalid tool_name_case=%q: want snake, camel or kebab", *toolNameCase)
+	}
+	if *clientStreaming != "skip" && *clientStreaming != "array" {
+		return nil, fmt.Errorf("invalid client_streaming=%q: want skip or array", *clientStreaming)
+	}
+	if *codegen != "inline" && *codegen != "runtime" {
+		return nil, fmt.Errorf("invalid codegen=%q: want inline or runtime", *codegen)
+	}
+	if *schemaDraft != "" && *schemaDraft != "2020-12" && *schemaDraft != "draft-07" {
+		return nil, fmt.Errorf("invalid schema_draft=%q: want 2020-12 or draft-07", *schemaDraft)
+	}
+	if *schemaFormat != "literal" && *schemaFormat != "json" && *schemaFormat != "struct" {
+		return nil, fmt.Errorf("invalid schema_format=%q: want literal, json or struct", *schemaFormat)
+	}
+	if *schemaFormat != "literal" && runtimeCodegen() {
+		return nil, fmt.Errorf("schema_format=%s requires codegen=inline: with codegen=runtime no schema is written out", *schemaFormat)
+	}
+	if !validPackageSuffix(*packageSuffix) {
+		return nil, fmt.Errorf("invalid package_suffix=%q: want lowercase letters and digits, e.g. tools", *packageSuffix)
+	}
+	if _, err := diFrameworks(*diProviders); err != nil {
+		return nil, err
+	}
+	if *translationsFile != "" {
+		if runtimeCodegen() {
+			return nil, fmt.Errorf("translations requires codegen=inline: with codegen=runtime field descriptions are read from the descriptors")
 		}
-		return nil
-	})
+		var err error
+		if translations, err = loadTranslations(*translationsFile); err != nil {
+			return nil, err
+		}
+	}
+	if dir := path.Clean(*schemaOut); *schemaOut != "" && (path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../")) {
+		return nil, fmt.Errorf("invalid schema_out=%q: want a directory inside the output directory", *schemaOut)
+	}
+	var files []fileMeta
+	var diags []diagnostic
+	for _, file := range plugin.Files {
+		if !file.Generate {
+			continue
+		}
+		services, fileDiags, err := collectServices(file)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fileMeta{file: file, services: services})
+		diags = append(diags, fileDiags...)
+		if len(services) > 0 && file.Desc.SourceLocations().Len() == 0 {
+			// Tools only depend on options, but documentation and
+			// diagnostics lose the comments and positions.
+			fmt.Fprintf(os.Stderr, "protoc-gen-go-genkit-tools: %s carries no source info, so its comments are left out of the generated documentation and diagnostics only name the file; the tools still take their descriptions from (genkit.tool.v1.tool_doc). Build descriptor sets with --include_source_info to keep comments\n", file.Desc.Path())
+		}
+	}
+	if *strict && len(diags) > 0 {
+		lines := make([]string, len(diags))
+		for i, d := range diags {
+			lines[i] = d.String()
+		}
+		return nil, fmt.Errorf("strict=true: generation is incomplete:\n\t%s", strings.Join(lines, "\n\t"))
+	}
+	if err := checkDuplicateToolNames(files); err != nil {
+		return nil, err
+	}
+	if *breakingAgainst != "" {
+		if err := checkBreakingChanges(files, *breakingAgainst); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
 }
 
 type methodMeta struct {
-	method      *protogen.Method
-	toolDoc     *pb.ToolDoc
-	toolName    string
-	description string
-	inputSchema map[string]any
+	method  *protogen.Method
+	toolDoc *pb.ToolDoc
+	// goName prefixes the Go identifiers generated for the method, e.g.
+	// <goName>Tool. It is <Service><Method> unless that collides.
+	goName       string
+	toolName     string
+	description  string
+	inputSchema  map[string]any
+	outputSchema map[string]any
+	sensitive    []string
+	// unsanitized lists the request fields exempt from input sanitizers,
+	// relative to a single request.
+	unsanitized []string
+	// contextFields maps the dotted paths of request fields filled from
+	// caller metadata to their context_key.
+	contextFields map[string]string
+	// errorMessages maps the request fields annotated with an error_message
+	// to that message, relative to a single request.
+	errorMessages map[string]string
+	// media maps the response fields returned as media parts to their
+	// media_type.
+	media map[string]string
 }
 
-func generateFile(plugin *protogen.Plugin, file *protogen.File) {
-	var services []struct {
-		service *protogen.Service
-		methods []methodMeta
-	}
+type serviceMeta struct {
+	service *protogen.Service
+	methods []methodMeta
+	// resources are the methods exposed as Genkit resources instead of
+	// tools.
+	resources []methodMeta
+}
+
+type fileMeta struct {
+	file     *protogen.File
+	services []serviceMeta
+}
+
+// collectServices returns the services of file with at least one tool,
+// with collisions between them resolved, and diagnostics for the methods
+// left out and the types the schemas cannot describe. The latter are also
+// printed as warnings.
+func collectServices(file *protogen.File) ([]serviceMeta, []diagnostic, error) {
+	var services []serviceMeta
+	var diags []diagnostic
+	seen := make(map[protoreflect.FullName]bool)
 
 	for _, s := range file.Services {
-		var toolMethods []methodMeta
+		var toolMethods, resources []methodMeta
 		for _, m := range s.Methods {
 			td := getToolDoc(m.Desc)
 			if td == nil {
+				diags = append(diags, diagnostic{m.Desc, "skipped: no (genkit.tool.v1.tool_doc) option"})
 				continue
 			}
+			if err := checkResultFormat(m, td); err != nil {
+				return nil, nil, err
+			}
+			if err := checkProgress(m, td); err != nil {
+				return nil, nil, err
+			}
+			if err := checkBatch(m, td); err != nil {
+				return nil, nil, err
+			}
+			if err := checkMetadata(m, td); err != nil {
+				return nil, nil, err
+			}
+			if err := checkResponseLimits(m, td); err != nil {
+				return nil, nil, err
+			}
+			description, err := toolDescription(file, m, td)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := checkGoName(m, td); err != nil {
+				return nil, nil, err
+			}
+			if td.GetResource() {
+				if err := checkResource(m, td); err != nil {
+					return nil, nil, err
+				}
+				resources = append(resources, methodMeta{
+					method:      m,
+					toolDoc:     td,
+					goName:      methodGoName(s, m, td),
+					toolName:    deriveToolName(s, m, td),
+					description: description,
+				})
+				continue
+			}
+			if err := checkMedia(m, td); err != nil {
+				return nil, nil, err
+			}
+			if m.Desc.IsStreamingClient() && *clientStreaming == "skip" {
+				fmt.Fprintf(os.Stderr, "protoc-gen-go-genkit-tools: skipping %s: client-streaming methods need client_streaming=array\n", m.Desc.FullName())
+				diags = append(diags, diagnostic{m.Desc, "skipped: client-streaming methods need client_streaming=array"})
+				continue
+			}
+			sensitivePrefix := ""
+			if m.Desc.IsStreamingClient() {
+				sensitivePrefix = "/" + batchField + "/*"
+			}
 			meta := methodMeta{
-				method:      m,
-				toolDoc:     td,
-				toolName:    deriveToolName(s, m, td),
-				description: deriveDescription(m, td),
-				inputSchema: buildInputSchema(m.Desc, td),
+				method:        m,
+				toolDoc:       td,
+				goName:        methodGoName(s, m, td),
+				toolName:      deriveToolName(s, m, td),
+				description:   description,
+				inputSchema:   buildInputSchema(m.Desc, td),
+				outputSchema:  buildOutputSchema(m.Desc, td),
+				sensitive:     collectAnnotatedFields(m.Desc.Input(), sensitivePrefix, (*pb.ToolFieldDoc).GetSensitive, nil),
+				unsanitized:   collectAnnotatedFields(m.Desc.Input(), "", (*pb.ToolFieldDoc).GetSkipSanitizer, nil),
+				contextFields: collectContextFields(m.Desc.Input(), "", nil, nil),
+				errorMessages: collectErrorMessages(m.Desc.Input(), "", nil, nil),
+				media:         collectMediaFields(m.Desc.Output()),
 			}
 			toolMethods = append(toolMethods, meta)
+
+			unsupported := unsupportedTypes(m.Desc.Input(), seen)
+			if resultFormat(td) == pb.ResultFormat_RESULT_FORMAT_STRUCTURED {
+				unsupported = append(unsupported, unsupportedTypes(m.Desc.Output(), seen)...)
+			}
+			for _, d := range unsupported {
+				fmt.Fprintf(os.Stderr, "protoc-gen-go-genkit-tools: %s\n", d)
+			}
+			diags = append(diags, unsupported...)
 		}
 
-		if len(toolMethods) > 0 {
-			services = append(services, struct {
-				service *protogen.Service
-				methods []methodMeta
-			}{service: s, methods: toolMethods})
+		if len(toolMethods) > 0 || len(resources) > 0 {
+			services = append(services, serviceMeta{service: s, methods: toolMethods, resources: resources})
 		}
 	}
 
+	if err := resolveCollisions(services); err != nil {
+		return nil, nil, err
+	}
+	return services, diags, nil
+}
+
+func generateFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) error {
 	if len(services) == 0 {
-		return
+		return nil
 	}
 
-	filename := file.GeneratedFilenamePrefix + "_genkit.tools.go"
-	g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+	filename := toolsFilenamePrefix(file) + "_genkit.tools.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
 
-	g.P("// Code generated by protoc-gen-go-genkit-tools. DO NOT EDIT.")
-	g.P("// source: ", file.Desc.Path())
-	g.P("package ", file.GoPackageName)
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
 	g.P()
 
-	g.P("import (")
-	g.P(`"context"`)
-	g.P(`"encoding/json"`)
-	g.P(`"errors"`)
-	g.P(`"fmt"`)
-	g.P()
-	g.P(`genkitai "github.com/firebase/genkit/go/ai"`)
-	g.P(`"github.com/firebase/genkit/go/genkit"`)
-	g.P(`"google.golang.org/protobuf/encoding/protojson"`)
-	g.P(")")
-	g.P()
+	// Services exposing only resources need none of the tool machinery.
+	tools := toolServices(services)
+	inline := len(tools) > 0 && !runtimeCodegen()
+
+	// Import the packages the handlers name in their code before any
+	// message type is referenced, so they keep their usual names and
+	// protogen renames the packages of request and response messages that
+	// share one, such as an errors or context proto package.
+	imports := []protogen.GoImportPath{contextPackage, genkitAIPackage}
+	if inline {
+		imports = append(imports, jsonPackage)
+	}
+	// With codegen=runtime, only protovalidate checks and resume helpers
+	// still build errors.
+	if inline || (len(tools) > 0 && *useProtovalidate) || (!*lazyTools && hasInterruptible(tools)) {
+		imports = append(imports, errorsPackage)
+	}
+	if inline || (len(tools) > 0 && *useProtovalidate) {
+		imports = append(imports, fmtPackage)
+	}
+	if !*lazyTools {
+		imports = append(imports, genkitPackage)
+	}
+	if inline {
+		imports = append(imports, protojsonPackage)
+	}
+	for _, importPath := range imports {
+		g.QualifiedGoIdent(importPath.Ident(""))
+	}
 
+	schemas := g
+	if *splitSchemas && len(tools) > 0 {
+		schemas = plugin.NewGeneratedFile(toolsFilenamePrefix(file)+"_genkit.schemas.go", toolsImportPath(file))
+		writeHeader(schemas, plugin, file)
+		schemas.P("package ", toolsPackageName(file))
+		schemas.P()
+	}
+
+	stamp := generatedWith(plugin, file)
 	for _, svc := range services {
-		writeServiceHelpers(g, svc.service, svc.methods)
+		if len(svc.methods) > 0 {
+			writeServiceHelpers(g, schemas, svc.service, svc.methods, stamp)
+		}
+		if len(svc.resources) > 0 {
+			writeServiceResources(g, svc.service, svc.resources)
+		}
+	}
+	if *generateMCP {
+		generateMCPFile(plugin, file, services)
+	}
+	if len(tools) == 0 {
+		return nil
+	}
+	services = tools
+
+	if *generateMocks {
+		generateMockFile(plugin, file, services)
+	}
+	if *generateFakes {
+		generateFakeFile(plugin, file, services)
+	}
+	if *generateGRPC {
+		generateGRPCFile(plugin, file, services)
 	}
+	if *generateConnect {
+		generateConnectFile(plugin, file, services)
+	}
+	if *generateREST {
+		generateRESTFile(plugin, file, services)
+	}
+	if *generateLangChain {
+		generateLangChainFile(plugin, file, services)
+	}
+	if frameworks, _ := diFrameworks(*diProviders); len(frameworks) > 0 {
+		generateDIFile(plugin, file, services, frameworks)
+	}
+	if *mcpManifest {
+		generateMCPManifest(plugin, file, services)
+	}
+	if *openAITools {
+		generateOpenAITools(plugin, file, services)
+	}
+	if *anthropicTools {
+		generateAnthropicFiles(plugin, file, services)
+	}
+	if *generateA2A {
+		generateA2ACards(plugin, file, services)
+	}
+	if *generateOpenAPI {
+		generateOpenAPIFiles(plugin, file, services)
+	}
+	if *generateCLI {
+		generateCLIFile(plugin, file, services)
+	}
+	if *generateFlows {
+		generateFlowFile(plugin, file, services)
+	}
+	if *evalDatasets {
+		generateEvalDatasets(plugin, file, services)
+	}
+	if *goldenTests {
+		generateGoldenTestFile(plugin, file, services)
+	}
+	if *coercionTests {
+		generateCoercionTestFile(plugin, file, services)
+	}
+	if *fuzzTests {
+		generateFuzzTestFile(plugin, file, services)
+	}
+	if *generateExamples {
+		generateExampleFile(plugin, file, services)
+	}
+	if *generateMarkdown {
+		generateMarkdownFiles(plugin, file, services)
+	}
+	if *generateDotprompt {
+		generateDotpromptFiles(plugin, file, services)
+	}
+	if *schemaOut != "" {
+		generateSchemaFiles(plugin, file, services, *schemaOut)
+	}
+	return nil
 }
 
-func writeServiceHelpers(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+// writeServiceHelpers emits the tools of svc into g and their schemas into
+// schemas, which is g unless split_schemas is set.
+func writeServiceHelpers(g, schemas *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta, stamp string) {
 	implName := fmt.Sprintf("%sToolImpl", svc.GoName)
 
 	g.P("// ", implName, " defines the methods that can be wrapped as Genkit tools.")
 	g.P("type ", implName, " interface {")
 	for _, m := range methods {
-		g.P(m.method.GoName, "(context.Context, *", g.QualifiedGoIdent(m.method.Input.GoIdent), ") (*", g.QualifiedGoIdent(m.method.Output.GoIdent), ", error)")
+		g.P(m.method.GoName, implMethodSignature(g, m.method, implContextType(g)))
+	}
+	g.P("}")
+	g.P()
+
+	if *implFuncs {
+		writeImplFuncs(g, svc, methods)
+	}
+	if *generateHandlers {
+		writeHandlers(g, svc, methods)
+	}
+
+	g.P("// Tool names of ", svc.GoName, ". Each is an ai.ToolRef, so a registered tool")
+	g.P("// can be passed to ai.WithTools by its constant.")
+	for _, m := range methods {
+		constName := toolConstName(m)
+		g.P("const ", constName, " ", genkitAIPackage.Ident("ToolName"), " = ", strconv.Quote(m.toolName))
+	}
+	for _, m := range batchMethods(methods) {
+		g.P("const ", batchToolConstName(m), " ", genkitAIPackage.Ident("ToolName"), " = ", strconv.Quote(batchToolName(m)))
+	}
+	if *healthTools {
+		g.P("const ", healthToolConstName(svc), " ", genkitAIPackage.Ident("ToolName"), " = ", strconv.Quote(healthToolName(svc)))
+	}
+	if *listTools {
+		g.P("const ", listToolsToolConstName(svc), " ", genkitAIPackage.Ident("ToolName"), " = ", strconv.Quote(listToolsToolName(svc)))
+	}
+	g.P()
+
+	g.P("// ", svc.GoName, "GeneratedWith identifies the plugin build, options and source")
+	g.P("// proto that produced the tools of ", svc.GoName, ", for diagnostics and bug reports.")
+	g.P("const ", svc.GoName, "GeneratedWith = ", strconv.Quote(stamp))
+	g.P()
+
+	if *callConfig {
+		writeCallConfig(g, svc, methods)
+	}
+
+	if *lazyTools {
+		writeServiceConstructors(g, svc, methods)
+	} else {
+		writeServiceRegistration(g, svc, methods)
+
+		g.P("// ", svc.GoName, "ToolRefs returns a ToolRef for every tool of ", svc.GoName, " in")
+		g.P("// declaration order, for ai.WithTools once Register", svc.GoName, "Tools has")
+		g.P("// run.")
+		g.P("func ", svc.GoName, "ToolRefs() []", genkitAIPackage.Ident("ToolRef"), " {")
+		g.P("return []", genkitAIPackage.Ident("ToolRef"), "{")
+		for _, m := range methods {
+			g.P(toolConstName(m), ",")
+		}
+		for _, m := range batchMethods(methods) {
+			g.P(batchToolConstName(m), ",")
+		}
+		if *listTools {
+			g.P(listToolsToolConstName(svc), ",")
+		}
+		g.P("}")
+		g.P("}")
+		g.P()
+
+		if *toolsStruct {
+			writeToolsStruct(g, svc, methods)
+		}
+	}
+
+	g.P("func init() {")
+	g.P(genkittoolsPackage.Ident("ToolRegistry"), ".Add(")
+	for _, m := range methods {
+		g.P(toolInfoVarName(m), ",")
+	}
+	for _, m := range batchMethods(methods) {
+		g.P(toolInfoVarName(m), "Batch,")
+	}
+	if *healthTools {
+		g.P("toolInfo", healthGoName(svc), ",")
 	}
+	if *listTools {
+		g.P("toolInfo", listToolsGoName(svc), ",")
+	}
+	g.P(")")
 	g.P("}")
 	g.P()
 
+	g.P("// ", svc.GoName, "PromptSection describes the tools of ", svc.GoName, " for a system")
+	g.P("// prompt, listing each tool's name, description and key parameters. The")
+	g.P("// WithOnly and WithExcept options select the tools described.")
+	g.P("func ", svc.GoName, "PromptSection(opts ...", genkittoolsPackage.Ident("Option"), ") string {")
+	g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	g.P("var tools []*", genkittoolsPackage.Ident("ToolInfo"))
+	g.P("for _, info := range []*", genkittoolsPackage.Ident("ToolInfo"), "{")
 	for _, m := range methods {
-		constName := toolConstName(svc, m.method)
-		g.P("const ", constName, " genkitai.ToolName = ", strconv.Quote(m.toolName))
+		g.P(toolInfoVarName(m), ",")
 	}
+	for _, m := range batchMethods(methods) {
+		g.P(toolInfoVarName(m), "Batch,")
+	}
+	g.P("} {")
+	g.P("if o.Includes(info.Name) {")
+	g.P("tools = append(tools, info)")
+	g.P("}")
+	g.P("}")
+	g.P("return ", genkittoolsPackage.Ident("PromptSection"), "(tools)")
+	g.P("}")
 	g.P()
 
-	g.P("// Register", svc.GoName, "Tools registers all tool-enabled methods from ", svc.GoName, ".")
-	g.P("func Register", svc.GoName, "Tools(g *genkit.Genkit, impl ", implName, ") ([]genkitai.Tool, error) {")
-	g.P("var tools []genkitai.Tool")
 	for _, m := range methods {
-		funcName := defineFuncName(svc, m.method)
-		g.P("if t, err := ", funcName, "(g, impl); err != nil {")
+		writeMethodHelper(g, schemas, svc, m)
+		if m.toolDoc.GetBatch() {
+			writeBatchTool(g, schemas, svc, m)
+		}
+	}
+	if *healthTools {
+		writeHealthTool(g, schemas, svc)
+	}
+	if *listTools {
+		writeListToolsTool(g, schemas, svc, methods)
+	}
+	if !runtimeCodegen() {
+		for _, msg := range decodedMessages(methods) {
+			writeMessageDecoder(g, svc, msg)
+		}
+	}
+}
+
+func writeServiceRegistration(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := fmt.Sprintf("%sToolImpl", svc.GoName)
+
+	g.P("// Register", svc.GoName, "Tools registers all tool-enabled methods from ", svc.GoName, ",")
+	g.P("// returning the tools in declaration order.")
+	g.P("func Register", svc.GoName, "Tools(g *genkit.Genkit, impl ", implName, ", opts ...", genkittoolsPackage.Ident("Option"), ") ([]", genkitAIPackage.Ident("Tool"), ", error) {")
+	g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	g.P("var tools []", genkitAIPackage.Ident("Tool"))
+	for _, m := range methods {
+		funcName := defineFuncName(m)
+		g.P("if o.Includes(string(", toolConstName(m), ")) {")
+		g.P("if t, err := ", funcName, "(g, impl, o); err != nil {")
+		g.P("return nil, err")
+		g.P("} else {")
+		g.P("tools = append(tools, t)")
+		g.P("}")
+		g.P("}")
+	}
+	for _, m := range batchMethods(methods) {
+		g.P("if o.Includes(string(", batchToolConstName(m), ")) {")
+		g.P("if t, err := define", batchGoName(m), "Tool(g, impl, o); err != nil {")
 		g.P("return nil, err")
 		g.P("} else {")
 		g.P("tools = append(tools, t)")
 		g.P("}")
+		g.P("}")
+	}
+	if *healthTools {
+		writeHealthRegistration(g, svc)
+	}
+	if *listTools {
+		writeListToolsRegistration(g, svc)
 	}
 	g.P("return tools, nil")
 	g.P("}")
 	g.P()
 
-	g.P("// Register", svc.GoName, "ToolRefs registers tools and returns ToolRef slice for ai.WithTools.")
-	g.P("func Register", svc.GoName, "ToolRefs(g *genkit.Genkit, impl ", implName, ") ([]genkitai.ToolRef, error) {")
-	g.P("tools, err := Register", svc.GoName, "Tools(g, impl)")
+	g.P("// Register", svc.GoName, "ToolRefs registers tools and returns ToolRef slice for ai.WithTools,")
+	g.P("// in declaration order.")
+	g.P("func Register", svc.GoName, "ToolRefs(g *genkit.Genkit, impl ", implName, ", opts ...", genkittoolsPackage.Ident("Option"), ") ([]", genkitAIPackage.Ident("ToolRef"), ", error) {")
+	g.P("tools, err := Register", svc.GoName, "Tools(g, impl, opts...)")
 	g.P("if err != nil {")
 	g.P("return nil, err")
 	g.P("}")
-	g.P("refs := make([]genkitai.ToolRef, len(tools))")
+	g.P("refs := make([]", genkitAIPackage.Ident("ToolRef"), ", len(tools))")
 	g.P("for i, t := range tools {")
 	g.P("refs[i] = t")
 	g.P("}")
 	g.P("return refs, nil")
 	g.P("}")
 	g.P()
+}
+
+// writeServiceConstructors emits New<Service>Tools, which binds impl to
+// unregistered tools on demand so apps can assemble a tool set per
+// conversation and pass it to ai.WithTools.
+func writeServiceConstructors(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := fmt.Sprintf("%sToolImpl", svc.GoName)
 
+	g.P("// New", svc.GoName, "Tools binds impl to unregistered tools for every tool-enabled")
+	g.P("// method of ", svc.GoName, ", in declaration order. Genkit registers them")
+	g.P("// dynamically when they are passed to ai.WithTools.")
+	g.P("func New", svc.GoName, "Tools(impl ", implName, ", opts ...", genkittoolsPackage.Ident("Option"), ") []", genkitAIPackage.Ident("ToolRef"), " {")
+	g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	g.P("var refs []", genkitAIPackage.Ident("ToolRef"))
 	for _, m := range methods {
-		writeMethodHelper(g, svc, m)
+		g.P("if o.Includes(string(", toolConstName(m), ")) {")
+		g.P("refs = append(refs, ", newFuncName(m), "(impl, opts...))")
+		g.P("}")
+	}
+	for _, m := range batchMethods(methods) {
+		g.P("if o.Includes(string(", batchToolConstName(m), ")) {")
+		g.P("refs = append(refs, New", batchGoName(m), "Tool(impl, opts...))")
+		g.P("}")
 	}
+	if *healthTools {
+		writeHealthRegistration(g, svc)
+	}
+	if *listTools {
+		writeListToolsRegistration(g, svc)
+	}
+	g.P("return refs")
+	g.P("}")
+	g.P()
 }
 
-func writeMethodHelper(g *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta) {
-	funcName := defineFuncName(svc, meta.method)
+func writeMethodHelper(g, schemas *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta) {
+	funcName := defineFuncName(meta)
 	reqName := g.QualifiedGoIdent(meta.method.Input.GoIdent)
-	respName := g.QualifiedGoIdent(meta.method.Output.GoIdent)
-	coerceName := coerceFuncName(svc, meta.method)
-	schemaVar := schemaVarName(svc, meta.method)
+	outType := toolOutputType(g, meta.method)
+	coerceName := coerceFuncName(meta)
+	schemaVar := schemaVarName(meta)
+	outputSchemaVar := outputSchemaVarName(meta)
+	infoVar := toolInfoVarName(meta)
 
-	g.P("var ", schemaVar, " = ", renderSchemaLiteral(meta.inputSchema))
-	g.P()
-	g.P("// ", funcName, " defines Genkit tool wrapper for ", meta.toolName)
-	g.P("func ", funcName, "(g *genkit.Genkit, impl ", svc.GoName, "ToolImpl) (genkitai.Tool, error) {")
-	g.P(`tool := genkit.DefineToolWithInputSchema[*`, respName, "](")
-	g.P("g,")
-	g.P(strconv.Quote(meta.toolName), ",")
-	g.P(strconv.Quote(meta.description), ",")
-	g.P(schemaVar, ",")
-	g.P("func(ctx *genkitai.ToolContext, input any) (*", respName, ", error) {")
-	g.P("req, err := ", coerceName, "(input)")
-	g.P("if err != nil {")
-	g.P("return nil, err")
-	g.P("}")
-	g.P("return impl.", meta.method.GoName, "(ctx, req)")
-	g.P("},")
-	g.P(")")
-	g.P("return tool, nil")
+	writeToolSchemas(schemas, infoVar, schemaVar, inputSchemaExpr(schemas, meta), outputSchemaVar, outputSchemaExpr(schemas, meta))
+	if tmpl := meta.toolDoc.GetResultTemplate(); tmpl != "" {
+		g.P("var ", resultTemplateVarName(meta), " = ", templatePackage.Ident("Must"), "(", templatePackage.Ident("New"), "(", strconv.Quote(meta.toolName), ").Parse(", strconv.Quote(tmpl), "))")
+		g.P()
+	}
+	writeToolInfo(g, svc, meta, infoVar, schemaVar, outputSchemaVar)
+	def := toolDefinition{inType: toolInputType(meta), outType: outType, multipart: len(meta.media) > 0}
+	if typedInput(meta) {
+		writeInputStructs(g, meta)
+	} else {
+		def.schemaVar = schemaVar
+	}
+	if *lazyTools {
+		newName := newFuncName(meta)
+		g.P("// ", newName, " binds impl to an unregistered ", meta.toolName, " tool.")
+		g.P("func ", newName, "(impl ", implParamType(svc, meta), ", opts ...", genkittoolsPackage.Ident("Option"), ") ", genkitAIPackage.Ident("Tool"), " {")
+		g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	} else {
+		g.P("// ", funcName, " defines Genkit tool wrapper for ", meta.toolName)
+		g.P("func ", funcName, "(g *genkit.Genkit, impl ", implParamType(svc, meta), ", o *", genkittoolsPackage.Ident("Options"), ") (", genkitAIPackage.Ident("Tool"), ", error) {")
+	}
+	writeDefinitionStart(g, def, infoVar)
+	switch {
+	case len(meta.media) > 0:
+		writeMediaHandler(g, svc, meta)
+	case meta.toolDoc.GetInterruptible() || confirmsCalls(meta.toolDoc):
+		writeInterruptibleHandler(g, svc, meta)
+	default:
+		writeHandlerInput(g, meta, "ctx", outType)
+		writeInvoke(g, svc, meta, "return ", "ctx")
+		g.P("},")
+	}
+	writeDefinitionEnd(g, def)
 	g.P("}")
 	g.P()
+	if (meta.toolDoc.GetInterruptible() || confirmsCalls(meta.toolDoc)) && !*lazyTools {
+		writeResumeHelpers(g, svc, meta)
+	}
 
 	g.P("func ", coerceName, "(input any) (*", reqName, ", error) {")
-	g.P("if req, ok := input.(*", reqName, "); ok {")
-	g.P("return req, nil")
-	g.P("}")
+	if runtimeCodegen() {
+		writeRuntimeCoerce(g, meta)
+		g.P("}")
+		g.P()
+		return
+	}
+	g.P("req, ok := input.(*", reqName, ")")
+	g.P("if !ok {")
 	g.P("if input == nil {")
 	g.P("return nil, errors.New(", strconv.Quote(meta.toolName+" requires input"), ")")
 	g.P("}")
+	g.P("req = &", reqName, "{}")
+	g.P("if obj, isObject := input.(map[string]any); isObject {")
+	g.P("if err := ", decoderFuncName(svc, meta.method.Input), "(obj, \"\", req); err != nil {")
+	g.P(`return nil, fmt.Errorf("decode `, meta.toolName, ` input: %w", err)`)
+	g.P("}")
+	g.P("} else {")
 	g.P("raw, err := json.Marshal(input)")
 	g.P("if err != nil {")
 	g.P(`return nil, fmt.Errorf("marshal `, meta.toolName, ` input: %w", err)`)
 	g.P("}")
-	g.P("var req ", reqName)
-	g.P("if err := protojson.Unmarshal(raw, &req); err != nil {")
+	g.P("if err := protojson.Unmarshal(raw, req); err != nil {")
 	g.P(`return nil, fmt.Errorf("unmarshal `, meta.toolName, ` input: %w", err)`)
 	g.P("}")
-	g.P("return &req, nil")
+	g.P("}")
+	g.P("}")
+	if *useProtovalidate {
+		writeProtovalidateCheck(g, meta)
+	}
+	g.P("return req, nil")
 	g.P("}")
 	g.P()
 }
 
-func defineFuncName(svc *protogen.Service, m *protogen.Method) string {
-	return fmt.Sprintf("define%s%sTool", svc.GoName, m.GoName)
+// writeToolInfo emits the genkittools.ToolInfo variable name describing
+// meta's tool, with the schemas held in inputSchemaVar and outputSchemaVar.
+func writeToolInfo(g *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta, name, inputSchemaVar, outputSchemaVar string) {
+	g.P("var ", name, " = &", genkittoolsPackage.Ident("ToolInfo"), "{")
+	g.P("Name: string(", toolConstName(meta), "),")
+	g.P("Description: ", strconv.Quote(meta.description), ",")
+	if tags := meta.toolDoc.GetTags(); len(tags) > 0 {
+		g.P("Tags: ", renderSchemaLiteral(tags), ",")
+	}
+	writeToolMetadata(g, meta.toolDoc)
+	g.P("Service: ", strconv.Quote(string(svc.Desc.FullName())), ",")
+	g.P("GeneratedWith: ", svc.GoName, "GeneratedWith,")
+	if !runtimeCodegen() {
+		// Otherwise set by the init function of writeToolSchemas.
+		g.P("InputSchema: ", inputSchemaVar, ",")
+		g.P("OutputSchema: ", outputSchemaVar, ",")
+	}
+	if len(meta.sensitive) > 0 {
+		g.P("SensitiveFields: ", renderSchemaLiteral(meta.sensitive), ",")
+	}
+	if len(meta.unsanitized) > 0 {
+		g.P("UnsanitizedFields: ", renderSchemaLiteral(meta.unsanitized), ",")
+	}
+	if len(meta.contextFields) > 0 {
+		paths := make([]string, 0, len(meta.contextFields))
+		for path := range meta.contextFields {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		g.P("ContextFields: map[string]string{")
+		for _, path := range paths {
+			g.P(strconv.Quote(path), ": ", strconv.Quote(meta.contextFields[path]), ",")
+		}
+		g.P("},")
+	}
+	if len(meta.errorMessages) > 0 {
+		paths := make([]string, 0, len(meta.errorMessages))
+		for path := range meta.errorMessages {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		g.P("FieldErrorMessages: map[string]string{")
+		for _, path := range paths {
+			g.P(strconv.Quote(path), ": ", strconv.Quote(meta.errorMessages[path]), ",")
+		}
+		g.P("},")
+	}
+	if len(meta.media) > 0 {
+		g.P("MediaFields: map[string]string{")
+		for _, name := range sortedMediaFields(meta) {
+			g.P(strconv.Quote(name), ": ", strconv.Quote(meta.media[name]), ",")
+		}
+		g.P("},")
+	}
+	if isIdempotent(meta.method.Desc) {
+		g.P("Idempotent: true,")
+	}
+	if safety := safetyIdent(meta.toolDoc); safety != "" {
+		g.P("Safety: ", genkittoolsPackage.Ident(safety), ",")
+	}
+	if limits := responseLimitsLiteral(meta.toolDoc); limits != "" {
+		g.P("ResponseLimits: ", genkittoolsPackage.Ident("ResponseLimits"), "{", limits, "},")
+	}
+	g.P("}")
+	g.P()
 }
 
-func coerceFuncName(svc *protogen.Service, m *protogen.Method) string {
-	return fmt.Sprintf("coerce%s%sRequest", svc.GoName, m.GoName)
+// writeInterruptibleHandler emits the tool function of an interruptible or
+// destructive method, which hands the metadata of a restarted call to impl
+// and turns a genkittools.Interrupt error, or the confirmation request of a
+// destructive tool, into a Genkit tool interrupt.
+func writeInterruptibleHandler(g *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta) {
+	outType := toolOutputType(g, meta.method)
+	writeHandlerInput(g, meta, "tc", outType)
+	g.P("ctx := ", genkittoolsPackage.Ident("ContextWithResumed"), "(tc, tc.Resumed)")
+	if confirmsCalls(meta.toolDoc) {
+		g.P("var out ", outType)
+		g.P("err := o.Confirm(ctx, ", toolInfoVarName(meta), ")")
+		g.P("if err == nil {")
+		writeInvoke(g, svc, meta, "out, err = ", "tc")
+		g.P("}")
+	} else {
+		writeInvoke(g, svc, meta, "out, err := ", "tc")
+	}
+	g.P("if md, ok := ", genkittoolsPackage.Ident("IsInterrupt"), "(err); ok {")
+	g.P("return out, tc.Interrupt(&", genkitAIPackage.Ident("InterruptOptions"), "{Metadata: md})")
+	g.P("}")
+	g.P("return out, err")
+	g.P("},")
 }
 
-func schemaVarName(svc *protogen.Service, m *protogen.Method) string {
-	return fmt.Sprintf("schema%s%s", svc.GoName, m.GoName)
+// confirmsCalls reports whether the tool of doc asks for confirmation
+// before each call, as destructive tools do.
+func confirmsCalls(doc *pb.ToolDoc) bool {
+	return doc.GetSafety() == pb.Safety_SAFETY_DESTRUCTIVE
 }
 
-func toolConstName(svc *protogen.Service, m *protogen.Method) string {
-	return fmt.Sprintf("%s%sTool", svc.GoName, m.GoName)
+// safetyIdent returns the genkittools constant of the safety level of doc,
+// or "" when undeclared.
+func safetyIdent(doc *pb.ToolDoc) string {
+	switch doc.GetSafety() {
+	case pb.Safety_SAFETY_READ_ONLY:
+		return "SafetyReadOnly"
+	case pb.Safety_SAFETY_MUTATING:
+		return "SafetyMutating"
+	case pb.Safety_SAFETY_DESTRUCTIVE:
+		return "SafetyDestructive"
+	}
+	return ""
+}
+
+// writeResumeHelpers emits Resume<Service><Method> and
+// Respond<Service><Method>, which build the parts completing an interrupted
+// call of meta's tool.
+func writeResumeHelpers(g *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta) {
+	name := meta.goName
+	constName := toolConstName(meta)
+	outType := toolOutputType(g, meta.method)
+
+	option := g.QualifiedGoIdent(genkittoolsPackage.Ident("Option"))
+	g.P("// lookup", name, "Interrupt returns the ", meta.toolName, " tool registered with opts")
+	g.P("// if interrupt is one of its interrupted requests.")
+	g.P("func lookup", name, "Interrupt(g *genkit.Genkit, interrupt *", genkitAIPackage.Ident("Part"), ", opts []", option, ") (", genkitAIPackage.Ident("Tool"), ", error) {")
+	g.P("// The tool may be defined under a genkittools.WithNamePrefix prefix.")
+	g.P("toolName := ", genkittoolsPackage.Ident("NewOptions"), "(opts...).ToolName(string(", constName, "))")
+	g.P("if !interrupt.IsInterrupt() || interrupt.ToolRequest.Name != toolName {")
+	g.P("return nil, errors.New(", strconv.Quote("part is not an interrupted "+meta.toolName+" request"), ")")
+	g.P("}")
+	g.P("tool := ", lookupToolExpr(g, "toolName"))
+	g.P("if tool == nil {")
+	g.P("return nil, errors.New(", strconv.Quote(meta.toolName+" is not registered"), ")")
+	g.P("}")
+	g.P("return tool, nil")
+	g.P("}")
+	g.P()
+	g.P("// Resume", name, " restarts the interrupted ", meta.toolName, " request, calling")
+	g.P("// the implementation again with resumed available through genkittools.Resumed.")
+	g.P("// Pass the options the tool was registered with, and the returned part to")
+	g.P("// ai.WithToolRestarts.")
+	g.P("func Resume", name, "(g *genkit.Genkit, interrupt *", genkitAIPackage.Ident("Part"), ", resumed map[string]any, opts ...", option, ") (*", genkitAIPackage.Ident("Part"), ", error) {")
+	g.P("tool, err := lookup", name, "Interrupt(g, interrupt, opts)")
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("return tool.Restart(interrupt, &", genkitAIPackage.Ident("RestartOptions"), "{ResumedMetadata: resumed}), nil")
+	g.P("}")
+	g.P()
+	g.P("// Respond", name, " answers the interrupted ", meta.toolName, " request with out,")
+	g.P("// e.g. once a slow backend has completed, without calling the implementation.")
+	g.P("// Pass the options the tool was registered with, and the returned part to")
+	g.P("// ai.WithToolResponses.")
+	g.P("func Respond", name, "(g *genkit.Genkit, interrupt *", genkitAIPackage.Ident("Part"), ", out ", outType, ", opts ...", option, ") (*", genkitAIPackage.Ident("Part"), ", error) {")
+	g.P("tool, err := lookup", name, "Interrupt(g, interrupt, opts)")
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("return tool.Respond(interrupt, out, nil), nil")
+	g.P("}")
+	g.P()
+	if confirmsCalls(meta.toolDoc) {
+		g.P("// Confirm", name, " restarts the interrupted ", meta.toolName, " request once the")
+		g.P("// user has confirmed it, running the destructive call. Pass the returned part")
+		g.P("// to ai.WithToolRestarts; answer declined calls with Respond", name, ".")
+		g.P("func Confirm", name, "(g *genkit.Genkit, interrupt *", genkitAIPackage.Ident("Part"), ", opts ...", option, ") (*", genkitAIPackage.Ident("Part"), ", error) {")
+		g.P("return Resume", name, "(g, interrupt, map[string]any{", genkittoolsPackage.Ident("ConfirmedKey"), ": true}, opts...)")
+		g.P("}")
+		g.P()
+	}
+}
+
+// writeInvoke emits a genkittools.Invoke call running meta's method on impl
+// with the model input held in input and the options in o, preceded by lhs
+// (e.g. "return ").
+func writeInvoke(g *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta, lhs, toolCtx string) {
+	reqName := g.QualifiedGoIdent(meta.method.Input.GoIdent)
+	respName := g.QualifiedGoIdent(meta.method.Output.GoIdent)
+	outType := toolOutputType(g, meta.method)
+	coerceName := coerceFuncName(meta)
+	infoVar := toolInfoVarName(meta)
+
+	var toolCtxType string
+	if implToolContext() {
+		// Qualified only when used, so that companion files without tool
+		// contexts do not import the Genkit ai package.
+		toolCtxType = g.QualifiedGoIdent(genkitAIPackage.Ident("ToolContext"))
+		if toolCtx == "ctx" {
+			// The callback of Invoke shadows ctx.
+			g.P("tc := ctx")
+			toolCtx = "tc"
+		}
+	}
+	if meta.method.Desc.IsStreamingClient() {
+		g.P(lhs, genkittoolsPackage.Ident("Invoke"), "(ctx, o, ", infoVar, ", input, func(input any) ([]*", reqName, ", error) {")
+		g.P("return ", genkittoolsPackage.Ident("CoerceBatch"), "(input, ", coerceName, ")")
+		g.P("}, func(ctx context.Context, reqs []*", reqName, ") (", outType, ", error) {")
+	} else {
+		g.P(lhs, genkittoolsPackage.Ident("Invoke"), "(ctx, o, ", infoVar, ", input, ", coerceName, ", func(ctx context.Context, req *", reqName, ") (", outType, ", error) {")
+	}
+	ctxArg := writeImplToolContext(g, toolCtxType, toolCtx)
+	args := ctxArg + ", req"
+	if meta.method.Desc.IsStreamingClient() {
+		args = ctxArg + ", " + g.QualifiedGoIdent(slicesPackage.Ident("Values")) + "(reqs)"
+	}
+	format := resultFormat(meta.toolDoc)
+	ret := "return "
+	if format != pb.ResultFormat_RESULT_FORMAT_STRUCTURED {
+		ret = "resp, err := "
+	}
+	switch {
+	case reportsProgress(meta.method):
+		g.P(ret, "impl.", meta.method.GoName, "(", args, ", ", genkittoolsPackage.Ident("ProgressReporter"), "(ctx, string(", toolConstName(meta), ")))")
+	case !meta.method.Desc.IsStreamingServer():
+		g.P(ret, "impl.", meta.method.GoName, "(", args, ")")
+	case *streamingMode == "forward":
+		g.P(ret, genkittoolsPackage.Ident("ForwardStream"), "(ctx, string(", toolConstName(meta), "), func(send func(*", respName, ") error) error {")
+		g.P("return impl.", meta.method.GoName, "(", args, ", send)")
+		g.P("})")
+	default:
+		g.P(ret, genkittoolsPackage.Ident("CollectStream"), "(func(send func(*", respName, ") error) error {")
+		g.P("return impl.", meta.method.GoName, "(", args, ", send)")
+		g.P("})")
+	}
+	if format != pb.ResultFormat_RESULT_FORMAT_STRUCTURED {
+		// Invoke only sees the rendered text, so response hooks and
+		// truncation run here.
+		g.P("if err != nil {")
+		g.P(`return "", err`)
+		g.P("}")
+		g.P("if resp, err = ", genkittoolsPackage.Ident("RunResponseHooks"), "(ctx, o, ", infoVar, ", resp); err != nil {")
+		g.P(`return "", err`)
+		g.P("}")
+		if responseLimitsLiteral(meta.toolDoc) != "" {
+			g.P("resp = ", genkittoolsPackage.Ident("TruncateResponse"), "(", infoVar, ", resp)")
+		}
+	}
+	switch format {
+	case pb.ResultFormat_RESULT_FORMAT_JSON:
+		g.P("return ", genkittoolsPackage.Ident("RenderJSON"), "(resp)")
+	case pb.ResultFormat_RESULT_FORMAT_TEMPLATE:
+		g.P("return ", genkittoolsPackage.Ident("RenderTemplate"), "(", resultTemplateVarName(meta), ", resp)")
+	}
+	g.P("})")
+}
+
+// resultFormat returns how the tool described by doc returns its response.
+func resultFormat(doc *pb.ToolDoc) pb.ResultFormat {
+	if f := doc.GetResultFormat(); f != pb.ResultFormat_RESULT_FORMAT_UNSPECIFIED {
+		return f
+	}
+	return pb.ResultFormat_RESULT_FORMAT_STRUCTURED
+}
+
+// checkResultFormat reports a result_format and result_template combination
+// of doc that cannot be generated.
+func checkResultFormat(m *protogen.Method, doc *pb.ToolDoc) error {
+	tmpl := doc.GetResultTemplate()
+	switch format := resultFormat(doc); {
+	case format == pb.ResultFormat_RESULT_FORMAT_TEMPLATE && tmpl == "":
+		return fmt.Errorf("%s: result_format RESULT_FORMAT_TEMPLATE requires a result_template", m.Desc.FullName())
+	case format != pb.ResultFormat_RESULT_FORMAT_TEMPLATE && tmpl != "":
+		return fmt.Errorf("%s: result_template requires result_format RESULT_FORMAT_TEMPLATE", m.Desc.FullName())
+	case tmpl != "":
+		if _, err := template.New(m.GoName).Parse(tmpl); err != nil {
+			return fmt.Errorf("%s: result_template: %w", m.Desc.FullName(), err)
+		}
+	}
+	return nil
+}
+
+// checkMetadata reports metadata values of doc that are not JSON.
+func checkMetadata(m *protogen.Method, doc *pb.ToolDoc) error {
+	for _, key := range sortedKeys(doc.GetMetadata()) {
+		if !json.Valid([]byte(doc.GetMetadata()[key])) {
+			value := doc.GetMetadata()[key]
+			return fmt.Errorf("%s: metadata %q: value %s is not JSON; strings must be quoted, e.g. %q", m.Desc.FullName(), key, value, strconv.Quote(value))
+		}
+	}
+	return nil
+}
+
+// responseLimitsLiteral renders the fields of the genkittools.ResponseLimits
+// declared by doc, or "" when it declares none.
+func responseLimitsLiteral(doc *pb.ToolDoc) string {
+	var fields []string
+	if n := doc.GetMaxResponseBytes(); n > 0 {
+		fields = append(fields, "MaxBytes: "+strconv.FormatUint(uint64(n), 10))
+	}
+	if n := doc.GetMaxResponseItems(); n > 0 {
+		fields = append(fields, "MaxItems: "+strconv.FormatUint(uint64(n), 10))
+	}
+	return strings.Join(fields, ", ")
+}
+
+// checkResponseLimits reports why the max_response_bytes and
+// max_response_items options of doc cannot apply to m: the response must
+// declare the bool truncated field marking cut responses, and is not
+// streamed.
+func checkResponseLimits(m *protogen.Method, doc *pb.ToolDoc) error {
+	if doc.GetMaxResponseBytes() == 0 && doc.GetMaxResponseItems() == 0 {
+		return nil
+	}
+	name := m.Desc.FullName()
+	switch {
+	case m.Desc.IsStreamingServer():
+		return fmt.Errorf("%s: response limits cannot be set on server-streaming methods", name)
+	case doc.GetResource():
+		return fmt.Errorf("%s: response limits cannot be set on resource methods", name)
+	}
+	field := m.Desc.Output().Fields().ByName("truncated")
+	if field == nil || field.Kind() != protoreflect.BoolKind || field.IsList() {
+		return fmt.Errorf("%s: response limits need a bool truncated field in %s to mark cut responses", name, m.Desc.Output().FullName())
+	}
+	return nil
+}
+
+// writeToolMetadata emits the Metadata field of a genkittools.ToolInfo
+// literal, holding the metadata of doc decoded from JSON.
+func writeToolMetadata(g *protogen.GeneratedFile, doc *pb.ToolDoc) {
+	if len(doc.GetMetadata()) == 0 {
+		return
+	}
+	g.P("Metadata: map[string]any{")
+	for _, key := range sortedKeys(doc.GetMetadata()) {
+		var value any
+		// Checked by checkMetadata.
+		_ = json.Unmarshal([]byte(doc.GetMetadata()[key]), &value)
+		g.P(strconv.Quote(key), ": ", renderJSONLiteral(value), ",")
+	}
+	g.P("},")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderJSONLiteral renders a value decoded from JSON as a Go expression
+// building the same value, numbers as float64.
+func renderJSONLiteral(v any) string {
+	switch val := v.(type) {
+	case map[string]any:
+		var parts []string
+		for _, key := range sortedKeys(val) {
+			parts = append(parts, strconv.Quote(key)+": "+renderJSONLiteral(val[key]))
+		}
+		return "map[string]any{" + strings.Join(parts, ", ") + "}"
+	case []any:
+		var parts []string
+		for _, item := range val {
+			parts = append(parts, renderJSONLiteral(item))
+		}
+		return "[]any{" + strings.Join(parts, ", ") + "}"
+	case float64:
+		return "float64(" + strconv.FormatFloat(val, 'g', -1, 64) + ")"
+	case string:
+		return strconv.Quote(val)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	return "nil"
+}
+
+// writeProtovalidateCheck emits a protovalidate call on req that reports
+// violations as a *genkittools.ValidationError naming each offending field.
+func writeProtovalidateCheck(g *protogen.GeneratedFile, meta methodMeta) {
+	g.P("if err := ", protovalidatePackage.Ident("Validate"), "(req); err != nil {")
+	g.P("var verr *", protovalidatePackage.Ident("ValidationError"))
+	g.P("if !errors.As(err, &verr) {")
+	g.P(`return nil, fmt.Errorf("validate `, meta.toolName, ` input: %w", err)`)
+	g.P("}")
+	g.P("violations := make([]", genkittoolsPackage.Ident("FieldViolation"), ", len(verr.Violations))")
+	g.P("for i, v := range verr.Violations {")
+	g.P("violations[i] = ", genkittoolsPackage.Ident("FieldViolation"), "{Field: ", protovalidatePackage.Ident("FieldPathString"), "(v.Proto.GetField()), Message: v.Proto.GetMessage()}")
+	g.P("}")
+	g.P("return nil, &", genkittoolsPackage.Ident("ValidationError"), "{Tool: ", strconv.Quote(meta.toolName), ", Violations: violations}")
+	g.P("}")
+}
+
+// implRequestType renders the request parameter type of m in the
+// <Service>ToolImpl interface. Client-streaming methods receive their
+// requests as an iterator.
+func implRequestType(g *protogen.GeneratedFile, m *protogen.Method) string {
+	req := "*" + g.QualifiedGoIdent(m.Input.GoIdent)
+	if m.Desc.IsStreamingClient() {
+		return g.QualifiedGoIdent(iterPackage.Ident("Seq")) + "[" + req + "]"
+	}
+	return req
+}
+
+// implMethodSignature renders the parameters and results of m in the
+// <Service>ToolImpl interface. Server-streaming methods receive a send
+// callback for their response messages, and methods declared with progress a
+// genkittools.ProgressFunc.
+func implMethodSignature(g *protogen.GeneratedFile, m *protogen.Method, ctxType string) string {
+	resp := g.QualifiedGoIdent(m.Output.GoIdent)
+	if m.Desc.IsStreamingServer() {
+		return "(" + ctxType + ", " + implRequestType(g, m) + ", func(*" + resp + ") error) error"
+	}
+	if reportsProgress(m) {
+		return "(" + ctxType + ", " + implRequestType(g, m) + ", " + g.QualifiedGoIdent(genkittoolsPackage.Ident("ProgressFunc")) + ") (*" + resp + ", error)"
+	}
+	return "(" + ctxType + ", " + implRequestType(g, m) + ") (*" + resp + ", error)"
+}
+
+// implMethodParams renders the named parameters and the results of m in the
+// <Service>ToolImpl interface, for generated implementations. The parameters
+// are called ctx, req (reqs for client-streaming methods), send and progress.
+func implMethodParams(g *protogen.GeneratedFile, m *protogen.Method, ctx string) (params, results string) {
+	resp := g.QualifiedGoIdent(m.Output.GoIdent)
+	params = "ctx " + ctx + ", req " + implRequestType(g, m)
+	if m.Desc.IsStreamingClient() {
+		params = "ctx " + ctx + ", reqs " + implRequestType(g, m)
+	}
+	if m.Desc.IsStreamingServer() {
+		return params + ", send func(*" + resp + ") error", "error"
+	}
+	if reportsProgress(m) {
+		params += ", progress " + g.QualifiedGoIdent(genkittoolsPackage.Ident("ProgressFunc"))
+	}
+	return params, "(*" + resp + ", error)"
+}
+
+// implMethodArgs renders the arguments forwarding the parameters named by
+// implMethodParams to another implementation of m.
+func implMethodArgs(m *protogen.Method) string {
+	args := "ctx, req"
+	if m.Desc.IsStreamingClient() {
+		args = "ctx, reqs"
+	}
+	if m.Desc.IsStreamingServer() {
+		args += ", send"
+	}
+	if reportsProgress(m) {
+		args += ", progress"
+	}
+	return args
+}
+
+// reportsProgress reports whether m is declared with
+// (genkit.tool.v1.tool_doc).progress, so its implementation receives a
+// genkittools.ProgressFunc.
+func reportsProgress(m *protogen.Method) bool {
+	return getToolDoc(m.Desc).GetProgress()
+}
+
+// checkProgress reports why m, declared with
+// (genkit.tool.v1.tool_doc).progress, cannot report progress.
+// Server-streaming methods already stream their status through send.
+func checkProgress(m *protogen.Method, doc *pb.ToolDoc) error {
+	if doc.GetProgress() && m.Desc.IsStreamingServer() {
+		return fmt.Errorf("%s: progress cannot be set on server-streaming methods; send intermediate responses instead", m.Desc.FullName())
+	}
+	return nil
+}
+
+// toolOutputType renders the Go type returned by the tool wrapping m.
+func toolOutputType(g *protogen.GeneratedFile, m *protogen.Method) string {
+	if resultFormat(getToolDoc(m.Desc)) != pb.ResultFormat_RESULT_FORMAT_STRUCTURED {
+		return "string"
+	}
+	resp := "*" + g.QualifiedGoIdent(m.Output.GoIdent)
+	if m.Desc.IsStreamingServer() && *streamingMode == "aggregate" {
+		return "[]" + resp
+	}
+	return resp
+}
+
+func defineFuncName(meta methodMeta) string {
+	return "define" + meta.goName + "Tool"
+}
+
+func newFuncName(meta methodMeta) string {
+	return "New" + meta.goName + "Tool"
+}
+
+func coerceFuncName(meta methodMeta) string {
+	return "coerce" + meta.goName + "Request"
+}
+
+func schemaVarName(meta methodMeta) string {
+	return "schema" + meta.goName
+}
+
+func outputSchemaVarName(meta methodMeta) string {
+	return "outputSchema" + meta.goName
+}
+
+func resultTemplateVarName(meta methodMeta) string {
+	return "resultTemplate" + meta.goName
+}
+
+func toolInfoVarName(meta methodMeta) string {
+	return "toolInfo" + meta.goName
+}
+
+func toolConstName(meta methodMeta) string {
+	return meta.goName + "Tool"
 }
 
 func deriveToolName(svc *protogen.Service, m *protogen.Method, doc *pb.ToolDoc) string {
 	if doc != nil && doc.GetName() != "" {
 		return doc.GetName()
 	}
-	return strings.ToLower(svc.GoName + "_" + m.GoName)
+	return derivedToolName(svc.GoName, m.GoName)
 }
 
 func deriveDescription(m *protogen.Method, doc *pb.ToolDoc) string {
+	if desc := translation(m.Desc.FullName()); desc != "" {
+		return desc
+	}
 	if doc != nil && doc.GetDesc() != "" {
 		return doc.GetDesc()
 	}
+	if desc := openAPIv2Description(m.Desc); desc != "" {
+		return desc
+	}
 	return fmt.Sprintf("Tool wrapper for %s", m.GoName)
 }
 
+// toolDescription returns the description of m's tool: the contents of the
+// desc_file of doc, resolved against the directory of file under
+// desc_root, unless a translation overrides it, or deriveDescription's.
+func toolDescription(file *protogen.File, m *protogen.Method, doc *pb.ToolDoc) (string, error) {
+	name := doc.GetDescFile()
+	if name == "" || translation(m.Desc.FullName()) != "" {
+		return deriveDescription(m, doc), nil
+	}
+	if doc.GetDesc() != "" {
+		return "", fmt.Errorf("%s: desc and desc_file are mutually exclusive", m.Desc.FullName())
+	}
+	if path.IsAbs(name) {
+		return "", fmt.Errorf("%s: desc_file %q must be relative to %s", m.Desc.FullName(), name, file.Desc.Path())
+	}
+	raw, err := os.ReadFile(filepath.Join(*descRoot, filepath.FromSlash(path.Join(path.Dir(file.Desc.Path()), name))))
+	if err != nil {
+		return "", fmt.Errorf("%s: desc_file: %w; set desc_root to the directory holding the protos by import path", m.Desc.FullName(), err)
+	}
+	desc := strings.TrimSpace(string(raw))
+	if desc == "" {
+		return "", fmt.Errorf("%s: desc_file %q is empty", m.Desc.FullName(), name)
+	}
+	return desc, nil
+}
+
+func isIdempotent(method protoreflect.MethodDescriptor) bool {
+	opts, ok := method.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return false
+	}
+	switch opts.GetIdempotencyLevel() {
+	case descriptorpb.MethodOptions_IDEMPOTENT, descriptorpb.MethodOptions_NO_SIDE_EFFECTS:
+		return true
+	default:
+		return false
+	}
+}
+
 func getToolDoc(method protoreflect.MethodDescriptor) *pb.ToolDoc {
 	opts, ok := method.Options().(*descriptorpb.MethodOptions)
 	if !ok || opts == nil {
@@ -251,7 +1354,18 @@ func getFieldDoc(field protoreflect.FieldDescriptor) *pb.ToolFieldDoc {
 }
 
 func buildInputSchema(method protoreflect.MethodDescriptor, doc *pb.ToolDoc) map[string]any {
-	schema := buildMessageSchema(method.Input())
+	schema := omitOutputOnly(buildMessageSchema(method.Input()), method.Input())
+	if method.IsStreamingClient() {
+		items := schema
+		schema = map[string]any{
+			"type":     "object",
+			"required": []string{batchField},
+		}
+		schema["properties"] = map[string]any{batchField: map[string]any{
+			"type":  "array",
+			"items": schemautil.HoistDefinitions(schema, items),
+		}}
+	}
 	if doc != nil && doc.GetInput() != "" {
 		schema["description"] = doc.GetInput()
 	}
@@ -259,25 +1373,104 @@ func buildInputSchema(method protoreflect.MethodDescriptor, doc *pb.ToolDoc) map
 	return schema
 }
 
+func buildOutputSchema(method protoreflect.MethodDescriptor, doc *pb.ToolDoc) map[string]any {
+	schema := buildMessageSchema(method.Output())
+	if resultFormat(doc) != pb.ResultFormat_RESULT_FORMAT_STRUCTURED {
+		schema = map[string]any{"type": "string"}
+	} else if media := collectMediaFields(method.Output()); len(media) > 0 {
+		// Returned as media parts instead.
+		omitSchemaFields(schema, sortedKeys(media))
+	} else if method.IsStreamingServer() && *streamingMode == "aggregate" {
+		schema = listSchema(schema)
+	}
+	if doc != nil && doc.GetOutput() != "" {
+		schema["description"] = doc.GetOutput()
+	}
+
+	return schema
+}
+
+// omitSchemaFields deletes the properties names from the object schema and
+// its required list, as genkittools.OmitFields does.
+func omitSchemaFields(schema map[string]any, names []string) {
+	props := schema["properties"].(map[string]any)
+	for _, name := range names {
+		delete(props, name)
+	}
+	if required, ok := schema["required"].([]string); ok {
+		var kept []string
+		for _, name := range required {
+			if _, ok := props[name]; ok {
+				kept = append(kept, name)
+			}
+		}
+		if len(kept) == 0 {
+			delete(schema, "required")
+		} else {
+			schema["required"] = kept
+		}
+	}
+}
+
 func buildMessageSchema(msg protoreflect.MessageDescriptor) map[string]any {
+	schema := messageSchema(msg, nil)
+	if *schemaDraft != "" {
+		schemautil.AddDefinitions(schema, msg, *schemaDraft, func(def protoreflect.MessageDescriptor) map[string]any {
+			return messageSchema(def, nil)
+		})
+	}
+	return schema
+}
+
+// messageSchema builds the schema of msg, which may be declared in any file
+// or package of the request. visiting holds the messages being expanded:
+// a recursive reference is left as an open object.
+func messageSchema(msg protoreflect.MessageDescriptor, visiting map[protoreflect.FullName]bool) map[string]any {
+	if schema := wellKnownSchema(msg.FullName()); schema != nil {
+		return schema
+	}
+	if visiting[msg.FullName()] {
+		if *schemaDraft != "" {
+			return map[string]any{"$ref": schemautil.DefinitionRef(*schemaDraft, msg.FullName())}
+		}
+		return map[string]any{"type": "object"}
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
 	props := make(map[string]any)
 	var required []string
 
 	for i := 0; i < msg.Fields().Len(); i++ {
 		field := msg.Fields().Get(i)
-		prop := buildFieldSchema(field)
+		if getFieldDoc(field).GetContextKey() != "" {
+			// Filled from caller metadata, never by the model.
+			continue
+		}
+		prop := buildFieldSchema(field, visiting)
+		if *schemaDraft != "" && schemautil.AcceptsNull(field) {
+			prop = schemautil.NullableSchema(prop)
+		}
 
-		if fd := getFieldDoc(field); fd != nil {
-			if fd.Desc != "" {
-				prop["description"] = fd.Desc
-			}
-			if fd.Example != "" {
-				prop["example"] = fd.Example
-			}
-			if fd.Required {
-				required = append(required, string(field.Name()))
+		fd := getFieldDoc(field)
+		if desc := fieldDescription(field); desc != "" {
+			prop["description"] = desc
+		}
+		if fd.GetExample() != "" {
+			if *schemaDraft != "" {
+				prop["examples"] = []any{fd.GetExample()}
+			} else {
+				prop["example"] = fd.GetExample()
 			}
 		}
+		// proto2 required and editions LEGACY_REQUIRED fields fail to
+		// marshal when unset, so the model must always supply them.
+		if fd.GetRequired() || field.Cardinality() == protoreflect.Required {
+			required = append(required, string(field.Name()))
+		}
 		props[string(field.Name())] = prop
 	}
 
@@ -292,26 +1485,146 @@ func buildMessageSchema(msg protoreflect.MessageDescriptor) map[string]any {
 	return schema
 }
 
-func buildFieldSchema(field protoreflect.FieldDescriptor) map[string]any {
+// fieldDescription returns the description of field in tool schemas: its
+// translation, its field_doc desc or its OpenAPI v2 description, in that
+// order, or "".
+func fieldDescription(field protoreflect.FieldDescriptor) string {
+	if desc := translation(field.FullName()); desc != "" {
+		return desc
+	}
+	if desc := getFieldDoc(field).GetDesc(); desc != "" {
+		return desc
+	}
+	return openAPIv2FieldDescription(field)
+}
+
+// collectAnnotatedFields returns the JSON pointer patterns of the fields
+// whose field_doc satisfies annotated, using "*" for list elements and map
+// values. Fields below an annotated field are not listed.
+func collectAnnotatedFields(msg protoreflect.MessageDescriptor, prefix string, annotated func(*pb.ToolFieldDoc) bool, visiting map[protoreflect.FullName]bool) []string {
+	if visiting[msg.FullName()] {
+		return nil
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
+	var out []string
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		path := prefix + "/" + string(field.Name())
+		if fd := getFieldDoc(field); fd != nil && annotated(fd) {
+			out = append(out, path)
+			continue
+		}
+
+		switch {
+		case field.IsMap():
+			if mv := field.MapValue(); mv.Message() != nil {
+				out = append(out, collectAnnotatedFields(mv.Message(), path+"/*", annotated, visiting)...)
+			}
+		case field.Message() != nil:
+			if field.IsList() {
+				path += "/*"
+			}
+			out = append(out, collectAnnotatedFields(field.Message(), path, annotated, visiting)...)
+		}
+	}
+	return out
+}
+
+// collectContextFields returns the dotted paths of the singular fields of msg
+// annotated with a context_key, mapped to that key.
+func collectContextFields(msg protoreflect.MessageDescriptor, prefix string, out map[string]string, visiting map[protoreflect.FullName]bool) map[string]string {
+	if visiting[msg.FullName()] {
+		return out
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		if field.IsList() || field.IsMap() {
+			continue
+		}
+		path := prefix + string(field.Name())
+		if key := getFieldDoc(field).GetContextKey(); key != "" {
+			if out == nil {
+				out = make(map[string]string)
+			}
+			out[path] = key
+			continue
+		}
+		if field.Message() != nil {
+			out = collectContextFields(field.Message(), path+".", out, visiting)
+		}
+	}
+	return out
+}
+
+// collectErrorMessages maps the locations of the fields of msg annotated with an
+// error_message, below prefix, to that message, in the pattern syntax
+// accepted by Redact.
+func collectErrorMessages(msg protoreflect.MessageDescriptor, prefix string, out map[string]string, visiting map[protoreflect.FullName]bool) map[string]string {
+	if visiting[msg.FullName()] {
+		return out
+	}
+	if visiting == nil {
+		visiting = make(map[protoreflect.FullName]bool)
+	}
+	visiting[msg.FullName()] = true
+	defer delete(visiting, msg.FullName())
+
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		path := prefix + "/" + string(field.Name())
+		if message := getFieldDoc(field).GetErrorMessage(); message != "" {
+			if out == nil {
+				out = make(map[string]string)
+			}
+			out[path] = message
+		}
+		switch {
+		case field.IsMap():
+			if mv := field.MapValue(); mv.Message() != nil {
+				out = collectErrorMessages(mv.Message(), path+"/*", out, visiting)
+			}
+		case field.Message() != nil:
+			if field.IsList() {
+				path += "/*"
+			}
+			out = collectErrorMessages(field.Message(), path, out, visiting)
+		}
+	}
+	return out
+}
+
+func buildFieldSchema(field protoreflect.FieldDescriptor, visiting map[protoreflect.FullName]bool) map[string]any {
 	switch {
 	case field.IsList():
 		return map[string]any{
 			"type":  "array",
-			"items": scalarOrMessageSchema(field.Kind(), field.Message()),
+			"items": scalarOrMessageSchema(field, visiting),
 		}
 	case field.IsMap():
-		mv := field.MapValue()
 		return map[string]any{
 			"type":                 "object",
-			"additionalProperties": scalarOrMessageSchema(mv.Kind(), mv.Message()),
+			"additionalProperties": scalarOrMessageSchema(field.MapValue(), visiting),
 		}
 	default:
-		return scalarOrMessageSchema(field.Kind(), field.Message())
+		return scalarOrMessageSchema(field, visiting)
 	}
 }
 
-func scalarOrMessageSchema(kind protoreflect.Kind, msg protoreflect.MessageDescriptor) map[string]any {
-	switch kind {
+// scalarOrMessageSchema builds the schema of a single value of field,
+// ignoring its cardinality.
+func scalarOrMessageSchema(field protoreflect.FieldDescriptor, visiting map[protoreflect.FullName]bool) map[string]any {
+	switch field.Kind() {
 	case protoreflect.BoolKind:
 		return map[string]any{"type": "boolean"}
 	case protoreflect.DoubleKind, protoreflect.FloatKind:
@@ -322,13 +1635,65 @@ func scalarOrMessageSchema(kind protoreflect.Kind, msg protoreflect.MessageDescr
 		protoreflect.Uint32Kind, protoreflect.Uint64Kind,
 		protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
 		return map[string]any{"type": "integer"}
-	case protoreflect.MessageKind:
-		return buildMessageSchema(msg)
+	case protoreflect.EnumKind:
+		return enumSchema(field.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageSchema(field.Message(), visiting)
 	default:
 		return map[string]any{"type": "string"}
 	}
 }
 
+// enumSchema lists the value names of enum, which the decoders accept
+// alongside the numbers.
+func enumSchema(enum protoreflect.EnumDescriptor) map[string]any {
+	if enum.FullName() == "google.protobuf.NullValue" {
+		return map[string]any{"type": "null"}
+	}
+	values := enum.Values()
+	names := make([]string, values.Len())
+	for i := range names {
+		names[i] = string(values.Get(i).Name())
+	}
+	return map[string]any{"type": "string", "enum": names}
+}
+
+// wellKnownSchema returns the schema of the JSON form protojson gives the
+// well-known type name, or nil for other messages.
+func wellKnownSchema(name protoreflect.FullName) map[string]any {
+	switch name {
+	case "google.protobuf.Timestamp":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration":
+		return map[string]any{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]+)?s$`}
+	case "google.protobuf.FieldMask":
+		return map[string]any{"type": "string"}
+	case "google.protobuf.Struct":
+		return map[string]any{"type": "object"}
+	case "google.protobuf.ListValue":
+		return map[string]any{"type": "array"}
+	case "google.protobuf.Value":
+		return map[string]any{}
+	case "google.protobuf.Any":
+		return map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"@type": map[string]any{"type": "string"}},
+			"required":   []string{"@type"},
+		}
+	case "google.protobuf.BoolValue":
+		return map[string]any{"type": "boolean"}
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue":
+		return map[string]any{"type": "number"}
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return map[string]any{"type": "integer"}
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return map[string]any{"type": "string"}
+	default:
+		return nil
+	}
+}
+
 func renderSchemaLiteral(v any) string {
 	switch val := v.(type) {
 	case map[string]any:
@@ -356,6 +1721,14 @@ func renderSchemaLiteral(v any) string {
 			parts = append(parts, strconv.Quote(s))
 		}
 		return "[]" + "string{" + strings.Join(parts, ",") + "}"
+	case []any:
+		var parts []string
+		for _, item := range val {
+			parts = append(parts, renderSchemaLiteral(item))
+		}
+		return "[]any{" + strings.Join(parts, ", ") + "}"
+	case nil:
+		return "nil"
 	default:
 		return fmt.Sprintf("%#v", val)
 	}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// schemaConstraints lists the JSON Schema keywords rendered in the
+// Constraints column of the generated parameter tables.
+var schemaConstraints = []string{
+	"enum", "format", "pattern",
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+	"minLength", "maxLength", "minItems", "maxItems",
+}
+
+// generateMarkdownFiles emits one Markdown page per service into a companion
+// _<service>.tools.md file, documenting each tool with its parameters and
+// error behaviour.
+func generateMarkdownFiles(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	for _, svc := range services {
+		filename := file.GeneratedFilenamePrefix + "_" + strings.ToLower(svc.service.GoName) + ".tools.md"
+		g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+
+		g.P("<!-- Code generated by protoc-gen-go-genkit-tools. DO NOT EDIT. -->")
+		g.P("<!-- source: ", file.Desc.Path(), " -->")
+		g.P()
+		g.P("# ", svc.service.Desc.FullName(), " tools")
+		if desc := serviceDescription(svc.service); desc != "" {
+			g.P()
+			g.P(desc)
+		}
+		g.P()
+		for _, m := range svc.methods {
+			g.P("- [`", m.toolName, "`](#", m.toolName, ")")
+		}
+		for _, m := range svc.methods {
+			g.P()
+			writeToolMarkdown(g, m)
+		}
+	}
+}
+
+func writeToolMarkdown(g *protogen.GeneratedFile, m methodMeta) {
+	g.P("## ", m.toolName)
+	g.P()
+	g.P(m.description)
+	g.P()
+	g.P("- RPC: `", m.method.Desc.FullName(), "`")
+	if tags := m.toolDoc.GetTags(); len(tags) > 0 {
+		g.P("- Tags: `", strings.Join(tags, "`, `"), "`")
+	}
+	if m.method.Desc.IsStreamingClient() {
+		g.P("- Input: `{\"", batchField, "\": [...]}`, one entry per streamed request")
+	}
+	if m.method.Desc.IsStreamingServer() {
+		if *streamingMode == "aggregate" {
+			g.P("- Output: every streamed response, as a list")
+		} else {
+			g.P("- Output: the last streamed response; earlier ones go to the chunk callback")
+		}
+	}
+
+	g.P()
+	g.P("### Parameters")
+	g.P()
+	rows := schemaRows(m.inputSchema, "")
+	if len(rows) == 0 {
+		g.P("None.")
+	} else {
+		g.P("| Name | Type | Required | Description | Constraints | Example |")
+		g.P("| --- | --- | --- | --- | --- | --- |")
+		for _, r := range rows {
+			g.P("| `", r.name, "` | ", r.typ, " | ", r.required, " | ", markdownCell(r.description), " | ", markdownCell(r.constraints), " | ", markdownCell(r.example), " |")
+		}
+	}
+
+	g.P()
+	g.P("### Errors")
+	g.P()
+	g.P("- Arguments that cannot be decoded into `", m.method.Input.Desc.FullName(), "` fail with a `*genkittools.ValidationError` naming each offending field.")
+	if *useProtovalidate {
+		g.P("- Requests violating their protovalidate rules fail with a `*genkittools.ValidationError` listing every violation.")
+	}
+	g.P("- Errors returned by the implementation fail the tool call unchanged; panics fail with `genkittools.ErrToolPanic` unless recovery is disabled.")
+	if isIdempotent(m.method.Desc) {
+		g.P("- The RPC is idempotent, so failed calls are retried under `genkittools.WithRetry`.")
+	}
+	if len(m.sensitive) > 0 {
+		g.P("- Sensitive fields (`", strings.Join(m.sensitive, "`, `"), "`) are redacted from logs.")
+	}
+}
+
+// schemaRow documents one property of a tool input schema.
+type schemaRow struct {
+	name        string
+	typ         string
+	required    string
+	description string
+	constraints string
+	example     string
+}
+
+// schemaRows flattens the properties of an object schema into table rows,
+// naming nested properties by their dotted path and list elements with "[]".
+func schemaRows(schema map[string]any, prefix string) []schemaRow {
+	props, _ := schema["properties"].(map[string]any)
+	required := make(map[string]bool)
+	if req, ok := schema["required"].([]string); ok {
+		for _, name := range req {
+			required[name] = true
+		}
+	}
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rows []schemaRow
+	for _, name := range names {
+		prop, _ := props[name].(map[string]any)
+		row := schemaRow{
+			name:     prefix + name,
+			typ:      schemaType(prop),
+			required: "no",
+		}
+		if required[name] {
+			row.required = "yes"
+		}
+		if desc, ok := prop["description"].(string); ok {
+			row.description = desc
+		}
+		if ex, ok := prop["example"]; ok {
+			row.example = fmt.Sprint(ex)
+		} else if ex, ok := prop["examples"].([]any); ok && len(ex) > 0 {
+			row.example = fmt.Sprint(ex[0])
+		}
+		var constraints []string
+		for _, key := range schemaConstraints {
+			if v, ok := prop[key]; ok {
+				constraints = append(constraints, fmt.Sprintf("%s: %v", key, v))
+			}
+		}
+		row.constraints = strings.Join(constraints, ", ")
+		rows = append(rows, row)
+
+		switch {
+		case prop["type"] == "object":
+			rows = append(rows, schemaRows(prop, row.name+".")...)
+		case prop["type"] == "array":
+			if items, ok := prop["items"].(map[string]any); ok && items["type"] == "object" {
+				rows = append(rows, schemaRows(items, row.name+"[].")...)
+			}
+		}
+	}
+	return rows
+}
+
+// schemaType renders the type of a property schema, e.g. "array of string".
+func schemaType(schema map[string]any) string {
+	typ, _ := schema["type"].(string)
+	if ref, ok := schema["$ref"].(string); ok {
+		// A recursive message, with schema_draft set.
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	if types, ok := schema["type"].([]string); ok && len(types) == 2 && types[1] == "null" {
+		// A nullable scalar, with schema_draft set.
+		return types[0] + " or null"
+	}
+	switch typ {
+	case "array":
+		if items, ok := schema["items"].(map[string]any); ok {
+			return "array of " + schemaType(items)
+		}
+	case "object":
+		if values, ok := schema["additionalProperties"].(map[string]any); ok {
+			return "map of " + schemaType(values)
+		}
+	case "":
+		return "any"
+	}
+	return typ
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strconv"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	mcpPackage       = protogen.GoImportPath("github.com/mark3labs/mcp-go/mcp")
+	mcpServerPackage = protogen.GoImportPath("github.com/mark3labs/mcp-go/server")
+)
+
+// generateMCPFile emits helpers serving the tools and resources of each
+// service over the Model Context Protocol, with github.com/mark3labs/mcp-go,
+// into a companion _genkit.tools_mcp.go file.
+func generateMCPFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_mcp.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		if len(svc.methods) > 0 {
+			writeServiceMCP(g, svc.service, svc.methods)
+		}
+		if len(svc.resources) > 0 {
+			writeServiceMCPResources(g, svc.service, svc.resources)
+		}
+	}
+}
+
+// mcpTool is a tool entry in the result of the MCP tools/list method.
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// generateMCPManifest emits the tools of every service in file as an MCP
+// tools/list result into a companion _genkit.tools.mcp.json file, so hosts
+// that do not run Go can load the catalog.
+func generateMCPManifest(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	manifest := struct {
+		Tools []mcpTool `json:"tools"`
+	}{Tools: []mcpTool{}}
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			manifest.Tools = append(manifest.Tools, mcpTool{
+				Name:        m.toolName,
+				Description: m.description,
+				InputSchema: m.inputSchema,
+			})
+		}
+	}
+	raw := marshalSchemaJSON(manifest)
+
+	filename := file.GeneratedFilenamePrefix + "_genkit.tools.mcp.json"
+	g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+	g.P(string(raw))
+}
+
+func writeServiceMCP(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	mcpServer := g.QualifiedGoIdent(mcpServerPackage.Ident("MCPServer"))
+
+	g.P("// New", svc.GoName, "MCPServer returns an MCP server called name exposing the")
+	g.P("// tools of ", svc.GoName, " backed by impl. Serve it over stdio with")
+	g.P("// server.ServeStdio or over SSE with server.NewSSEServer.")
+	g.P("func New", svc.GoName, "MCPServer(name, version string, impl ", implName, ", opts ...", genkittoolsPackage.Ident("Option"), ") *", mcpServer, " {")
+	g.P("s := ", mcpServerPackage.Ident("NewMCPServer"), "(name, version, ", mcpServerPackage.Ident("WithToolCapabilities"), "(false))")
+	g.P("Add", svc.GoName, "MCPTools(s, impl, opts...)")
+	g.P("return s")
+	g.P("}")
+	g.P()
+
+	g.P("// Add", svc.GoName, "MCPTools adds the tools of ", svc.GoName, " to s, backed by impl.")
+	g.P("// The options apply as they do to the Genkit tools of the service, including")
+	g.P("// the names and descriptions set with genkittools.WithNamePrefix and")
+	g.P("// genkittools.WithDescriptionSuffix.")
+	g.P("func Add", svc.GoName, "MCPTools(s *", mcpServer, ", impl ", implName, ", opts ...", genkittoolsPackage.Ident("Option"), ") {")
+	g.P("o := ", genkittoolsPackage.Ident("NewOptions"), "(opts...)")
+	for _, m := range methods {
+		g.P("if o.Includes(string(", toolConstName(m), ")) {")
+		g.P("s.AddTool(", mcpPackage.Ident("NewToolWithRawSchema"), "(o.ToolName(string(", toolConstName(m), ")), o.ToolDescription(", toolInfoVarName(m), ".Description), ", toolInfoVarName(m), ".InputSchemaJSON()),")
+		g.P("func(ctx ", contextPackage.Ident("Context"), ", call ", mcpPackage.Ident("CallToolRequest"), ") (*", mcpPackage.Ident("CallToolResult"), ", error) {")
+		g.P("var input any = call.GetArguments()")
+		writeInvoke(g, svc, m, "out, err := ", "")
+		g.P("if err != nil {")
+		g.P("return ", mcpPackage.Ident("NewToolResultError"), "(err.Error()), nil")
+		g.P("}")
+		if resultFormat(m.toolDoc) != pb.ResultFormat_RESULT_FORMAT_STRUCTURED {
+			g.P("return ", mcpPackage.Ident("NewToolResultText"), "(out), nil")
+		} else {
+			g.P("raw, err := ", genkittoolsPackage.Ident("MarshalOutput"), "(out)")
+			g.P("if err != nil {")
+			g.P("return nil, err")
+			g.P("}")
+			g.P("return ", mcpPackage.Ident("NewToolResultText"), "(string(raw)), nil")
+		}
+		g.P("})")
+		g.P("}")
+	}
+	g.P("}")
+	g.P()
+}
+
+// writeServiceMCPResources emits Add<Service>MCPResources, serving the
+// resources of svc as MCP resources under the URIs of their Genkit
+// resources, so hosts read them without spending tool calls.
+func writeServiceMCPResources(g *protogen.GeneratedFile, svc *protogen.Service, resources []methodMeta) {
+	implName := svc.GoName + "ResourceImpl"
+
+	g.P("// Add", svc.GoName, "MCPResources adds the resources of ", svc.GoName, " to s, backed by")
+	g.P("// impl, under the same URIs and templates as their Genkit resources.")
+	g.P("func Add", svc.GoName, "MCPResources(s *", mcpServerPackage.Ident("MCPServer"), ", impl ", implName, ") {")
+	for _, m := range resources {
+		mimeType := strconv.Quote(resourceMIMEType(m))
+		if _, templated := resourceURI(svc, m); templated {
+			g.P("s.AddResourceTemplate(", mcpPackage.Ident("NewResourceTemplate"), "(", resourceConstName(m), ", ", strconv.Quote(m.toolName), ", ",
+				mcpPackage.Ident("WithTemplateDescription"), "(", strconv.Quote(m.description), "), ", mcpPackage.Ident("WithTemplateMIMEType"), "(", mimeType, ")),")
+		} else {
+			g.P("s.AddResource(", mcpPackage.Ident("NewResource"), "(", resourceConstName(m), ", ", strconv.Quote(m.toolName), ", ",
+				mcpPackage.Ident("WithResourceDescription"), "(", strconv.Quote(m.description), "), ", mcpPackage.Ident("WithMIMEType"), "(", mimeType, ")),")
+		}
+		g.P("func(ctx ", contextPackage.Ident("Context"), ", read ", mcpPackage.Ident("ReadResourceRequest"), ") ([]", mcpPackage.Ident("ResourceContents"), ", error) {")
+		writeResourceRead(g, svc, m, g.QualifiedGoIdent(genkittoolsPackage.Ident("URIArguments"))+"(read.Params.Arguments)")
+		g.P("return []", mcpPackage.Ident("ResourceContents"), "{", mcpPackage.Ident("TextResourceContents"), "{URI: read.Params.URI, MIMEType: ", mimeType, ", Text: text}}, nil")
+		g.P("})")
+	}
+	g.P("}")
+	g.P()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// collectMediaFields returns the fields of the response msg annotated with a
+// media_type, mapped to that MIME type.
+func collectMediaFields(msg protoreflect.MessageDescriptor) map[string]string {
+	var out map[string]string
+	for i := 0; i < msg.Fields().Len(); i++ {
+		field := msg.Fields().Get(i)
+		if mediaType := getFieldDoc(field).GetMediaType(); mediaType != "" {
+			if out == nil {
+				out = make(map[string]string)
+			}
+			out[string(field.Name())] = mediaType
+		}
+	}
+	return out
+}
+
+// sortedMediaFields returns the names of the media fields of meta's
+// response, sorted.
+func sortedMediaFields(meta methodMeta) []string {
+	names := make([]string, 0, len(meta.media))
+	for name := range meta.media {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkMedia reports media fields in the response of m that cannot be
+// returned as media parts. Only singular bytes fields, sent as data URLs,
+// and string fields holding a URL can, and only in a structured result of a
+// single response.
+func checkMedia(m *protogen.Method, doc *pb.ToolDoc) error {
+	name := m.Desc.FullName()
+	fields := m.Desc.Output().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if getFieldDoc(field).GetMediaType() == "" {
+			continue
+		}
+		switch {
+		case field.IsList() || field.IsMap() || (field.Kind() != protoreflect.BytesKind && field.Kind() != protoreflect.StringKind):
+			return fmt.Errorf("%s: media_type requires a singular bytes or string field", field.FullName())
+		case m.Desc.IsStreamingServer():
+			return fmt.Errorf("%s: media fields are not supported for server-streaming methods", name)
+		case resultFormat(doc) != pb.ResultFormat_RESULT_FORMAT_STRUCTURED:
+			return fmt.Errorf("%s: media fields require result_format RESULT_FORMAT_STRUCTURED", name)
+		case doc.GetInterruptible():
+			return fmt.Errorf("%s: media fields cannot be returned by interruptible tools", name)
+		case confirmsCalls(doc):
+			return fmt.Errorf("%s: media fields cannot be returned by destructive tools, which ask for confirmation", name)
+		}
+	}
+	return nil
+}
+
+// writeMediaHandler emits the tool function of a method whose response has
+// media fields. It returns the fields as media parts next to the rest of
+// the response.
+func writeMediaHandler(g *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta) {
+	writeHandlerInput(g, meta, "ctx", "*"+g.QualifiedGoIdent(genkitAIPackage.Ident("MultipartToolResponse")))
+	writeInvoke(g, svc, meta, "out, err := ", "ctx")
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("out, media := ", genkittoolsPackage.Ident("SplitMedia"), "(", toolInfoVarName(meta), ", out)")
+	g.P("parts := make([]*", genkitAIPackage.Ident("Part"), ", len(media))")
+	g.P("for i, m := range media {")
+	g.P("parts[i] = ", genkitAIPackage.Ident("NewMediaPart"), "(m.ContentType, m.URL)")
+	g.P("}")
+	g.P("return &", genkitAIPackage.Ident("MultipartToolResponse"), "{Output: out, Content: parts}, nil")
+	g.P("},")
+}
+
+// omitMediaExpr wraps the output schema expression expr of meta's tool so
+// that it leaves out the media fields.
+func omitMediaExpr(g *protogen.GeneratedFile, meta methodMeta, expr string) string {
+	if len(meta.media) == 0 {
+		return expr
+	}
+	expr = g.QualifiedGoIdent(genkittoolsPackage.Ident("OmitFields")) + "(" + expr
+	for _, name := range sortedMediaFields(meta) {
+		expr += ", " + strconv.Quote(name)
+	}
+	return expr + ")"
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	contextPackage = protogen.GoImportPath("context")
+	errorsPackage  = protogen.GoImportPath("errors")
+	syncPackage    = protogen.GoImportPath("sync")
+)
+
+// generateMockFile emits <Service>ToolImplMock types with per-method function
+// fields and call recording into a companion _genkit.tools_mock.go file.
+func generateMockFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_mock.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		writeServiceMock(g, svc.service, svc.methods)
+	}
+}
+
+func writeServiceMock(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	mockName := implName + "Mock"
+
+	g.P("// ", mockName, " is a configurable ", implName, " for tests. Set the")
+	g.P("// <Method>Func fields to control responses; every call is recorded.")
+	g.P("type ", mockName, " struct {")
+	for _, m := range methods {
+		g.P(m.method.GoName, "Func func", implMethodSignature(g, m.method, implContextType(g)))
+	}
+	g.P()
+	g.P("mu ", syncPackage.Ident("Mutex"))
+	for _, m := range methods {
+		g.P(mockCallsField(m.method), " []*", m.method.Input.GoIdent)
+	}
+	g.P("}")
+	g.P()
+	g.P("var _ ", implName, " = (*", mockName, ")(nil)")
+	g.P()
+
+	for _, m := range methods {
+		name := m.method.GoName
+		reqName := g.QualifiedGoIdent(m.method.Input.GoIdent)
+		field := mockCallsField(m.method)
+
+		params, results := implMethodParams(g, m.method, implContextType(g))
+		args := "ctx, req"
+		if m.method.Desc.IsStreamingClient() {
+			args = "ctx, " + g.QualifiedGoIdent(slicesPackage.Ident("Values")) + "(batch)"
+		}
+		if m.method.Desc.IsStreamingServer() {
+			args += ", send"
+		}
+		if reportsProgress(m.method) {
+			args += ", progress"
+		}
+
+		g.P("// ", name, " records the call and delegates to ", name, "Func.")
+		g.P("func (m *", mockName, ") ", name, "(", params, ") ", results, " {")
+		if m.method.Desc.IsStreamingClient() {
+			g.P("var batch []*", reqName)
+			g.P("for req := range reqs {")
+			g.P("batch = append(batch, req)")
+			g.P("}")
+			g.P("m.mu.Lock()")
+			g.P("m.", field, " = append(m.", field, ", batch...)")
+		} else {
+			g.P("m.mu.Lock()")
+			g.P("m.", field, " = append(m.", field, ", req)")
+		}
+		g.P("fn := m.", name, "Func")
+		g.P("m.mu.Unlock()")
+		g.P("if fn == nil {")
+		if m.method.Desc.IsStreamingServer() {
+			g.P("return ", errorsPackage.Ident("New"), `("`, mockName, ".", name, ": ", name, `Func is not set")`)
+		} else {
+			g.P("return nil, ", errorsPackage.Ident("New"), `("`, mockName, ".", name, ": ", name, `Func is not set")`)
+		}
+		g.P("}")
+		g.P("return fn(", args, ")")
+		g.P("}")
+		g.P()
+		g.P("// ", name, "Calls returns the requests passed to ", name, " so far.")
+		g.P("func (m *", mockName, ") ", name, "Calls() []*", reqName, " {")
+		g.P("m.mu.Lock()")
+		g.P("defer m.mu.Unlock()")
+		g.P("return append([]*", reqName, "(nil), m.", field, "...)")
+		g.P("}")
+		g.P()
+	}
+}
+
+func mockCallsField(m *protogen.Method) string {
+	return strings.ToLower(m.GoName[:1]) + m.GoName[1:] + "Calls"
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// openAIKeywords are the JSON Schema keywords OpenAI accepts in function
+// parameters. Other keywords, such as example or minLength, are dropped.
+var openAIKeywords = map[string]bool{
+	"type": true, "description": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true,
+	"items": true, "anyOf": true, "pattern": true, "format": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"multipleOf": true, "minItems": true, "maxItems": true,
+	"$ref": true, "$defs": true, "definitions": true,
+}
+
+// openAIFormats are the string formats OpenAI accepts.
+var openAIFormats = map[string]bool{
+	"date-time": true, "time": true, "date": true, "duration": true,
+	"email": true, "hostname": true, "ipv4": true, "ipv6": true, "uuid": true,
+}
+
+// openAITool is an entry of the tools parameter of the OpenAI Chat
+// Completions API.
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// generateOpenAITools emits the tools of every service in file as an OpenAI
+// tools array into a companion _genkit.tools.openai.json file, so the same
+// protos can drive function calling outside Genkit.
+func generateOpenAITools(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	tools := []openAITool{}
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			tools = append(tools, openAITool{
+				Type: "function",
+				Function: openAIFunction{
+					Name:        m.toolName,
+					Description: m.description,
+					Parameters:  filterSchema(m.inputSchema, openAIKeywords, openAIFormats),
+				},
+			})
+		}
+	}
+	raw := marshalSchemaJSON(tools)
+
+	filename := file.GeneratedFilenamePrefix + "_genkit.tools.openai.json"
+	g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+	g.P(string(raw))
+}
+
+// filterSchema returns a copy of schema keeping only the keywords in
+// keywords and, when formats is not nil, only the string formats in formats.
+// Subschemas under properties, items, additionalProperties and the
+// definitions of schema_draft are filtered alike.
+func filterSchema(schema map[string]any, keywords, formats map[string]bool) map[string]any {
+	out := make(map[string]any, len(schema))
+	for key, v := range schema {
+		if !keywords[key] {
+			continue
+		}
+		switch key {
+		case "properties", "$defs", "definitions":
+			props := make(map[string]any)
+			for name, prop := range v.(map[string]any) {
+				props[name] = filterSchema(prop.(map[string]any), keywords, formats)
+			}
+			v = props
+		case "items", "additionalProperties":
+			if sub, ok := v.(map[string]any); ok {
+				v = filterSchema(sub, keywords, formats)
+			}
+		case "format":
+			if formats != nil && !formats[v.(string)] {
+				continue
+			}
+		}
+		out[key] = v
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// openAPIVersion is the OpenAPI release the generated documents conform to.
+// Its schema dialect is JSON Schema 2020-12, so tool schemas embed unchanged.
+const openAPIVersion = "3.1.0"
+
+// generateOpenAPIFiles emits one OpenAPI document per service into a
+// companion <service>.openapi.json file. Each tool becomes a POST operation
+// whose operationId is the tool name, whose request body is the input schema
+// and whose response is the output schema.
+func generateOpenAPIFiles(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	for _, svc := range services {
+		paths := make(map[string]any, len(svc.methods))
+		for _, m := range svc.methods {
+			op := map[string]any{
+				"operationId": m.toolName,
+				"summary":     m.description,
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  jsonContent(m.inputSchema),
+				},
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "Tool output.",
+						"content":     jsonContent(m.outputSchema),
+					},
+					"default": map[string]any{
+						"description": "The tool failed.",
+					},
+				},
+			}
+			if tags := m.toolDoc.GetTags(); len(tags) > 0 {
+				op["tags"] = tags
+			}
+			if desc := strings.TrimSpace(string(m.method.Comments.Leading)); desc != "" {
+				op["description"] = desc
+			}
+			paths["/tools/"+m.toolName] = map[string]any{"post": op}
+		}
+
+		info := map[string]any{
+			"title":   string(svc.service.Desc.FullName()),
+			"version": "0.0.0",
+		}
+		if desc := serviceDescription(svc.service); desc != "" {
+			info["description"] = desc
+		}
+		doc := map[string]any{
+			"openapi": openAPIVersion,
+			"info":    info,
+			"paths":   paths,
+		}
+		raw := marshalSchemaJSON(doc)
+
+		filename := file.GeneratedFilenamePrefix + "_" + strings.ToLower(svc.service.GoName) + ".openapi.json"
+		g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+		g.P(string(raw))
+	}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{
+		"application/json": map[string]any{"schema": schema},
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/internal/schemautil"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// openAPIv2Description returns the description an openapiv2_operation
+// gives method: its summary, or else its description.
+func openAPIv2Description(method protoreflect.MethodDescriptor) string {
+	fields := schemautil.RawOptionStrings(method.Options(), schemautil.OpenAPIv2OptionNumber)
+	if fields[2] != "" { // Operation.summary
+		return fields[2]
+	}
+	return fields[3] // Operation.description
+}
+
+// openAPIv2FieldDescription returns the description an openapiv2_field
+// gives field: its description, or else its title.
+func openAPIv2FieldDescription(field protoreflect.FieldDescriptor) string {
+	fields := schemautil.RawOptionStrings(field.Options(), schemautil.OpenAPIv2OptionNumber)
+	if fields[6] != "" { // JSONSchema.description
+		return fields[6]
+	}
+	return fields[5] // JSONSchema.title
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// checkResource reports why m, declared with
+// (genkit.tool.v1.tool_doc).resource, cannot be exposed as a resource.
+// Agents fetch resources without asking, so the method must be read-only,
+// and its request must be filled from the URI alone.
+func checkResource(m *protogen.Method, doc *pb.ToolDoc) error {
+	name := m.Desc.FullName()
+	switch {
+	case m.Desc.IsStreamingClient() || m.Desc.IsStreamingServer():
+		return fmt.Errorf("%s: resource methods cannot stream", name)
+	case doc.GetInterruptible():
+		return fmt.Errorf("%s: resource methods cannot be interruptible", name)
+	case doc.GetProgress():
+		return fmt.Errorf("%s: resource methods cannot report progress", name)
+	case doc.GetSafety() == pb.Safety_SAFETY_MUTATING || doc.GetSafety() == pb.Safety_SAFETY_DESTRUCTIVE:
+		return fmt.Errorf("%s: resource methods must be read-only, not %s", name, doc.GetSafety())
+	}
+	if opts, ok := m.Desc.Options().(*descriptorpb.MethodOptions); !ok || opts.GetIdempotencyLevel() != descriptorpb.MethodOptions_NO_SIDE_EFFECTS {
+		return fmt.Errorf("%s: resource methods must be read-only; set option idempotency_level = NO_SIDE_EFFECTS", name)
+	}
+	fields := m.Desc.Input().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if field := fields.Get(i); field.IsList() || field.IsMap() || field.Message() != nil {
+			return fmt.Errorf("%s: resource request field %s cannot be filled from a URI; only singular scalar fields can", name, field.Name())
+		}
+	}
+	return nil
+}
+
+// resourceURI returns the URI template of meta's resource:
+// <service>://<name>/{field}/... over the fields of the request, in
+// declaration order. templated is false when the request has no fields.
+func resourceURI(svc *protogen.Service, meta methodMeta) (uri string, templated bool) {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(svc.GoName) + "://" + meta.toolName)
+	fields := meta.method.Desc.Input().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		b.WriteString("/{" + string(fields.Get(i).Name()) + "}")
+	}
+	return b.String(), fields.Len() > 0
+}
+
+// writeServiceResources emits the resources of svc: the
+// <Service>ResourceImpl interface, their URI constants, and
+// Register<Service>Resources, or New<Service>Resources with lazy=true.
+func writeServiceResources(g *protogen.GeneratedFile, svc *protogen.Service, resources []methodMeta) {
+	implName := svc.GoName + "ResourceImpl"
+
+	g.P("// ", implName, " defines the methods that can be exposed as Genkit resources.")
+	g.P("type ", implName, " interface {")
+	for _, m := range resources {
+		g.P(m.method.GoName, implMethodSignature(g, m.method, "context.Context"))
+	}
+	g.P("}")
+	g.P()
+
+	g.P("// Resource URIs of ", svc.GoName, ". Templates name the request fields they are")
+	g.P("// filled from, e.g. for ai.NewResourcePart.")
+	for _, m := range resources {
+		uri, _ := resourceURI(svc, m)
+		g.P("const ", resourceConstName(m), " = ", strconv.Quote(uri))
+	}
+	g.P()
+	for _, m := range resources {
+		if tmpl := m.toolDoc.GetResultTemplate(); tmpl != "" {
+			g.P("var ", resultTemplateVarName(m), " = ", templatePackage.Ident("Must"), "(", templatePackage.Ident("New"), "(", strconv.Quote(m.toolName), ").Parse(", strconv.Quote(tmpl), "))")
+			g.P()
+		}
+	}
+
+	if *lazyTools {
+		g.P("// New", svc.GoName, "Resources binds impl to unregistered resources for every")
+		g.P("// resource-enabled method of ", svc.GoName, ", in declaration order, for")
+		g.P("// ai.WithResources.")
+		g.P("func New", svc.GoName, "Resources(impl ", implName, ") []", genkitAIPackage.Ident("Resource"), " {")
+	} else {
+		g.P("// Register", svc.GoName, "Resources registers all resource-enabled methods from")
+		g.P("// ", svc.GoName, ", returning the resources in declaration order.")
+		g.P("func Register", svc.GoName, "Resources(g *genkit.Genkit, impl ", implName, ") []", genkitAIPackage.Ident("Resource"), " {")
+	}
+	g.P("return []", genkitAIPackage.Ident("Resource"), "{")
+	for _, m := range resources {
+		writeResource(g, svc, m)
+	}
+	g.P("}")
+	g.P("}")
+	g.P()
+}
+
+// writeResource emits the definition of meta's resource, which decodes the
+// URI variables into the request and returns the response as text in the
+// tool's result_format.
+func writeResource(g *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta) {
+	_, templated := resourceURI(svc, meta)
+	switch {
+	case compatAPI() && *lazyTools:
+		g.P(genkitCompatPackage.Ident("NewResource"), "(", strconv.Quote(meta.toolName), ", &", genkitAIPackage.Ident("ResourceOptions"), "{")
+	case compatAPI():
+		g.P(genkitCompatPackage.Ident("DefineResource"), "(g, ", strconv.Quote(meta.toolName), ", &", genkitAIPackage.Ident("ResourceOptions"), "{")
+	case *lazyTools:
+		g.P(genkitAIPackage.Ident("NewResource"), "(", strconv.Quote(meta.toolName), ", &", genkitAIPackage.Ident("ResourceOptions"), "{")
+	default:
+		g.P("genkit.DefineResource(g, ", strconv.Quote(meta.toolName), ", &", genkitAIPackage.Ident("ResourceOptions"), "{")
+	}
+	if templated {
+		g.P("Template: ", resourceConstName(meta), ",")
+	} else {
+		g.P("URI: ", resourceConstName(meta), ",")
+	}
+	g.P("Description: ", strconv.Quote(meta.description), ",")
+	g.P("}, func(ctx context.Context, in *", genkitAIPackage.Ident("ResourceInput"), ") (*", genkitAIPackage.Ident("ResourceOutput"), ", error) {")
+	writeResourceRead(g, svc, meta, "in.Variables")
+	g.P("return &", genkitAIPackage.Ident("ResourceOutput"), "{Content: []*", genkitAIPackage.Ident("Part"), "{", genkitAIPackage.Ident("NewTextPart"), "(text)}}, nil")
+	g.P("}),")
+}
+
+// writeResourceRead emits the start of a handler reading meta's resource:
+// it decodes the URI variables held by the map[string]string expression
+// vars into the request, calls impl and renders the response into text,
+// returning nil and the error on failure.
+func writeResourceRead(g *protogen.GeneratedFile, svc *protogen.Service, meta methodMeta, vars string) {
+	g.P("req := &", meta.method.Input.GoIdent, "{}")
+	if _, templated := resourceURI(svc, meta); templated {
+		g.P("if err := ", genkittoolsPackage.Ident("DecodeURIVariables"), "(", strconv.Quote(meta.toolName), ", ", vars, ", req); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+	}
+	g.P("resp, err := impl.", meta.method.GoName, "(ctx, req)")
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	if resultFormat(meta.toolDoc) == pb.ResultFormat_RESULT_FORMAT_TEMPLATE {
+		g.P("text, err := ", genkittoolsPackage.Ident("RenderTemplate"), "(", resultTemplateVarName(meta), ", resp)")
+	} else {
+		g.P("text, err := ", genkittoolsPackage.Ident("RenderJSON"), "(resp)")
+	}
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+}
+
+// resourceMIMEType is the media type of the text of meta's resource.
+func resourceMIMEType(meta methodMeta) string {
+	if resultFormat(meta.toolDoc) == pb.ResultFormat_RESULT_FORMAT_TEMPLATE {
+		return "text/plain"
+	}
+	return "application/json"
+}
+
+func resourceConstName(meta methodMeta) string {
+	return meta.goName + "ResourceURI"
+}
+
+// toolServices returns the services with at least one tool, leaving out
+// those that only expose resources.
+func toolServices(services []serviceMeta) []serviceMeta {
+	var out []serviceMeta
+	for _, svc := range services {
+		if len(svc.methods) > 0 {
+			out = append(out, svc)
+		}
+	}
+	return out
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const httpPackage = protogen.GoImportPath("net/http")
+
+// httpRuleFieldNumber is the number of the google.api.http method option.
+// The extension is decoded from the raw option bytes so the plugin does not
+// depend on the googleapis Go packages.
+const httpRuleFieldNumber = 72295728
+
+// httpRule is the primary binding of a google.api.HttpRule.
+type httpRule struct {
+	method       string
+	pattern      string
+	body         string
+	responseBody string
+}
+
+// getHTTPRule returns the google.api.http binding of method, if any.
+func getHTTPRule(method protoreflect.MethodDescriptor) (httpRule, bool) {
+	opts, ok := method.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return httpRule{}, false
+	}
+	var rule httpRule
+	found := false
+	for b := opts.ProtoReflect().GetUnknown(); len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return httpRule{}, false
+		}
+		b = b[n:]
+		if num == httpRuleFieldNumber && typ == protowire.BytesType {
+			v, m := protowire.ConsumeBytes(b)
+			if m < 0 {
+				return httpRule{}, false
+			}
+			found = parseHTTPRule(v, &rule) || found
+			b = b[m:]
+			continue
+		}
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return httpRule{}, false
+		}
+		b = b[m:]
+	}
+	return rule, found && rule.method != ""
+}
+
+// parseHTTPRule merges the encoded google.api.HttpRule in b into rule.
+func parseHTTPRule(b []byte, rule *httpRule) bool {
+	verbs := map[protowire.Number]string{2: "GET", 3: "PUT", 4: "POST", 5: "DELETE", 6: "PATCH"}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			m := protowire.ConsumeFieldValue(num, typ, b)
+			if m < 0 {
+				return false
+			}
+			b = b[m:]
+			continue
+		}
+		v, m := protowire.ConsumeBytes(b)
+		if m < 0 {
+			return false
+		}
+		b = b[m:]
+		switch num {
+		case 2, 3, 4, 5, 6:
+			rule.method, rule.pattern = verbs[num], string(v)
+		case 7:
+			rule.body = string(v)
+		case 8: // CustomHttpPattern{kind = 1, path = 2}
+			for len(v) > 0 {
+				cnum, ctyp, cn := protowire.ConsumeTag(v)
+				if cn < 0 || ctyp != protowire.BytesType {
+					return false
+				}
+				cv, cm := protowire.ConsumeBytes(v[cn:])
+				if cm < 0 {
+					return false
+				}
+				v = v[cn+cm:]
+				switch cnum {
+				case 1:
+					rule.method = string(cv)
+				case 2:
+					rule.pattern = string(cv)
+				}
+			}
+		case 12:
+			rule.responseBody = string(v)
+		}
+	}
+	return true
+}
+
+// generateRESTFile emits New<Service>RESTImpl adapters calling the REST
+// endpoints bound by google.api.http into a companion _genkit.tools_rest.go
+// file.
+func generateRESTFile(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta) {
+	filename := toolsFilenamePrefix(file) + "_genkit.tools_rest.go"
+	g := plugin.NewGeneratedFile(filename, toolsImportPath(file))
+
+	writeHeader(g, plugin, file)
+	g.P("package ", toolsPackageName(file))
+	g.P()
+
+	for _, svc := range services {
+		writeServiceRESTAdapter(g, svc.service, svc.methods)
+	}
+}
+
+func writeServiceRESTAdapter(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	adapterName := strings.ToLower(svc.GoName[:1]) + svc.GoName[1:] + "RESTImpl"
+	client := g.QualifiedGoIdent(httpPackage.Ident("Client"))
+
+	g.P("// New", svc.GoName, "RESTImpl implements ", implName, " by issuing the REST")
+	g.P("// calls bound by the google.api.http annotations of ", svc.GoName, " against")
+	g.P("// baseURL. A nil client uses http.DefaultClient.")
+	g.P("func New", svc.GoName, "RESTImpl(baseURL string, client *", client, ") ", implName, " {")
+	g.P("return &", adapterName, "{baseURL: baseURL, client: client}")
+	g.P("}")
+	g.P()
+	g.P("type ", adapterName, " struct {")
+	g.P("baseURL string")
+	g.P("client *", client)
+	g.P("}")
+	g.P()
+
+	for _, m := range methods {
+		params, results := implMethodParams(g, m.method, implContextType(g))
+		g.P("func (x *", adapterName, ") ", m.method.GoName, "(", params, ") ", results, " {")
+
+		rule, ok := getHTTPRule(m.method.Desc)
+		var reason string
+		switch {
+		case m.method.Desc.IsStreamingClient() || m.method.Desc.IsStreamingServer():
+			reason = "streaming methods cannot be called over REST"
+		case !ok:
+			reason = "no google.api.http binding"
+		}
+		if reason != "" {
+			msg := strconv.Quote(string(m.method.Desc.FullName()) + ": " + reason)
+			if m.method.Desc.IsStreamingServer() {
+				g.P("return ", errorsPackage.Ident("New"), "(", msg, ")")
+			} else {
+				g.P("return nil, ", errorsPackage.Ident("New"), "(", msg, ")")
+			}
+			g.P("}")
+			g.P()
+			continue
+		}
+
+		g.P("resp := &", m.method.Output.GoIdent, "{}")
+		g.P("rule := ", genkittoolsPackage.Ident("HTTPRule"), "{")
+		g.P("Method: ", strconv.Quote(rule.method), ",")
+		g.P("Pattern: ", strconv.Quote(rule.pattern), ",")
+		if rule.body != "" {
+			g.P("Body: ", strconv.Quote(rule.body), ",")
+		}
+		if rule.responseBody != "" {
+			g.P("ResponseBody: ", strconv.Quote(rule.responseBody), ",")
+		}
+		g.P("}")
+		g.P("if err := ", genkittoolsPackage.Ident("CallREST"), "(ctx, x.client, x.baseURL, rule, req, resp); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return resp, nil")
+		g.P("}")
+		g.P()
+	}
+}
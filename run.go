@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// run reads a CodeGeneratorRequest from in and writes the response to out,
+// like protogen.Options.Run does, except that the files of the request are
+// generated concurrently.
+func run(opts protogen.Options, args []string, in io.Reader, out io.Writer) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unknown argument %q (this program should be run by protoc, not directly)", args[0])
+	}
+	raw, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	req := &pluginpb.CodeGeneratorRequest{}
+	if err := proto.Unmarshal(raw, req); err != nil {
+		return err
+	}
+	plugin, err := opts.New(req)
+	if err != nil {
+		return err
+	}
+	plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL | pluginpb.CodeGeneratorResponse_FEATURE_SUPPORTS_EDITIONS)
+	plugin.SupportedEditionsMinimum = descriptorpb.Edition_EDITION_PROTO2
+	plugin.SupportedEditionsMaximum = descriptorpb.Edition_EDITION_2024
+
+	raw, err = proto.Marshal(generate(plugin))
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(raw)
+	return err
+}
+
+// generate generates the files of the request on a pool of up to GOMAXPROCS
+// workers. Each file is generated into a plugin of its own, and their
+// outputs are joined in request order, so the response does not depend on
+// scheduling. An error fails the whole response, reporting the first
+// failing file in request order.
+func generate(plugin *protogen.Plugin) *pluginpb.CodeGeneratorResponse {
+	files, err := prepare(plugin)
+	if err != nil {
+		plugin.Error(err)
+		return plugin.Response()
+	}
+	if len(files) <= 1 {
+		for _, f := range files {
+			if err := generateFile(plugin, f.file, f.services); err != nil {
+				plugin.Error(err)
+			}
+		}
+		return plugin.Response()
+	}
+
+	resps := make([]*pluginpb.CodeGeneratorResponse, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				resps[i] = generateAlone(plugin.Request, files[i])
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	resp := plugin.Response()
+	for _, r := range resps {
+		if r.Error != nil {
+			resp.Error = r.Error
+			resp.File = nil
+			return resp
+		}
+		resp.File = append(resp.File, r.File...)
+	}
+	return resp
+}
+
+// generateAlone generates f into a plugin holding no files but the options
+// of req, and returns its response. Generated code only depends on the
+// descriptors and Go identifiers of f, which are safe to share between
+// goroutines. The options are already parsed into the flags, so they are
+// ignored here.
+func generateAlone(req *pluginpb.CodeGeneratorRequest, f fileMeta) *pluginpb.CodeGeneratorResponse {
+	opts := protogen.Options{ParamFunc: func(string, string) error { return nil }}
+	plugin, err := opts.New(&pluginpb.CodeGeneratorRequest{
+		Parameter:       req.Parameter,
+		CompilerVersion: req.CompilerVersion,
+	})
+	if err != nil {
+		return &pluginpb.CodeGeneratorResponse{Error: proto.String(err.Error())}
+	}
+	if err := generateFile(plugin, f.file, f.services); err != nil {
+		plugin.Error(err)
+	}
+	return plugin.Response()
+}
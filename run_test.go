@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	pb "github.com/nemo1105/protoc-gen-go-genkit-tools/genkit/tool/v1"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestRunWithoutSourceInfo(t *testing.T) {
+	methodOpts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(methodOpts, pb.E_ToolDoc, &pb.ToolDoc{Desc: "Check the server is up."})
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("health/v1/health.proto"),
+		Package:    proto.String("health.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"genkit/tool/v1/tool_metadata.proto"},
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("example.com/health/v1;healthv1")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("PingRequest")},
+			{Name: proto.String("PingResponse")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Health"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Ping"),
+				InputType:  proto.String(".health.v1.PingRequest"),
+				OutputType: proto.String(".health.v1.PingResponse"),
+				Options:    methodOpts,
+			}},
+		}},
+	}
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(pb.File_genkit_tool_v1_tool_metadata_proto),
+			file,
+		},
+	}
+	in, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run(protogen.Options{ParamFunc: flags.Set}, nil, bytes.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	resp := &pluginpb.CodeGeneratorResponse{}
+	if err := proto.Unmarshal(out.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("generation failed: %s", resp.GetError())
+	}
+	if len(resp.File) != 1 || resp.File[0].GetName() != "health/v1/health_genkit.tools.go" {
+		t.Fatalf("unexpected files %v", resp.File)
+	}
+	if !regexp.MustCompile(`Description:\s+"Check the server is up.",`).MatchString(resp.File[0].GetContent()) {
+		t.Fatalf("tool description missing from\n%s", resp.File[0].GetContent())
+	}
+}
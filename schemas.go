@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"path"
+
+	"github.com/nemo1105/protoc-gen-go-genkit-tools/internal/schemautil"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// schemaDialect identifies the JSON Schema draft the generated schemas
+// follow in standalone schema files.
+func schemaDialect() string {
+	if *schemaDraft == schemautil.Draft07 {
+		return "http://json-schema.org/draft-07/schema#"
+	}
+	return "https://json-schema.org/draft/2020-12/schema"
+}
+
+// generateSchemaFiles writes the input and output schema of every tool as
+// standalone <tool>.input.schema.json and <tool>.output.schema.json files
+// under dir, relative to the plugin output directory.
+func generateSchemaFiles(plugin *protogen.Plugin, file *protogen.File, services []serviceMeta, dir string) {
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			writeSchemaFile(plugin, file, path.Join(dir, m.toolName+".input.schema.json"), m.toolName+" input", m.inputSchema)
+			writeSchemaFile(plugin, file, path.Join(dir, m.toolName+".output.schema.json"), m.toolName+" output", m.outputSchema)
+		}
+	}
+}
+
+func writeSchemaFile(plugin *protogen.Plugin, file *protogen.File, filename, title string, schema map[string]any) {
+	doc := make(map[string]any, len(schema)+2)
+	for k, v := range schema {
+		doc[k] = v
+	}
+	doc["$schema"] = schemaDialect()
+	if _, ok := doc["title"]; !ok {
+		doc["title"] = title
+	}
+	raw := marshalSchemaJSON(doc)
+	g := plugin.NewGeneratedFile(filename, file.GoImportPath)
+	g.P(string(raw))
+}
+
+// marshalSchemaJSON returns v, a document built around tool schemas, as
+// indented JSON.
+func marshalSchemaJSON(v any) []byte {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		// Schemas only hold maps, slices and scalars, so this cannot fail.
+		panic(err)
+	}
+	return raw
+}
+
+// With schema_draft set, schemas use the keywords of that JSON Schema draft
+// where the default dialect, kept for the models Genkit's plugins serve,
+// departs from the standard: field examples are listed under examples,
+// fields telling unset from the zero value also accept null, and recursive
+// messages refer to definitions at the root of the schema instead of being
+// cut off. Package schemautil, shared with the runtime schemas of
+// genkittools, builds those parts.
+
+// listSchema returns the schema of an array of items.
+func listSchema(items map[string]any) map[string]any {
+	schema := map[string]any{"type": "array"}
+	schema["items"] = schemautil.HoistDefinitions(schema, items)
+	return schema
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// derivedToolName returns the tool name of a method without an explicit
+// name: <service>_<method> in lower case, or the words of both cased as
+// tool_name_case says.
+func derivedToolName(service, method string) string {
+	if *toolNameCase == "" {
+		return strings.ToLower(service + "_" + method)
+	}
+	words := append(goNameWords(service), goNameWords(method)...)
+	switch *toolNameCase {
+	case "camel":
+		for i := 1; i < len(words); i++ {
+			words[i] = capitalize(words[i])
+		}
+		return strings.Join(words, "")
+	case "kebab":
+		return strings.Join(words, "-")
+	default:
+		return strings.Join(words, "_")
+	}
+}
+
+// toolNameSuffix returns word appended to a tool name in the case of
+// derived names, e.g. "_batch", "Batch" or "-batch".
+func toolNameSuffix(word string) string {
+	switch *toolNameCase {
+	case "camel":
+		return capitalize(word)
+	case "kebab":
+		return "-" + word
+	default:
+		return "_" + word
+	}
+}
+
+// goNameWords splits a Go identifier into lower-case words at underscores
+// and case changes, keeping acronyms and digits together: GetHTTPStatus
+// gives get, http, status and ReportV2 gives report, v2.
+func goNameWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	r := []rune(word)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
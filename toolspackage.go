@@ -0,0 +1,50 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// The Go code generated for a file goes into the package of its messages,
+// or with package_suffix into a <package><suffix> sub-package of it, named
+// like the protoc-gen-connect-go one. The sub-package imports the message
+// package, which is left free of genkit imports and of identifiers that
+// could collide with protoc-gen-go output.
+
+// toolsPackageName returns the name of the package the Go code of file is
+// generated into.
+func toolsPackageName(file *protogen.File) protogen.GoPackageName {
+	return file.GoPackageName + protogen.GoPackageName(*packageSuffix)
+}
+
+// toolsImportPath returns the import path of the package the Go code of
+// file is generated into.
+func toolsImportPath(file *protogen.File) protogen.GoImportPath {
+	if *packageSuffix == "" {
+		return file.GoImportPath
+	}
+	return protogen.GoImportPath(path.Join(string(file.GoImportPath), string(toolsPackageName(file))))
+}
+
+// toolsFilenamePrefix returns the prefix of the names of the Go files
+// generated for file, in the directory of their package.
+func toolsFilenamePrefix(file *protogen.File) string {
+	if *packageSuffix == "" {
+		return file.GeneratedFilenamePrefix
+	}
+	prefix := filepath.ToSlash(file.GeneratedFilenamePrefix)
+	return path.Join(path.Dir(prefix), string(toolsPackageName(file)), path.Base(prefix))
+}
+
+// validPackageSuffix reports whether suffix, appended to a package name,
+// keeps it a conventional Go package name.
+func validPackageSuffix(suffix string) bool {
+	for _, r := range suffix {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
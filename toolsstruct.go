@@ -0,0 +1,112 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// writeToolsStruct emits the <Service>Tools struct of tools_struct=true,
+// binding an implementation of svc to the options its tools are registered
+// with, with Register and Refs methods and a method per tool returning its
+// registered name.
+func writeToolsStruct(g *protogen.GeneratedFile, svc *protogen.Service, methods []methodMeta) {
+	implName := svc.GoName + "ToolImpl"
+	structName := svc.GoName + "Tools"
+	option := genkittoolsPackage.Ident("Option")
+
+	g.P("// ", structName, " holds an implementation of ", svc.GoName, " and the options its")
+	g.P("// tools are registered with, as an alternative to Register", svc.GoName, "Tools")
+	g.P("// and ", svc.GoName, "ToolRefs that keeps several instances of the service in one")
+	g.P("// binary apart, e.g. each with its own genkittools.WithNamePrefix.")
+	g.P("type ", structName, " struct {")
+	g.P("impl ", implName)
+	g.P("opts []", option)
+	g.P("o    *", genkittoolsPackage.Ident("Options"))
+	g.P("}")
+	g.P()
+
+	g.P("// New", structName, " returns the tools of impl, registered with opts.")
+	g.P("func New", structName, "(impl ", implName, ", opts ...", option, ") *", structName, " {")
+	g.P("return &", structName, "{impl: impl, opts: opts, o: ", genkittoolsPackage.Ident("NewOptions"), "(opts...)}")
+	g.P("}")
+	g.P()
+
+	g.P("// Register registers the tools of s in g, returning them in declaration order.")
+	g.P("func (s *", structName, ") Register(g *genkit.Genkit) ([]", genkitAIPackage.Ident("Tool"), ", error) {")
+	g.P("return Register", svc.GoName, "Tools(g, s.impl, s.opts...)")
+	g.P("}")
+	g.P()
+
+	g.P("// Refs returns a ToolRef for every tool of s in declaration order, under the")
+	g.P("// names they are registered with, for ai.WithTools once Register has run.")
+	g.P("func (s *", structName, ") Refs() []", genkitAIPackage.Ident("ToolRef"), " {")
+	g.P("var refs []", genkitAIPackage.Ident("ToolRef"))
+	g.P("for _, name := range []", genkitAIPackage.Ident("ToolName"), "{")
+	for _, m := range methods {
+		g.P(toolConstName(m), ",")
+	}
+	for _, m := range batchMethods(methods) {
+		g.P(batchToolConstName(m), ",")
+	}
+	if *listTools {
+		g.P(listToolsToolConstName(svc), ",")
+	}
+	g.P("} {")
+	g.P("if s.o.Includes(string(name)) {")
+	g.P("refs = append(refs, ", genkitAIPackage.Ident("ToolName"), "(s.o.ToolName(string(name))))")
+	g.P("}")
+	g.P("}")
+	if *healthTools {
+		g.P("if _, ok := s.impl.(", healthPingerName(svc), "); ok && s.o.Includes(string(", healthToolConstName(svc), ")) {")
+		g.P("refs = append(refs, ", genkitAIPackage.Ident("ToolName"), "(s.o.ToolName(string(", healthToolConstName(svc), "))))")
+		g.P("}")
+	}
+	g.P("return refs")
+	g.P("}")
+	g.P()
+
+	type toolMethod struct{ name, constName, toolName string }
+	var tools []toolMethod
+	for _, m := range methods {
+		tools = append(tools, toolMethod{m.goName, toolConstName(m), m.toolName})
+	}
+	for _, m := range batchMethods(methods) {
+		tools = append(tools, toolMethod{batchGoName(m), batchToolConstName(m), batchToolName(m)})
+	}
+	if *listTools {
+		tools = append(tools, toolMethod{listToolsGoName(svc), listToolsToolConstName(svc), listToolsToolName(svc)})
+	}
+	goNames := make([]string, len(tools))
+	for i, t := range tools {
+		goNames[i] = t.name
+	}
+	names := toolsStructMethodNames(svc, goNames)
+	for i, t := range tools {
+		g.P("// ", names[i], " returns the name the ", t.toolName, " tool of s is registered")
+		g.P("// under, which is a ToolRef for ai.WithTools.")
+		g.P("func (s *", structName, ") ", names[i], "() ", genkitAIPackage.Ident("ToolName"), " {")
+		g.P("return ", genkitAIPackage.Ident("ToolName"), "(s.o.ToolName(string(", t.constName, ")))")
+		g.P("}")
+		g.P()
+	}
+}
+
+// toolsStructMethodNames returns the names of the per-tool methods of a
+// <Service>Tools struct: the Go name of each tool without the service prefix,
+// followed by Tool. A tool keeps its whole Go name when the shortened one is
+// not an exported identifier or is taken.
+func toolsStructMethodNames(svc *protogen.Service, goNames []string) []string {
+	names := make([]string, len(goNames))
+	taken := make(map[string]bool)
+	for i, goName := range goNames {
+		short := strings.TrimPrefix(strings.TrimPrefix(goName, svc.GoName), "_")
+		if !token.IsIdentifier(short) || !token.IsExported(short) || taken[short+"Tool"] {
+			short = goName
+		}
+		names[i] = short + "Tool"
+		taken[names[i]] = true
+	}
+	return names
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// translations holds the localized descriptions loaded from the file named
+// by the translations option, keyed by the full name of the service, method
+// or field they describe.
+var translations map[string]string
+
+// loadTranslations reads the translations file at name, relative to the
+// working directory: a JSON object mapping proto full names, such as
+// "city.v1.CityDirectory.GetCity" or "city.v1.GetCityRequest.name", to the
+// description to use instead of the one in the protos. Names matching
+// nothing are ignored, so one file can serve every generation run.
+func loadTranslations(name string) (map[string]string, error) {
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("translations: %w", err)
+	}
+	var out map[string]string
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("translations: %s: want a JSON object of descriptions by proto full name: %w", name, err)
+	}
+	for key, text := range out {
+		if !protoreflect.FullName(key).IsValid() {
+			return nil, fmt.Errorf("translations: %s: %q is not a proto full name", name, key)
+		}
+		if text == "" {
+			return nil, fmt.Errorf("translations: %s: %s: empty description", name, key)
+		}
+	}
+	return out, nil
+}
+
+// translation returns the localized description of the method or field
+// named name, or "".
+func translation(name protoreflect.FullName) string {
+	return translations[string(name)]
+}
+
+// serviceDescription returns the localized description of s, or its
+// leading comment.
+func serviceDescription(s *protogen.Service) string {
+	if desc := translation(s.Desc.FullName()); desc != "" {
+		return desc
+	}
+	return strings.TrimSpace(string(s.Comments.Leading))
+}
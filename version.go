@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// version is the plugin version. Release builds set it with
+// -ldflags "-X main.version=v1.2.3"; otherwise it is taken from the module
+// version recorded by go install.
+var version string
+
+func pluginVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// compilerVersion formats the protoc version sent in the request, the way
+// protoc-gen-go does.
+func compilerVersion(plugin *protogen.Plugin) string {
+	v := plugin.Request.GetCompilerVersion()
+	if v == nil {
+		return "(unknown)"
+	}
+	s := fmt.Sprintf("v%d.%d.%d", v.GetMajor(), v.GetMinor(), v.GetPatch())
+	if suffix := v.GetSuffix(); suffix != "" {
+		s += "-" + suffix
+	}
+	return s
+}
+
+// writeHeader emits the comment opening every generated Go file: the
+// generated-code marker, the plugin and protoc versions, the plugin options
+// and the source proto. It is kept apart from the package clause, so it
+// is not taken for package documentation.
+func writeHeader(g *protogen.GeneratedFile, plugin *protogen.Plugin, file *protogen.File) {
+	g.P("// Code generated by protoc-gen-go-genkit-tools. DO NOT EDIT.")
+	g.P("// versions:")
+	g.P("// \tprotoc-gen-go-genkit-tools ", pluginVersion())
+	g.P("// \tprotoc ", compilerVersion(plugin))
+	if param := plugin.Request.GetParameter(); param != "" {
+		g.P("// options: ", param)
+	}
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+}
+
+// generatedWith is the value of the <Service>GeneratedWith constants of
+// file, identifying the generator build, options and source behind it.
+func generatedWith(plugin *protogen.Plugin, file *protogen.File) string {
+	parts := []string{
+		"protoc-gen-go-genkit-tools " + pluginVersion(),
+		"protoc " + compilerVersion(plugin),
+	}
+	if param := plugin.Request.GetParameter(); param != "" {
+		parts = append(parts, "options "+param)
+	}
+	parts = append(parts, "source "+file.Desc.Path())
+	return strings.Join(parts, "; ")
+}